@@ -0,0 +1,152 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-community/cargo/runner"
+)
+
+const (
+	AllocatorJemalloc = "jemalloc"
+	AllocatorMimalloc = "mimalloc"
+)
+
+// allocatorCrates maps the sys crates that build an alternative allocator to the allocator
+var allocatorCrates = map[string]string{
+	"jemalloc-sys":      AllocatorJemalloc,
+	"libmimalloc-sys":   AllocatorMimalloc,
+	"tikv-jemalloc-sys": AllocatorJemalloc,
+}
+
+// DetectAllocators returns the alternative allocators built by the packages in the Cargo.lock file in appDir, sorted
+// by name. Returns nothing if there is no Cargo.lock file.
+func DetectAllocators(appDir string) ([]string, error) {
+	var lock struct {
+		Packages []struct {
+			Name string `toml:"name"`
+		} `toml:"package"`
+	}
+
+	lockFile := filepath.Join(appDir, "Cargo.lock")
+	if _, err := toml.DecodeFile(lockFile, &lock); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", lockFile, err)
+	}
+
+	found := map[string]bool{}
+	for _, p := range lock.Packages {
+		if allocator, ok := allocatorCrates[p.Name]; ok {
+			found[allocator] = true
+		}
+	}
+
+	var allocators []string
+	for a := range found {
+		allocators = append(allocators, a)
+	}
+	sort.Strings(allocators)
+
+	return allocators, nil
+}
+
+// ResolveAllocators returns the allocators to prepare the build for, those detected in the application and the one
+// requested with BP_CARGO_ALLOCATOR, which is empty, `jemalloc` or `mimalloc`
+func ResolveAllocators(appDir string, requested string) ([]string, error) {
+	if requested != "" && requested != AllocatorJemalloc && requested != AllocatorMimalloc {
+		return nil, fmt.Errorf("BP_CARGO_ALLOCATOR must be %q or %q, found %q", AllocatorJemalloc, AllocatorMimalloc, requested)
+	}
+
+	allocators, err := DetectAllocators(appDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to detect allocators\n%w", err)
+	}
+
+	if requested != "" && !contains(allocators, requested) {
+		allocators = append(allocators, requested)
+		sort.Strings(allocators)
+	}
+
+	return allocators, nil
+}
+
+// AllocatorBuildEnvironment returns the environment that the sys crates of the allocators need to build for the
+// target triple, the host if empty. Variables that are already set are left alone.
+//
+//   - jemalloc is built with 64 KiB pages on aarch64, so that the binaries also run on kernels with 64 KiB pages
+//   - musl no longer declares the `*64` file functions that the allocators use unless `_LARGEFILE64_SOURCE` is defined
+func AllocatorBuildEnvironment(allocators []string, triple string) (map[string]string, error) {
+	env := map[string]string{}
+	if len(allocators) == 0 {
+		return env, nil
+	}
+
+	arch, _, _ := strings.Cut(triple, "-")
+	if triple == "" {
+		var ok bool
+		if arch, ok = runner.SupportedArchitectures[runner.HostPlatform().Arch]; !ok {
+			return nil, fmt.Errorf("unsupported architecture %q", runner.HostPlatform().Arch)
+		}
+	}
+
+	setDefault := func(name string, value string) {
+		if _, ok := os.LookupEnv(name); !ok {
+			env[name] = value
+		}
+	}
+
+	if contains(allocators, AllocatorJemalloc) && arch == "aarch64" {
+		setDefault("JEMALLOC_SYS_WITH_LG_PAGE", "16")
+	}
+
+	if strings.HasSuffix(triple, "-musl") {
+		// read by the cc crate that compiles the C sources of the allocators
+		setDefault(fmt.Sprintf("CFLAGS_%s", strings.ReplaceAll(triple, "-", "_")), "-D_LARGEFILE64_SOURCE")
+	}
+
+	return env, nil
+}
+
+// AllocatorLaunchEnvironment returns the defaults for the environment of the application at launch that tune the
+// allocators, jemalloc purges unused memory with background threads instead of on allocation
+func AllocatorLaunchEnvironment(allocators []string) map[string]string {
+	env := map[string]string{}
+
+	if contains(allocators, AllocatorJemalloc) {
+		// jemalloc-sys prefixes its symbols and variables with _RJEM_, unless built without the prefix
+		env["_RJEM_MALLOC_CONF"] = "background_thread:true"
+		env["MALLOC_CONF"] = "background_thread:true"
+	}
+
+	return env
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
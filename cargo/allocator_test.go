@@ -0,0 +1,107 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testAllocator(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.lock"), []byte(`version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+
+[[package]]
+name = "tikv-jemalloc-sys"
+version = "0.5.4+5.3.0-patched"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "tikv-jemallocator"
+version = "0.5.4"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`), 0644)).To(Succeed())
+	})
+
+	it("detects allocators in Cargo.lock", func() {
+		Expect(cargo.DetectAllocators(appDir)).To(Equal([]string{"jemalloc"}))
+		Expect(cargo.DetectAllocators(t.TempDir())).To(BeEmpty())
+	})
+
+	it("adds the requested allocator", func() {
+		Expect(cargo.ResolveAllocators(appDir, "")).To(Equal([]string{"jemalloc"}))
+		Expect(cargo.ResolveAllocators(appDir, "jemalloc")).To(Equal([]string{"jemalloc"}))
+		Expect(cargo.ResolveAllocators(appDir, "mimalloc")).To(Equal([]string{"jemalloc", "mimalloc"}))
+
+		_, err := cargo.ResolveAllocators(appDir, "tcmalloc")
+		Expect(err).To(MatchError(`BP_CARGO_ALLOCATOR must be "jemalloc" or "mimalloc", found "tcmalloc"`))
+	})
+
+	context("AllocatorBuildEnvironment", func() {
+		it("does nothing without allocators", func() {
+			Expect(cargo.AllocatorBuildEnvironment(nil, "aarch64-unknown-linux-musl")).To(BeEmpty())
+		})
+
+		it("uses 64 KiB pages for jemalloc on aarch64", func() {
+			Expect(cargo.AllocatorBuildEnvironment([]string{"jemalloc"}, "aarch64-unknown-linux-gnu")).To(Equal(map[string]string{
+				"JEMALLOC_SYS_WITH_LG_PAGE": "16",
+			}))
+			Expect(cargo.AllocatorBuildEnvironment([]string{"mimalloc"}, "aarch64-unknown-linux-gnu")).To(BeEmpty())
+			Expect(cargo.AllocatorBuildEnvironment([]string{"jemalloc"}, "x86_64-unknown-linux-gnu")).To(BeEmpty())
+		})
+
+		it("uses the host architecture without a target", func() {
+			t.Setenv("BP_ARCH", "arm64")
+			Expect(cargo.AllocatorBuildEnvironment([]string{"jemalloc"}, "")).To(HaveKeyWithValue("JEMALLOC_SYS_WITH_LG_PAGE", "16"))
+		})
+
+		it("declares the large file functions on musl", func() {
+			Expect(cargo.AllocatorBuildEnvironment([]string{"mimalloc"}, "x86_64-unknown-linux-musl")).To(Equal(map[string]string{
+				"CFLAGS_x86_64_unknown_linux_musl": "-D_LARGEFILE64_SOURCE",
+			}))
+		})
+
+		it("leaves variables that are already set alone", func() {
+			t.Setenv("JEMALLOC_SYS_WITH_LG_PAGE", "14")
+			Expect(cargo.AllocatorBuildEnvironment([]string{"jemalloc"}, "aarch64-unknown-linux-gnu")).To(BeEmpty())
+		})
+	})
+
+	it("tunes jemalloc at launch", func() {
+		Expect(cargo.AllocatorLaunchEnvironment([]string{"jemalloc"})).To(Equal(map[string]string{
+			"_RJEM_MALLOC_CONF": "background_thread:true",
+			"MALLOC_CONF":       "background_thread:true",
+		}))
+		Expect(cargo.AllocatorLaunchEnvironment([]string{"mimalloc"})).To(BeEmpty())
+	})
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-community/cargo/manifest"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// ApplicationVersion returns the version of the application in appPath. This is the version of the root package,
+// or of the first workspace member selected by the patterns of workspaceMembers, or the version shared by the
+// workspace. Returns an empty string if no version is found.
+func ApplicationVersion(appPath string, workspaceMembers string) (string, error) {
+	root, err := manifest.Load(filepath.Join(appPath, "Cargo.toml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if root.Package != nil {
+		return root.Version(root), nil
+	}
+
+	if root.Workspace == nil {
+		return "", nil
+	}
+
+	if filter := runner.ParseMemberFilter(workspaceMembers); !filter.Empty() {
+		var members []manifest.Manifest
+		for _, pattern := range root.Workspace.Members {
+			paths, err := filepath.Glob(filepath.Join(appPath, pattern))
+			if err != nil {
+				return "", fmt.Errorf("unable to resolve workspace member %s\n%w", pattern, err)
+			}
+
+			for _, path := range paths {
+				m, err := manifest.Load(filepath.Join(path, "Cargo.toml"))
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				} else if err != nil {
+					return "", err
+				}
+
+				if m.Package != nil && filter.Matches(m.Package.Name) {
+					members = append(members, m)
+				}
+			}
+		}
+
+		// the first pattern that includes a member selects it, the first member is selected if there are only exclusions
+		for _, pattern := range append(filter.Includes, "*") {
+			for _, m := range members {
+				if (runner.MemberFilter{Includes: []string{pattern}}).Matches(m.Package.Name) {
+					return m.Version(root), nil
+				}
+			}
+		}
+	}
+
+	if root.Workspace.Package != nil {
+		return root.Workspace.Package.Version, nil
+	}
+
+	return "", nil
+}
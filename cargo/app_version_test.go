@@ -0,0 +1,73 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testAppVersion(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath string
+	)
+
+	it.Before(func() {
+		appPath = t.TempDir()
+	})
+
+	it("uses the version of the root package", func() {
+		Expect(os.WriteFile(filepath.Join(appPath, "Cargo.toml"), []byte("[package]\nname = \"app\"\nversion = \"1.2.3\"\n"), 0644)).To(Succeed())
+
+		Expect(cargo.ApplicationVersion(appPath, "")).To(Equal("1.2.3"))
+	})
+
+	context("workspaces", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(appPath, "Cargo.toml"), []byte("[workspace]\nmembers = [\"crates/*\"]\n\n[workspace.package]\nversion = \"2.0.0\"\n"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(appPath, "crates", "api"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(appPath, "crates", "api", "Cargo.toml"), []byte("[package]\nname = \"api\"\nversion = \"3.1.0\"\n"), 0644)).To(Succeed())
+		})
+
+		it("uses the version of the first selected member", func() {
+			Expect(cargo.ApplicationVersion(appPath, "api, worker")).To(Equal("3.1.0"))
+		})
+
+		it("uses the version of the first member selected by a pattern", func() {
+			Expect(os.MkdirAll(filepath.Join(appPath, "crates", "svc-orders"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(appPath, "crates", "svc-orders", "Cargo.toml"), []byte("[package]\nname = \"svc-orders\"\nversion.workspace = true\n"), 0644)).To(Succeed())
+
+			Expect(cargo.ApplicationVersion(appPath, "svc-*")).To(Equal("2.0.0"))
+			Expect(cargo.ApplicationVersion(appPath, "!svc-orders")).To(Equal("3.1.0"))
+		})
+
+		it("uses the version of the workspace", func() {
+			Expect(cargo.ApplicationVersion(appPath, "")).To(Equal("2.0.0"))
+		})
+	})
+
+	it("returns nothing without a manifest", func() {
+		Expect(cargo.ApplicationVersion(appPath, "")).To(BeEmpty())
+	})
+}
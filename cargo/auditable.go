@@ -0,0 +1,294 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"compress/zlib"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/sbom"
+)
+
+// AuditablePackage is a package of the dependency list that cargo-auditable embeds into binaries
+type AuditablePackage struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Root    bool   `json:"root"`
+	Source  string `json:"source"`
+	Version string `json:"version"`
+}
+
+// PURL returns the package URL of the package, like `pkg:cargo/serde@1.0.0`
+func (a AuditablePackage) PURL() string {
+	return fmt.Sprintf("pkg:cargo/%s@%s", a.Name, a.Version)
+}
+
+// AsSyftArtifact renders a bill of materials entry describing the package, found in the binary at location, as Syft
+func (a AuditablePackage) AsSyftArtifact(location string) (sbom.SyftArtifact, error) {
+	artifact := sbom.SyftArtifact{
+		Name:      a.Name,
+		Version:   a.Version,
+		Type:      "rust-crate",
+		FoundBy:   "cargo-auditable",
+		Locations: []sbom.SyftLocation{{Path: location}},
+		Language:  "rust",
+		PURL:      a.PURL(),
+	}
+
+	var err error
+	artifact.ID, err = artifact.Hash()
+	if err != nil {
+		return sbom.SyftArtifact{}, fmt.Errorf("unable to generate hash\n%w", err)
+	}
+
+	return artifact, nil
+}
+
+// AuditableData returns the dependency list that cargo-auditable embedded into the ELF, PE or Mach-O binary at path.
+// Returns nothing if the file is not a binary or was built without cargo-auditable.
+func AuditableData(path string) ([]AuditablePackage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer f.Close()
+
+	var section io.Reader
+	if e, err := elf.NewFile(f); err == nil {
+		if s := e.Section(".dep-v0"); s != nil {
+			section = s.Open()
+		}
+	} else if p, err := pe.NewFile(f); err == nil {
+		if s := p.Section(".dep-v0"); s != nil {
+			section = s.Open()
+		}
+	} else if m, err := macho.NewFile(f); err == nil {
+		if s := m.Section("__dep_v0"); s != nil {
+			section = s.Open()
+		}
+	}
+
+	if section == nil {
+		return nil, nil
+	}
+
+	// the section holds zlib compressed JSON, PE sections may be padded after the end of the stream
+	r, err := zlib.NewReader(section)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress auditable data of %s\n%w", path, err)
+	}
+	defer r.Close()
+
+	var data struct {
+		Packages []AuditablePackage `json:"packages"`
+	}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("unable to decode auditable data of %s\n%w", path, err)
+	}
+
+	return data.Packages, nil
+}
+
+// MergeAuditableSBOM adds the packages embedded by cargo-auditable into the binaries of the layer to the Syft and
+// CycloneDX SBOMs of the layer, unless they are already listed, so that the image SBOM matches the binaries. Returns
+// the number of packages added.
+func MergeAuditableSBOM(layer libcnb.Layer) (int, error) {
+	var artifacts []sbom.SyftArtifact
+	var packages []AuditablePackage
+	seen := map[string]bool{}
+
+	for _, dir := range []string{"bin", "platforms"} {
+		err := filepath.WalkDir(filepath.Join(layer.Path, dir), func(path string, d fs.DirEntry, err error) error {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			found, err := AuditableData(path)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range found {
+				if seen[p.PURL()] {
+					continue
+				}
+				seen[p.PURL()] = true
+
+				artifact, err := p.AsSyftArtifact(path)
+				if err != nil {
+					return fmt.Errorf("unable to get SBOM artifact %s\n%w", p.Name, err)
+				}
+				artifacts = append(artifacts, artifact)
+				packages = append(packages, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("unable to read auditable data\n%w", err)
+		}
+	}
+
+	if len(packages) == 0 {
+		return 0, nil
+	}
+
+	added, err := mergeSyftArtifacts(layer.SBOMPath(libcnb.SyftJSON), layer.Path, artifacts)
+	if err != nil {
+		return 0, fmt.Errorf("unable to merge Syft SBOM\n%w", err)
+	}
+
+	if err := mergeCycloneDXComponents(layer.SBOMPath(libcnb.CycloneDXJSON), packages); err != nil {
+		return 0, fmt.Errorf("unable to merge CycloneDX SBOM\n%w", err)
+	}
+
+	return added, nil
+}
+
+// mergeSyftArtifacts appends the artifacts that are not listed yet to the Syft SBOM at path, which is created if it
+// does not exist
+func mergeSyftArtifacts(path string, dependencyPath string, artifacts []sbom.SyftArtifact) (int, error) {
+	document, err := readJSONDocument(path)
+	if os.IsNotExist(err) {
+		return len(artifacts), sbom.NewSyftDependency(dependencyPath, artifacts).WriteTo(path)
+	} else if err != nil {
+		return 0, err
+	}
+
+	existing, _ := document["artifacts"].([]interface{})
+	listed := listedPURLs(existing)
+
+	added := 0
+	for _, a := range artifacts {
+		if listed[a.PURL] {
+			continue
+		}
+
+		var locations []interface{}
+		for _, l := range a.Locations {
+			locations = append(locations, map[string]interface{}{"path": l.Path})
+		}
+
+		// documents written by the syft CLI use lower case keys, unlike those written by libpak
+		existing = append(existing, map[string]interface{}{
+			"cpes":      []interface{}{},
+			"foundBy":   a.FoundBy,
+			"id":        a.ID,
+			"language":  a.Language,
+			"licenses":  []interface{}{},
+			"locations": locations,
+			"name":      a.Name,
+			"purl":      a.PURL,
+			"type":      a.Type,
+			"version":   a.Version,
+		})
+		added++
+	}
+	document["artifacts"] = existing
+
+	return added, writeJSONDocument(path, document)
+}
+
+// mergeCycloneDXComponents appends the packages that are not listed yet as components of the CycloneDX SBOM at path,
+// if it exists
+func mergeCycloneDXComponents(path string, packages []AuditablePackage) error {
+	document, err := readJSONDocument(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	existing, _ := document["components"].([]interface{})
+	listed := listedPURLs(existing)
+
+	sort.Slice(packages, func(i, j int) bool { return packages[i].PURL() < packages[j].PURL() })
+	for _, p := range packages {
+		if listed[p.PURL()] {
+			continue
+		}
+
+		existing = append(existing, map[string]interface{}{
+			"bom-ref": p.PURL(),
+			"name":    p.Name,
+			"purl":    p.PURL(),
+			"type":    "library",
+			"version": p.Version,
+		})
+	}
+	document["components"] = existing
+
+	return writeJSONDocument(path, document)
+}
+
+// listedPURLs returns the package URLs of SBOM entries, keyed `purl` or `PURL` depending on the writer
+func listedPURLs(entries []interface{}) map[string]bool {
+	listed := map[string]bool{}
+	for _, e := range entries {
+		if m, ok := e.(map[string]interface{}); ok {
+			for _, key := range []string{"purl", "PURL"} {
+				if purl, ok := m[key].(string); ok {
+					listed[purl] = true
+				}
+			}
+		}
+	}
+	return listed
+}
+
+func readJSONDocument(path string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(b, &document); err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+
+	return document, nil
+}
+
+func writeJSONDocument(path string, document map[string]interface{}) error {
+	b, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("unable to encode %s\n%w", path, err)
+	}
+
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", path, err)
+	}
+
+	return nil
+}
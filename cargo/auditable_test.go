@@ -0,0 +1,162 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testAuditable(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layer libcnb.Layer
+	)
+
+	// writes a minimal ELF binary with a `.dep-v0` section holding the packages, like cargo-auditable does
+	writeBinary := func(path string, packages string) {
+		t.Helper()
+
+		data := &bytes.Buffer{}
+		w := zlib.NewWriter(data)
+		_, err := w.Write([]byte(packages))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+
+		names := []byte("\x00.dep-v0\x00.shstrtab\x00")
+		dataOffset := uint64(binary.Size(elf.Header64{}))
+		namesOffset := dataOffset + uint64(data.Len())
+		sectionsOffset := namesOffset + uint64(len(names))
+
+		header := elf.Header64{
+			Type:      uint16(elf.ET_EXEC),
+			Machine:   uint16(elf.EM_X86_64),
+			Version:   uint32(elf.EV_CURRENT),
+			Shoff:     sectionsOffset,
+			Ehsize:    uint16(binary.Size(elf.Header64{})),
+			Shentsize: uint16(binary.Size(elf.Section64{})),
+			Shnum:     3,
+			Shstrndx:  2,
+		}
+		copy(header.Ident[:], elf.ELFMAG)
+		header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+		header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+		header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+		sections := []elf.Section64{
+			{},
+			{Name: 1, Type: uint32(elf.SHT_PROGBITS), Off: dataOffset, Size: uint64(data.Len()), Addralign: 1},
+			{Name: 9, Type: uint32(elf.SHT_STRTAB), Off: namesOffset, Size: uint64(len(names)), Addralign: 1},
+		}
+
+		b := &bytes.Buffer{}
+		Expect(binary.Write(b, binary.LittleEndian, header)).To(Succeed())
+		b.Write(data.Bytes())
+		b.Write(names)
+		Expect(binary.Write(b, binary.LittleEndian, sections)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, b.Bytes(), 0755)).To(Succeed())
+	}
+
+	it.Before(func() {
+		layers := libcnb.Layers{Path: t.TempDir()}
+
+		var err error
+		layer, err = layers.Layer("Rust Application")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeBinary(filepath.Join(layer.Path, "bin", "app"), `{"packages":[
+			{"name":"app","version":"0.1.0","source":"local","root":true,"dependencies":[1,2]},
+			{"name":"serde","version":"1.0.0","source":"crates.io"},
+			{"name":"cc","version":"1.0.0","source":"crates.io","kind":"build"}]}`)
+	})
+
+	it("reads the packages embedded into a binary", func() {
+		Expect(cargo.AuditableData(filepath.Join(layer.Path, "bin", "app"))).To(Equal([]cargo.AuditablePackage{
+			{Name: "app", Version: "0.1.0", Source: "local", Root: true},
+			{Name: "serde", Version: "1.0.0", Source: "crates.io"},
+			{Name: "cc", Version: "1.0.0", Source: "crates.io", Kind: "build"},
+		}))
+	})
+
+	it("ignores binaries without auditable data", func() {
+		Expect(os.WriteFile(filepath.Join(layer.Path, "bin", "script"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+		Expect(cargo.AuditableData(filepath.Join(layer.Path, "bin", "script"))).To(BeEmpty())
+	})
+
+	it("creates a Syft SBOM if the layer has none", func() {
+		Expect(cargo.MergeAuditableSBOM(layer)).To(Equal(3))
+
+		b, err := os.ReadFile(layer.SBOMPath(libcnb.SyftJSON))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`"PURL":"pkg:cargo/serde@1.0.0"`))
+		Expect(string(b)).To(ContainSubstring(`"FoundBy":"cargo-auditable"`))
+	})
+
+	it("merges packages that are not listed yet", func() {
+		Expect(os.WriteFile(layer.SBOMPath(libcnb.SyftJSON),
+			[]byte(`{"artifacts":[{"name":"serde","version":"1.0.0","purl":"pkg:cargo/serde@1.0.0"}],"schema":{"version":"1"}}`), 0644)).To(Succeed())
+		Expect(os.WriteFile(layer.SBOMPath(libcnb.CycloneDXJSON),
+			[]byte(`{"bomFormat":"CycloneDX","components":[{"name":"app","purl":"pkg:cargo/app@0.1.0"}]}`), 0644)).To(Succeed())
+
+		Expect(cargo.MergeAuditableSBOM(layer)).To(Equal(2))
+
+		var syft struct {
+			Artifacts []map[string]interface{} `json:"artifacts"`
+			Schema    map[string]interface{}   `json:"schema"`
+		}
+		b, err := os.ReadFile(layer.SBOMPath(libcnb.SyftJSON))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(b, &syft)).To(Succeed())
+		Expect(syft.Artifacts).To(HaveLen(3))
+		Expect(syft.Artifacts[1]).To(HaveKeyWithValue("purl", "pkg:cargo/app@0.1.0"))
+		Expect(syft.Artifacts[1]).To(HaveKeyWithValue("foundBy", "cargo-auditable"))
+		Expect(syft.Schema).To(HaveKeyWithValue("version", "1"))
+
+		var cdx struct {
+			BOMFormat  string                   `json:"bomFormat"`
+			Components []map[string]interface{} `json:"components"`
+		}
+		b, err = os.ReadFile(layer.SBOMPath(libcnb.CycloneDXJSON))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(b, &cdx)).To(Succeed())
+		Expect(cdx.BOMFormat).To(Equal("CycloneDX"))
+		Expect(cdx.Components).To(HaveLen(3))
+		Expect(cdx.Components[1]).To(HaveKeyWithValue("purl", "pkg:cargo/cc@1.0.0"))
+		Expect(cdx.Components[2]).To(HaveKeyWithValue("purl", "pkg:cargo/serde@1.0.0"))
+	})
+
+	it("does nothing without auditable binaries", func() {
+		Expect(os.RemoveAll(filepath.Join(layer.Path, "bin"))).To(Succeed())
+
+		Expect(cargo.MergeAuditableSBOM(layer)).To(BeZero())
+		Expect(layer.SBOMPath(libcnb.SyftJSON)).NotTo(BeAnExistingFile())
+	})
+}
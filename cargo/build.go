@@ -17,23 +17,43 @@
 package cargo
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/buildpacks/libcnb"
 	"github.com/heroku/color"
 	"github.com/mattn/go-shellwords"
 	"github.com/paketo-buildpacks/libpak"
 	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/bindings"
 	"github.com/paketo-buildpacks/libpak/effect"
 	"github.com/paketo-buildpacks/libpak/sbom"
+	"github.com/paketo-community/cargo/download"
+	"github.com/paketo-community/cargo/helper"
 	"github.com/paketo-community/cargo/runner"
+	"github.com/paketo-community/cargo/rustup"
 	"github.com/paketo-community/cargo/tini"
 )
 
+// JobsAuto is the value of BP_CARGO_JOBS that derives the number of jobs of cargo from the available memory
+const JobsAuto = "auto"
+
+// DefaultNetworkRetries is the number of times a cargo command that fails with a network error is retried
+const DefaultNetworkRetries = 2
+
+// BindingTypeCargoHome is the type of a binding that provides a pre-populated CARGO_HOME
+const BindingTypeCargoHome = "cargo-home"
+
 type Build struct {
 	CargoService runner.CargoService
-	Logger       bard.Logger
+	// Context aborts the commands of the build when it is done, like when the platform stops the build
+	Context context.Context
+	Logger  bard.Logger
 }
 
 func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
@@ -50,30 +70,120 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 			return libcnb.BuildResult{}, fmt.Errorf("unable to create configuration resolver\n%w", err)
 		}
 
-		tiniEnabled := !cr.ResolveBool("BP_CARGO_TINI_DISABLED")
-		if tiniEnabled {
-			dr, err := libpak.NewDependencyResolver(context)
-			if err != nil {
-				return libcnb.BuildResult{}, fmt.Errorf("unable to create dependency resolver\n%w", err)
-			}
+		dr, err := libpak.NewDependencyResolver(context)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to create dependency resolver\n%w", err)
+		}
 
-			dc, err := libpak.NewDependencyCache(context)
-			if err != nil {
-				return libcnb.BuildResult{}, fmt.Errorf("unable to create dependency cache\n%w", err)
+		digests, _ := cr.Resolve("BP_CARGO_DEPENDENCY_DIGESTS")
+		verifier, err := NewDependencyVerifier(digests, cr.ResolveBool("BP_CARGO_STRICT_VERIFICATION"), b.Logger)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_DEPENDENCY_DIGESTS\n%w", err)
+		}
+
+		toolStrategiesRaw, _ := cr.Resolve("BP_CARGO_INSTALL_TOOLS_STRATEGY")
+		toolStrategies, err := runner.ParseToolStrategies(toolStrategiesRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_INSTALL_TOOLS_STRATEGY=%q\n%w", toolStrategiesRaw, err)
+		}
+
+		// cargo binstall downloads binaries without a digest to verify them against
+		if unverified := toolStrategies.Unverified(); verifier.Strict && len(unverified) > 0 {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_STRICT_VERIFICATION only installs tools from source, "+
+				"found BP_CARGO_INSTALL_TOOLS_STRATEGY=%s which downloads unverified binaries", strings.Join(unverified, ","))
+		}
+
+		dc, err := libpak.NewDependencyCache(context)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to create dependency cache\n%w", err)
+		}
+		dc.Logger = b.Logger
+
+		retriesRaw, _ := cr.Resolve("BP_CARGO_DOWNLOAD_RETRIES")
+		retries := download.DefaultRetries
+		if retriesRaw != "" {
+			retries, err = strconv.Atoi(retriesRaw)
+			if err != nil || retries < 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_DOWNLOAD_RETRIES must be a non-negative number, found %q", retriesRaw)
 			}
-			dc.Logger = b.Logger
+		}
+
+		downloader := download.NewDownloader(dc,
+			download.WithAllowUnverified(!verifier.Strict),
+			download.WithLogger(b.Logger),
+			download.WithRetries(retries))
+
+		cacheWarming := cr.ResolveBool("BP_CARGO_CACHE_WARMING")
+		if cacheWarming {
+			b.Logger.Body("Cache warming build, only dependencies are compiled and no launch layers are contributed")
+		}
 
+		tiniEnabled := !cr.ResolveBool("BP_CARGO_TINI_DISABLED") && !cacheWarming
+		if tiniEnabled {
 			dep, err := dr.Resolve("tini", "")
 			if err != nil {
 				return libcnb.BuildResult{}, fmt.Errorf("unable to find dependency\n%w", err)
 			}
 
-			tini := tini.NewTini(dep, dc)
+			dep, err = verifier.Verify(dep)
+			if err != nil {
+				return libcnb.BuildResult{}, err
+			}
+
+			tini := tini.NewTini(dep, downloader)
 			tini.Logger = b.Logger
 			result.Layers = append(result.Layers, tini)
 		}
 
 		cargoHome, found := cr.Resolve("CARGO_HOME")
+		rustVersion, _ := cr.Resolve("BP_RUST_VERSION")
+
+		toolchainPath, err := LocateToolchain(cargoHome)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to locate Rust toolchain\n%w", err)
+		}
+
+		toolchainFile, err := runner.ToolchainRequirements(context.Application.Path)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to read toolchain file\n%w", err)
+		}
+
+		if toolchainPath == "" && cr.ResolveBool("BP_CARGO_RUSTUP_ENABLED") {
+			dep, err := dr.Resolve("rustup-init", "")
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to find dependency\n%w", err)
+			}
+
+			dep, err = verifier.Verify(dep)
+			if err != nil {
+				return libcnb.BuildResult{}, err
+			}
+
+			// rustup selects the toolchain of a toolchain file itself, installing it up front avoids a second toolchain
+			toolchain := rustVersion
+			if toolchain == "" && toolchainFile != nil {
+				toolchain = toolchainFile.Channel
+			}
+
+			r := rustup.NewRustup(dep, downloader, toolchain)
+			r.Logger = b.Logger
+
+			// the toolchain is needed to plan the build, so it is installed now rather than when layers are contributed
+			layer, err := context.Layers.Layer(r.Name())
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to create layer %s\n%w", r.Name(), err)
+			}
+
+			layer, err = r.Contribute(layer)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to install Rust toolchain\n%w", err)
+			}
+			result.Layers = append(result.Layers, contributedLayer{Layer: layer})
+
+			cargoHome, found = rustup.CargoHome(layer), true
+			toolchainPath = filepath.Join(cargoHome, "bin")
+		}
+
 		if !found {
 			return libcnb.BuildResult{}, fmt.Errorf("unable to locate cargo home")
 		}
@@ -89,66 +199,793 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 
 		excludeFolders, _ := cr.Resolve("BP_EXCLUDE_FILES")
 
-		cargoWorkspaceMembers, _ := cr.Resolve("BP_CARGO_WORKSPACE_MEMBERS")
-		cargoInstallArgs, _ := cr.Resolve("BP_CARGO_INSTALL_ARGS")
+		workingDir, _ := cr.Resolve("BP_CARGO_WORKING_DIR")
+		manifestDir := context.Application.Path
+		if workingDir != "" && !filepath.IsAbs(workingDir) {
+			manifestDir = filepath.Join(manifestDir, workingDir)
+		}
+
+		projectSettings, err := ResolveProjectSettings(cr, manifestDir, b.Logger)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+
+		cargoWorkspaceMembers := projectSettings.WorkspaceMembers
+		if err := runner.ParseMemberFilter(cargoWorkspaceMembers).Validate(); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_WORKSPACE_MEMBERS\n%w", err)
+		}
+		memberSelection, _ := cr.Resolve("BP_CARGO_WORKSPACE_MEMBER_SELECTION")
+		if memberSelection != "" && memberSelection != runner.MemberSelectionPath && memberSelection != runner.MemberSelectionPackage {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_WORKSPACE_MEMBER_SELECTION must be %q or %q, found %q", runner.MemberSelectionPath, runner.MemberSelectionPackage, memberSelection)
+		}
+		unmatchedMembers, _ := cr.Resolve("BP_CARGO_WORKSPACE_MEMBERS_UNMATCHED")
+		if unmatchedMembers != "" && unmatchedMembers != runner.UnmatchedMembersFail && unmatchedMembers != runner.UnmatchedMembersWarn {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_WORKSPACE_MEMBERS_UNMATCHED must be %q or %q, found %q", runner.UnmatchedMembersFail, runner.UnmatchedMembersWarn, unmatchedMembers)
+		}
+		cargoInstallArgs := projectSettings.InstallArgs
 		skipSBOMScan := cr.ResolveBool("BP_DISABLE_SBOM")
-		staticType, _ := cr.Resolve("BP_STATIC_BINARY_TYPE")
+		dependencySBOM := !skipSBOMScan && cr.ResolveBool("BP_CARGO_DEPENDENCY_SBOM_ENABLED")
+		licenseReport := cr.ResolveBool("BP_CARGO_LICENSE_REPORT_ENABLED")
+		staticType := projectSettings.StaticType
+
+		portabilityCheck, _ := cr.Resolve("BP_CARGO_PORTABILITY_CHECK")
+		nonPortable, err := CheckPortability(portabilityCheck, context.Application.Path, cargoInstallArgs)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+		for _, f := range nonPortable {
+			b.Logger.Infof("%s: %s, binaries may crash with SIGILL on nodes with a different CPU than the builder", color.YellowString("Warning"), f)
+		}
+
+		buildCommand, _ := cr.Resolve("BP_CARGO_BUILD_COMMAND")
+		if buildCommand != "" && buildCommand != runner.BuildCommandInstall && buildCommand != runner.BuildCommandBuild {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_BUILD_COMMAND must be %q or %q, found %q", runner.BuildCommandInstall, runner.BuildCommandBuild, buildCommand)
+		}
+
+		buildProfile, _ := cr.Resolve("BP_CARGO_BUILD_PROFILE")
+		debugBuild := cr.ResolveBool("BP_CARGO_DEBUG_BUILD")
+
+		logMode, _ := cr.Resolve("BP_CARGO_LOG_MODE")
+		if logMode != "" && logMode != runner.LogModeFull && logMode != runner.LogModeSummary {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_LOG_MODE must be %q or %q, found %q", runner.LogModeFull, runner.LogModeSummary, logMode)
+		}
+		messageSummary := cr.ResolveBool("BP_CARGO_MESSAGE_SUMMARY")
+
+		unstable := cr.ResolveBool("BP_CARGO_UNSTABLE_ENABLED")
+		unstableFlagsRaw, _ := cr.Resolve("BP_CARGO_UNSTABLE_FLAGS")
+		unstableFlags, err := shellwords.Parse(unstableFlagsRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_UNSTABLE_FLAGS=%q\n%w", unstableFlagsRaw, err)
+		}
+
+		for i, flag := range unstableFlags {
+			if !strings.HasPrefix(flag, "-Z") && (i == 0 || unstableFlags[i-1] != "-Z") {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_UNSTABLE_FLAGS only accepts -Z flags, found %q", flag)
+			}
+		}
+
+		if unstable {
+			b.Logger.Infof("%s: unstable Rust features are enabled with RUSTC_BOOTSTRAP=1. Unstable features may change or be removed in any release and the build is not guaranteed to work with a different toolchain.", color.YellowString("Warning"))
+			if len(unstableFlags) > 0 {
+				b.Logger.Infof("%s: passing unstable flags to cargo: %s", color.YellowString("Warning"), strings.Join(unstableFlags, " "))
+			}
+		}
+
+		// an offline build only uses the dependencies vendored with the application
+		offlineBuild := cr.ResolveBool("BP_CARGO_OFFLINE_BUILD")
+
+		// a locked build fails instead of updating Cargo.lock
+		lockedBuild := cr.ResolveBool("BP_CARGO_LOCKED_BUILD")
+
+		// there is nothing to download for an offline build
+		fetch := cr.ResolveBool("BP_CARGO_FETCH_ENABLED") && !offlineBuild
+
+		runTests := cr.ResolveBool("BP_CARGO_RUN_TESTS")
+		testArgsRaw, _ := cr.Resolve("BP_CARGO_TEST_ARGS")
+		testArgs, err := shellwords.Parse(testArgsRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_TEST_ARGS=%q\n%w", testArgsRaw, err)
+		}
+
+		slowestCratesRaw, _ := cr.Resolve("BP_CARGO_REPORT_SLOWEST_CRATES")
+		slowestCrates := 0
+		if slowestCratesRaw != "" {
+			slowestCrates, err = strconv.Atoi(slowestCratesRaw)
+			if err != nil || slowestCrates < 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_REPORT_SLOWEST_CRATES must be a non-negative number, found %q", slowestCratesRaw)
+			}
+		}
+
+		// the timings of each unit are only written as JSON with unstable options
+		timings := cr.ResolveBool("BP_CARGO_TIMINGS_ENABLED")
+
+		targetCacheMaxSizeRaw, _ := cr.Resolve("BP_CARGO_TARGET_CACHE_MAX_SIZE")
+		var targetCacheMaxSize int64
+		if targetCacheMaxSizeRaw != "" {
+			targetCacheMaxSize, err = runner.ParseBytes(targetCacheMaxSizeRaw)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_TARGET_CACHE_MAX_SIZE\n%w", err)
+			}
+		}
+
+		targetRetentionRaw, _ := cr.Resolve("BP_CARGO_TARGET_CACHE_RETENTION")
+		var targetRetention runner.RetentionPolicy
+		if targetRetentionRaw != "" {
+			targetRetention, err = runner.ParseRetentionPolicy(targetRetentionRaw)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_TARGET_CACHE_RETENTION\n%w", err)
+			}
+		}
+
+		// the cached target directory is linked into each project, cargo install compiles in it when it is bounded
+		targetDir := ""
+		if targetCacheMaxSize > 0 {
+			targetDir = "target"
+		}
+
+		profileOverrides := map[string]string{}
+		for name, setting := range map[string]string{
+			"BP_CARGO_DEBUG_ASSERTIONS": "debug-assertions",
+			"BP_CARGO_OVERFLOW_CHECKS":  "overflow-checks",
+		} {
+			raw, _ := cr.Resolve(name)
+			if raw == "" {
+				continue
+			}
+
+			enabled, err := strconv.ParseBool(raw)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("%s must be true or false, found %q", name, raw)
+			}
+			profileOverrides[setting] = strconv.FormatBool(enabled)
+		}
+
+		profileVariables, err := runner.ProfileVariables(os.Environ())
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+
+		stripSymbols := cr.ResolveBool("BP_CARGO_STRIP_SYMBOLS")
+		compressBinaries := cr.ResolveBool("BP_CARGO_COMPRESS_BINARIES")
+		auditable := cr.ResolveBool("BP_CARGO_AUDITABLE_ENABLED")
+
+		securityAudit := cr.ResolveBool("BP_CARGO_AUDIT_ENABLED")
+		auditFailSeverity, _ := cr.Resolve("BP_CARGO_AUDIT_FAIL_SEVERITY")
+		if auditFailSeverity != "" {
+			if err := runner.ValidateSeverity(auditFailSeverity); err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("invalid BP_CARGO_AUDIT_FAIL_SEVERITY\n%w", err)
+			}
+		}
+		auditIgnoreRaw, _ := cr.Resolve("BP_CARGO_AUDIT_IGNORE")
+		auditIgnore := strings.FieldsFunc(auditIgnoreRaw, func(r rune) bool { return r == ',' || r == ' ' })
+
+		// sccache caches compiled crates in its own layer, which is shared by all projects
+		sccacheEnabled := cr.ResolveBool("BP_CARGO_SCCACHE_ENABLED")
+		var sccacheDir string
+		if sccacheEnabled {
+			sccache := SccacheCache{Logger: b.Logger}
+			layer, err := context.Layers.Layer(sccache.Name())
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to get layer %s\n%w", sccache.Name(), err)
+			}
+			sccacheDir = layer.Path
+			result.Layers = append(result.Layers, sccache)
+		}
+
+		rustcWrapperRaw, _ := cr.Resolve("BP_CARGO_RUSTC_WRAPPER")
+		rustcWrapper, err := runner.ResolveRustcWrapper(rustcWrapperRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve BP_CARGO_RUSTC_WRAPPER\n%w", err)
+		}
+
+		// link time dominates the builds of many large applications, which faster linkers cut down
+		linker, _ := cr.Resolve("BP_CARGO_LINKER")
+		linkerPath, err := runner.ResolveLinker(linker)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve BP_CARGO_LINKER\n%w", err)
+		}
+		if linker != "" {
+			b.Logger.Bodyf("Linking with %s at %s, RUSTFLAGS gets %s", linker, linkerPath, strings.Join(runner.LinkerFlags(linker), " "))
+		}
+
+		platformsRaw, _ := cr.Resolve("BP_CARGO_PLATFORMS")
+		platforms, err := runner.ParsePlatforms(platformsRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_PLATFORMS=%q\n%w", platformsRaw, err)
+		}
+
+		targetsRaw, _ := cr.Resolve("BP_CARGO_TARGETS")
+		targets, err := runner.ParseTargets(targetsRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_TARGETS=%q\n%w", targetsRaw, err)
+		}
+
+		crossTool, _ := cr.Resolve("BP_CARGO_CROSS_TOOL")
+		if crossTool != "" && crossTool != runner.CrossToolCargo && crossTool != runner.CrossToolZigbuild {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_CROSS_TOOL must be %q or %q, found %q", runner.CrossToolCargo, runner.CrossToolZigbuild, crossTool)
+		}
+
+		// a target replaces the default target of the stack, like --target in the install arguments
+		target, _ := cr.Resolve("BP_CARGO_TARGET")
+		targetArgs := cargoInstallArgs
+		if target != "" {
+			targetArgs = strings.TrimSpace(fmt.Sprintf("%s --target=%s", cargoInstallArgs, target))
+		}
+
+		targetTriple, err := runner.ResolveTargetTriple(targetArgs, context.StackID, staticType)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve target triple\n%w", err)
+		}
+
+		allocatorRaw, _ := cr.Resolve("BP_CARGO_ALLOCATOR")
+		allocators, err := ResolveAllocators(context.Application.Path, allocatorRaw)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+
+		allocatorFeature, _ := cr.Resolve("BP_CARGO_ALLOCATOR_FEATURE")
+		provenanceEnabled := cr.ResolveBool("BP_CARGO_PROVENANCE_ENABLED")
+
+		// all conflicts are reported together, before anything is compiled
+		if err := CheckConflicts(Configuration{
+			Allocator:         allocatorRaw,
+			AllocatorFeature:  allocatorFeature,
+			AuditFailSeverity: auditFailSeverity,
+			Auditable:         auditable,
+			BuildCommand:      buildCommand,
+			BuildProfile:      buildProfile,
+			CacheWarming:      cacheWarming,
+			CompressBinaries:  compressBinaries,
+			CrossTool:         crossTool,
+			DebugBuild:        debugBuild,
+			InstallArgs:       cargoInstallArgs,
+			Linker:            linker,
+			MemberSelection:   memberSelection,
+			Platforms:         platforms,
+			Provenance:        provenanceEnabled,
+			RustcWrapper:      rustcWrapperRaw,
+			Sccache:           sccacheEnabled,
+			SecurityAudit:     securityAudit,
+			Stack:             context.StackID,
+			StaticType:        staticType,
+			Target:            target,
+			Targets:           targets,
+			Timings:           timings,
+			UnmatchedMembers:  unmatchedMembers,
+			Unstable:          unstable,
+			UnstableFlags:     unstableFlags,
+			WorkspaceMembers:  cargoWorkspaceMembers,
+		}); err != nil {
+			return libcnb.BuildResult{}, err
+		}
+
+		allocatorEnv, err := AllocatorBuildEnvironment(allocators, targetTriple)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to determine allocator build environment\n%w", err)
+		}
+		if len(allocators) > 0 {
+			b.Logger.Bodyf("Preparing the build for the %s allocator", strings.Join(allocators, " and "))
+		}
+
+		configSources, err := CargoConfigSources(context.Application.Path, cr.ResolveBool("BP_CARGO_CONFIG_MERGE_ENABLED"), context.Platform.Bindings)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to read Cargo configuration\n%w", err)
+		}
+		for _, source := range configSources {
+			b.Logger.Bodyf("Merging the Cargo configuration of %s into CARGO_HOME", source.Name)
+		}
+		if err := MergeCargoConfig(cargoHome, configSources); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to merge Cargo configuration\n%w", err)
+		}
+
+		installRegistry, _ := cr.Resolve("BP_CARGO_INSTALL_REGISTRY")
+		installIndex, _ := cr.Resolve("BP_CARGO_INSTALL_INDEX")
+
+		// the registries of bindings are configured for cargo, so that their tokens are not part of any argument
+		registryCredentials, err := runner.RegistryCredentials(context.Platform.Bindings)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to configure registries of bindings\n%w", err)
+		}
+
+		if err := ValidateInstallSource(context.Application.Path, cargoHome, cargoInstallArgs, installRegistry, installIndex, registryCredentials); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("invalid registry for cargo install\n%w", err)
+		}
+
+		gitCredentials, err := runner.GitCredentials(context.Platform.Bindings)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to configure git credentials of bindings\n%w", err)
+		}
+
+		// only the name of the temporary directory is reserved, each cargo layer writes the credentials into it while
+		// it is built and removes them once it is done, so that nothing is left behind if the build fails
+		var gitCredentialsDir string
+		if len(gitCredentials) > 0 {
+			gitCredentialsDir, err = os.MkdirTemp("", "git-credentials")
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to create git credentials directory\n%w", err)
+			}
+			if err := os.Remove(gitCredentialsDir); err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to remove %s\n%w", gitCredentialsDir, err)
+			}
+			b.Logger.Bodyf("Fetching git dependencies with the git CLI and the credentials of %d bindings", len(gitCredentials))
+		}
+		gitEnv := runner.GitEnvironment(gitCredentialsDir, gitCredentials)
+
+		cargoEnv := map[string]string{}
+		for k, v := range gitEnv {
+			cargoEnv[k] = v
+		}
+		for k, v := range allocatorEnv {
+			cargoEnv[k] = v
+		}
+
+		// enables the feature of the application that selects the allocator as #[global_allocator]
+		if allocatorFeature != "" {
+			cargoInstallArgs = strings.TrimSpace(fmt.Sprintf("%s --features %s", cargoInstallArgs, allocatorFeature))
+		}
+
+		colorRaw, _ := cr.Resolve("BP_CARGO_COLOR")
+		cargoColor, err := runner.ResolveColor(colorRaw, os.Stdout)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve BP_CARGO_COLOR\n%w", err)
+		}
+
+		// colored diagnostics are only emitted by rustc when it runs in a terminal, commands whose output is parsed run
+		// without it
+		executor := runner.ProcessExecutor{TTY: cargoColor == runner.ColorAlways}
+
+		commandTimeoutRaw, _ := cr.Resolve("BP_CARGO_COMMAND_TIMEOUT")
+		var commandTimeout time.Duration
+		if commandTimeoutRaw != "" {
+			commandTimeout, err = time.ParseDuration(commandTimeoutRaw)
+			if err != nil || commandTimeout <= 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_COMMAND_TIMEOUT must be a positive duration like 30m, found %q", commandTimeoutRaw)
+			}
+		}
+
+		networkRetriesRaw, _ := cr.Resolve("BP_CARGO_NETWORK_RETRIES")
+		retryPolicy := runner.RetryPolicy{Backoff: runner.DefaultRetryBackoff, Retries: DefaultNetworkRetries}
+		if networkRetriesRaw != "" {
+			retryPolicy.Retries, err = strconv.Atoi(networkRetriesRaw)
+			if err != nil || retryPolicy.Retries < 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_NETWORK_RETRIES must be a non-negative number, found %q", networkRetriesRaw)
+			}
+		}
+		retryBackoffRaw, _ := cr.Resolve("BP_CARGO_NETWORK_RETRY_BACKOFF")
+		if retryBackoffRaw != "" {
+			retryPolicy.Backoff, err = time.ParseDuration(retryBackoffRaw)
+			if err != nil || retryPolicy.Backoff < 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_NETWORK_RETRY_BACKOFF must be a duration like 5s, found %q", retryBackoffRaw)
+			}
+		}
+
+		memoryPerJob := runner.DefaultMemoryPerJob
+		if memoryPerJobRaw, _ := cr.Resolve("BP_CARGO_MEMORY_PER_JOB"); memoryPerJobRaw != "" {
+			memoryPerJob, err = runner.ParseBytes(memoryPerJobRaw)
+			if err != nil || memoryPerJob <= 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_MEMORY_PER_JOB must be a positive size like 2G, found %q", memoryPerJobRaw)
+			}
+		}
+
+		jobsRaw, _ := cr.Resolve("BP_CARGO_JOBS")
+		jobs := 0
+		switch jobsRaw {
+		case "":
+		case JobsAuto:
+			// builders often have less memory than rustc needs for a job on every CPU, which gets the build OOM-killed
+			resources, err := runner.AvailableResources("/")
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to determine available resources\n%w", err)
+			}
+			if jobs = resources.Jobs(memoryPerJob); jobs > 0 {
+				b.Logger.Bodyf("Limiting cargo to %d parallel jobs, as %s of memory is available for %d CPUs",
+					jobs, runner.FormatBytes(resources.Memory), resources.CPUs)
+			}
+		default:
+			jobs, err = strconv.Atoi(jobsRaw)
+			if err != nil || jobs <= 0 {
+				return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_JOBS must be %q or a positive number, found %q", JobsAuto, jobsRaw)
+			}
+		}
+
+		statistics := &runner.Statistics{}
+		cacheUsage := &CacheUsage{}
 
 		service := b.CargoService
 		if service == nil {
+			if err := CheckToolchain(toolchainPath, cr.ResolveBool("BP_CARGO_RUSTUP_ENABLED")); err != nil {
+				return libcnb.BuildResult{}, err
+			}
+
 			service = runner.NewCargoRunner(
+				runner.WithAuditIgnore(auditIgnore),
+				runner.WithAuditable(auditable),
+				runner.WithCargoFeatures(projectSettings.Features),
 				runner.WithCargoHome(cargoHome),
 				runner.WithCargoWorkspaceMembers(cargoWorkspaceMembers),
 				runner.WithCargoInstallArgs(cargoInstallArgs),
-				runner.WithExecutor(effect.NewExecutor()),
+				runner.WithColor(cargoColor),
+				runner.WithCommandTimeout(commandTimeout),
+				runner.WithCompressBinaries(compressBinaries),
+				runner.WithContext(b.Context),
+				runner.WithCrossTool(crossTool),
+				runner.WithDebugBuild(debugBuild),
+				runner.WithEnv(cargoEnv),
+				runner.WithExecutor(executor),
+				runner.WithIndex(installIndex),
+				runner.WithJobs(jobs),
+				runner.WithLinker(linker),
+				runner.WithLockedBuild(lockedBuild),
+				runner.WithLogMode(logMode),
 				runner.WithLogger(b.Logger),
+				runner.WithMemberSelection(memberSelection),
+				runner.WithMessageSummary(messageSummary),
+				runner.WithNoDefaultFeatures(projectSettings.NoDefaultFeatures),
+				runner.WithOfflineBuild(offlineBuild),
+				runner.WithProfile(buildProfile),
+				runner.WithProfileOverrides(profileOverrides),
+				runner.WithProfileVariables(profileVariables),
+				runner.WithRegistry(installRegistry),
+				runner.WithRegistryCredentials(registryCredentials),
+				runner.WithRetryPolicy(retryPolicy),
+				runner.WithRustcWrapper(rustcWrapper),
+				runner.WithSccache(sccacheDir),
 				runner.WithStack(context.StackID),
-				runner.WithStaticType(staticType))
+				runner.WithStaticType(staticType),
+				runner.WithStatistics(statistics),
+				runner.WithStripSymbols(stripSymbols),
+				runner.WithTarget(target),
+				runner.WithTargetDir(targetDir),
+				runner.WithTimings(slowestCrates > 0),
+				runner.WithTimingsJSON(timings),
+				runner.WithToolStrategies(toolStrategies),
+				runner.WithToolchainPath(toolchainPath),
+				runner.WithUnmatchedMembers(unmatchedMembers),
+				runner.WithUnstable(unstable, unstableFlags),
+				runner.WithWorkingDir(workingDir))
+		}
+
+		if err := ValidateRustVersion(service, rustVersion); err != nil {
+			return libcnb.BuildResult{}, err
+		}
+
+		if err := service.ValidateToolchain(context.Application.Path); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unsupported Rust toolchain\n%w", err)
+		}
+
+		if err := ValidateToolchainFile(service, toolchainFile); err != nil {
+			return libcnb.BuildResult{}, err
 		}
 
-		cache := Cache{
-			AppPath: context.Application.Path,
-			Logger:  b.Logger,
+		toolchainCheck, _ := cr.Resolve("BP_CARGO_RUST_TOOLCHAIN_CHECK")
+		if toolchainCheck == "" {
+			toolchainCheck = ToolchainCheckWarn
+		}
+		if toolchainCheck != ToolchainCheckWarn && toolchainCheck != ToolchainCheckFail && toolchainCheck != ToolchainCheckOff {
+			return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_RUST_TOOLCHAIN_CHECK must be %q, %q or %q, found %q",
+				ToolchainCheckWarn, ToolchainCheckFail, ToolchainCheckOff, toolchainCheck)
+		}
+
+		if toolchainCheck != ToolchainCheckOff {
+			maxBehindRaw, _ := cr.Resolve("BP_CARGO_RUST_MAX_RELEASES_BEHIND")
+			maxBehind := DefaultMaxReleasesBehind
+			if maxBehindRaw != "" {
+				maxBehind, err = strconv.Atoi(maxBehindRaw)
+				if err != nil || maxBehind < 0 {
+					return libcnb.BuildResult{}, fmt.Errorf("BP_CARGO_RUST_MAX_RELEASES_BEHIND must be a non-negative number, found %q", maxBehindRaw)
+				}
+			}
+
+			calendar, found, err := NewReleaseCalendar(context.Buildpack.Metadata)
+			if err != nil {
+				return libcnb.BuildResult{}, err
+			}
+			if path, ok := cr.Resolve("BP_CARGO_RUST_RELEASE_CALENDAR"); ok && path != "" {
+				if calendar, err = ReadReleaseCalendar(path); err != nil {
+					return libcnb.BuildResult{}, err
+				}
+				found = true
+			}
+
+			if found {
+				version, err := service.RustVersion()
+				if err != nil {
+					return libcnb.BuildResult{}, fmt.Errorf("unable to determine rust version\n%w", err)
+				}
+
+				if err := CheckToolchainAge(calendar, version.Version, maxBehind, toolchainCheck, time.Now(), b.Logger); err != nil {
+					return libcnb.BuildResult{}, err
+				}
+			}
 		}
-		result.Layers = append(result.Layers, cache)
 
 		sbomScanner := sbom.NewSyftCLISBOMScanner(context.Layers, effect.NewExecutor(), b.Logger)
 
 		cargoToolsRaw, _ := cr.Resolve("BP_CARGO_INSTALL_TOOLS")
-		cargoTools, err := shellwords.Parse(cargoToolsRaw)
+		toolRequests, err := runner.ParseToolRequests(cargoToolsRaw)
 		if err != nil {
 			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_INSTALL_TOOLS=%q\n%w", cargoToolsRaw, err)
 		}
 
+		if err := runner.ValidateToolRequests(toolRequests, toolStrategies); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("invalid BP_CARGO_INSTALL_TOOLS=%q\n%w", cargoToolsRaw, err)
+		}
+
 		cargoToolsArgsRaw, _ := cr.Resolve("BP_CARGO_INSTALL_TOOLS_ARGS")
 		cargoToolsArgs, err := shellwords.Parse(cargoToolsArgsRaw)
 		if err != nil {
 			return libcnb.BuildResult{}, fmt.Errorf("unable to parse BP_CARGO_INSTALL_TOOLS_ARGS=%q\n%w", cargoToolsArgsRaw, err)
 		}
 
-		cargoLayer, err := NewCargo(
-			WithApplicationPath(context.Application.Path),
-			WithCargoService(service),
-			WithIncludeFolders(includeFolders),
-			WithExcludeFolders(excludeFolders),
-			WithInstallArgs(cargoInstallArgs),
-			WithLogger(b.Logger),
-			WithRunSBOMScan(!skipSBOMScan),
-			WithSBOMScanner(sbomScanner),
-			WithStack(context.StackID),
-			WithTools(cargoTools),
-			WithToolsArgs(cargoToolsArgs),
-			WithWorkspaceMembers(cargoWorkspaceMembers))
+		additionalMetadata := map[string]interface{}{}
+		if workingDir != "" {
+			additionalMetadata["working-dir"] = workingDir
+		}
+		if installRegistry != "" {
+			additionalMetadata["install-registry"] = installRegistry
+		}
+		if installIndex != "" {
+			additionalMetadata["install-index"] = installIndex
+		}
+		// the features are not part of the install arguments, but change the binaries
+		if features := runner.ParseFeatures(projectSettings.Features); len(features) > 0 {
+			additionalMetadata["features"] = features
+		}
+		if projectSettings.NoDefaultFeatures {
+			additionalMetadata["no-default-features"] = true
+		}
+		// the overrides are not part of the install arguments, but change the binaries
+		if buildProfile != "" {
+			additionalMetadata["build-profile"] = buildProfile
+		}
+		if debugBuild {
+			additionalMetadata["debug-build"] = true
+		}
+		if len(profileOverrides) > 0 {
+			additionalMetadata["profile-overrides"] = profileOverrides
+		}
+		if len(profileVariables) > 0 {
+			additionalMetadata["profile-variables"] = profileVariables
+		}
+		if stripSymbols {
+			additionalMetadata["strip-symbols"] = true
+		}
+		if compressBinaries {
+			additionalMetadata["compress-binaries"] = true
+		}
+		if len(auditIgnore) > 0 {
+			additionalMetadata["audit-ignore"] = auditIgnore
+		}
+		if len(platforms) > 0 {
+			var names []string
+			for _, p := range platforms {
+				names = append(names, p.String())
+			}
+			additionalMetadata["platforms"] = names
+		}
+		if len(targets) > 0 {
+			var triples []string
+			for _, t := range targets {
+				triples = append(triples, t.Target)
+			}
+			additionalMetadata["targets"] = triples
+		}
+
+		// a pre-populated CARGO_HOME may be provided by a mounted volume or a binding
+		cargoHomeSeed, _ := cr.Resolve("BP_CARGO_HOME_SEED")
+		if cargoHomeSeed == "" {
+			binding, ok, err := bindings.ResolveOne(context.Platform.Bindings, bindings.OfType(BindingTypeCargoHome))
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to resolve binding %s\n%w", BindingTypeCargoHome, err)
+			}
+			if ok {
+				cargoHomeSeed = binding.Path
+			}
+		}
+
+		var indexSnapshot string
+		if cr.ResolveBool("BP_CARGO_INDEX_SNAPSHOT") {
+			indexSnapshot = filepath.Join(context.Layers.Path, IndexSnapshot{}.Name())
+		}
+
+		projectsRaw, _ := cr.Resolve("BP_CARGO_PROJECTS")
+		projects, err := Projects(context.Application.Path, projectsRaw)
 		if err != nil {
-			return libcnb.BuildResult{}, fmt.Errorf("unable to create cargo layer contributor\n%w", err)
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve BP_CARGO_PROJECTS\n%w", err)
+		}
+
+		for i, project := range projects {
+			if lockedBuild {
+				if err := service.ValidateLockFile(project.Path); err != nil {
+					return libcnb.BuildResult{}, err
+				}
+			}
+
+			cacheKey, err := service.CacheKey(project.Path)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to compute cache key\n%w", err)
+			}
+
+			result.Layers = append(result.Layers, Cache{
+				AppPath: project.Path,
+				Key:     cacheKey,
+				Logger:  b.Logger,
+				Project: project.Name,
+			})
+
+			// tools are shared by all projects, so they are only installed once
+			tools := toolRequests
+			if i > 0 {
+				tools = nil
+			}
+
+			cargoLayer, err := NewCargo(
+				WithAddDefaultTarget(cr.ResolveBool("BP_CARGO_ADD_DEFAULT_TARGET")),
+				WithAdditionalMetadata(additionalMetadata),
+				WithApplicationPath(project.Path),
+				WithAuditFailSeverity(auditFailSeverity),
+				WithAuditable(auditable),
+				WithCacheUsage(cacheUsage),
+				WithBuildCommand(buildCommand),
+				WithCacheWarming(cacheWarming),
+				WithCargoHomeSeed(cargoHomeSeed),
+				WithCargoService(service),
+				WithCrossTool(crossTool),
+				WithDependencySBOM(dependencySBOM),
+				WithGitCredentials(gitCredentialsDir, gitCredentials),
+				WithIncludeFolders(includeFolders),
+				WithIndexSnapshot(indexSnapshot),
+				WithExcludeFolders(excludeFolders),
+				WithFetch(fetch),
+				WithInstallArgs(cargoInstallArgs),
+				WithLicenseReport(licenseReport),
+				WithLogger(b.Logger),
+				WithPlatforms(append(platforms, targets...)),
+				WithProject(project.Name),
+				WithRunSBOMScan(!skipSBOMScan),
+				WithRunTests(runTests),
+				WithSBOMScanner(sbomScanner),
+				WithSccache(sccacheEnabled),
+				WithSecurityAudit(securityAudit),
+				WithSlowestCrates(slowestCrates),
+				WithStack(context.StackID),
+				WithStatistics(statistics),
+				WithTarget(target),
+				WithTargetCacheMaxSize(targetCacheMaxSize),
+				WithTargetRetention(targetRetention),
+				WithTestArgs(testArgs),
+				WithTimings(timings),
+				WithTools(tools),
+				WithToolsArgs(cargoToolsArgs),
+				WithUnstableFlags(unstableFlags),
+				WithWorkspaceMembers(cargoWorkspaceMembers))
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to create cargo layer contributor\n%w", err)
+			}
+
+			// a cache warming build does not install any binaries to launch
+			if !cacheWarming {
+				processes, err := cargoLayer.BuildProcessTypes(tiniEnabled)
+				if err != nil {
+					return libcnb.BuildResult{}, fmt.Errorf("unable to build list of process types\n%w", err)
+				}
+
+				// only the default process type of the first project remains the default
+				if len(result.Processes) > 0 {
+					for j := range processes {
+						processes[j].Default = false
+					}
+				}
+				result.Processes = append(result.Processes, processes...)
+			}
+
+			result.Layers = append(result.Layers, cargoLayer)
 		}
 
-		result.Processes, err = cargoLayer.BuildProcessTypes(tiniEnabled)
+		// the default process may be a binary of any project, so it is selected once all process types are known
+		if defaultProcess, _ := cr.Resolve("BP_CARGO_DEFAULT_PROCESS"); defaultProcess != "" && !cacheWarming {
+			if err := runner.SelectDefaultProcess(result.Processes, defaultProcess); err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to select BP_CARGO_DEFAULT_PROCESS=%s\n%w", defaultProcess, err)
+			}
+		}
+
+		targetSpec, err := runner.TargetSpec(cargoInstallArgs)
 		if err != nil {
-			return libcnb.BuildResult{}, fmt.Errorf("unable to build list of process types\n%w", err)
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve target specification\n%w", err)
 		}
 
-		result.Layers = append(result.Layers, cargoLayer)
+		var targetPath string
+		if targetSpec != "" {
+			targetPath = runner.CargoRunner{CargoHome: cargoHome}.TargetSpecDir()
+		}
+
+		result.Layers = append(result.Layers, Environment{
+			CargoHome:    cargoHome,
+			Logger:       b.Logger,
+			TargetPath:   targetPath,
+			TargetTriple: targetTriple,
+		})
+
+		// a cache warming build has nothing to launch
+		if !cacheWarming {
+			backtrace, _ := cr.Resolve("BP_CARGO_RUST_BACKTRACE")
+			rustLog, _ := cr.Resolve("BP_CARGO_RUST_LOG")
+			result.Layers = append(result.Layers, RuntimeEnvironment{
+				Allocators: allocators,
+				Backtrace:  backtrace,
+				Log:        rustLog,
+				Logger:     b.Logger,
+			})
+		}
+
+		result.Layers = append(result.Layers, CacheStatistics{Logger: b.Logger, Usage: cacheUsage})
+
+		if timings {
+			var paths []string
+			for _, project := range projects {
+				paths = append(paths, project.Path)
+			}
+			result.Layers = append(result.Layers, Timings{ApplicationPaths: paths, Logger: b.Logger, Statistics: statistics})
+		}
+
+		// taken after building, so that the next build restores the state of the index left by this one
+		if indexSnapshot != "" {
+			result.Layers = append(result.Layers, IndexSnapshot{CargoHome: cargoHome, Logger: b.Logger})
+		}
+
+		diskUsage := DiskUsage{
+			CargoHome: cargoHome,
+			Layers:    context.Layers,
+			Logger:    b.Logger,
+		}
+		for _, l := range result.Layers {
+			diskUsage.LayerNames = append(diskUsage.LayerNames, l.Name())
+		}
+		result.Layers = append(result.Layers, diskUsage)
+
+		if cr.ResolveBool("BP_CARGO_DIAGNOSTICS") {
+			if err := (Diagnostics{
+				ConfigurationResolver: cr,
+				Contributors:          result.Layers,
+				Environment:           os.Environ(),
+				Layers:                context.Layers,
+				Logger:                b.Logger,
+				Service:               service,
+				TargetTriple:          targetTriple,
+				ToolchainPath:         toolchainPath,
+			}).Log(); err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to log diagnostics\n%w", err)
+			}
+		}
+
+		appVersion, err := ApplicationVersion(context.Application.Path, cargoWorkspaceMembers)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to determine application version\n%w", err)
+		}
+
+		if appVersion != "" {
+			b.Logger.Bodyf("Labeling image with application version %s", appVersion)
+			result.Labels = append(result.Labels, libcnb.Label{Key: "org.opencontainers.image.version", Value: appVersion})
+		}
+
+		if provenanceEnabled && !cacheWarming {
+			provenance, err := b.provenance(context.Application.Path, appVersion, service)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to determine build provenance\n%w", err)
+			}
+
+			h := libpak.NewHelperLayerContributor(context.Buildpack, "provenance")
+			h.Logger = b.Logger
+			result.Layers = append(result.Layers, Provenance{Logger: b.Logger, Provenance: provenance}, h)
+		}
+
+		if !cacheWarming {
+			static, err := runner.IsStaticBuild(targetArgs, context.StackID, staticType)
+			if err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to determine if binaries are static\n%w", err)
+			}
+			result.Labels = append(result.Labels, RunImageLabels(static, context.StackID, b.Logger)...)
+		}
 
 		if skipSBOMScan {
 			result.Labels = append(result.Labels, libcnb.Label{Key: "io.paketo.sbom.disabled", Value: "true"})
@@ -157,3 +994,44 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 
 	return result, nil
 }
+
+// contributedLayer is a layer that was already contributed while the build was planned
+type contributedLayer struct {
+	Layer libcnb.Layer
+}
+
+func (c contributedLayer) Contribute(libcnb.Layer) (libcnb.Layer, error) {
+	return c.Layer, nil
+}
+
+func (c contributedLayer) Name() string {
+	return c.Layer.Name
+}
+
+// provenance describes how the application is built, for the `provenance` exec.d helper
+func (b Build) provenance(appDir string, appVersion string, service runner.CargoService) (helper.BuildProvenance, error) {
+	p := helper.BuildProvenance{AppVersion: appVersion}
+
+	var err error
+	if p.GitSHA, err = GitCommit(appDir); err != nil {
+		return helper.BuildProvenance{}, fmt.Errorf("unable to determine git commit\n%w", err)
+	}
+
+	if p.BuildTime, err = BuildTime(); err != nil {
+		return helper.BuildProvenance{}, fmt.Errorf("unable to determine build time\n%w", err)
+	}
+
+	cargoVersion, err := service.CargoVersion()
+	if err != nil {
+		return helper.BuildProvenance{}, fmt.Errorf("unable to determine cargo version\n%w", err)
+	}
+	p.CargoVersion = cargoVersion.Raw()
+
+	rustVersion, err := service.RustVersion()
+	if err != nil {
+		return helper.BuildProvenance{}, fmt.Errorf("unable to determine rust version\n%w", err)
+	}
+	p.RustVersion = rustVersion.Raw()
+
+	return p, nil
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+// BuildManifestFile is the file of the application layer that lists the artifacts produced by the build
+const BuildManifestFile = "build-manifest.json"
+
+// Artifact is a binary produced by the build. The package, member, profile and target are those recorded by
+// `cargo install` and are empty if the binary was not installed by cargo, like when cross compiling with zigbuild. The
+// platform, like `linux/arm64`, is the one of the directory that the binary is installed into.
+type Artifact struct {
+	Member   string `json:"member,omitempty"`
+	Name     string `json:"name"`
+	Package  string `json:"package,omitempty"`
+	Path     string `json:"path"`
+	Platform string `json:"platform,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+	SHA256   string `json:"sha256"`
+	Target   string `json:"target,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+// BuildManifest lists the artifacts produced by the build, so that downstream automation like signing does not have to
+// guess what was built. Platforms maps each platform to the paths of its artifacts, so that multi-arch image pipelines
+// can pick the binaries of each architecture.
+type BuildManifest struct {
+	Artifacts []Artifact          `json:"artifacts"`
+	Platforms map[string][]string `json:"platforms,omitempty"`
+}
+
+// installRecord is an entry of the `.crates2.json` file that `cargo install` writes into its root
+type installRecord struct {
+	Bins    []string `json:"bins"`
+	Profile string   `json:"profile"`
+	Target  string   `json:"target"`
+}
+
+// NewBuildManifest lists the binaries of the layer, in `bin` for the host platform and in `platforms/<os>-<arch>/bin`.
+// Members are relative to appDir, `.` for the root package.
+func NewBuildManifest(layerPath string, appDir string) (BuildManifest, error) {
+	manifest := BuildManifest{Artifacts: []Artifact{}, Platforms: map[string][]string{}}
+	host := runner.HostPlatform().String()
+	records := map[string]map[string]installRecord{}
+
+	for _, dir := range []string{"bin", "platforms"} {
+		err := filepath.WalkDir(filepath.Join(layerPath, dir), func(path string, d fs.DirEntry, err error) error {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+
+			if !d.Type().IsRegular() || filepath.Base(filepath.Dir(path)) != "bin" {
+				return nil
+			}
+
+			// the root of `cargo install` holds the bin directory
+			root := filepath.Dir(filepath.Dir(path))
+			if _, ok := records[root]; !ok {
+				r, err := readInstallRecords(root)
+				if err != nil {
+					return err
+				}
+				records[root] = r
+			}
+
+			digest, err := fileSHA256(path)
+			if err != nil {
+				return err
+			}
+
+			artifact := Artifact{Name: d.Name(), Path: path, Platform: host, SHA256: digest}
+			if dir == "platforms" {
+				artifact.Platform = platformOf(filepath.Join(layerPath, dir), path)
+			}
+
+			for id, record := range records[root] {
+				if !containsBinary(record.Bins, d.Name()) {
+					continue
+				}
+
+				artifact.Package, artifact.Version, artifact.Member = parsePackageID(id, appDir)
+				artifact.Profile = record.Profile
+				artifact.Target = record.Target
+			}
+
+			manifest.Artifacts = append(manifest.Artifacts, artifact)
+			return nil
+		})
+		if err != nil {
+			return BuildManifest{}, fmt.Errorf("unable to list artifacts\n%w", err)
+		}
+	}
+
+	sort.Slice(manifest.Artifacts, func(i, j int) bool {
+		return manifest.Artifacts[i].Path < manifest.Artifacts[j].Path
+	})
+
+	for _, artifact := range manifest.Artifacts {
+		manifest.Platforms[artifact.Platform] = append(manifest.Platforms[artifact.Platform], artifact.Path)
+	}
+
+	return manifest, nil
+}
+
+// platformOf returns the platform of a binary in `platforms/<os>-<arch>/bin`, like `linux/arm64`
+func platformOf(platformsDir string, path string) string {
+	rel, err := filepath.Rel(platformsDir, path)
+	if err != nil {
+		return ""
+	}
+
+	dir, _, _ := strings.Cut(rel, string(filepath.Separator))
+	goos, arch, _ := strings.Cut(dir, "-")
+	return runner.Platform{OS: goos, Arch: arch}.String()
+}
+
+// Write writes the manifest to BuildManifestFile in the layer
+func (m BuildManifest) Write(layerPath string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode build manifest\n%w", err)
+	}
+
+	file := filepath.Join(layerPath, BuildManifestFile)
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+
+	return nil
+}
+
+func readInstallRecords(root string) (map[string]installRecord, error) {
+	var crates struct {
+		Installs map[string]installRecord `json:"installs"`
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, ".crates2.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read %s\n%w", filepath.Join(root, ".crates2.json"), err)
+	}
+
+	if err := json.Unmarshal(b, &crates); err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", filepath.Join(root, ".crates2.json"), err)
+	}
+
+	return crates.Installs, nil
+}
+
+// parsePackageID returns the name, version and member of a package id, like `app 0.1.0 (path+file:///workspace/app)`
+// or `path+file:///workspace/app#0.1.0`. The member is empty if the package is not a path of appDir.
+func parsePackageID(id string, appDir string) (string, string, string) {
+	var name, version, source string
+
+	if before, after, ok := strings.Cut(id, " ("); ok {
+		name, version, _ = strings.Cut(before, " ")
+		source = strings.TrimSuffix(after, ")")
+	} else {
+		var fragment string
+		source, fragment, _ = strings.Cut(id, "#")
+		if n, v, ok := strings.Cut(fragment, "@"); ok {
+			name, version = n, v
+		} else {
+			name, version = filepath.Base(strings.TrimPrefix(source, "path+file://")), fragment
+		}
+	}
+
+	if !strings.HasPrefix(source, "path+") {
+		return name, version, ""
+	}
+
+	u, err := url.Parse(strings.TrimPrefix(source, "path+"))
+	if err != nil {
+		return name, version, ""
+	}
+
+	member, err := filepath.Rel(appDir, u.Path)
+	if err != nil || strings.HasPrefix(member, "..") {
+		return name, version, ""
+	}
+
+	return name, version, member
+}
+
+func containsBinary(bins []string, name string) bool {
+	for _, b := range bins {
+		if b == name || b == strings.TrimSuffix(name, ".exe") {
+			return true
+		}
+	}
+	return false
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("unable to hash %s\n%w", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testBuildManifest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		layerPath string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		layerPath = t.TempDir()
+	})
+
+	write := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0755)).To(Succeed())
+	}
+
+	it("lists the binaries installed by cargo", func() {
+		write(filepath.Join(layerPath, "bin", "api"), "api-binary")
+		write(filepath.Join(layerPath, "bin", "worker"), "worker-binary")
+		write(filepath.Join(layerPath, ".crates2.json"), `{"installs": {
+			"api 0.1.0 (path+file://`+appDir+`/api)": {"bins": ["api"], "profile": "release", "target": "x86_64-unknown-linux-gnu"},
+			"path+file://`+appDir+`#worker@0.2.0": {"bins": ["worker"], "profile": "dev", "target": "x86_64-unknown-linux-musl"}
+		}}`)
+
+		manifest, err := cargo.NewBuildManifest(layerPath, appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(manifest.Artifacts).To(Equal([]cargo.Artifact{
+			{
+				Member:   "api",
+				Name:     "api",
+				Package:  "api",
+				Path:     filepath.Join(layerPath, "bin", "api"),
+				Platform: runner.HostPlatform().String(),
+				Profile:  "release",
+				SHA256:   "1cf323f540c124af3cfb06659f9653a0d048995e07b63919f4120732a8522e93",
+				Target:   "x86_64-unknown-linux-gnu",
+				Version:  "0.1.0",
+			},
+			{
+				Member:   ".",
+				Name:     "worker",
+				Package:  "worker",
+				Path:     filepath.Join(layerPath, "bin", "worker"),
+				Platform: runner.HostPlatform().String(),
+				Profile:  "dev",
+				SHA256:   "5c27c46d5f64239ea02773b2eab1ff05506066560fc247412c6142ee271c1d6f",
+				Target:   "x86_64-unknown-linux-musl",
+				Version:  "0.2.0",
+			},
+		}))
+	})
+
+	it("lists the binaries of other platforms", func() {
+		write(filepath.Join(layerPath, "bin", "app"), "app")
+		write(filepath.Join(layerPath, "platforms", "windows-amd64", "bin", "app.exe"), "app.exe")
+		write(filepath.Join(layerPath, "platforms", "windows-amd64", ".crates2.json"), `{"installs": {
+			"path+file://`+appDir+`#app@1.0.0": {"bins": ["app"], "profile": "release", "target": "x86_64-pc-windows-gnu"}
+		}}`)
+		Expect(os.MkdirAll(filepath.Join(layerPath, "platforms", "linux-amd64"), 0755)).To(Succeed())
+		Expect(os.Symlink(filepath.Join("..", "..", "bin"), filepath.Join(layerPath, "platforms", "linux-amd64", "bin"))).To(Succeed())
+
+		manifest, err := cargo.NewBuildManifest(layerPath, appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(manifest.Artifacts).To(HaveLen(2))
+		Expect(manifest.Artifacts[0].Path).To(Equal(filepath.Join(layerPath, "bin", "app")))
+		Expect(manifest.Artifacts[0].Target).To(BeEmpty())
+		Expect(manifest.Artifacts[1].Name).To(Equal("app.exe"))
+		Expect(manifest.Artifacts[1].Package).To(Equal("app"))
+		Expect(manifest.Artifacts[1].Target).To(Equal("x86_64-pc-windows-gnu"))
+		Expect(manifest.Artifacts[1].Platform).To(Equal("windows/amd64"))
+	})
+
+	it("groups the binaries by platform", func() {
+		t.Setenv("BP_ARCH", "amd64")
+		write(filepath.Join(layerPath, "bin", "app"), "app")
+		write(filepath.Join(layerPath, "platforms", "linux-amd64", "bin", "app"), "app-musl")
+		write(filepath.Join(layerPath, "platforms", "linux-arm64", "bin", "app"), "app-arm64")
+		write(filepath.Join(layerPath, "platforms", "linux-arm64", "bin", "worker"), "worker-arm64")
+
+		manifest, err := cargo.NewBuildManifest(layerPath, appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(manifest.Platforms).To(Equal(map[string][]string{
+			"linux/amd64": {
+				filepath.Join(layerPath, "bin", "app"),
+				filepath.Join(layerPath, "platforms", "linux-amd64", "bin", "app"),
+			},
+			"linux/arm64": {
+				filepath.Join(layerPath, "platforms", "linux-arm64", "bin", "app"),
+				filepath.Join(layerPath, "platforms", "linux-arm64", "bin", "worker"),
+			},
+		}))
+	})
+
+	it("writes the manifest into the layer", func() {
+		Expect(cargo.BuildManifest{Artifacts: []cargo.Artifact{{Name: "app", Path: "/layers/cargo/bin/app", SHA256: "abc"}}}.Write(layerPath)).To(Succeed())
+
+		b, err := os.ReadFile(filepath.Join(layerPath, cargo.BuildManifestFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		var manifest map[string]interface{}
+		Expect(json.Unmarshal(b, &manifest)).To(Succeed())
+		Expect(manifest).To(Equal(map[string]interface{}{
+			"artifacts": []interface{}{
+				map[string]interface{}{"name": "app", "path": "/layers/cargo/bin/app", "sha256": "abc"},
+			},
+		}))
+	})
+
+	it("is empty without binaries", func() {
+		manifest, err := cargo.NewBuildManifest(layerPath, appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Artifacts).To(BeEmpty())
+	})
+}
@@ -28,6 +28,8 @@ import (
 type Cache struct {
 	Logger  bard.Logger
 	AppPath string
+	Key     string
+	Project string
 }
 
 func (c Cache) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
@@ -50,10 +52,29 @@ func (c Cache) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 		c.Logger.Bodyf("Creating cached target directory %s", targetPath)
 	}
 
+	// the key of the previous build tells whether the cached dependencies match Cargo.lock and the toolchain
+	if c.Key != "" {
+		if previous, ok := layer.Metadata["cache-key"]; !ok {
+			c.Logger.Body("No cache key recorded for the cached target directory")
+		} else if previous == c.Key {
+			c.Logger.Body("Cargo.lock and toolchain are unchanged, cached dependencies are up to date")
+		} else {
+			c.Logger.Body("Cargo.lock or toolchain changed, cached dependencies will be rebuilt as needed")
+		}
+
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata["cache-key"] = c.Key
+	}
+
 	layer.Cache = true
 	return layer, nil
 }
 
-func (Cache) Name() string {
+func (c Cache) Name() string {
+	if c.Project != "" {
+		return fmt.Sprintf("Cargo Cache %s", c.Project)
+	}
 	return "Cargo Cache"
 }
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+// CacheStatisticsHistory is the number of builds kept in the layer metadata
+const CacheStatisticsHistory = 10
+
+// CacheUsage is how the registry and target caches were used while building the application
+type CacheUsage struct {
+	Builds       int
+	Compiled     int
+	CompileTime  time.Duration
+	Downloaded   int
+	Fresh        int
+	RegistryHits int
+	Registry     int
+	TargetReused bool
+}
+
+// Record adds the usage of building one project. compiled and downloaded are the crates processed by cargo, packages
+// and registry are the number of packages in Cargo.lock and how many of those come from a registry.
+func (c *CacheUsage) Record(compiled int, downloaded int, packages int, registry int, compileTime time.Duration, targetReused bool) {
+	c.Builds++
+	c.Compiled += compiled
+	c.CompileTime += compileTime
+	c.Downloaded += downloaded
+	c.Fresh += max(packages-compiled, 0)
+	c.Registry += registry
+	c.RegistryHits += max(registry-downloaded, 0)
+	c.TargetReused = c.TargetReused || targetReused
+}
+
+// CacheStatistics logs how effective the caches were and keeps a history of recent builds in the layer metadata, so
+// that trends can be analyzed
+type CacheStatistics struct {
+	Logger bard.Logger
+	Usage  *CacheUsage
+}
+
+func (c CacheStatistics) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	history := previousCacheStatistics(layer.Metadata)
+	layer.Metadata = map[string]interface{}{"cache-statistics": history}
+	// cached so that the history is kept across builds
+	layer.Cache = true
+
+	c.Logger.Header("Cache statistics")
+
+	if c.Usage == nil || c.Usage.Builds == 0 {
+		c.Logger.Body("Application layer reused, nothing was built")
+		return layer, nil
+	}
+
+	u := c.Usage
+	c.Logger.Bodyf("Registry cache: %d of %d crates served from cache, %d downloaded", u.RegistryHits, u.Registry, u.Downloaded)
+	if u.TargetReused {
+		c.Logger.Bodyf("Target cache: reused, %d crates were up to date", u.Fresh)
+	} else {
+		c.Logger.Body("Target cache: empty, all crates were compiled")
+	}
+
+	// the compile time of a crate is estimated from this build, or the previous build if nothing was compiled
+	var perCrate time.Duration
+	if u.Compiled > 0 {
+		perCrate = u.CompileTime / time.Duration(u.Compiled)
+	} else if len(history) > 0 {
+		if ms, ok := history[len(history)-1]["compile-time-per-crate-ms"].(int64); ok {
+			perCrate = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	saved := time.Duration(u.Fresh) * perCrate
+	if perCrate > 0 {
+		c.Logger.Bodyf("Estimated time saved: %s", saved.Round(time.Second))
+	} else {
+		c.Logger.Body("Estimated time saved: unknown")
+	}
+
+	history = append(history, map[string]interface{}{
+		"compiled":                  int64(u.Compiled),
+		"compile-time-per-crate-ms": perCrate.Milliseconds(),
+		"downloaded":                int64(u.Downloaded),
+		"fresh":                     int64(u.Fresh),
+		"registry":                  int64(u.Registry),
+		"registry-hits":             int64(u.RegistryHits),
+		"target-reused":             u.TargetReused,
+		"time-saved-ms":             saved.Milliseconds(),
+	})
+	if len(history) > CacheStatisticsHistory {
+		history = history[len(history)-CacheStatisticsHistory:]
+	}
+	layer.Metadata["cache-statistics"] = history
+
+	return layer, nil
+}
+
+func (CacheStatistics) Name() string {
+	return "Cargo Cache Statistics"
+}
+
+func previousCacheStatistics(metadata map[string]interface{}) []map[string]interface{} {
+	var history []map[string]interface{}
+
+	switch h := metadata["cache-statistics"].(type) {
+	case []map[string]interface{}:
+		history = h
+	case []interface{}:
+		for _, entry := range h {
+			if m, ok := entry.(map[string]interface{}); ok {
+				history = append(history, m)
+			}
+		}
+	}
+
+	return history
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testCacheStatistics(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf    *bytes.Buffer
+		layers libcnb.Layers
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+		layers = libcnb.Layers{Path: t.TempDir()}
+	})
+
+	it("records the cache usage", func() {
+		usage := &cargo.CacheUsage{}
+		usage.Record(2, 1, 10, 8, 4*time.Second, true)
+
+		Expect(*usage).To(Equal(cargo.CacheUsage{
+			Builds:       1,
+			Compiled:     2,
+			CompileTime:  4 * time.Second,
+			Downloaded:   1,
+			Fresh:        8,
+			Registry:     8,
+			RegistryHits: 7,
+			TargetReused: true,
+		}))
+	})
+
+	it("logs and stores the cache statistics", func() {
+		layer, err := layers.Layer("Cargo Cache Statistics")
+		Expect(err).NotTo(HaveOccurred())
+		layer.Metadata = map[string]interface{}{"cache-statistics": []interface{}{
+			map[string]interface{}{"compiled": int64(10)},
+		}}
+
+		usage := &cargo.CacheUsage{}
+		usage.Record(2, 1, 10, 8, 4*time.Second, true)
+
+		layer, err = cargo.CacheStatistics{Logger: bard.NewLogger(buf), Usage: usage}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Cache).To(BeTrue())
+		Expect(layer.Metadata["cache-statistics"]).To(Equal([]map[string]interface{}{
+			{"compiled": int64(10)},
+			{
+				"compiled":                  int64(2),
+				"compile-time-per-crate-ms": int64(2000),
+				"downloaded":                int64(1),
+				"fresh":                     int64(8),
+				"registry":                  int64(8),
+				"registry-hits":             int64(7),
+				"target-reused":             true,
+				"time-saved-ms":             int64(16000),
+			},
+		}))
+
+		Expect(buf.String()).To(ContainSubstring("Registry cache: 7 of 8 crates served from cache, 1 downloaded"))
+		Expect(buf.String()).To(ContainSubstring("Target cache: reused, 8 crates were up to date"))
+		Expect(buf.String()).To(ContainSubstring("Estimated time saved: 16s"))
+	})
+
+	it("keeps the history when nothing was built", func() {
+		layer, err := layers.Layer("Cargo Cache Statistics")
+		Expect(err).NotTo(HaveOccurred())
+		layer.Metadata = map[string]interface{}{"cache-statistics": []interface{}{
+			map[string]interface{}{"compiled": int64(10)},
+		}}
+
+		layer, err = cargo.CacheStatistics{Logger: bard.NewLogger(buf), Usage: &cargo.CacheUsage{}}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Metadata["cache-statistics"]).To(HaveLen(1))
+		Expect(buf.String()).To(ContainSubstring("Application layer reused, nothing was built"))
+	})
+}
@@ -17,12 +17,14 @@
 package cargo_test
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-community/cargo/cargo"
 	"github.com/sclevine/spec"
 )
@@ -66,4 +68,38 @@ func testCache(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(os.Readlink(targetPath)).To(Equal(layer.Path))
 	})
+
+	it("records the cache key and compares it to the cached build", func() {
+		buf := &bytes.Buffer{}
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.Cache{AppPath: appDir, Key: "abc", Logger: bard.NewLogger(buf)}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layer.Metadata).To(HaveKeyWithValue("cache-key", "abc"))
+		Expect(buf.String()).To(ContainSubstring("No cache key recorded"))
+
+		buf.Reset()
+		Expect(os.Remove(filepath.Join(appDir, "target"))).To(Succeed())
+		layer, err = cargo.Cache{AppPath: appDir, Key: "abc", Logger: bard.NewLogger(buf)}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).To(ContainSubstring("cached dependencies are up to date"))
+
+		buf.Reset()
+		Expect(os.Remove(filepath.Join(appDir, "target"))).To(Succeed())
+		layer, err = cargo.Cache{AppPath: appDir, Key: "def", Logger: bard.NewLogger(buf)}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layer.Metadata).To(HaveKeyWithValue("cache-key", "def"))
+		Expect(buf.String()).To(ContainSubstring("Cargo.lock or toolchain changed"))
+	})
+
+	it("does not record an empty cache key", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.Cache{AppPath: appDir}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layer.Metadata).NotTo(HaveKey("cache-key"))
+	})
 }
@@ -18,9 +18,12 @@ package cargo
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/buildpacks/libcnb"
 	"github.com/paketo-buildpacks/libpak"
@@ -30,11 +33,21 @@ import (
 	"github.com/paketo-buildpacks/source-removal/logic"
 	"github.com/paketo-community/cargo/mtimes"
 	"github.com/paketo-community/cargo/runner"
+	cargosbom "github.com/paketo-community/cargo/sbom"
 )
 
 // Option is a function for configuring a Cargo
 type Option func(cargo Cargo) Cargo
 
+// WithAddDefaultTarget sets whether the default target of a tiny or static stack, like musl, is installed with
+// `rustup target add` if it is missing
+func WithAddDefaultTarget(add bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.AddDefaultTarget = add
+		return cargo
+	}
+}
+
 // WithAdditionalMetadata sets additional metadata to include
 func WithAdditionalMetadata(metadata map[string]interface{}) Option {
 	return func(cargo Cargo) Cargo {
@@ -51,6 +64,30 @@ func WithApplicationPath(ap string) Option {
 	}
 }
 
+// WithAuditFailSeverity sets the severity of vulnerabilities, found by the security audit, that fails the build
+func WithAuditFailSeverity(severity string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.AuditFailSeverity = severity
+		return cargo
+	}
+}
+
+// WithAuditable sets whether the binaries are built with `cargo auditable`, which is installed if it is missing
+func WithAuditable(auditable bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Auditable = auditable
+		return cargo
+	}
+}
+
+// WithBuildCommand sets the command that builds the binaries, runner.BuildCommandInstall by default
+func WithBuildCommand(command string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.BuildCommand = command
+		return cargo
+	}
+}
+
 // WithCargoService sets cargo service
 func WithCargoService(s runner.CargoService) Option {
 	return func(cargo Cargo) Cargo {
@@ -67,6 +104,48 @@ func WithIncludeFolders(f string) Option {
 	}
 }
 
+// WithCacheUsage sets where the usage of the registry and target caches is recorded
+func WithCacheUsage(usage *CacheUsage) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.CacheUsage = usage
+		return cargo
+	}
+}
+
+// WithCacheWarming only fetches and compiles the dependencies of the application, so that the caches are warm for
+// later builds
+func WithCacheWarming(cacheWarming bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.CacheWarming = cacheWarming
+		return cargo
+	}
+}
+
+// WithCargoHomeSeed sets a pre-populated CARGO_HOME that seeds the registry and git caches on cold builds
+func WithCargoHomeSeed(seed string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.CargoHomeSeed = seed
+		return cargo
+	}
+}
+
+// WithCrossTool sets the tool used to cross compile for other platforms and targets, which is installed if it is
+// missing
+func WithCrossTool(crossTool string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.CrossTool = crossTool
+		return cargo
+	}
+}
+
+// WithDependencySBOM sets whether the crates resolved by `cargo metadata` are added to the SBOM of the layer
+func WithDependencySBOM(enabled bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.DependencySBOM = enabled
+		return cargo
+	}
+}
+
 // WithExcludeFolders sets logger
 func WithExcludeFolders(f string) Option {
 	return func(cargo Cargo) Cargo {
@@ -75,6 +154,33 @@ func WithExcludeFolders(f string) Option {
 	}
 }
 
+// WithGitCredentials sets the credentials of git dependencies and the temporary directory that they are written to
+// while the layer is built, which must not exist
+func WithGitCredentials(dir string, credentials []runner.GitCredential) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.GitCredentials = credentials
+		cargo.GitCredentialsDir = dir
+		return cargo
+	}
+}
+
+// WithIndexSnapshot sets the layer holding a snapshot of the registry index caches, restored into CARGO_HOME before
+// building
+func WithIndexSnapshot(snapshot string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.IndexSnapshot = snapshot
+		return cargo
+	}
+}
+
+// WithFetch sets whether the dependencies are downloaded with `cargo fetch` before compiling
+func WithFetch(fetch bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Fetch = fetch
+		return cargo
+	}
+}
+
 // WithInstallArgs sets install args
 func WithInstallArgs(args string) Option {
 	return func(cargo Cargo) Cargo {
@@ -83,6 +189,14 @@ func WithInstallArgs(args string) Option {
 	}
 }
 
+// WithLicenseReport sets whether the licenses of the dependencies are listed in a report in the layer
+func WithLicenseReport(report bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.LicenseReport = report
+		return cargo
+	}
+}
+
 // WithLogger sets logger
 func WithLogger(l bard.Logger) Option {
 	return func(cargo Cargo) Cargo {
@@ -91,6 +205,23 @@ func WithLogger(l bard.Logger) Option {
 	}
 }
 
+// WithPlatforms sets the platforms to cross compile the binaries for, in addition to the host platform
+func WithPlatforms(platforms []runner.Platform) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Platforms = platforms
+		return cargo
+	}
+}
+
+// WithProject sets the name of the project, which namespaces the layer and process types when an application has
+// multiple projects
+func WithProject(project string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Project = project
+		return cargo
+	}
+}
+
 // WithRunSBOMScan sets workspace members
 func WithRunSBOMScan(sc bool) Option {
 	return func(cargo Cargo) Cargo {
@@ -99,6 +230,14 @@ func WithRunSBOMScan(sc bool) Option {
 	}
 }
 
+// WithRunTests sets whether the tests of the workspace members are run before installing them
+func WithRunTests(runTests bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.RunTests = runTests
+		return cargo
+	}
+}
+
 // WithSBOMScanner sets workspace members
 func WithSBOMScanner(sc sbom.SBOMScanner) Option {
 	return func(cargo Cargo) Cargo {
@@ -107,6 +246,30 @@ func WithSBOMScanner(sc sbom.SBOMScanner) Option {
 	}
 }
 
+// WithSccache sets whether rustc is run through sccache, which is installed if it is missing
+func WithSccache(sccache bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Sccache = sccache
+		return cargo
+	}
+}
+
+// WithSecurityAudit sets whether the dependencies are audited with cargo-audit before compiling
+func WithSecurityAudit(audit bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.SecurityAudit = audit
+		return cargo
+	}
+}
+
+// WithSlowestCrates sets the number of slowest crates to report, zero disables the report
+func WithSlowestCrates(n int) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.SlowestCrates = n
+		return cargo
+	}
+}
+
 // WithStack sets logger
 func WithStack(stack string) Option {
 	return func(cargo Cargo) Cargo {
@@ -115,8 +278,58 @@ func WithStack(stack string) Option {
 	}
 }
 
-// WithTools sets logger
-func WithTools(tools []string) Option {
+// WithStatistics sets where the crates processed by cargo are counted
+func WithStatistics(statistics *runner.Statistics) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Statistics = statistics
+		return cargo
+	}
+}
+
+// WithTarget sets the target triple that the binaries are built for, which is installed if it is missing
+func WithTarget(target string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Target = target
+		return cargo
+	}
+}
+
+// WithTargetCacheMaxSize sets the maximum size in bytes of the cached target directory, which is pruned after
+// compiling, zero disables pruning
+func WithTargetCacheMaxSize(size int64) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.TargetCacheMaxSize = size
+		return cargo
+	}
+}
+
+// WithTargetRetention sets which artifacts of compiled units are kept in the cached target directory, which is swept
+// after compiling
+func WithTargetRetention(policy runner.RetentionPolicy) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.TargetRetention = policy
+		return cargo
+	}
+}
+
+// WithTestArgs sets additional arguments of the test runner
+func WithTestArgs(args []string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.TestArgs = args
+		return cargo
+	}
+}
+
+// WithTimings sets whether the timings of the build are kept, see Timings
+func WithTimings(timings bool) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.Timings = timings
+		return cargo
+	}
+}
+
+// WithTools sets the tools to install, each with its own version, features and arguments
+func WithTools(tools []runner.ToolRequest) Option {
 	return func(cargo Cargo) Cargo {
 		cargo.Tools = tools
 		return cargo
@@ -131,6 +344,14 @@ func WithToolsArgs(toolsArgs []string) Option {
 	}
 }
 
+// WithUnstableFlags sets the unstable `-Z` flags passed to cargo
+func WithUnstableFlags(flags []string) Option {
+	return func(cargo Cargo) Cargo {
+		cargo.UnstableFlags = flags
+		return cargo
+	}
+}
+
 // WithWorkspaceMembers sets workspace members
 func WithWorkspaceMembers(ap string) Option {
 	return func(cargo Cargo) Cargo {
@@ -140,20 +361,47 @@ func WithWorkspaceMembers(ap string) Option {
 }
 
 type Cargo struct {
+	AddDefaultTarget   bool
 	AdditionalMetadata map[string]interface{}
 	ApplicationPath    string
+	AuditFailSeverity  string
+	Auditable          bool
+	BuildCommand       string
 	Cache              Cache
+	CacheUsage         *CacheUsage
+	CacheWarming       bool
+	CargoHomeSeed      string
 	CargoService       runner.CargoService
+	CrossTool          string
+	DependencySBOM     bool
+	GitCredentials     []runner.GitCredential
+	GitCredentialsDir  string
 	IncludeFolders     string
+	IndexSnapshot      string
 	ExcludeFolders     string
+	Fetch              bool
 	InstallArgs        string
 	LayerContributor   libpak.LayerContributor
+	LicenseReport      bool
 	Logger             bard.Logger
+	Platforms          []runner.Platform
+	Project            string
 	RunSBOMScan        bool
+	RunTests           bool
 	SBOMScanner        sbom.SBOMScanner
+	Sccache            bool
+	SecurityAudit      bool
+	SlowestCrates      int
 	Stack              string
-	Tools              []string
+	Statistics         *runner.Statistics
+	Target             string
+	TargetCacheMaxSize int64
+	TargetRetention    runner.RetentionPolicy
+	TestArgs           []string
+	Timings            bool
+	Tools              []runner.ToolRequest
 	ToolsArgs          []string
+	UnstableFlags      []string
 	WorkspaceMembers   string
 }
 
@@ -168,34 +416,98 @@ func NewCargo(options ...Option) (Cargo, error) {
 	metadata := map[string]interface{}{
 		"additional-arguments": cargo.InstallArgs,
 		"stack":                cargo.Stack,
-		"tools":                cargo.Tools,
+		"tools":                toolSpecs(cargo.Tools),
 		"tools-args":           cargo.ToolsArgs,
 		"workspace-members":    cargo.WorkspaceMembers,
 	}
 
+	// only included when set, so enabling the key does not invalidate existing caches
+	if len(cargo.UnstableFlags) > 0 {
+		metadata["unstable-flags"] = cargo.UnstableFlags
+	}
+	if args := perToolArgs(cargo.Tools); len(args) > 0 {
+		metadata["per-tool-args"] = args
+	}
+	if cargo.Auditable {
+		metadata["auditable"] = true
+	}
+	if cargo.SecurityAudit {
+		metadata["security-audit"] = true
+	}
+	if cargo.LicenseReport {
+		metadata["license-report"] = true
+	}
+	if cargo.AuditFailSeverity != "" {
+		metadata["audit-fail-severity"] = cargo.AuditFailSeverity
+	}
+	if cargo.BuildCommand == runner.BuildCommandBuild {
+		metadata["build-command"] = cargo.BuildCommand
+	}
+	if cargo.CrossTool == runner.CrossToolZigbuild {
+		metadata["cross-tool"] = cargo.CrossTool
+	}
+	if cargo.DependencySBOM {
+		metadata["dependency-sbom"] = true
+	}
+	if cargo.Target != "" {
+		metadata["target"] = cargo.Target
+	}
+	if cargo.RunTests {
+		metadata["run-tests"] = true
+		if len(cargo.TestArgs) > 0 {
+			metadata["test-args"] = cargo.TestArgs
+		}
+	}
+
+	// a cache warming build only compiles the dependencies, which only change with Cargo.lock and the toolchain, so
+	// changes to the sources of the application do not invalidate the layer
+	if cargo.CacheWarming {
+		key, err := cargo.CargoService.CacheKey(cargo.ApplicationPath)
+		if err != nil {
+			return Cargo{}, fmt.Errorf("unable to compute cache key for %s\n%w", cargo.ApplicationPath, err)
+		}
+		if key != "" {
+			metadata["cache-key"] = key
+		}
+	}
+
 	var err error
-	metadata["files"], err = sherpa.NewFileListingHash(cargo.ApplicationPath)
-	if err != nil {
-		return Cargo{}, fmt.Errorf("unable to create file listing for %s\n%w", cargo.ApplicationPath, err)
+	if _, ok := metadata["cache-key"]; !ok {
+		metadata["files"], err = sherpa.NewFileListingHash(cargo.ApplicationPath)
+		if err != nil {
+			return Cargo{}, fmt.Errorf("unable to create file listing for %s\n%w", cargo.ApplicationPath, err)
+		}
 	}
 
-	metadata["cargo-version"], err = cargo.CargoService.CargoVersion()
+	cargoVersion, err := cargo.CargoService.CargoVersion()
 	if err != nil {
 		return Cargo{}, fmt.Errorf("unable to determine cargo version\n%w", err)
 	}
+	metadata["cargo-version"] = cargoVersion.String()
 
-	metadata["rust-version"], err = cargo.CargoService.RustVersion()
+	rustVersion, err := cargo.CargoService.RustVersion()
 	if err != nil {
 		return Cargo{}, fmt.Errorf("unable to determine rust version\n%w", err)
 	}
+	metadata["rust-version"] = rustVersion.String()
 
 	for k, v := range cargo.AdditionalMetadata {
 		metadata[k] = v
 	}
 
-	cargo.LayerContributor = libpak.NewLayerContributor("Rust Application", metadata, libcnb.LayerTypes{
+	// a layer that only holds warm caches must not be reused by a regular build
+	if cargo.CacheWarming {
+		metadata["cache-warming"] = true
+	}
+
+	name := "Rust Application"
+	if cargo.Project != "" {
+		name = fmt.Sprintf("%s %s", name, cargo.Project)
+	}
+
+	cargo.LayerContributor = libpak.NewLayerContributor(name, metadata, libcnb.LayerTypes{
 		Cache:  true,
-		Launch: true,
+		Launch: !cargo.CacheWarming,
 	})
 	cargo.LayerContributor.Logger = cargo.Logger
 
@@ -204,6 +516,15 @@ func NewCargo(options ...Option) (Cargo, error) {
 
 func (c Cargo) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 	layer, err := c.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+		// the credentials only exist while the layer is built, so that they are removed whether the build succeeds or
+		// fails, and are never written if the layer is restored from the cache or an earlier layer fails
+		if len(c.GitCredentials) > 0 {
+			if _, err := runner.WriteGitCredentials(c.GitCredentialsDir, c.GitCredentials); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to write git credentials\n%w", err)
+			}
+			defer os.RemoveAll(c.GitCredentialsDir)
+		}
+
 		preserver := mtimes.NewPreserver(c.Logger)
 
 		targetPath, err := os.Readlink(filepath.Join(c.ApplicationPath, "target"))
@@ -216,63 +537,327 @@ func (c Cargo) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 			return libcnb.Layer{}, fmt.Errorf("unable to find CARGO_HOME, it must be set")
 		}
 
+		if c.CargoHomeSeed != "" {
+			seeded, err := runner.SeedCargoHome(cargoHome, c.CargoHomeSeed)
+			if err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to seed CARGO_HOME\n%w", err)
+			}
+
+			if seeded {
+				c.Logger.Bodyf("Seeded the registry cache of CARGO_HOME from %s", c.CargoHomeSeed)
+			}
+		}
+
+		if c.IndexSnapshot != "" {
+			restored, err := RestoreIndexSnapshot(cargoHome, c.IndexSnapshot)
+			if err != nil {
+				return libcnb.Layer{}, err
+			}
+
+			if restored > 0 {
+				c.Logger.Bodyf("Restored %d registry index files from the snapshot", restored)
+			}
+		}
+
+		if err := CheckOffline(c.ApplicationPath, c.InstallArgs, cargoHome); err != nil {
+			return libcnb.Layer{}, err
+		}
+
 		if err := os.Setenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL", "sparse"); err != nil {
 			return libcnb.Layer{}, fmt.Errorf("unable to set CARGO_REGISTRIES_CRATES_IO_PROTOCOL\n%w", err)
 		}
 
+		entries, err := os.ReadDir(targetPath)
+		if err != nil && !os.IsNotExist(err) {
+			return libcnb.Layer{}, fmt.Errorf("unable to read %s\n%w", targetPath, err)
+		}
+		targetReused := len(entries) > 0
+
+		var before runner.Statistics
+		if c.Statistics != nil {
+			before = *c.Statistics
+		}
+
 		if err = preserver.RestoreAll(targetPath, cargoHome, layer.Path); err != nil {
 			return libcnb.Layer{}, fmt.Errorf("unable to restore all\n%w", err)
 		}
 
+		statistics := BuildStatistics{Crates: c.Statistics, Logger: c.Logger}
+		downloadSnapshot, err := SnapshotDownloads(cargoHome)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to snapshot downloads\n%w", err)
+		}
+
+		// the layer still holds the metadata of the build that contributed it
+		if err := PruneTools(c.CargoService, cargoHome, PreviousTools(layer.Metadata), toolSpecs(c.Tools), c.Logger); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to prune tools\n%w", err)
+		}
+
+		// a misspelt target fails here, before tools are compiled, rather than in cargo
+		for _, target := range c.targets() {
+			if err := c.CargoService.ValidateTarget(target); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to validate target %s\n%w", target, err)
+			}
+		}
+
+		start := time.Now()
 		for _, tool := range c.Tools {
 			if err := c.CargoService.InstallTool(tool, c.ToolsArgs); err != nil {
-				return libcnb.Layer{}, fmt.Errorf("unable to install tool %s with args %v\n%w", tool, c.ToolsArgs, err)
+				return libcnb.Layer{}, fmt.Errorf("unable to install tool %s with args %v\n%w", tool, tool.InstallArgs(c.ToolsArgs), err)
 			}
 		}
 
-		members, err := c.CargoService.WorkspaceMembers(c.ApplicationPath, layer)
-		if err != nil {
-			return libcnb.Layer{}, fmt.Errorf("unable to fetch members\n%w", err)
+		if len(c.Tools) > 0 {
+			statistics.Record("tools", start)
 		}
 
-		isPathSet, err := c.IsPathSet()
-		if err != nil {
-			return libcnb.Layer{}, fmt.Errorf("unable to check if path set\n%w", err)
+		// the standard library of the target must be installed before anything is compiled for it
+		if c.Target != "" {
+			if err := c.CargoService.EnsureTarget(c.Target); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to install target %s\n%w", c.Target, err)
+			}
 		}
 
-		if len(members) == 0 {
-			c.Logger.Body("WARNING: no members detected, trying to install with no path. This may fail.")
-			// run `cargo install`
-			err = c.CargoService.Install(c.ApplicationPath, layer)
+		// rustup installs the target of the host only, the musl target of a tiny or static stack would otherwise fail
+		// midway through the compilation for a missing `core` crate
+		if c.AddDefaultTarget {
+			if err := c.CargoService.EnsureDefaultTarget(); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to install default target\n%w", err)
+			}
+		}
+
+		// cargo-zigbuild may have been installed as a tool, otherwise it is installed now
+		if c.CrossTool == runner.CrossToolZigbuild {
+			if err := c.CargoService.EnsureCrossTool(); err != nil {
+				return libcnb.Layer{}, err
+			}
+		}
+
+		// sccache may have been installed as a tool, otherwise it is installed now
+		if c.Sccache {
+			if err := c.CargoService.EnsureSccache(); err != nil {
+				return libcnb.Layer{}, err
+			}
+		}
+
+		// cargo-auditable may have been installed as a tool, otherwise it is installed now
+		if c.Auditable {
+			if err := c.CargoService.EnsureAuditable(); err != nil {
+				return libcnb.Layer{}, err
+			}
+		}
+
+		// reports from previous builds are restored with the target cache
+		if c.SlowestCrates > 0 || c.Timings {
+			if err := os.RemoveAll(TimingsPath(c.ApplicationPath)); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to remove previous timings\n%w", err)
+			}
+		}
+
+		// the units that the build uses are told apart from stale ones by the time the build started
+		sweep := c.TargetRetention.Enabled() && !c.CacheWarming
+		if sweep {
+			if err := c.CargoService.StampTargetDir(c.ApplicationPath); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to stamp target directory\n%w", err)
+			}
+		}
+
+		// dependencies are downloaded before anything is compiled, so that a failing download is not reported as a
+		// failing compilation
+		if c.Fetch {
+			start = time.Now()
+			if err := c.CargoService.Fetch(c.ApplicationPath); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to fetch dependencies\n%w", err)
+			}
+			statistics.Record("fetch", start)
+		}
+
+		// the audit gates the build before anything is compiled, cargo-audit may have been installed as a tool
+		if c.SecurityAudit && !c.CacheWarming {
+			start = time.Now()
+			if err := SecurityAudit(c.Logger, c.CargoService, c.ApplicationPath, c.AuditFailSeverity); err != nil {
+				return libcnb.Layer{}, err
+			}
+			statistics.Record("audit", start)
+		}
+
+		// tests are run before installing, so that a failing test fails the build before anything is installed
+		if c.RunTests && !c.CacheWarming {
+			start = time.Now()
+			if err := c.CargoService.Test(c.ApplicationPath, c.TestArgs); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("tests failed\n%w", err)
+			}
+			statistics.Record("test", start)
+		}
+
+		start = time.Now()
+		phase := "install"
+		var compileErr error
+		if c.CacheWarming {
+			phase = "dependencies"
+			if err := c.CargoService.BuildDependencies(c.ApplicationPath); err != nil {
+				compileErr = fmt.Errorf("unable to build dependencies\n%w", err)
+			}
+		} else {
+			compileErr = c.install(layer)
+		}
+
+		// the server is stopped even if compiling failed, so that it does not outlive the build
+		if c.Sccache {
+			stats, err := c.CargoService.StopSccache()
+			if err != nil && compileErr == nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to stop sccache\n%w", err)
+			} else if err == nil {
+				c.Logger.Bodyf("sccache: %d cache hits, %d cache misses (%.0f%% hit rate)", stats.Hits, stats.Misses, stats.HitRate())
+			}
+		}
+
+		if compileErr != nil {
+			return libcnb.Layer{}, compileErr
+		}
+
+		compileTime := time.Since(start)
+		statistics.Record(phase, start)
+
+		if c.SlowestCrates > 0 {
+			if err := LogSlowestCrates(c.Logger, TimingsPath(c.ApplicationPath), c.SlowestCrates); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to report slowest crates\n%w", err)
+			}
+		}
+
+		if sweep {
+			swept, err := c.CargoService.SweepTargetDir(c.ApplicationPath, c.TargetRetention)
 			if err != nil {
-				return libcnb.Layer{}, fmt.Errorf("unable to install default\n%w", err)
+				return libcnb.Layer{}, fmt.Errorf("unable to sweep target directory\n%w", err)
 			}
-		} else if (len(members) == 1 && members[0].Path == c.ApplicationPath) || isPathSet {
-			// run `cargo install`
-			err = c.CargoService.Install(c.ApplicationPath, layer)
+			if swept.Entries > 0 {
+				c.Logger.Bodyf("Swept %d stale entries (%s) from the cached target directory", swept.Entries, runner.FormatBytes(swept.Bytes))
+			}
+		}
+
+		// artifacts of crates that are no longer built would otherwise be cached forever
+		if c.TargetCacheMaxSize > 0 {
+			pruned, err := c.CargoService.PruneTargetDir(c.ApplicationPath, c.TargetCacheMaxSize)
 			if err != nil {
-				return libcnb.Layer{}, fmt.Errorf("unable to install single\n%w", err)
+				return libcnb.Layer{}, fmt.Errorf("unable to prune target directory\n%w", err)
 			}
-		} else { // if len(members) > 1 and --path not set
-			// run `cargo install --path=` for each member in the workspace
-			for _, member := range members {
-				err = c.CargoService.InstallMember(member.Path, c.ApplicationPath, layer)
-				if err != nil {
-					return libcnb.Layer{}, fmt.Errorf("unable to install member\n%w", err)
-				}
+			if pruned.Entries > 0 {
+				c.Logger.Bodyf("Pruned %d entries (%s) from the cached target directory to fit %s",
+					pruned.Entries, runner.FormatBytes(pruned.Bytes), runner.FormatBytes(c.TargetCacheMaxSize))
 			}
 		}
 
-		if c.RunSBOMScan {
+		if c.RunSBOMScan && !c.CacheWarming {
+			start = time.Now()
 			if err := c.SBOMScanner.ScanLayer(layer, c.ApplicationPath, libcnb.CycloneDXJSON, libcnb.SyftJSON); err != nil {
 				return libcnb.Layer{}, fmt.Errorf("unable to create layer %s SBoM \n%w", layer.Name, err)
 			}
+
+			// binaries built with cargo-auditable list their dependencies, which are added if the scan missed them
+			added, err := MergeAuditableSBOM(layer)
+			if err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to add auditable data to layer %s SBoM\n%w", layer.Name, err)
+			}
+
+			if added > 0 {
+				c.Logger.Bodyf("Added %d packages embedded by cargo-auditable to the SBOM", added)
+			}
+
+			// the dependency graph of cargo also lists crates that a scan of stripped binaries cannot find
+			if c.DependencySBOM {
+				graph, err := cargosbom.Generate(c.CargoService, c.ApplicationPath)
+				if err != nil {
+					return libcnb.Layer{}, fmt.Errorf("unable to resolve dependency graph\n%w", err)
+				}
+
+				added, err := graph.WriteTo(layer, time.Now())
+				if err != nil {
+					return libcnb.Layer{}, fmt.Errorf("unable to add dependency graph to layer %s SBoM\n%w", layer.Name, err)
+				}
+				c.Logger.Bodyf("Added %d crates resolved by cargo metadata to the SBOM", added)
+			}
+			statistics.Record("sbom", start)
 		}
 
+		// binaries are post-processed after the SBOM, as compressed binaries cannot be scanned, and before they are
+		// listed, so that the digests of the build manifest match the binaries that are launched
+		if !c.CacheWarming {
+			if err := c.postProcess(layer); err != nil {
+				return libcnb.Layer{}, err
+			}
+
+			manifest, err := NewBuildManifest(layer.Path, c.ApplicationPath)
+			if err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to create build manifest\n%w", err)
+			}
+
+			if err := manifest.Write(layer.Path); err != nil {
+				return libcnb.Layer{}, err
+			}
+			c.Logger.Bodyf("Listed %d artifacts in %s", len(manifest.Artifacts), BuildManifestFile)
+			if len(manifest.Platforms) > 1 {
+				var platforms []string
+				for platform := range manifest.Platforms {
+					platforms = append(platforms, platform)
+				}
+				sort.Strings(platforms)
+
+				for _, platform := range platforms {
+					c.Logger.Bodyf("  %d for %s", len(manifest.Platforms[platform]), platform)
+				}
+			}
+
+			if c.LicenseReport {
+				report, err := c.CargoService.Licenses(c.ApplicationPath)
+				if err != nil {
+					return libcnb.Layer{}, fmt.Errorf("unable to list licenses\n%w", err)
+				}
+
+				if err := WriteLicenseReport(layer.Path, report); err != nil {
+					return libcnb.Layer{}, err
+				}
+				c.Logger.Bodyf("Listed %d licenses of %d dependencies in %s", len(report.Licenses), len(report.Packages), LicenseReportFile)
+
+				if unasserted := report.Unasserted(); len(unasserted) > 0 {
+					var names []string
+					for _, p := range unasserted {
+						names = append(names, fmt.Sprintf("%s@%s", p.Name, p.Version))
+					}
+					c.Logger.Bodyf("%d dependencies declare no license expression: %s", len(unasserted), strings.Join(names, ", "))
+				}
+			}
+		}
+
+		start = time.Now()
 		err = preserver.PreserveAll(targetPath, cargoHome, layer.Path)
 		if err != nil {
 			return libcnb.Layer{}, fmt.Errorf("unable to preserve all\n%w", err)
 		}
+		statistics.Record("preserve", start)
+
+		downloads, err := downloadSnapshot.Since(cargoHome)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to determine downloads\n%w", err)
+		}
+
+		packages, err := LockedPackages(filepath.Join(c.ApplicationPath, "Cargo.lock"))
+		if err != nil {
+			return libcnb.Layer{}, err
+		}
+
+		if c.CacheUsage != nil && c.Statistics != nil {
+			registry, err := RegistryPackages(filepath.Join(c.ApplicationPath, "Cargo.lock"))
+			if err != nil {
+				return libcnb.Layer{}, err
+			}
+
+			c.CacheUsage.Record(c.Statistics.Compiled-before.Compiled, c.Statistics.Downloaded-before.Downloaded,
+				packages, registry, compileTime, targetReused)
+		}
+
+		if err := statistics.Log(filepath.Join(layer.Path, "bin"), downloads, packages); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to log build summary\n%w", err)
+		}
 
 		return layer, nil
 	})
@@ -280,6 +865,19 @@ func (c Cargo) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 		return libcnb.Layer{}, fmt.Errorf("unable to contribute application layer\n%w", err)
 	}
 
+	// tools are only available during the build, so they are recorded in the build SBOM
+	if cargoHome, found := os.LookupEnv("CARGO_HOME"); found && c.RunSBOMScan && len(c.Tools) > 0 {
+		layers := libcnb.Layers{Path: filepath.Dir(layer.Path)}
+		if err := WriteToolsSBOM(layers.BuildSBOMPath(libcnb.SyftJSON), cargoHome, toolSpecs(c.Tools)); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to record tools in SBOM\n%w", err)
+		}
+	}
+
+	// a cache warming build only fills the caches, there is nothing to launch
+	if c.CacheWarming {
+		return layer, nil
+	}
+
 	c.Logger.Header("Removing source code")
 	err = logic.Include(c.ApplicationPath, c.IncludeFolders)
 	if err != nil {
@@ -318,6 +916,177 @@ func (c Cargo) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 	return layer, nil
 }
 
+// postProcess shrinks the binaries installed into the layer, for the host and the other platforms, and logs their
+// sizes before and after
+func (c Cargo) postProcess(layer libcnb.Layer) error {
+	var binaries []string
+	for _, dir := range []string{filepath.Join(layer.Path, "bin"), filepath.Join(layer.Path, "platforms")} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		// the binaries of the host platform are linked, they are not walked twice
+		if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				binaries = append(binaries, path)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("unable to list binaries in %s\n%w", dir, err)
+		}
+	}
+
+	sizes, err := c.CargoService.PostProcess(binaries)
+	if err != nil {
+		return fmt.Errorf("unable to post-process binaries\n%w", err)
+	}
+
+	for _, size := range sizes {
+		rel, err := filepath.Rel(layer.Path, size.Path)
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s relative to %s\n%w", size.Path, layer.Path, err)
+		}
+
+		c.Logger.Bodyf("Compressed %s from %s to %s (%.0f%% smaller)", rel, runner.FormatBytes(size.Before), runner.FormatBytes(size.After), size.Saved())
+	}
+
+	return nil
+}
+
+// install installs the workspace members, or the project if it is not a workspace, into the layer and cross compiles
+// them for the configured platforms. Everything is installed into a staging directory in the layer first and moved
+// into place once all installs succeeded, so that an interrupted build never leaves a partially populated `bin/`.
+func (c Cargo) install(layer libcnb.Layer) error {
+	staging := layer
+	staging.Path = filepath.Join(layer.Path, ".install")
+
+	// left behind by an interrupted build
+	if err := os.RemoveAll(staging.Path); err != nil {
+		return fmt.Errorf("unable to remove %s\n%w", staging.Path, err)
+	}
+	if err := os.MkdirAll(staging.Path, 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", staging.Path, err)
+	}
+
+	if err := c.installInto(layer, staging); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(staging.Path)
+	if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", staging.Path, err)
+	}
+
+	// each entry is renamed into place atomically, an interruption in between leaves entries missing, which are
+	// installed again by the next build, but never partially populated
+	for _, e := range entries {
+		destination := filepath.Join(layer.Path, e.Name())
+		if err := os.RemoveAll(destination); err != nil {
+			return fmt.Errorf("unable to remove %s\n%w", destination, err)
+		}
+		if err := os.Rename(filepath.Join(staging.Path, e.Name()), destination); err != nil {
+			return fmt.Errorf("unable to move %s into place\n%w", e.Name(), err)
+		}
+	}
+
+	if err := os.Remove(staging.Path); err != nil {
+		return fmt.Errorf("unable to remove %s\n%w", staging.Path, err)
+	}
+
+	return nil
+}
+
+// installInto installs into the staging directory of a layer
+func (c Cargo) installInto(layer libcnb.Layer, staging libcnb.Layer) error {
+	members, err := c.CargoService.WorkspaceMembers(c.ApplicationPath, layer)
+	if err != nil {
+		return fmt.Errorf("unable to fetch members\n%w", err)
+	}
+
+	isPathSet, err := c.IsPathSet()
+	if err != nil {
+		return fmt.Errorf("unable to check if path set\n%w", err)
+	}
+
+	// `.` installs the project with the path from the install arguments, if set
+	var paths []string
+	if len(members) == 0 {
+		c.Logger.Body("WARNING: no members detected, trying to install with no path. This may fail.")
+		paths = []string{"."}
+	} else if (len(members) == 1 && members[0].Path == c.ApplicationPath) || isPathSet {
+		paths = []string{"."}
+	} else { // if len(members) > 1 and --path not set
+		for _, member := range members {
+			paths = append(paths, member.Path)
+		}
+	}
+
+	if c.BuildCommand == runner.BuildCommandBuild {
+		// run `cargo build` for the selected members at once
+		if err := c.CargoService.Build(c.ApplicationPath, staging); err != nil {
+			return fmt.Errorf("unable to build\n%w", err)
+		}
+	} else {
+		if len(paths) == 1 && paths[0] == "." {
+			// run `cargo install`
+			if err := c.CargoService.Install(c.ApplicationPath, staging); err != nil {
+				return fmt.Errorf("unable to install\n%w", err)
+			}
+		} else {
+			// run `cargo install --path=` for each member of the workspace, in the order of their dependencies
+			if err := c.CargoService.InstallAll(c.ApplicationPath, staging); err != nil {
+				return fmt.Errorf("unable to install members\n%w", err)
+			}
+		}
+	}
+
+	host := runner.HostPlatform()
+	for _, platform := range c.Platforms {
+		dir := filepath.Join(staging.Path, "platforms", platform.Dir())
+
+		// binaries for the host platform are already installed, unless a target is explicitly built for it
+		if platform == host {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("unable to create %s\n%w", dir, err)
+			}
+			if err := os.Symlink(filepath.Join("..", "..", "bin"), filepath.Join(dir, "bin")); err != nil {
+				return fmt.Errorf("unable to link binaries for %s\n%w", platform, err)
+			}
+			continue
+		}
+
+		if platform.Target != "" {
+			c.Logger.Bodyf("Cross compiling for %s with the target %s", platform, platform.Target)
+		} else {
+			c.Logger.Bodyf("Cross compiling for %s", platform)
+		}
+		for _, path := range paths {
+			if err := c.CargoService.CrossInstallMember(path, c.ApplicationPath, dir, platform); err != nil {
+				return fmt.Errorf("unable to cross compile for %s\n%w", platform, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// targets returns the explicit target triples of the build, of BP_CARGO_TARGET and of BP_CARGO_TARGETS
+func (c Cargo) targets() []string {
+	var targets []string
+	if c.Target != "" {
+		targets = append(targets, c.Target)
+	}
+	for _, platform := range c.Platforms {
+		if platform.Target != "" {
+			targets = append(targets, platform.Target)
+		}
+	}
+	return targets
+}
+
 func (c Cargo) IsPathSet() (bool, error) {
 	envArgs, err := runner.FilterInstallArgs(c.InstallArgs)
 	if err != nil {
@@ -333,40 +1102,26 @@ func (c Cargo) IsPathSet() (bool, error) {
 	return false, nil
 }
 
+// BuildProcessTypes returns a process type for each binary target, prefixed with the name of the project if there are
+// several projects, which are run by tini if it is enabled
 func (c Cargo) BuildProcessTypes(tiniEnabled bool) ([]libcnb.Process, error) {
 	binaryTargets, err := c.CargoService.ProjectTargets(c.ApplicationPath)
 	if err != nil {
 		return []libcnb.Process{}, fmt.Errorf("unable to find project targets\n%w", err)
 	}
 
-	procs := []libcnb.Process{}
-	for _, target := range binaryTargets {
-		command := filepath.Join(c.ApplicationPath, "bin", target)
-		args := []string{}
-		if tiniEnabled {
-			args = append([]string{"-g", "--", command}, args...)
-			command = "tini"
-		}
-		procs = append(procs, libcnb.Process{
-			Type:      target,
-			Command:   command,
-			Arguments: args,
-			Direct:    true,
-			Default:   false,
-		})
+	procs, err := runner.ProcessTypes(binaryTargets, filepath.Join(c.ApplicationPath, "bin"), "")
+	if err != nil {
+		return []libcnb.Process{}, err
 	}
 
-	if len(procs) > 0 {
-		found := false
-		for i := 0; i < len(procs) && !found; i++ {
-			if procs[i].Type == "web" {
-				procs[i].Default = true
-				found = true
-			}
+	for i := range procs {
+		if tiniEnabled {
+			procs[i].Arguments = append([]string{"-g", "--", procs[i].Command}, procs[i].Arguments...)
+			procs[i].Command = "tini"
 		}
-
-		if !found {
-			procs[0].Default = true
+		if c.Project != "" {
+			procs[i].Type = fmt.Sprintf("%s-%s", c.Project, procs[i].Type)
 		}
 	}
 
@@ -374,5 +1129,8 @@ func (c Cargo) BuildProcessTypes(tiniEnabled bool) ([]libcnb.Process, error) {
 }
 
 func (c Cargo) Name() string {
+	if c.Project != "" {
+		return fmt.Sprintf("Cargo %s", c.Project)
+	}
 	return "Cargo"
 }
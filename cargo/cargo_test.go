@@ -17,6 +17,8 @@
 package cargo_test
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"net/url"
 	"os"
@@ -28,6 +30,7 @@ import (
 	"github.com/paketo-buildpacks/libpak/bard"
 	sbomMocks "github.com/paketo-buildpacks/libpak/sbom/mocks"
 	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
 	"github.com/paketo-community/cargo/runner/mocks"
 	"github.com/sclevine/spec"
 	"github.com/stretchr/testify/mock"
@@ -73,12 +76,22 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 
 	context("contribution scenarios", func() {
 		var (
-			appFile string
+			appFile     string
+			postProcess []string
+			binarySizes []runner.BinarySize
 		)
 
 		it.Before(func() {
-			service.On("CargoVersion").Return("1.2.3", nil)
-			service.On("RustVersion").Return("1.2.3", nil)
+			version, err := runner.ParseVersion("1.2.3")
+			Expect(err).NotTo(HaveOccurred())
+			service.On("CargoVersion").Return(version, nil)
+			service.On("RustVersion").Return(version, nil)
+
+			postProcess, binarySizes = nil, nil
+			service.On("PostProcess", mock.Anything).Return(func(binaries []string) []runner.BinarySize {
+				postProcess = binaries
+				return binarySizes
+			}, nil)
 
 			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "src"), 0755)).To(Succeed())
 			appFile = filepath.Join(ctx.Application.Path, "src", "main.rs")
@@ -98,7 +111,7 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 					cargo.WithCargoService(service),
 					cargo.WithInstallArgs("--path=./todo --foo=bar --foo baz"),
 					cargo.WithStack("foo-stack"),
-					cargo.WithTools([]string{"foo-tool"}),
+					cargo.WithTools([]runner.ToolRequest{{Name: "foo-tool"}}),
 					cargo.WithToolsArgs([]string{"--tool-arg"}),
 					cargo.WithSBOMScanner(sbomScanner))
 
@@ -266,7 +279,7 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 					cargo.WithApplicationPath(ctx.Application.Path),
 					cargo.WithCargoService(service),
 					cargo.WithSBOMScanner(sbomScanner),
-					cargo.WithTools([]string{"foo-tool"}),
+					cargo.WithTools([]runner.ToolRequest{{Name: "foo-tool"}}),
 					cargo.WithToolsArgs([]string{"--baz"}),
 					cargo.WithRunSBOMScan(true))
 
@@ -274,7 +287,7 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("installs a tool", func() {
-				service.On("InstallTool", "foo-tool", []string{"--baz"}).Return(nil)
+				service.On("InstallTool", runner.ToolRequest{Name: "foo-tool"}, []string{"--baz"}).Return(nil)
 				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
 				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
 					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
@@ -295,219 +308,1311 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
 
 				Expect(service.Calls[2].Method).To(Equal("InstallTool"))
-				Expect(service.Calls[2].Arguments[0]).To(Equal("foo-tool"))
+				Expect(service.Calls[2].Arguments[0]).To(Equal(runner.ToolRequest{Name: "foo-tool"}))
 				Expect(service.Calls[2].Arguments[1]).To(Equal([]string{"--baz"}))
 			})
-		})
 
-		context("cargo workspace members", func() {
-			var (
-				c          cargo.Cargo
-				cacheLayer libcnb.Layer
-			)
+			it("installs a tool with its own features and arguments", func() {
+				tool := runner.ToolRequest{Name: "foo-tool", Version: "0.7", Features: []string{"postgres"}, Locked: true}
 
-			it.Before(func() {
 				var err error
+				c, err = cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithSBOMScanner(sbomScanner),
+					cargo.WithTools([]runner.ToolRequest{tool}),
+					cargo.WithToolsArgs([]string{"--baz"}))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("tools", []string{"foo-tool@0.7"}))
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("per-tool-args", map[string][]string{"foo-tool": {"--features", "postgres", "--locked"}}))
+
+				service.On("InstallTool", tool, []string{"--baz"}).Return(nil)
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "InstallTool", tool, []string{"--baz"})
+			})
+
+			it("does not add per-tool arguments without customized tools", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithTools([]runner.ToolRequest{{Name: "foo-tool"}}))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("per-tool-args"))
+			})
+		})
 
+		context("cache warming", func() {
+			it.Before(func() {
 				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
-				cacheLayer, err = ctx.Layers.Layer("cache-layer")
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
 				Expect(err).NotTo(HaveOccurred())
-				cacheLayer, err = cache.Contribute(cacheLayer)
+				_, err = cache.Contribute(cacheLayer)
 				Expect(err).NotTo(HaveOccurred())
+			})
 
-				c, err = cargo.NewCargo(
+			it("only builds dependencies", func() {
+				service.On("CacheKey", ctx.Application.Path).Return("abc", nil)
+
+				c, err := cargo.NewCargo(
 					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCacheWarming(true),
 					cargo.WithCargoService(service),
 					cargo.WithSBOMScanner(sbomScanner),
 					cargo.WithRunSBOMScan(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("cache-warming", true))
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("cache-key", "abc"))
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("files"))
+
+				service.On("BuildDependencies", ctx.Application.Path).Return(nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "BuildDependencies", ctx.Application.Path)
+				service.AssertNotCalled(t, "WorkspaceMembers", mock.Anything, mock.Anything)
+				sbomScanner.AssertNotCalled(t, "ScanLayer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 
+				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
+				Expect(outputLayer.LayerTypes.Launch).To(BeFalse())
+
+				// source code is kept and nothing is linked
+				Expect(appFile).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "bin")).NotTo(BeADirectory())
+			})
+			it("falls back to the file listing without a cache key", func() {
+				service.On("CacheKey", ctx.Application.Path).Return("", nil)
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCacheWarming(true),
+					cargo.WithCargoService(service))
 				Expect(err).ToNot(HaveOccurred())
+
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("cache-key"))
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKey("files"))
 			})
+		})
+
+		context("build command", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("builds the binaries with cargo build", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithBuildCommand(runner.BuildCommandBuild),
+					cargo.WithCargoService(service),
+					cargo.WithSBOMScanner(sbomScanner))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("build-command", "build"))
 
-			it("contributes cargo layer with no members", func() {
 				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
-				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+				service.On("Build", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
 					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
-					err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
-					Expect(err).ToNot(HaveOccurred())
-					return nil
+					return os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0755)
 				})
 				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+				sbomScanner.On("ScanLayer", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 				inputLayer, err := ctx.Layers.Layer("cargo-layer")
 				Expect(err).ToNot(HaveOccurred())
 
-				sbomScanner.On("ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON).Return(nil)
-
 				outputLayer, err := c.Contribute(inputLayer)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
-
-				sbomScanner.AssertCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
-
-				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
-				Expect(outputLayer.LayerTypes.Build).To(BeFalse())
-				Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
-
-				// app files should be deleted
-				Expect(appFile).ToNot(BeAnExistingFile())
-
-				// preserver should have run
-				Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
-				Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
-				Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
-
-				// we should have two copies of the binary, one in the layer an one in the app root
+				service.AssertCalled(t, "Build", ctx.Application.Path, mock.AnythingOfType("libcnb.Layer"))
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
 				Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
-				Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
-				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+			})
 
-				// Ensure `/workspace/bin` is added to the PATH at launch
-				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
+			it("does not add the default build command to the metadata", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithBuildCommand(runner.BuildCommandInstall),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("build-command"))
 			})
+		})
 
-			it("contributes cargo layer with one member", func() {
-				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{
-					{Scheme: "file", Path: filepath.Join(ctx.Application.Path)},
-				}, nil)
+		context("tests", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
 
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
 				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
-					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
-					err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
-					Expect(err).ToNot(HaveOccurred())
-					return nil
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
 				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+			})
 
-				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary", "other"}, nil)
+			it("runs the tests before installing", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithRunTests(true),
+					cargo.WithTestArgs([]string{"--", "--skip", "slow"}))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("run-tests", true))
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("test-args", []string{"--", "--skip", "slow"}))
+
+				service.On("Test", ctx.Application.Path, []string{"--", "--skip", "slow"}).Return(nil)
 
 				inputLayer, err := ctx.Layers.Layer("cargo-layer")
 				Expect(err).ToNot(HaveOccurred())
 
-				sbomScanner.On("ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON).Return(nil)
-
-				outputLayer, err := c.Contribute(inputLayer)
+				_, err = c.Contribute(inputLayer)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
+				service.AssertCalled(t, "Test", ctx.Application.Path, []string{"--", "--skip", "slow"})
+				service.AssertCalled(t, "Install", mock.Anything, mock.Anything)
+			})
 
-				sbomScanner.AssertCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+			it("does not install when a test fails", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithRunTests(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("test-args"))
 
-				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
-				Expect(outputLayer.LayerTypes.Build).To(BeFalse())
-				Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+				service.On("Test", ctx.Application.Path, []string(nil)).Return(errors.New("exit status 101"))
 
-				// app files should be deleted
-				Expect(appFile).ToNot(BeAnExistingFile())
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
 
-				// preserver should have run
-				Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
-				Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
-				Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("tests failed")))
 
-				// we should have two copies of the binary, one in the layer an one in the app root
-				Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
-				Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
-				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
 
-				// Ensure `/workspace/bin` is added to the PATH at launch
-				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
+			it("does not run the tests by default", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("run-tests"))
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertNotCalled(t, "Test", mock.Anything, mock.Anything)
 			})
+		})
 
-			it("contributes cargo layer with one member without SBOM", func() {
-				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{
-					{Scheme: "file", Path: filepath.Join(ctx.Application.Path)},
-				}, nil)
+		context("target", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
 
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
 				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
-					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
-					err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
-					Expect(err).ToNot(HaveOccurred())
-					return nil
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
 				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+			})
 
-				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary", "other"}, nil)
+			it("installs the target before building", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithTarget("wasm32-wasip1"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("target", "wasm32-wasip1"))
+
+				service.On("ValidateTarget", "wasm32-wasip1").Return(nil)
+				service.On("EnsureTarget", "wasm32-wasip1").Return(nil)
 
 				inputLayer, err := ctx.Layers.Layer("cargo-layer")
 				Expect(err).ToNot(HaveOccurred())
 
-				c.RunSBOMScan = false
-
-				outputLayer, err := c.Contribute(inputLayer)
+				_, err = c.Contribute(inputLayer)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
-
-				sbomScanner.AssertNotCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+				service.AssertCalled(t, "ValidateTarget", "wasm32-wasip1")
+				service.AssertCalled(t, "EnsureTarget", "wasm32-wasip1")
+				service.AssertCalled(t, "Install", mock.Anything, mock.Anything)
+			})
 
-				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
-				Expect(outputLayer.LayerTypes.Build).To(BeFalse())
-				Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+			it("installs the default target of the stack", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithAddDefaultTarget(true),
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
 
-				// app files should be deleted
-				Expect(appFile).ToNot(BeAnExistingFile())
+				service.On("EnsureDefaultTarget").Return(nil)
 
-				// preserver should have run
-				Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
-				Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
-				Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
 
-				// we should have two copies of the binary, one in the layer an one in the app root
-				Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
-				Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
-				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
 
-				// Ensure `/workspace/bin` is added to the PATH at launch
-				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
+				service.AssertCalled(t, "EnsureDefaultTarget")
+				service.AssertCalled(t, "Install", mock.Anything, mock.Anything)
 			})
 
-			context("--path is set", func() {
-				it("contributes cargo layer with multiples member but --path set", func() {
-					service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{
-						{Scheme: "file", Path: filepath.Join(ctx.Application.Path, "basics")},
-						{Scheme: "file", Path: filepath.Join(ctx.Application.Path, "todo")},
-					}, nil)
+			it("fails when the default target cannot be installed", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithAddDefaultTarget(true),
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
 
-					// include `--path`
-					c.InstallArgs = "--path=./todo"
+				service.On("EnsureDefaultTarget").Return(errors.New("unable to run rustup"))
 
-					service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
-						Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
-						err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
-						Expect(err).ToNot(HaveOccurred())
-						return nil
-					})
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
 
-					service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to install default target")))
 
-					inputLayer, err := ctx.Layers.Layer("cargo-layer")
-					Expect(err).ToNot(HaveOccurred())
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
 
-					sbomScanner.On("ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON).Return(nil)
+			it("fails for a target that rustc does not know", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithTarget("x86_64-unknwon-linux-musl"))
+				Expect(err).ToNot(HaveOccurred())
 
-					outputLayer, err := c.Contribute(inputLayer)
-					Expect(err).NotTo(HaveOccurred())
+				service.On("ValidateTarget", "x86_64-unknwon-linux-musl").Return(errors.New("unknown target x86_64-unknwon-linux-musl"))
 
-					Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
 
-					sbomScanner.AssertCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to validate target x86_64-unknwon-linux-musl")))
 
-					Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
-					Expect(outputLayer.LayerTypes.Build).To(BeFalse())
-					Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+				service.AssertNotCalled(t, "EnsureTarget", mock.Anything)
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
 
-					// app files should be deleted
-					Expect(appFile).ToNot(BeAnExistingFile())
+			it("fails when the target cannot be installed", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithTarget("wasm32-wasip1"))
+				Expect(err).ToNot(HaveOccurred())
 
-					// preserver should have run
-					Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
-					Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
-					Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+				service.On("ValidateTarget", "wasm32-wasip1").Return(nil)
+				service.On("EnsureTarget", "wasm32-wasip1").Return(errors.New("toolchain 'stable' does not support target 'wasm32-wasip1'"))
 
-					// we should have two copies of the binary, one in the layer an one in the app root
-					Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
-					Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
-					Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to install target wasm32-wasip1")))
+
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+		})
+
+		context("git credentials", func() {
+			var (
+				credentials []runner.GitCredential
+				dir         string
+			)
+
+			it.Before(func() {
+				credentials = []runner.GitCredential{{Binding: "git", URL: "https://git.example.com", Username: "git", Password: "s3cr3t"}}
+				dir = filepath.Join(t.TempDir(), "git-credentials")
+			})
+
+			it("writes the git credentials while the layer is built", func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithGitCredentials(dir, credentials))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("git-credentials"))
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(filepath.Join(dir, "credentials")).To(BeARegularFile())
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "Install", mock.Anything, mock.Anything)
+				Expect(dir).NotTo(BeAnExistingFile())
+			})
+
+			it("removes the git credentials when the build fails", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithGitCredentials(dir, credentials))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				// the target link is missing, so that the contribution fails
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(HaveOccurred())
+				Expect(dir).NotTo(BeAnExistingFile())
+			})
+
+			it("does not write the git credentials into an existing directory", func() {
+				Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithGitCredentials(dir, credentials))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to write git credentials")))
+				Expect(dir).To(BeADirectory())
+				Expect(filepath.Join(dir, "credentials")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		context("post-processing", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).To(Succeed())
+					return os.WriteFile(filepath.Join(layer.Path, "bin", "app"), []byte("\x7fELF binary"), 0755)
+				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+			})
+
+			it("post-processes the installed binaries and logs their sizes", func() {
+				buf := &bytes.Buffer{}
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithLogger(bard.NewLogger(buf)))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+				binarySizes = []runner.BinarySize{{After: 1024, Before: 4096, Path: filepath.Join(inputLayer.Path, "bin", "app")}}
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(postProcess).To(Equal([]string{filepath.Join(inputLayer.Path, "bin", "app")}))
+				Expect(buf.String()).To(ContainSubstring("Compressed bin/app from 4.0 KB to 1.0 KB (75% smaller)"))
+			})
+		})
+
+		context("sccache", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+				service.On("EnsureSccache").Return(nil)
+				service.On("StopSccache").Return(runner.SccacheStatistics{Hits: 3, Misses: 1}, nil)
+			})
+
+			it("compiles through sccache and reports its statistics", func() {
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+
+				buf := &bytes.Buffer{}
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithLogger(bard.NewLogger(buf)),
+					cargo.WithSccache(true))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "EnsureSccache")
+				service.AssertCalled(t, "StopSccache")
+				Expect(buf.String()).To(ContainSubstring("sccache: 3 cache hits, 1 cache misses (75% hit rate)"))
+			})
+
+			it("stops sccache when compiling fails", func() {
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(errors.New("exit status 101"))
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithSccache(true))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("exit status 101")))
+
+				service.AssertCalled(t, "StopSccache")
+			})
+		})
+
+		context("target cache", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+			})
+
+			it("prunes the target directory after compiling", func() {
+				service.On("PruneTargetDir", ctx.Application.Path, int64(1<<30)).Return(runner.CleanStatistics{Bytes: 3 << 20, Entries: 12}, nil)
+
+				buf := &bytes.Buffer{}
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithLogger(bard.NewLogger(buf)),
+					cargo.WithTargetCacheMaxSize(1<<30))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "PruneTargetDir", ctx.Application.Path, int64(1<<30))
+				Expect(buf.String()).To(ContainSubstring("Pruned 12 entries (3.0 MB) from the cached target directory to fit 1.0 GB"))
+			})
+
+			it("sweeps the artifacts that the build did not use", func() {
+				policy := runner.RetentionPolicy{Builds: 2}
+				service.On("StampTargetDir", ctx.Application.Path).Return(nil)
+				service.On("SweepTargetDir", ctx.Application.Path, policy).Return(runner.CleanStatistics{Bytes: 2 << 20, Entries: 5}, nil)
+
+				buf := &bytes.Buffer{}
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithLogger(bard.NewLogger(buf)),
+					cargo.WithTargetRetention(policy))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "StampTargetDir", ctx.Application.Path)
+				service.AssertCalled(t, "SweepTargetDir", ctx.Application.Path, policy)
+				Expect(buf.String()).To(ContainSubstring("Swept 5 stale entries (2.0 MB) from the cached target directory"))
+			})
+
+			it("does not prune or sweep by default", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertNotCalled(t, "PruneTargetDir", mock.Anything, mock.Anything)
+				service.AssertNotCalled(t, "StampTargetDir", mock.Anything)
+				service.AssertNotCalled(t, "SweepTargetDir", mock.Anything, mock.Anything)
+			})
+		})
+
+		context("fetch", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+			})
+
+			it("fetches the dependencies before compiling", func() {
+				var fetched bool
+				service.On("Fetch", ctx.Application.Path).Run(func(mock.Arguments) { fetched = true }).Return(nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(fetched).To(BeTrue())
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithFetch(true))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "Fetch", ctx.Application.Path)
+				service.AssertCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+
+			it("does not compile when fetching fails", func() {
+				service.On("Fetch", ctx.Application.Path).Return(errors.New("exit status 101"))
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithFetch(true))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to fetch dependencies")))
+
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+		})
+
+		context("auditable", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+			})
+
+			it("installs cargo-auditable and records it in the layer metadata", func() {
+				service.On("EnsureAuditable").Return(nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithAuditable(true),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("auditable", true))
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outputLayer.Metadata).To(HaveKeyWithValue("auditable", true))
+
+				service.AssertCalled(t, "EnsureAuditable")
+			})
+
+			it("fails before compiling if cargo-auditable cannot be installed", func() {
+				service.On("EnsureAuditable").Return(errors.New("unable to install cargo-auditable"))
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithAuditable(true),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to install cargo-auditable")))
+
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+
+			it("does not record auditable builds by default", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("auditable"))
+			})
+		})
+
+		context("zigbuild", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+			})
+
+			it("installs cargo-zigbuild and records it in the layer metadata", func() {
+				service.On("ValidateTarget", "x86_64-unknown-linux-gnu.2.17").Return(nil)
+				service.On("EnsureTarget", "x86_64-unknown-linux-gnu.2.17").Return(nil)
+				service.On("EnsureCrossTool").Return(nil)
+				service.On("Build", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithBuildCommand(runner.BuildCommandBuild),
+					cargo.WithCargoService(service),
+					cargo.WithCrossTool(runner.CrossToolZigbuild),
+					cargo.WithTarget("x86_64-unknown-linux-gnu.2.17"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("cross-tool", "zigbuild"))
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "EnsureCrossTool")
+				service.AssertCalled(t, "Build", ctx.Application.Path, mock.AnythingOfType("libcnb.Layer"))
+			})
+
+			it("fails before compiling if cargo-zigbuild cannot be installed", func() {
+				service.On("EnsureCrossTool").Return(errors.New("unable to install cargo-zigbuild"))
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithCrossTool(runner.CrossToolZigbuild))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("unable to install cargo-zigbuild")))
+
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+
+			it("does not install a cross tool with cargo", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithCrossTool(runner.CrossToolCargo))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).NotTo(HaveKey("cross-tool"))
+			})
+		})
+
+		context("security audit", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+				service.On("SecurityAudit", ctx.Application.Path).Return(runner.AuditReport{
+					Vulnerabilities: []runner.Vulnerability{{ID: "RUSTSEC-2023-0001", Package: "tokio", Score: 9.8, Severity: runner.SeverityCritical, Version: "1.0.0"}},
+				}, nil)
+			})
+
+			it("fails before compiling if a vulnerability reaches the severity", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithAuditFailSeverity(runner.SeverityHigh),
+					cargo.WithCargoService(service),
+					cargo.WithSecurityAudit(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("security-audit", true))
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("audit-fail-severity", "high"))
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("RUSTSEC-2023-0001 (tokio 1.0.0, critical)")))
+
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+		})
+
+		context("dependency sbom", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("adds the crates resolved by cargo metadata to the SBOM", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithDependencySBOM(true),
+					cargo.WithRunSBOMScan(true),
+					cargo.WithSBOMScanner(sbomScanner))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("dependency-sbom", true))
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					return os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0755)
+				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+				service.On("Metadata", ctx.Application.Path).Return([]byte(`{
+					"packages": [{"id": "app 0.1.0", "name": "my-binary", "version": "0.1.0", "source": null, "license": "MIT"}],
+					"resolve": {"nodes": [{"id": "app 0.1.0", "deps": []}]},
+					"workspace_members": ["app 0.1.0"]
+				}`), nil)
+				sbomScanner.On("ScanLayer", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "Metadata", ctx.Application.Path)
+				Expect(outputLayer.SBOMPath(libcnb.SPDXJSON)).To(BeARegularFile())
+				Expect(os.ReadFile(outputLayer.SBOMPath(libcnb.CycloneDXJSON))).To(ContainSubstring(`"purl":"pkg:cargo/my-binary@0.1.0"`))
+			})
+		})
+
+		context("license report", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					return os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)
+				})
+			})
+
+			it("writes the licenses of the dependencies into the layer", func() {
+				service.On("Licenses", ctx.Application.Path).Return(runner.LicenseReport{
+					Licenses: []runner.LicenseUsage{
+						{License: "MIT", Packages: []string{"serde@1.0.210"}},
+						{License: runner.NoAssertion, Packages: []string{"libc@0.2.158"}},
+					},
+					Packages: []runner.PackageLicense{
+						{License: runner.NoAssertion, Name: "libc", Version: "0.2.158"},
+						{License: "MIT", Name: "serde", Version: "1.0.210"},
+					},
+				}, nil)
+
+				buf := &bytes.Buffer{}
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithLicenseReport(true),
+					cargo.WithLogger(bard.NewLogger(buf)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("license-report", true))
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.ReadFile(filepath.Join(outputLayer.Path, cargo.LicenseReportFile))).To(MatchJSON(`{
+					"licenses": [
+						{"license": "MIT", "packages": ["serde@1.0.210"]},
+						{"license": "NOASSERTION", "packages": ["libc@0.2.158"]}
+					],
+					"packages": [
+						{"license": "NOASSERTION", "name": "libc", "version": "0.2.158"},
+						{"license": "MIT", "name": "serde", "version": "1.0.210"}
+					]
+				}`))
+				Expect(buf.String()).To(ContainSubstring("Listed 2 licenses of 2 dependencies in license-report.json"))
+				Expect(buf.String()).To(ContainSubstring("1 dependencies declare no license expression: libc@0.2.158"))
+			})
+
+			it("does not list licenses by default", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(outputLayer.Path, cargo.LicenseReportFile)).NotTo(BeAnExistingFile())
+				service.AssertNotCalled(t, "Licenses", mock.Anything)
+			})
+		})
+
+		context("cross compilation", func() {
+			it.Before(func() {
+				t.Setenv("BP_ARCH", "amd64")
+
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("installs the binaries for each platform", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithPlatforms([]runner.Platform{{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}}),
+					cargo.WithSBOMScanner(sbomScanner))
+				Expect(err).ToNot(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					return os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0755)
+				})
+				service.On("CrossInstallMember", ".", ctx.Application.Path, mock.AnythingOfType("string"), runner.Platform{OS: "linux", Arch: "arm64"}).Return(nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "CrossInstallMember", ".", ctx.Application.Path,
+					filepath.Join(inputLayer.Path, ".install", "platforms", "linux-arm64"), runner.Platform{OS: "linux", Arch: "arm64"})
+				service.AssertNumberOfCalls(t, "CrossInstallMember", 1)
+
+				Expect(filepath.Join(inputLayer.Path, "platforms", "linux-amd64", "bin", "my-binary")).To(BeARegularFile())
+			})
+
+			it("cross compiles an explicit target of the host platform", func() {
+				amd64 := runner.Platform{OS: "linux", Arch: "amd64", Target: "x86_64-unknown-linux-musl"}
+				arm64 := runner.Platform{OS: "linux", Arch: "arm64", Target: "aarch64-unknown-linux-musl"}
+
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithPlatforms([]runner.Platform{amd64, arm64}),
+					cargo.WithSBOMScanner(sbomScanner))
+				Expect(err).ToNot(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					return os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0755)
+				})
+				service.On("ValidateTarget", mock.AnythingOfType("string")).Return(nil)
+				service.On("CrossInstallMember", ".", ctx.Application.Path, mock.AnythingOfType("string"), mock.AnythingOfType("runner.Platform")).Return(nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				service.AssertCalled(t, "CrossInstallMember", ".", ctx.Application.Path,
+					filepath.Join(inputLayer.Path, ".install", "platforms", "linux-amd64"), amd64)
+				service.AssertCalled(t, "CrossInstallMember", ".", ctx.Application.Path,
+					filepath.Join(inputLayer.Path, ".install", "platforms", "linux-arm64"), arm64)
+				service.AssertNumberOfCalls(t, "CrossInstallMember", 2)
+				service.AssertCalled(t, "ValidateTarget", "x86_64-unknown-linux-musl")
+				service.AssertCalled(t, "ValidateTarget", "aarch64-unknown-linux-musl")
+			})
+		})
+
+		context("atomic installation", func() {
+			var c cargo.Cargo
+
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				c, err = cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithSBOMScanner(sbomScanner))
+				Expect(err).ToNot(HaveOccurred())
+
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+			})
+
+			it("moves the installed binaries into place", func() {
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					Expect(os.WriteFile(filepath.Join(layer.Path, ".crates.toml"), []byte{}, 0644)).ToNot(HaveOccurred())
+					return os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0755)
+				})
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				// left behind by an interrupted build
+				Expect(os.MkdirAll(filepath.Join(inputLayer.Path, ".install", "bin"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(inputLayer.Path, ".install", "bin", "partial"), []byte{}, 0755)).To(Succeed())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(inputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(inputLayer.Path, "bin", "partial")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(inputLayer.Path, ".crates.toml")).To(BeARegularFile())
+				Expect(filepath.Join(inputLayer.Path, ".install")).NotTo(BeAnExistingFile())
+			})
+
+			it("does not populate the layer if an install fails", func() {
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					Expect(os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0755)).ToNot(HaveOccurred())
+					return errors.New("interrupted")
+				})
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("interrupted")))
+
+				Expect(filepath.Join(inputLayer.Path, "bin")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		context("offline mode", func() {
+			it.Before(func() {
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err := ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.lock"), []byte(`version = 3
+
+[[package]]
+name = "serde"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`), 0644)).To(Succeed())
+			})
+
+			it("fails before building if packages are missing from CARGO_HOME", func() {
+				c, err := cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithInstallArgs("--offline"),
+					cargo.WithSBOMScanner(sbomScanner))
+				Expect(err).ToNot(HaveOccurred())
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = c.Contribute(inputLayer)
+				Expect(err).To(MatchError(ContainSubstring("offline mode is enabled with --offline in $BP_CARGO_INSTALL_ARGS but 1 packages are missing from CARGO_HOME: serde 1.0.0 (crate, index entry)")))
+
+				service.AssertNotCalled(t, "Install", mock.Anything, mock.Anything)
+			})
+		})
+
+		context("cargo workspace members", func() {
+			var (
+				c          cargo.Cargo
+				cacheLayer libcnb.Layer
+			)
+
+			it.Before(func() {
+				var err error
+
+				cache := cargo.Cache{AppPath: ctx.Application.Path, Logger: logger}
+				cacheLayer, err = ctx.Layers.Layer("cache-layer")
+				Expect(err).NotTo(HaveOccurred())
+				cacheLayer, err = cache.Contribute(cacheLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				c, err = cargo.NewCargo(
+					cargo.WithApplicationPath(ctx.Application.Path),
+					cargo.WithCargoService(service),
+					cargo.WithSBOMScanner(sbomScanner),
+					cargo.WithRunSBOMScan(true))
+
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			it("contributes cargo layer with no members", func() {
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{}, nil)
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
+					Expect(err).ToNot(HaveOccurred())
+					return nil
+				})
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				sbomScanner.On("ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON).Return(nil)
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
+
+				sbomScanner.AssertCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+
+				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
+				Expect(outputLayer.LayerTypes.Build).To(BeFalse())
+				Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+
+				// app files should be deleted
+				Expect(appFile).ToNot(BeAnExistingFile())
+
+				// preserver should have run
+				Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
+				Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
+				Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+
+				// we should have two copies of the binary, one in the layer an one in the app root
+				Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+
+				// Ensure `/workspace/bin` is added to the PATH at launch
+				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
+			})
+
+			it("contributes cargo layer with one member", func() {
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{
+					{Scheme: "file", Path: filepath.Join(ctx.Application.Path)},
+				}, nil)
+
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
+					Expect(err).ToNot(HaveOccurred())
+					return nil
+				})
+
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary", "other"}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				sbomScanner.On("ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON).Return(nil)
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
+
+				sbomScanner.AssertCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+
+				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
+				Expect(outputLayer.LayerTypes.Build).To(BeFalse())
+				Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+
+				// app files should be deleted
+				Expect(appFile).ToNot(BeAnExistingFile())
+
+				// preserver should have run
+				Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
+				Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
+				Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+
+				// we should have two copies of the binary, one in the layer an one in the app root
+				Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+
+				// Ensure `/workspace/bin` is added to the PATH at launch
+				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
+			})
+
+			it("contributes cargo layer with one member without SBOM", func() {
+				service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{
+					{Scheme: "file", Path: filepath.Join(ctx.Application.Path)},
+				}, nil)
+
+				service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+					err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
+					Expect(err).ToNot(HaveOccurred())
+					return nil
+				})
+
+				service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary", "other"}, nil)
+
+				inputLayer, err := ctx.Layers.Layer("cargo-layer")
+				Expect(err).ToNot(HaveOccurred())
+
+				c.RunSBOMScan = false
+
+				outputLayer, err := c.Contribute(inputLayer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
+
+				sbomScanner.AssertNotCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+
+				Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
+				Expect(outputLayer.LayerTypes.Build).To(BeFalse())
+				Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+
+				// app files should be deleted
+				Expect(appFile).ToNot(BeAnExistingFile())
+
+				// preserver should have run
+				Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
+				Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
+				Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+
+				// we should have two copies of the binary, one in the layer an one in the app root
+				Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
+				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
+
+				// Ensure `/workspace/bin` is added to the PATH at launch
+				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
+			})
+
+			context("--path is set", func() {
+				it("contributes cargo layer with multiples member but --path set", func() {
+					service.On("WorkspaceMembers", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return([]url.URL{
+						{Scheme: "file", Path: filepath.Join(ctx.Application.Path, "basics")},
+						{Scheme: "file", Path: filepath.Join(ctx.Application.Path, "todo")},
+					}, nil)
+
+					// include `--path`
+					c.InstallArgs = "--path=./todo"
+
+					service.On("Install", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
+						Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
+						err := os.WriteFile(filepath.Join(layer.Path, "bin", "my-binary"), []byte("contents"), 0644)
+						Expect(err).ToNot(HaveOccurred())
+						return nil
+					})
+
+					service.On("ProjectTargets", mock.AnythingOfType("string")).Return([]string{"my-binary"}, nil)
+
+					inputLayer, err := ctx.Layers.Layer("cargo-layer")
+					Expect(err).ToNot(HaveOccurred())
+
+					sbomScanner.On("ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON).Return(nil)
+
+					outputLayer, err := c.Contribute(inputLayer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(os.Getenv("CARGO_REGISTRIES_CRATES_IO_PROTOCOL")).To(Equal("sparse"))
+
+					sbomScanner.AssertCalled(t, "ScanLayer", inputLayer, ctx.Application.Path, libcnb.CycloneDXJSON, libcnb.SyftJSON)
+
+					Expect(outputLayer.LayerTypes.Cache).To(BeTrue())
+					Expect(outputLayer.LayerTypes.Build).To(BeFalse())
+					Expect(outputLayer.LayerTypes.Launch).To(BeTrue())
+
+					// app files should be deleted
+					Expect(appFile).ToNot(BeAnExistingFile())
+
+					// preserver should have run
+					Expect(filepath.Join(outputLayer.Path, "mtimes.json")).To(BeARegularFile())
+					Expect(filepath.Join(cargoHome, "mtimes.json")).To(BeARegularFile())
+					Expect(filepath.Join(cacheLayer.Path, "mtimes.json")).To(BeARegularFile())
+
+					// we should have two copies of the binary, one in the layer an one in the app root
+					Expect(filepath.Join(outputLayer.Path, "bin", "my-binary")).To(BeARegularFile())
+					Expect(filepath.Join(ctx.Application.Path, "bin", "my-binary")).To(BeARegularFile())
+					Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
 
 					// Ensure `/workspace/bin` is added to the PATH at launch
 					Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
@@ -521,10 +1626,12 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 					{Scheme: "file", Path: filepath.Join(ctx.Application.Path, "hello")},
 				}, nil)
 
-				service.On("InstallMember", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(memberPath string, srcDir string, layer libcnb.Layer) error {
+				service.On("InstallAll", mock.AnythingOfType("string"), mock.AnythingOfType("libcnb.Layer")).Return(func(srcDir string, layer libcnb.Layer) error {
 					Expect(os.MkdirAll(filepath.Join(layer.Path, "bin"), 0755)).ToNot(HaveOccurred())
-					err := os.WriteFile(filepath.Join(layer.Path, "bin", filepath.Base(memberPath)), []byte("contents"), 0644)
-					Expect(err).ToNot(HaveOccurred())
+					for _, member := range []string{"basics", "todo", "hello"} {
+						err := os.WriteFile(filepath.Join(layer.Path, "bin", member), []byte("contents"), 0644)
+						Expect(err).ToNot(HaveOccurred())
+					}
 					return nil
 				})
 
@@ -563,8 +1670,9 @@ func testCargo(t *testing.T, context spec.G, it spec.S) {
 				Expect(filepath.Join(ctx.Application.Path, "bin", "hello")).To(BeARegularFile())
 				Expect(filepath.Join(ctx.Application.Path, "mtimes.json")).ToNot(BeARegularFile())
 
-				// make sure InstallMember ran three times
-				service.AssertNumberOfCalls(t, "InstallMember", 3)
+				// make sure the members were installed together
+				service.AssertNumberOfCalls(t, "InstallAll", 1)
+				service.AssertNotCalled(t, "InstallMember", mock.Anything, mock.Anything, mock.Anything)
 
 				// Ensure `/workspace/bin` is added to the PATH at launch
 				Expect(outputLayer.LaunchEnvironment["PATH.append"]).To(Equal(filepath.Join(ctx.Application.Path, "bin")))
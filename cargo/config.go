@@ -0,0 +1,202 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bindings"
+)
+
+// BindingTypeCargoConfig is the type of a binding with a `config.toml` key, which is Cargo configuration that is merged
+// into the config.toml of CARGO_HOME
+const BindingTypeCargoConfig = "cargo-config"
+
+// CargoConfigSource is Cargo configuration that is merged into CARGO_HOME. Relative paths are resolved against Dir,
+// like cargo resolves them against the directory that contains the .cargo directory.
+type CargoConfigSource struct {
+	Config map[string]interface{}
+	Dir    string
+	Name   string
+}
+
+// CargoConfigSources returns the Cargo configuration of the bindings of type cargo-config, ordered by name, and, if
+// project is true, the .cargo/config.toml of the application in appDir, which is merged first.
+func CargoConfigSources(appDir string, project bool, binds libcnb.Bindings) ([]CargoConfigSource, error) {
+	var sources []CargoConfigSource
+
+	if project {
+		for _, name := range []string{".cargo/config.toml", ".cargo/config"} {
+			config := map[string]interface{}{}
+			if _, err := toml.DecodeFile(filepath.Join(appDir, name), &config); errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("unable to decode %s\n%w", name, err)
+			}
+
+			sources = append(sources, CargoConfigSource{Config: config, Dir: appDir, Name: name})
+			break
+		}
+	}
+
+	resolved := bindings.Resolve(binds, bindings.OfType(BindingTypeCargoConfig))
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Name < resolved[j].Name })
+
+	for _, b := range resolved {
+		raw, ok := b.Secret["config.toml"]
+		if !ok {
+			return nil, fmt.Errorf("binding %s of type %s requires a config.toml", b.Name, BindingTypeCargoConfig)
+		}
+
+		config := map[string]interface{}{}
+		if _, err := toml.Decode(raw, &config); err != nil {
+			return nil, fmt.Errorf("unable to decode config.toml of binding %s\n%w", b.Name, err)
+		}
+
+		sources = append(sources, CargoConfigSource{Config: config, Dir: b.Path, Name: fmt.Sprintf("binding %s", b.Name)})
+	}
+
+	return sources, nil
+}
+
+// MergeCargoConfig merges sources, in order, into the config.toml of cargoHome, like cargo merges configuration files:
+// tables are merged, arrays are joined and other values are replaced. The config.toml that CARGO_HOME had before the
+// first merge is kept as config.toml.base and is the base of every later merge, so that configuration that is removed
+// from the sources is also removed from CARGO_HOME. Without sources, the original config.toml is restored.
+func MergeCargoConfig(cargoHome string, sources []CargoConfigSource) error {
+	path := filepath.Join(cargoHome, "config.toml")
+	basePath := filepath.Join(cargoHome, "config.toml.base")
+
+	base, err := os.ReadFile(basePath)
+	if errors.Is(err, os.ErrNotExist) {
+		if len(sources) == 0 {
+			return nil
+		}
+
+		if base, err = os.ReadFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to read %s\n%w", path, err)
+		}
+		// an empty base records that CARGO_HOME had no configuration
+		if err := os.WriteFile(basePath, base, 0644); err != nil {
+			return fmt.Errorf("unable to write %s\n%w", basePath, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", basePath, err)
+	}
+
+	if len(sources) == 0 {
+		if len(base) == 0 {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("unable to remove %s\n%w", path, err)
+			}
+		} else if err := os.WriteFile(path, base, 0644); err != nil {
+			return fmt.Errorf("unable to restore %s\n%w", path, err)
+		}
+
+		if err := os.Remove(basePath); err != nil {
+			return fmt.Errorf("unable to remove %s\n%w", basePath, err)
+		}
+		return nil
+	}
+
+	merged := map[string]interface{}{}
+	if _, err := toml.Decode(string(base), &merged); err != nil {
+		return fmt.Errorf("unable to decode %s\n%w", basePath, err)
+	}
+
+	var names []string
+	for _, s := range sources {
+		mergeConfig(merged, resolveConfigPaths(s.Config, s.Dir))
+		names = append(names, s.Name)
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# merged by the cargo buildpack from %s\n", strings.Join(names, ", "))
+	if err := toml.NewEncoder(buf).Encode(merged); err != nil {
+		return fmt.Errorf("unable to encode %s\n%w", path, err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", path, err)
+	}
+
+	return nil
+}
+
+func mergeConfig(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			if existing, ok := dst[k].(map[string]interface{}); ok {
+				mergeConfig(existing, value)
+				continue
+			}
+		case []interface{}:
+			if existing, ok := dst[k].([]interface{}); ok {
+				dst[k] = append(existing, value...)
+				continue
+			}
+		}
+
+		dst[k] = v
+	}
+}
+
+// resolveConfigPaths makes the paths of source replacements, path overrides and the target directory absolute, as
+// they are relative to the configuration they are declared in rather than to CARGO_HOME
+func resolveConfigPaths(config map[string]interface{}, dir string) map[string]interface{} {
+	resolve := func(v interface{}) interface{} {
+		if s, ok := v.(string); ok && !filepath.IsAbs(s) {
+			return filepath.Join(dir, s)
+		}
+		return v
+	}
+
+	if sources, ok := config["source"].(map[string]interface{}); ok {
+		for _, s := range sources {
+			if source, ok := s.(map[string]interface{}); ok {
+				for _, key := range []string{"directory", "local-registry"} {
+					if v, ok := source[key]; ok {
+						source[key] = resolve(v)
+					}
+				}
+			}
+		}
+	}
+
+	if paths, ok := config["paths"].([]interface{}); ok {
+		for i, p := range paths {
+			paths[i] = resolve(p)
+		}
+	}
+
+	if build, ok := config["build"].(map[string]interface{}); ok {
+		if v, ok := build["target-dir"]; ok {
+			build["target-dir"] = resolve(v)
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/cargo"
+)
+
+func testConfig(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		cargoHome string
+		binds     libcnb.Bindings
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		cargoHome = t.TempDir()
+
+		Expect(os.MkdirAll(filepath.Join(appDir, ".cargo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, ".cargo", "config.toml"), []byte(`
+[build]
+rustflags = ["-C", "target-cpu=x86-64-v2"]
+
+[source.crates-io]
+replace-with = "vendored"
+
+[source.vendored]
+directory = "vendor"
+`), 0644)).To(Succeed())
+
+		binds = libcnb.Bindings{
+			{
+				Name: "registries",
+				Path: "/bindings/registries",
+				Type: cargo.BindingTypeCargoConfig,
+				Secret: map[string]string{"config.toml": `
+[build]
+rustflags = ["--cfg", "internal"]
+
+[registries.internal]
+index = "sparse+https://registry.example.com/index/"
+`},
+			},
+			{
+				Name:   "other",
+				Type:   "maven",
+				Secret: map[string]string{"config.toml": "[build]\njobs = 1\n"},
+			},
+		}
+	})
+
+	read := func() map[string]interface{} {
+		config := map[string]interface{}{}
+		_, err := toml.DecodeFile(filepath.Join(cargoHome, "config.toml"), &config)
+		Expect(err).NotTo(HaveOccurred())
+		return config
+	}
+
+	it("reads the configuration of bindings", func() {
+		sources, err := cargo.CargoConfigSources(appDir, false, binds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(HaveLen(1))
+		Expect(sources[0].Name).To(Equal("binding registries"))
+		Expect(sources[0].Dir).To(Equal("/bindings/registries"))
+	})
+
+	it("reads the configuration of the application when enabled", func() {
+		sources, err := cargo.CargoConfigSources(appDir, true, binds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(HaveLen(2))
+		Expect(sources[0].Name).To(Equal(".cargo/config.toml"))
+		Expect(sources[0].Dir).To(Equal(appDir))
+	})
+
+	it("requires a config.toml in bindings", func() {
+		_, err := cargo.CargoConfigSources(appDir, false, libcnb.Bindings{
+			{Name: "empty", Type: cargo.BindingTypeCargoConfig, Secret: map[string]string{}},
+		})
+		Expect(err).To(MatchError("binding empty of type cargo-config requires a config.toml"))
+	})
+
+	it("merges the configuration into CARGO_HOME", func() {
+		Expect(os.WriteFile(filepath.Join(cargoHome, "config.toml"), []byte("[net]\ngit-fetch-with-cli = true\n\n[build]\njobs = 4\n"), 0644)).To(Succeed())
+
+		sources, err := cargo.CargoConfigSources(appDir, true, binds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cargo.MergeCargoConfig(cargoHome, sources)).To(Succeed())
+
+		config := read()
+		Expect(config["net"]).To(Equal(map[string]interface{}{"git-fetch-with-cli": true}))
+		Expect(config["build"]).To(Equal(map[string]interface{}{
+			"jobs":      int64(4),
+			"rustflags": []interface{}{"-C", "target-cpu=x86-64-v2", "--cfg", "internal"},
+		}))
+		Expect(config["source"]).To(HaveKeyWithValue("vendored", map[string]interface{}{"directory": filepath.Join(appDir, "vendor")}))
+		Expect(config["registries"]).To(HaveKey("internal"))
+
+		// merging again starts from the original configuration
+		Expect(cargo.MergeCargoConfig(cargoHome, sources[1:])).To(Succeed())
+		config = read()
+		Expect(config["build"]).To(Equal(map[string]interface{}{"jobs": int64(4), "rustflags": []interface{}{"--cfg", "internal"}}))
+		Expect(config).NotTo(HaveKey("source"))
+	})
+
+	it("restores the original configuration without sources", func() {
+		Expect(cargo.MergeCargoConfig(cargoHome, nil)).To(Succeed())
+		Expect(filepath.Join(cargoHome, "config.toml")).NotTo(BeAnExistingFile())
+
+		sources, err := cargo.CargoConfigSources(appDir, false, binds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cargo.MergeCargoConfig(cargoHome, sources)).To(Succeed())
+		Expect(read()).To(HaveKey("registries"))
+
+		Expect(cargo.MergeCargoConfig(cargoHome, nil)).To(Succeed())
+		Expect(filepath.Join(cargoHome, "config.toml")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(cargoHome, "config.toml.base")).NotTo(BeAnExistingFile())
+	})
+}
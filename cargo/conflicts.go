@@ -0,0 +1,222 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+// Configuration holds the options of a build that are checked for conflicts with each other and with the stack
+type Configuration struct {
+	Allocator         string
+	AllocatorFeature  string
+	AuditFailSeverity string
+	Auditable         bool
+	BuildCommand      string
+	BuildProfile      string
+	CacheWarming      bool
+	CompressBinaries  bool
+	CrossTool         string
+	DebugBuild        bool
+	InstallArgs       string
+	Linker            string
+	MemberSelection   string
+	Platforms         []runner.Platform
+	Provenance        bool
+	RustcWrapper      string
+	Sccache           bool
+	SecurityAudit     bool
+	Stack             string
+	StaticType        string
+	Target            string
+	Targets           []runner.Platform
+	Timings           bool
+	UnmatchedMembers  string
+	Unstable          bool
+	UnstableFlags     []string
+	WorkspaceMembers  string
+}
+
+// Conflicts returns a description of each combination of options that is incoherent, because an option is ignored
+// or cannot work with another one, like a static binary type on a stack that does not build static binaries
+func Conflicts(config Configuration) ([]string, error) {
+	var conflicts []string
+
+	installTarget, err := runner.ResolveTargetTriple(config.InstallArgs, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve target triple\n%w", err)
+	}
+
+	target := installTarget
+	if config.Target != "" {
+		target = config.Target
+		if installTarget != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_TARGET=%s cannot be combined with --target=%s in BP_CARGO_INSTALL_ARGS", config.Target, installTarget))
+		}
+		if strings.HasSuffix(config.Target, ".json") {
+			conflicts = append(conflicts, "BP_CARGO_TARGET must be a target triple, set custom target specifications with --target in BP_CARGO_INSTALL_ARGS")
+		}
+	}
+
+	if config.StaticType != "" {
+		if _, ok := runner.LookupStackPolicy(config.Stack); !ok {
+			conflicts = append(conflicts, fmt.Sprintf("BP_STATIC_BINARY_TYPE only applies to tiny, static and registered stacks, but the stack is %s", config.Stack))
+		} else if config.Target != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_STATIC_BINARY_TYPE is ignored when BP_CARGO_TARGET=%s is set", config.Target))
+		} else if installTarget != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_STATIC_BINARY_TYPE is ignored when --target=%s is set in BP_CARGO_INSTALL_ARGS", installTarget))
+		}
+	}
+
+	if strings.HasPrefix(target, "wasm") {
+		if config.WorkspaceMembers != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_WORKSPACE_MEMBERS selects binaries to install, but the %s target builds WebAssembly modules that cannot be installed as processes", target))
+		}
+		if config.Allocator != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_ALLOCATOR=%s does not support the %s target", config.Allocator, target))
+		}
+	}
+
+	if len(config.Platforms) > 0 && config.Target != "" {
+		conflicts = append(conflicts, "BP_CARGO_PLATFORMS cannot be combined with BP_CARGO_TARGET")
+	} else if len(config.Platforms) > 0 && installTarget != "" {
+		conflicts = append(conflicts, "BP_CARGO_PLATFORMS cannot be combined with --target in BP_CARGO_INSTALL_ARGS")
+	}
+
+	if len(config.Targets) > 0 && len(config.Platforms) > 0 {
+		conflicts = append(conflicts, "BP_CARGO_TARGETS cannot be combined with BP_CARGO_PLATFORMS, list a target for each platform")
+	}
+	if len(config.Targets) > 0 && config.Target != "" {
+		conflicts = append(conflicts, "BP_CARGO_TARGETS cannot be combined with BP_CARGO_TARGET")
+	} else if len(config.Targets) > 0 && installTarget != "" {
+		conflicts = append(conflicts, "BP_CARGO_TARGETS cannot be combined with --target in BP_CARGO_INSTALL_ARGS")
+	}
+
+	cross := len(config.Platforms) > 0 || len(config.Targets) > 0 || target != ""
+	if config.CrossTool == runner.CrossToolZigbuild && !cross {
+		conflicts = append(conflicts, "BP_CARGO_CROSS_TOOL=zigbuild only applies to BP_CARGO_PLATFORMS, BP_CARGO_TARGETS or a target, none of which is set")
+	}
+
+	// cargo zigbuild builds like cargo build, there is no equivalent of cargo install
+	if config.CrossTool == runner.CrossToolZigbuild && target != "" && config.BuildCommand != runner.BuildCommandBuild {
+		conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_CROSS_TOOL=zigbuild builds the target %s with cargo zigbuild, which requires BP_CARGO_BUILD_COMMAND=build", target))
+	}
+
+	if _, glibc := runner.SplitGlibcVersion(target); glibc != "" && config.CrossTool != runner.CrossToolZigbuild {
+		conflicts = append(conflicts, fmt.Sprintf("the target %s links against glibc %s, which requires BP_CARGO_CROSS_TOOL=zigbuild", target, glibc))
+	}
+	for _, t := range config.Targets {
+		if _, glibc := runner.SplitGlibcVersion(t.Target); glibc != "" && config.CrossTool != runner.CrossToolZigbuild {
+			conflicts = append(conflicts, fmt.Sprintf("the target %s of BP_CARGO_TARGETS links against glibc %s, which requires BP_CARGO_CROSS_TOOL=zigbuild", t.Target, glibc))
+		}
+	}
+
+	if config.MemberSelection != "" && config.WorkspaceMembers == "" {
+		conflicts = append(conflicts, "BP_CARGO_WORKSPACE_MEMBER_SELECTION requires BP_CARGO_WORKSPACE_MEMBERS")
+	}
+
+	if config.UnmatchedMembers != "" && config.WorkspaceMembers == "" {
+		conflicts = append(conflicts, "BP_CARGO_WORKSPACE_MEMBERS_UNMATCHED requires BP_CARGO_WORKSPACE_MEMBERS")
+	}
+
+	if len(config.UnstableFlags) > 0 && !config.Unstable {
+		conflicts = append(conflicts, "BP_CARGO_UNSTABLE_FLAGS requires BP_CARGO_UNSTABLE_ENABLED=true")
+	}
+
+	if config.Timings && !config.Unstable {
+		conflicts = append(conflicts, "BP_CARGO_TIMINGS_ENABLED requires BP_CARGO_UNSTABLE_ENABLED=true, as --timings=json is unstable")
+	}
+
+	if config.AuditFailSeverity != "" && !config.SecurityAudit {
+		conflicts = append(conflicts, "BP_CARGO_AUDIT_FAIL_SEVERITY requires BP_CARGO_AUDIT_ENABLED=true")
+	}
+
+	if config.AllocatorFeature != "" && config.Allocator == "" {
+		conflicts = append(conflicts, "BP_CARGO_ALLOCATOR_FEATURE requires BP_CARGO_ALLOCATOR")
+	}
+
+	if config.BuildCommand == runner.BuildCommandBuild {
+		unsupported, err := runner.InstallOnlyArgs(config.InstallArgs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse install arguments\n%w", err)
+		}
+		for _, arg := range unsupported {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_BUILD_COMMAND=build does not support %s in BP_CARGO_INSTALL_ARGS, select members with BP_CARGO_WORKSPACE_MEMBERS", arg))
+		}
+	}
+
+	if config.BuildProfile != "" {
+		profile, err := runner.ExplicitProfile(config.InstallArgs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse install arguments\n%w", err)
+		}
+		if profile != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_BUILD_PROFILE=%s cannot be combined with the %s profile selected in BP_CARGO_INSTALL_ARGS", config.BuildProfile, profile))
+		}
+	}
+
+	if config.DebugBuild {
+		profile, err := runner.ExplicitProfile(config.InstallArgs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse install arguments\n%w", err)
+		}
+		if config.BuildProfile != "" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_DEBUG_BUILD builds with the dev profile, which cannot be combined with BP_CARGO_BUILD_PROFILE=%s", config.BuildProfile))
+		} else if profile != "" && profile != "dev" {
+			conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_DEBUG_BUILD builds with the dev profile, which cannot be combined with the %s profile selected in BP_CARGO_INSTALL_ARGS", profile))
+		}
+		if config.CompressBinaries {
+			conflicts = append(conflicts, "BP_CARGO_DEBUG_BUILD builds binaries to debug, which cannot be combined with BP_CARGO_COMPRESS_BINARIES, as binaries compressed with UPX cannot be debugged")
+		}
+	}
+
+	if config.Sccache && config.RustcWrapper != "" {
+		conflicts = append(conflicts, fmt.Sprintf("BP_CARGO_SCCACHE_ENABLED runs rustc through sccache, which cannot be combined with BP_CARGO_RUSTC_WRAPPER=%s", config.RustcWrapper))
+	}
+
+	if config.Linker == runner.LinkerMold && config.CrossTool == runner.CrossToolZigbuild && cross {
+		conflicts = append(conflicts, "BP_CARGO_LINKER=mold cannot be combined with BP_CARGO_CROSS_TOOL=zigbuild, which links with the linker of zig")
+	}
+
+	if config.Auditable && config.CrossTool == runner.CrossToolZigbuild && cross {
+		conflicts = append(conflicts, "BP_CARGO_AUDITABLE_ENABLED builds with cargo auditable, which cannot be combined with BP_CARGO_CROSS_TOOL=zigbuild")
+	}
+
+	if config.CacheWarming && config.Provenance {
+		conflicts = append(conflicts, "BP_CARGO_PROVENANCE_ENABLED has no effect on a cache warming build, which does not contribute launch layers")
+	}
+
+	return conflicts, nil
+}
+
+// CheckConflicts returns an error listing every conflict of the configuration, so that all of them can be fixed
+// before compiling
+func CheckConflicts(config Configuration) error {
+	conflicts, err := Conflicts(config)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("conflicting configuration, fix the following before building:\n  %s", strings.Join(conflicts, "\n  "))
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testConflicts(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it.Before(func() {
+		t.Setenv("RUSTFLAGS", "")
+	})
+
+	it("accepts a coherent configuration", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			Allocator:        cargo.AllocatorJemalloc,
+			AllocatorFeature: "jemalloc",
+			InstallArgs:      "--locked",
+			Stack:            libpak.JammyTinyStackID,
+			StaticType:       runner.StaticTypeGNULIBC,
+			WorkspaceMembers: "api",
+		})).To(BeEmpty())
+
+		Expect(cargo.CheckConflicts(cargo.Configuration{Stack: libpak.JammyStackID})).To(Succeed())
+	})
+
+	it("finds a static binary type that does not apply", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{Stack: libpak.JammyStackID, StaticType: runner.StaticTypeMUSLC})).To(Equal([]string{
+			"BP_STATIC_BINARY_TYPE only applies to tiny, static and registered stacks, but the stack is " + libpak.JammyStackID,
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{
+			InstallArgs: "--target=x86_64-unknown-linux-musl",
+			Stack:       libpak.JammyStaticStackID,
+			StaticType:  runner.StaticTypeGNULIBC,
+		})).To(Equal([]string{
+			"BP_STATIC_BINARY_TYPE is ignored when --target=x86_64-unknown-linux-musl is set in BP_CARGO_INSTALL_ARGS",
+		}))
+	})
+
+	it("finds options that do not work for WebAssembly", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			Allocator:        cargo.AllocatorMimalloc,
+			InstallArgs:      "--target wasm32-wasip1",
+			WorkspaceMembers: "api",
+		})).To(Equal([]string{
+			"BP_CARGO_WORKSPACE_MEMBERS selects binaries to install, but the wasm32-wasip1 target builds WebAssembly modules that cannot be installed as processes",
+			"BP_CARGO_ALLOCATOR=mimalloc does not support the wasm32-wasip1 target",
+		}))
+	})
+
+	it("finds options that do not work with a target", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			Allocator:   cargo.AllocatorMimalloc,
+			InstallArgs: "--target=x86_64-unknown-linux-musl",
+			Platforms:   []runner.Platform{{OS: "linux", Arch: "arm64"}},
+			Stack:       libpak.JammyStaticStackID,
+			StaticType:  runner.StaticTypeGNULIBC,
+			Target:      "wasm32-wasip1",
+		})).To(Equal([]string{
+			"BP_CARGO_TARGET=wasm32-wasip1 cannot be combined with --target=x86_64-unknown-linux-musl in BP_CARGO_INSTALL_ARGS",
+			"BP_STATIC_BINARY_TYPE is ignored when BP_CARGO_TARGET=wasm32-wasip1 is set",
+			"BP_CARGO_ALLOCATOR=mimalloc does not support the wasm32-wasip1 target",
+			"BP_CARGO_PLATFORMS cannot be combined with BP_CARGO_TARGET",
+		}))
+	})
+
+	it("finds options that require another option", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			AllocatorFeature: "jemalloc",
+			CacheWarming:     true,
+			CrossTool:        runner.CrossToolZigbuild,
+			MemberSelection:  runner.MemberSelectionPackage,
+			Provenance:       true,
+			Timings:          true,
+			UnmatchedMembers: runner.UnmatchedMembersWarn,
+			UnstableFlags:    []string{"-Zbuild-std"},
+		})).To(Equal([]string{
+			"BP_CARGO_CROSS_TOOL=zigbuild only applies to BP_CARGO_PLATFORMS, BP_CARGO_TARGETS or a target, none of which is set",
+			"BP_CARGO_WORKSPACE_MEMBER_SELECTION requires BP_CARGO_WORKSPACE_MEMBERS",
+			"BP_CARGO_WORKSPACE_MEMBERS_UNMATCHED requires BP_CARGO_WORKSPACE_MEMBERS",
+			"BP_CARGO_UNSTABLE_FLAGS requires BP_CARGO_UNSTABLE_ENABLED=true",
+			"BP_CARGO_TIMINGS_ENABLED requires BP_CARGO_UNSTABLE_ENABLED=true, as --timings=json is unstable",
+			"BP_CARGO_ALLOCATOR_FEATURE requires BP_CARGO_ALLOCATOR",
+			"BP_CARGO_PROVENANCE_ENABLED has no effect on a cache warming build, which does not contribute launch layers",
+		}))
+	})
+
+	it("finds install arguments that cargo build does not support", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			BuildCommand: runner.BuildCommandBuild,
+			InstallArgs:  "--locked --path=./api",
+		})).To(Equal([]string{
+			"BP_CARGO_BUILD_COMMAND=build does not support --path in BP_CARGO_INSTALL_ARGS, select members with BP_CARGO_WORKSPACE_MEMBERS",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{
+			BuildCommand: runner.BuildCommandInstall,
+			InstallArgs:  "--locked --path=./api",
+		})).To(BeEmpty())
+	})
+
+	it("finds a build profile with a profile in the install arguments", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{BuildProfile: "release-lto", InstallArgs: "--locked --profile=small"})).To(Equal([]string{
+			"BP_CARGO_BUILD_PROFILE=release-lto cannot be combined with the small profile selected in BP_CARGO_INSTALL_ARGS",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{BuildProfile: "release-lto", InstallArgs: "--locked"})).To(BeEmpty())
+	})
+
+	it("finds a debug build with another profile", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{BuildProfile: "release-lto", DebugBuild: true})).To(Equal([]string{
+			"BP_CARGO_DEBUG_BUILD builds with the dev profile, which cannot be combined with BP_CARGO_BUILD_PROFILE=release-lto",
+		}))
+		Expect(cargo.Conflicts(cargo.Configuration{DebugBuild: true, InstallArgs: "--profile=release"})).To(Equal([]string{
+			"BP_CARGO_DEBUG_BUILD builds with the dev profile, which cannot be combined with the release profile selected in BP_CARGO_INSTALL_ARGS",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{DebugBuild: true, InstallArgs: "--debug"})).To(BeEmpty())
+	})
+
+	it("finds a debug build with compressed binaries", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{CompressBinaries: true, DebugBuild: true})).To(Equal([]string{
+			"BP_CARGO_DEBUG_BUILD builds binaries to debug, which cannot be combined with BP_CARGO_COMPRESS_BINARIES, as binaries compressed with UPX cannot be debugged",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{CompressBinaries: true})).To(BeEmpty())
+	})
+
+	it("finds a rustc wrapper that replaces sccache", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{RustcWrapper: "cachepot", Sccache: true})).To(Equal([]string{
+			"BP_CARGO_SCCACHE_ENABLED runs rustc through sccache, which cannot be combined with BP_CARGO_RUSTC_WRAPPER=cachepot",
+		}))
+	})
+
+	it("finds an audit fail severity without the audit", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{AuditFailSeverity: runner.SeverityHigh})).To(Equal([]string{
+			"BP_CARGO_AUDIT_FAIL_SEVERITY requires BP_CARGO_AUDIT_ENABLED=true",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{AuditFailSeverity: runner.SeverityHigh, SecurityAudit: true})).To(BeEmpty())
+	})
+
+	it("finds cargo auditable with the zigbuild cross tool", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			Auditable: true,
+			CrossTool: runner.CrossToolZigbuild,
+			Platforms: []runner.Platform{{OS: "linux", Arch: "arm64"}},
+		})).To(Equal([]string{
+			"BP_CARGO_AUDITABLE_ENABLED builds with cargo auditable, which cannot be combined with BP_CARGO_CROSS_TOOL=zigbuild",
+		}))
+	})
+
+	it("finds a target built with zigbuild without cargo build", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			CrossTool: runner.CrossToolZigbuild,
+			Target:    "aarch64-unknown-linux-gnu",
+		})).To(Equal([]string{
+			"BP_CARGO_CROSS_TOOL=zigbuild builds the target aarch64-unknown-linux-gnu with cargo zigbuild, which requires BP_CARGO_BUILD_COMMAND=build",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{
+			BuildCommand: runner.BuildCommandBuild,
+			CrossTool:    runner.CrossToolZigbuild,
+			InstallArgs:  "--target=x86_64-unknown-linux-gnu.2.17",
+		})).To(BeEmpty())
+	})
+
+	it("finds a glibc version without zigbuild", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			BuildCommand: runner.BuildCommandBuild,
+			Target:       "x86_64-unknown-linux-gnu.2.17",
+		})).To(Equal([]string{
+			"the target x86_64-unknown-linux-gnu.2.17 links against glibc 2.17, which requires BP_CARGO_CROSS_TOOL=zigbuild",
+		}))
+	})
+
+	it("finds options that do not work with targets", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			Platforms: []runner.Platform{{OS: "linux", Arch: "amd64"}},
+			Target:    "x86_64-unknown-linux-musl",
+			Targets: []runner.Platform{
+				{OS: "linux", Arch: "amd64", Target: "x86_64-unknown-linux-musl"},
+				{OS: "linux", Arch: "arm64", Target: "aarch64-unknown-linux-gnu.2.17"},
+			},
+		})).To(Equal([]string{
+			"BP_CARGO_PLATFORMS cannot be combined with BP_CARGO_TARGET",
+			"BP_CARGO_TARGETS cannot be combined with BP_CARGO_PLATFORMS, list a target for each platform",
+			"BP_CARGO_TARGETS cannot be combined with BP_CARGO_TARGET",
+			"the target aarch64-unknown-linux-gnu.2.17 of BP_CARGO_TARGETS links against glibc 2.17, which requires BP_CARGO_CROSS_TOOL=zigbuild",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{
+			CrossTool: runner.CrossToolZigbuild,
+			Targets: []runner.Platform{
+				{OS: "linux", Arch: "amd64", Target: "x86_64-unknown-linux-musl"},
+				{OS: "linux", Arch: "arm64", Target: "aarch64-unknown-linux-gnu.2.17"},
+			},
+		})).To(BeEmpty())
+	})
+
+	it("finds mold with the zigbuild cross tool", func() {
+		Expect(cargo.Conflicts(cargo.Configuration{
+			CrossTool: runner.CrossToolZigbuild,
+			Linker:    runner.LinkerMold,
+			Platforms: []runner.Platform{{OS: "linux", Arch: "arm64"}},
+		})).To(Equal([]string{
+			"BP_CARGO_LINKER=mold cannot be combined with BP_CARGO_CROSS_TOOL=zigbuild, which links with the linker of zig",
+		}))
+
+		Expect(cargo.Conflicts(cargo.Configuration{
+			CrossTool: runner.CrossToolZigbuild,
+			Linker:    runner.LinkerLLD,
+			Platforms: []runner.Platform{{OS: "linux", Arch: "arm64"}},
+		})).To(BeEmpty())
+	})
+
+	it("reports all conflicts together", func() {
+		err := cargo.CheckConflicts(cargo.Configuration{
+			InstallArgs: "--target=aarch64-unknown-linux-gnu",
+			Platforms:   []runner.Platform{{OS: "linux", Arch: "amd64"}},
+			Stack:       libpak.JammyStackID,
+			StaticType:  runner.StaticTypeMUSLC,
+		})
+
+		Expect(err).To(MatchError(And(
+			ContainSubstring("conflicting configuration"),
+			ContainSubstring("BP_STATIC_BINARY_TYPE only applies to tiny, static and registered stacks"),
+			ContainSubstring("BP_CARGO_PLATFORMS cannot be combined with --target"),
+		)))
+	})
+}
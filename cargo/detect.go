@@ -22,9 +22,12 @@ import (
 	"path/filepath"
 
 	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-community/cargo/runner"
 )
 
 const (
+	PlanEntryRust      = "rust"
 	PlanEntryRustCargo = "rust-cargo"
 	PlanEntrySyft      = "syft"
 )
@@ -33,13 +36,66 @@ type Detect struct {
 }
 
 func (d Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error) {
-	found, err := d.cargoProject(context.Application.Path)
+	cr, err := libpak.NewConfigurationResolver(context.Buildpack, nil)
 	if err != nil {
-		return libcnb.DetectResult{}, fmt.Errorf("unable to detect cargo requirements\n%w", err)
+		return libcnb.DetectResult{}, fmt.Errorf("unable to create configuration resolver\n%w", err)
 	}
 
-	if !found {
-		return libcnb.DetectResult{Pass: false}, nil
+	if projects, ok := cr.Resolve("BP_CARGO_PROJECTS"); ok && projects != "" {
+		// every configured project must exist, otherwise the build would fail
+		if _, err := Projects(context.Application.Path, projects); err != nil {
+			return libcnb.DetectResult{Pass: false}, nil
+		}
+	} else {
+		found, err := d.cargoProject(context.Application.Path)
+		if err != nil {
+			return libcnb.DetectResult{}, fmt.Errorf("unable to detect cargo requirements\n%w", err)
+		}
+
+		if !found {
+			return libcnb.DetectResult{Pass: false}, nil
+		}
+	}
+
+	rustVersion, _ := cr.Resolve("BP_RUST_VERSION")
+
+	file, err := runner.ToolchainRequirements(context.Application.Path)
+	if err != nil {
+		return libcnb.DetectResult{}, fmt.Errorf("unable to read toolchain file\n%w", err)
+	}
+
+	// unstable features are allowed on any toolchain when opted in to with BP_CARGO_UNSTABLE_ENABLED
+	if nightly, err := NightlyConfigured(context.Application.Path, rustVersion); err != nil {
+		return libcnb.DetectResult{}, fmt.Errorf("unable to determine configured toolchain\n%w", err)
+	} else if !nightly && !cr.ResolveBool("BP_CARGO_UNSTABLE_ENABLED") {
+		requirement, err := NightlyRequirement(context.Application.Path)
+		if err != nil {
+			return libcnb.DetectResult{}, fmt.Errorf("unable to detect nightly requirements\n%w", err)
+		}
+
+		if requirement != "" {
+			return libcnb.DetectResult{}, fmt.Errorf("this project requires a nightly toolchain because it uses %s, "+
+				"set BP_RUST_VERSION=nightly, add a rust-toolchain.toml file selecting a nightly channel or opt in to unstable "+
+				"features with BP_CARGO_UNSTABLE_ENABLED=true", requirement)
+		}
+	}
+
+	requires := []libcnb.BuildPlanRequire{
+		{Name: PlanEntrySyft},
+		{Name: PlanEntryRustCargo},
+	}
+
+	// with rustup enabled, the toolchain is installed by this buildpack if no other buildpack provides one
+	if !cr.ResolveBool("BP_CARGO_RUSTUP_ENABLED") {
+		// the toolchain is only required at build time, the application binaries do not need it at launch
+		rustMetadata := map[string]interface{}{"build": true}
+		if rustVersion != "" {
+			rustMetadata["version"] = rustVersion
+		} else if file != nil && file.Channel != "" {
+			rustMetadata["version"] = file.Channel
+			rustMetadata["version-source"] = file.File
+		}
+		requires = append(requires, libcnb.BuildPlanRequire{Name: PlanEntryRust, Metadata: rustMetadata})
 	}
 
 	return libcnb.DetectResult{
@@ -49,11 +105,7 @@ func (d Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error
 				Provides: []libcnb.BuildPlanProvide{
 					{Name: PlanEntryRustCargo},
 				},
-				Requires: []libcnb.BuildPlanRequire{
-					{Name: PlanEntrySyft},
-					{Name: PlanEntryRustCargo},
-					{Name: "rust"},
-				},
+				Requires: requires,
 			},
 		},
 	}, nil
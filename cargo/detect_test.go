@@ -85,10 +85,134 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 					Requires: []libcnb.BuildPlanRequire{
 						{Name: "syft"},
 						{Name: "rust-cargo"},
-						{Name: "rust"},
+						{Name: "rust", Metadata: map[string]interface{}{"build": true}},
 					},
 				},
 			},
 		}))
 	})
+
+	context("BP_CARGO_RUSTUP_ENABLED is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_CARGO_RUSTUP_ENABLED", "true")
+		})
+
+		it("does not require a Rust toolchain", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.toml"), []byte{}, 0644))
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.lock"), []byte{}, 0644))
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Plans[0].Requires).To(Equal([]libcnb.BuildPlanRequire{
+				{Name: "syft"},
+				{Name: "rust-cargo"},
+			}))
+		})
+	})
+
+	context("BP_RUST_VERSION is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_RUST_VERSION", "1.78.0")
+		})
+
+		it("requests the Rust version in the build plan", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.toml"), []byte{}, 0644))
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.lock"), []byte{}, 0644))
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Plans[0].Requires).To(ContainElement(libcnb.BuildPlanRequire{
+				Name:     "rust",
+				Metadata: map[string]interface{}{"build": true, "version": "1.78.0"},
+			}))
+		})
+	})
+
+	context("a rust-toolchain.toml file is present", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.toml"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.lock"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "rust-toolchain.toml"), []byte("[toolchain]\nchannel = \"1.80.1\"\n"), 0644)).To(Succeed())
+		})
+
+		it("requests the channel of the toolchain file in the build plan", func() {
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Plans[0].Requires).To(ContainElement(libcnb.BuildPlanRequire{
+				Name:     "rust",
+				Metadata: map[string]interface{}{"build": true, "version": "1.80.1", "version-source": "rust-toolchain.toml"},
+			}))
+		})
+
+		it("prefers BP_RUST_VERSION", func() {
+			t.Setenv("BP_RUST_VERSION", "1.78.0")
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Plans[0].Requires).To(ContainElement(libcnb.BuildPlanRequire{
+				Name:     "rust",
+				Metadata: map[string]interface{}{"build": true, "version": "1.78.0"},
+			}))
+		})
+	})
+
+	context("nightly features", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.toml"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Cargo.lock"), []byte{}, 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "src"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "src", "main.rs"), []byte("#![feature(test)]\nfn main() {}\n"), 0644)).To(Succeed())
+		})
+
+		it("fails when a nightly toolchain is required", func() {
+			_, err := detect.Detect(ctx)
+			Expect(err).To(MatchError(ContainSubstring("this project requires a nightly toolchain because it uses #![feature(...)] in src/main.rs")))
+		})
+
+		it("passes when BP_RUST_VERSION selects nightly", func() {
+			t.Setenv("BP_RUST_VERSION", "nightly")
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Pass).To(BeTrue())
+		})
+
+		it("passes when unstable features are enabled", func() {
+			t.Setenv("BP_CARGO_UNSTABLE_ENABLED", "true")
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Pass).To(BeTrue())
+		})
+
+		it("passes when rust-toolchain.toml selects nightly", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "rust-toolchain.toml"), []byte("[toolchain]\nchannel = \"nightly-2024-05-01\"\n"), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Pass).To(BeTrue())
+		})
+	})
+
+	context("BP_CARGO_PROJECTS is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_CARGO_PROJECTS", "api")
+		})
+
+		it("passes when the projects exist", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "api"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "api", "Cargo.toml"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "api", "Cargo.lock"), []byte{}, 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Pass).To(BeTrue())
+		})
+
+		it("fails when a project is missing", func() {
+			result, err := detect.Detect(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Pass).To(BeFalse())
+		})
+	})
 }
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/runner"
+)
+
+const redacted = "<redacted>"
+
+var sensitiveNamePattern = regexp.MustCompile(`(?i)(TOKEN|PASSWORD|SECRET|CREDENTIAL|AUTH|KEY)`)
+
+// Diagnostics logs the effective configuration of a build when BP_CARGO_DIAGNOSTICS is set. Values that may contain
+// credentials are redacted.
+type Diagnostics struct {
+	ConfigurationResolver libpak.ConfigurationResolver
+	Contributors          []libcnb.LayerContributor
+	Environment           []string
+	Layers                libcnb.Layers
+	Logger                bard.Logger
+	Service               runner.CargoService
+	TargetTriple          string
+	ToolchainPath         string
+}
+
+// Log writes the diagnostics to the logger
+func (d Diagnostics) Log() error {
+	d.Logger.Header("Diagnostics")
+
+	d.Logger.Body("Configuration:")
+	for _, c := range d.ConfigurationResolver.Configurations {
+		value, set := d.ConfigurationResolver.Resolve(c.Name)
+		source := "default"
+		if set {
+			source = "environment"
+		}
+		d.Logger.Bodyf("  %s=%s (%s)", c.Name, Redact(c.Name, value), source)
+	}
+
+	d.Logger.Body("Environment:")
+	var env []string
+	for _, e := range d.Environment {
+		name, value, _ := strings.Cut(e, "=")
+		if strings.HasPrefix(name, "CARGO") || strings.HasPrefix(name, "RUST") {
+			env = append(env, fmt.Sprintf("%s=%s", name, Redact(name, value)))
+		}
+	}
+	sort.Strings(env)
+	for _, e := range env {
+		d.Logger.Bodyf("  %s", e)
+	}
+
+	cargoVersion, err := d.Service.CargoVersion()
+	if err != nil {
+		return fmt.Errorf("unable to determine cargo version\n%w", err)
+	}
+
+	rustVersion, err := d.Service.RustVersion()
+	if err != nil {
+		return fmt.Errorf("unable to determine rust version\n%w", err)
+	}
+
+	toolchainPath := d.ToolchainPath
+	if toolchainPath == "" {
+		toolchainPath = "PATH"
+	}
+	targetTriple := d.TargetTriple
+	if targetTriple == "" {
+		targetTriple = "host"
+	}
+
+	d.Logger.Body("Toolchain:")
+	d.Logger.Bodyf("  location: %s", toolchainPath)
+	d.Logger.Bodyf("  cargo: %s", cargoVersion.Raw())
+	d.Logger.Bodyf("  rustc: %s", rustVersion.Raw())
+	d.Logger.Bodyf("  target: %s", targetTriple)
+
+	d.Logger.Body("Layers:")
+	for _, c := range d.Contributors {
+		layer, err := d.Layers.Layer(c.Name())
+		if err != nil {
+			return fmt.Errorf("unable to read layer %s\n%w", c.Name(), err)
+		}
+
+		state := "new"
+		if len(layer.Metadata) > 0 {
+			state = "cached"
+		}
+		d.Logger.Bodyf("  %s (%s)", c.Name(), state)
+	}
+
+	return nil
+}
+
+// Redact hides the value of variables that may contain credentials
+func Redact(name string, value string) string {
+	if value != "" && sensitiveNamePattern.MatchString(name) {
+		return redacted
+	}
+	return runner.RedactURLs(value)
+}
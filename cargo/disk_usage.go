@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// DiskUsage measures the size of the layers contributed by this buildpack and of CARGO_HOME once the application
+// is built. The sizes are logged with the change since the previous build and stored in the layer metadata.
+type DiskUsage struct {
+	CargoHome  string
+	LayerNames []string
+	Layers     libcnb.Layers
+	Logger     bard.Logger
+}
+
+func (d DiskUsage) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	previous, _ := layer.Metadata["disk-usage"].(map[string]interface{})
+
+	type entry struct {
+		name string
+		path string
+	}
+	var entries []entry
+	for _, name := range d.LayerNames {
+		entries = append(entries, entry{name, filepath.Join(d.Layers.Path, name)})
+	}
+	entries = append(entries,
+		entry{"CARGO_HOME registry", filepath.Join(d.CargoHome, "registry")},
+		entry{"CARGO_HOME git", filepath.Join(d.CargoHome, "git")},
+		entry{"CARGO_HOME bin", filepath.Join(d.CargoHome, "bin")})
+
+	d.Logger.Header("Disk usage")
+
+	usage := map[string]interface{}{}
+	for _, e := range entries {
+		size, err := DirectorySize(e.path)
+		if err != nil {
+			return libcnb.Layer{}, err
+		}
+		usage[e.name] = size
+
+		line := fmt.Sprintf("%s: %s", e.name, runner.FormatBytes(size))
+		if p, ok := previous[e.name].(int64); ok && p != size {
+			if size > p {
+				line = fmt.Sprintf("%s (+%s)", line, runner.FormatBytes(size-p))
+			} else {
+				line = fmt.Sprintf("%s (-%s)", line, runner.FormatBytes(p-size))
+			}
+		}
+		d.Logger.Body(line)
+	}
+
+	layer.Metadata = map[string]interface{}{"disk-usage": usage}
+	// cached so that the next build can report the change in size
+	layer.Cache = true
+	return layer, nil
+}
+
+func (DiskUsage) Name() string {
+	return "Cargo Disk Usage"
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testDiskUsage(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf       *bytes.Buffer
+		cargoHome string
+		layers    libcnb.Layers
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+		cargoHome = t.TempDir()
+		layers = libcnb.Layers{Path: t.TempDir()}
+
+		Expect(os.MkdirAll(filepath.Join(layers.Path, "Cargo", "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layers.Path, "Cargo", "bin", "app"), make([]byte, 2048), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(cargoHome, "registry", "cache"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(cargoHome, "registry", "cache", "serde.crate"), make([]byte, 1024), 0644)).To(Succeed())
+	})
+
+	it("measures and stores the layer sizes", func() {
+		layer, err := layers.Layer("Cargo Disk Usage")
+		Expect(err).NotTo(HaveOccurred())
+		layer.Metadata = map[string]interface{}{"disk-usage": map[string]interface{}{"Cargo": int64(4096)}}
+
+		layer, err = cargo.DiskUsage{
+			CargoHome:  cargoHome,
+			LayerNames: []string{"Cargo Cache", "Cargo"},
+			Layers:     layers,
+			Logger:     bard.NewLogger(buf),
+		}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Cache).To(BeTrue())
+		Expect(layer.Metadata).To(Equal(map[string]interface{}{"disk-usage": map[string]interface{}{
+			"Cargo Cache":         int64(0),
+			"Cargo":               int64(2048),
+			"CARGO_HOME registry": int64(1024),
+			"CARGO_HOME git":      int64(0),
+			"CARGO_HOME bin":      int64(0),
+		}}))
+
+		Expect(buf.String()).To(ContainSubstring("Disk usage"))
+		Expect(buf.String()).To(ContainSubstring("Cargo: 2.0 KB (-2.0 KB)"))
+		Expect(buf.String()).To(ContainSubstring("CARGO_HOME registry: 1.0 KB"))
+	})
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Transfer is a number of downloads and their total size
+type Transfer struct {
+	Bytes int64
+	Count int
+}
+
+// Downloads are the crates and git repositories that a build added to the caches of CARGO_HOME, with the crates by
+// registry, like `index.crates.io-6f17d22bba15001f` or the directory of a mirror
+type Downloads struct {
+	Git        Transfer
+	Registries map[string]Transfer
+}
+
+// Crates returns the crates downloaded from all registries
+func (d Downloads) Crates() Transfer {
+	var t Transfer
+	for _, r := range d.Registries {
+		t.Bytes += r.Bytes
+		t.Count += r.Count
+	}
+	return t
+}
+
+// DownloadSnapshot is the content of the download caches of CARGO_HOME, the crates in `registry/cache` and the git
+// databases in `git/db`, taken before a build to find what the build downloaded
+type DownloadSnapshot struct {
+	files map[string]bool
+}
+
+// SnapshotDownloads takes a snapshot of the download caches of CARGO_HOME
+func SnapshotDownloads(cargoHome string) (DownloadSnapshot, error) {
+	s := DownloadSnapshot{files: map[string]bool{}}
+
+	err := walkDownloads(cargoHome, func(rel string, _ int64) {
+		s.files[rel] = true
+	})
+	if err != nil {
+		return DownloadSnapshot{}, err
+	}
+
+	return s, nil
+}
+
+// Since returns what was added to the download caches of CARGO_HOME since the snapshot was taken
+func (s DownloadSnapshot) Since(cargoHome string) (Downloads, error) {
+	d := Downloads{Registries: map[string]Transfer{}}
+	repositories := map[string]bool{}
+
+	err := walkDownloads(cargoHome, func(rel string, size int64) {
+		if s.files[rel] {
+			return
+		}
+
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 4)
+		switch parts[0] {
+		case "registry":
+			r := d.Registries[parts[2]]
+			r.Bytes += size
+			if strings.HasSuffix(rel, ".crate") {
+				r.Count++
+			}
+			d.Registries[parts[2]] = r
+		case "git":
+			d.Git.Bytes += size
+			repositories[parts[2]] = true
+		}
+	})
+	if err != nil {
+		return Downloads{}, err
+	}
+
+	d.Git.Count = len(repositories)
+	return d, nil
+}
+
+// walkDownloads calls f with the path relative to CARGO_HOME and the size of each file in the download caches
+func walkDownloads(cargoHome string, f func(rel string, size int64)) error {
+	for _, dir := range []string{filepath.Join("registry", "cache"), filepath.Join("git", "db")} {
+		root := filepath.Join(cargoHome, dir)
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return fmt.Errorf("unable to stat %s\n%w", path, err)
+			}
+
+			rel, err := filepath.Rel(cargoHome, path)
+			if err != nil {
+				return fmt.Errorf("unable to resolve %s relative to %s\n%w", path, cargoHome, err)
+			}
+
+			f(rel, info.Size())
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to walk %s\n%w", root, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testDownloads(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cargoHome string
+	)
+
+	it.Before(func() {
+		cargoHome = t.TempDir()
+	})
+
+	write := func(path string, size int) {
+		t.Helper()
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(cargoHome, path)), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(cargoHome, path), make([]byte, size), 0644)).To(Succeed())
+	}
+
+	it("tolerates empty caches", func() {
+		snapshot, err := cargo.SnapshotDownloads(cargoHome)
+		Expect(err).NotTo(HaveOccurred())
+
+		downloads, err := snapshot.Since(cargoHome)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(downloads.Crates()).To(Equal(cargo.Transfer{}))
+		Expect(downloads.Git).To(Equal(cargo.Transfer{}))
+	})
+
+	it("counts new crates by registry", func() {
+		write("registry/cache/index.crates.io-6f17d22bba15001f/cached-1.0.0.crate", 100)
+
+		snapshot, err := cargo.SnapshotDownloads(cargoHome)
+		Expect(err).NotTo(HaveOccurred())
+
+		write("registry/cache/index.crates.io-6f17d22bba15001f/serde-1.0.0.crate", 10)
+		write("registry/cache/index.crates.io-6f17d22bba15001f/libc-0.2.0.crate", 20)
+		write("registry/cache/mirror.example.com-0123456789abcdef/log-0.4.0.crate", 5)
+
+		downloads, err := snapshot.Since(cargoHome)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(downloads.Registries).To(Equal(map[string]cargo.Transfer{
+			"index.crates.io-6f17d22bba15001f":    {Bytes: 30, Count: 2},
+			"mirror.example.com-0123456789abcdef": {Bytes: 5, Count: 1},
+		}))
+		Expect(downloads.Crates()).To(Equal(cargo.Transfer{Bytes: 35, Count: 3}))
+	})
+
+	it("counts new git repositories", func() {
+		write("git/db/cached-0123456789abcdef/HEAD", 100)
+
+		snapshot, err := cargo.SnapshotDownloads(cargoHome)
+		Expect(err).NotTo(HaveOccurred())
+
+		write("git/db/cached-0123456789abcdef/objects/pack/pack-1.pack", 7)
+		write("git/db/fetched-fedcba9876543210/HEAD", 3)
+		write("git/db/fetched-fedcba9876543210/objects/pack/pack-2.pack", 40)
+
+		downloads, err := snapshot.Since(cargoHome)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(downloads.Git).To(Equal(cargo.Transfer{Bytes: 50, Count: 2}))
+		Expect(downloads.Registries).To(BeEmpty())
+	})
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+// Environment exports the toolchain environment used by this buildpack, so that subsequent buildpacks can invoke
+// cargo and rustc in the same way
+type Environment struct {
+	CargoHome    string
+	Logger       bard.Logger
+	TargetPath   string
+	TargetTriple string
+}
+
+func (e Environment) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	layer.BuildEnvironment.Default("CARGO_HOME", e.CargoHome)
+	layer.BuildEnvironment.Prepend("PATH", string(os.PathListSeparator), filepath.Join(e.CargoHome, "bin"))
+	e.Logger.Bodyf("Exporting CARGO_HOME=%s for subsequent buildpacks", e.CargoHome)
+
+	if e.TargetTriple != "" {
+		layer.BuildEnvironment.Default("CARGO_BUILD_TARGET", e.TargetTriple)
+		e.Logger.Bodyf("Exporting CARGO_BUILD_TARGET=%s for subsequent buildpacks", e.TargetTriple)
+	}
+
+	// the target of a custom target specification is only found by name with RUST_TARGET_PATH
+	if e.TargetPath != "" {
+		layer.BuildEnvironment.Prepend("RUST_TARGET_PATH", string(os.PathListSeparator), e.TargetPath)
+		e.Logger.Bodyf("Exporting RUST_TARGET_PATH=%s for subsequent buildpacks", e.TargetPath)
+	}
+
+	layer.Build = true
+	return layer, nil
+}
+
+func (Environment) Name() string {
+	return "Cargo Environment"
+}
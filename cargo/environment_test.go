@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testEnvironment(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx libcnb.BuildContext
+	)
+
+	it.Before(func() {
+		ctx.Layers.Path = t.TempDir()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(ctx.Layers.Path)).To(Succeed())
+	})
+
+	it("exports CARGO_HOME and PATH", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.Environment{CargoHome: "/cargo/home"}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Build).To(BeTrue())
+		Expect(layer.Launch).To(BeFalse())
+		Expect(layer.BuildEnvironment).To(Equal(libcnb.Environment{
+			"CARGO_HOME.default": "/cargo/home",
+			"PATH.delim":         ":",
+			"PATH.prepend":       filepath.Join("/cargo/home", "bin"),
+		}))
+	})
+
+	it("exports the target triple", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.Environment{CargoHome: "/cargo/home", TargetTriple: "x86_64-unknown-linux-musl"}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.BuildEnvironment).To(HaveKeyWithValue("CARGO_BUILD_TARGET.default", "x86_64-unknown-linux-musl"))
+	})
+
+	it("exports the path of a custom target specification", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.Environment{
+			CargoHome:    "/cargo/home",
+			TargetPath:   "/cargo/home/target-specs",
+			TargetTriple: "my-target",
+		}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.BuildEnvironment).To(HaveKeyWithValue("CARGO_BUILD_TARGET.default", "my-target"))
+		Expect(layer.BuildEnvironment).To(HaveKeyWithValue("RUST_TARGET_PATH.prepend", "/cargo/home/target-specs"))
+	})
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// IndexSnapshot keeps a snapshot of the registry index caches of CARGO_HOME in a cached layer. The cache files of a
+// sparse index start with the etag or last modified time of their last fetch, so with a snapshot restored, index
+// refreshes are answered with `304 Not Modified` instead of fetching the index again, even if the layer holding
+// CARGO_HOME was not restored.
+type IndexSnapshot struct {
+	CargoHome string
+	Logger    bard.Logger
+}
+
+func (i IndexSnapshot) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	snapshot := filepath.Join(layer.Path, "index")
+	if err := os.RemoveAll(snapshot); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to remove previous snapshot\n%w", err)
+	}
+
+	type counts struct {
+		files      int64
+		validators int64
+	}
+
+	indexes := map[string]*counts{}
+	err := walkIndexCaches(filepath.Join(i.CargoHome, "registry", "index"), func(path string, rel string) error {
+		destination := filepath.Join(snapshot, rel)
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			return fmt.Errorf("unable to create %s\n%w", filepath.Dir(destination), err)
+		}
+
+		if err := runner.CloneFile(path, destination); err != nil {
+			return fmt.Errorf("unable to snapshot %s\n%w", rel, err)
+		}
+
+		name, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		if indexes[name] == nil {
+			indexes[name] = &counts{}
+		}
+		indexes[name].files++
+
+		if validator, err := IndexCacheValidator(path); err != nil {
+			return err
+		} else if validator != "" {
+			indexes[name].validators++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to snapshot registry index\n%w", err)
+	}
+
+	var names []string
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	i.Logger.Header("Registry index snapshot")
+	if len(names) == 0 {
+		i.Logger.Body("No registry index cache to snapshot")
+	}
+
+	metadata := map[string]interface{}{}
+	for _, name := range names {
+		i.Logger.Bodyf("%s: %d cached files, %d with an etag or last modified time", name, indexes[name].files, indexes[name].validators)
+		metadata[name] = map[string]interface{}{"files": indexes[name].files, "validators": indexes[name].validators}
+	}
+
+	layer.Metadata = map[string]interface{}{"index-snapshot": metadata}
+	layer.Cache = true
+	return layer, nil
+}
+
+func (IndexSnapshot) Name() string {
+	return "Cargo Index Snapshot"
+}
+
+// RestoreIndexSnapshot restores the files of a snapshot that are missing from the registry index caches of CARGO_HOME
+// and returns the number of files restored. Files in CARGO_HOME are never replaced, as they are at least as recent.
+func RestoreIndexSnapshot(cargoHome string, snapshotLayer string) (int, error) {
+	restored := 0
+	err := walkIndexCaches(filepath.Join(snapshotLayer, "index"), func(path string, rel string) error {
+		destination := filepath.Join(cargoHome, "registry", "index", rel)
+		if found, err := sherpa.FileExists(destination); err != nil {
+			return fmt.Errorf("unable to check for %s\n%w", destination, err)
+		} else if found {
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			return fmt.Errorf("unable to create %s\n%w", filepath.Dir(destination), err)
+		}
+
+		if err := runner.CloneFile(path, destination); err != nil {
+			return fmt.Errorf("unable to restore %s\n%w", rel, err)
+		}
+		restored++
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to restore registry index snapshot\n%w", err)
+	}
+
+	return restored, nil
+}
+
+// IndexCacheValidator returns the etag or last modified time that a registry index cache file was fetched with, like
+// `etag: "5f3c..."`. Cache files start with a version byte and a 4 byte index version, followed by the validator
+// terminated with a NUL byte. Returns an empty string if the file has no validator.
+func IndexCacheValidator(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+	header = header[:n]
+
+	if len(header) <= 5 {
+		return "", nil
+	}
+
+	validator, _, found := bytes.Cut(header[5:], []byte{0})
+	if !found || !(bytes.HasPrefix(validator, []byte("etag: ")) || bytes.HasPrefix(validator, []byte("last-modified: "))) {
+		return "", nil
+	}
+
+	return string(validator), nil
+}
+
+// walkIndexCaches calls f with the path of each file in the `.cache` directories of the registry indexes under root,
+// and the path relative to root
+func walkIndexCaches(root string, f func(path string, rel string) error) error {
+	indexes, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", root, err)
+	}
+
+	for _, index := range indexes {
+		cache := filepath.Join(root, index.Name(), ".cache")
+		err := filepath.WalkDir(cache, func(path string, d fs.DirEntry, err error) error {
+			if os.IsNotExist(err) && path == cache {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return fmt.Errorf("unable to resolve %s relative to %s\n%w", path, root, err)
+			}
+
+			return f(path, rel)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testIndexSnapshot(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf       *bytes.Buffer
+		cargoHome string
+		layers    libcnb.Layers
+		cache     string
+	)
+
+	// writes a cache file in the format of cargo, a version byte, a 4 byte index version and the validator
+	writeCacheFile := func(path string, validator string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		content := append([]byte{3, 2, 0, 0, 0}, []byte(validator)...)
+		content = append(content, 0)
+		content = append(content, []byte(`{"name":"serde","vers":"1.0.0"}`)...)
+		Expect(os.WriteFile(path, content, 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+		cargoHome = t.TempDir()
+		layers = libcnb.Layers{Path: t.TempDir()}
+		cache = filepath.Join(cargoHome, "registry", "index", "index.crates.io-6f17d22bba15001f", ".cache")
+
+		writeCacheFile(filepath.Join(cache, "se", "rd", "serde"), `etag: "5f3c"`)
+		writeCacheFile(filepath.Join(cache, "to", "ki", "tokio"), "")
+	})
+
+	it("reads the validator of a cache file", func() {
+		Expect(cargo.IndexCacheValidator(filepath.Join(cache, "se", "rd", "serde"))).To(Equal(`etag: "5f3c"`))
+		Expect(cargo.IndexCacheValidator(filepath.Join(cache, "to", "ki", "tokio"))).To(BeEmpty())
+	})
+
+	it("snapshots and restores the index caches", func() {
+		layer, err := layers.Layer("index-snapshot")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.IndexSnapshot{CargoHome: cargoHome, Logger: bard.NewLogger(buf)}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.LayerTypes.Cache).To(BeTrue())
+		Expect(layer.Metadata).To(HaveKeyWithValue("index-snapshot", map[string]interface{}{
+			"index.crates.io-6f17d22bba15001f": map[string]interface{}{"files": int64(2), "validators": int64(1)},
+		}))
+		Expect(buf.String()).To(ContainSubstring("index.crates.io-6f17d22bba15001f: 2 cached files, 1 with an etag or last modified time"))
+
+		// a fresh CARGO_HOME that only has a newer version of one file
+		restored := t.TempDir()
+		newer := filepath.Join(restored, "registry", "index", "index.crates.io-6f17d22bba15001f", ".cache", "se", "rd", "serde")
+		writeCacheFile(newer, `etag: "7a1b"`)
+
+		Expect(cargo.RestoreIndexSnapshot(restored, layer.Path)).To(Equal(1))
+		Expect(cargo.IndexCacheValidator(newer)).To(Equal(`etag: "7a1b"`))
+		Expect(filepath.Join(restored, "registry", "index", "index.crates.io-6f17d22bba15001f", ".cache", "to", "ki", "tokio")).To(BeARegularFile())
+	})
+
+	it("does nothing without a snapshot", func() {
+		Expect(cargo.RestoreIndexSnapshot(cargoHome, filepath.Join(layers.Path, "missing"))).To(Equal(0))
+	})
+}
@@ -25,9 +25,38 @@ import (
 
 func TestUnitRustCargo(t *testing.T) {
 	suite := spec.New("Rust Cargo", spec.Report(report.Terminal{}))
+	suite("Allocator", testAllocator)
+	suite("AppVersion", testAppVersion)
+	suite("Auditable", testAuditable)
 	suite("Build", testBuild)
+	suite("BuildManifest", testBuildManifest)
 	suite("Detect", testDetect)
+	suite("Diagnostics", testDiagnostics)
+	suite("DiskUsage", testDiskUsage)
+	suite("Downloads", testDownloads)
 	suite("Cargo", testCargo)
 	suite("Cache", testCache)
+	suite("CacheStatistics", testCacheStatistics)
+	suite("Config", testConfig)
+	suite("Conflicts", testConflicts)
+	suite("Environment", testEnvironment)
+	suite("IndexSnapshot", testIndexSnapshot)
+	suite("Nightly", testNightly)
+	suite("Offline", testOffline)
+	suite("Portability", testPortability)
+	suite("Projects", testProjects)
+	suite("ProjectSettings", testProjectSettings)
+	suite("Registry", testRegistry)
+	suite("ReleaseCalendar", testReleaseCalendar)
+	suite("Provenance", testProvenance)
+	suite("RunImage", testRunImage)
+	suite("RuntimeEnvironment", testRuntimeEnvironment)
+	suite("Sccache", testSccache)
+	suite("SecurityAudit", testSecurityAudit)
+	suite("Statistics", testStatistics)
+	suite("Timings", testTimings)
+	suite("Toolchain", testToolchain)
+	suite("Tools", testTools)
+	suite("Verify", testVerify)
 	suite.Run(t)
 }
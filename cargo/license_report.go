@@ -0,0 +1,45 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+// LicenseReportFile is the file of the application layer that lists the licenses of the dependencies
+const LicenseReportFile = "license-report.json"
+
+// WriteLicenseReport writes the license report to LicenseReportFile in the layer, so that it ships with the binaries
+// that it describes
+func WriteLicenseReport(layerPath string, report runner.LicenseReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode license report\n%w", err)
+	}
+
+	file := filepath.Join(layerPath, LicenseReportFile)
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-community/cargo/manifest"
+	"github.com/paketo-community/cargo/runner"
+)
+
+var (
+	featureGatePattern    = regexp.MustCompile(`(?m)^\s*#!\[feature\(`)
+	unstableTablePattern  = regexp.MustCompile(`(?m)^\s*\[unstable\]`)
+	unstableFlagPattern   = regexp.MustCompile(`["'\s]-Z\s*[a-z]`)
+	nightlyChannelPattern = regexp.MustCompile(`\bnightly\b`)
+)
+
+// NightlyRequirement returns a description of the first nightly-only feature used by the project in appDir, like
+// `cargo-features` in a manifest, `#![feature(...)]` gates in the crate roots of its packages or `-Z` flags in the
+// Cargo configuration. Vendored dependencies are not scanned, as their build scripts probe for nightly features.
+// Returns an empty string if the project builds with a stable toolchain.
+func NightlyRequirement(appDir string) (string, error) {
+	skipped := map[string]bool{filepath.Join(appDir, "vendor"): true}
+
+	for _, name := range []string{".cargo/config.toml", ".cargo/config"} {
+		b, err := os.ReadFile(filepath.Join(appDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("unable to read %s\n%w", name, err)
+		}
+
+		if unstableTablePattern.Match(b) {
+			return fmt.Sprintf("an [unstable] table in %s", name), nil
+		}
+		if unstableFlagPattern.Match(b) {
+			return fmt.Sprintf("-Z flags in %s", name), nil
+		}
+
+		// configurations that cannot be decoded are reported by cargo during the build
+		var config struct {
+			Source map[string]struct {
+				Directory string `toml:"directory"`
+			} `toml:"source"`
+		}
+		if _, err := toml.Decode(string(b), &config); err == nil {
+			for _, source := range config.Source {
+				if source.Directory == "" {
+					continue
+				}
+
+				// relative directories are resolved against the parent of the .cargo directory
+				dir := source.Directory
+				if !filepath.IsAbs(dir) {
+					dir = filepath.Join(appDir, dir)
+				}
+				skipped[filepath.Clean(dir)] = true
+			}
+		}
+	}
+
+	var requirement string
+	err := filepath.WalkDir(appDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != appDir && (d.Name() == "target" || strings.HasPrefix(d.Name(), ".") || skipped[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var found string
+		switch {
+		case d.Name() == "Cargo.toml":
+			// manifests that cannot be parsed are reported by cargo during the build
+			if m, err := manifest.Load(path); err == nil && len(m.CargoFeatures) > 0 {
+				found = "cargo-features"
+			}
+		case filepath.Ext(path) == ".rs" && crateRoot(path):
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("unable to read %s\n%w", path, err)
+			}
+			if featureGatePattern.Match(b) {
+				found = "#![feature(...)]"
+			}
+		}
+
+		if found == "" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(appDir, path)
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s relative to %s\n%w", path, appDir, err)
+		}
+
+		requirement = fmt.Sprintf("%s in %s", found, rel)
+		return filepath.SkipAll
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to scan %s for nightly features\n%w", appDir, err)
+	}
+
+	return requirement, nil
+}
+
+// crateRoot returns true if path is the root module of a library or binary of a package, like `src/lib.rs`,
+// `src/main.rs` or a file in `src/bin`, the only modules that may enable features with `#![feature(...)]`
+func crateRoot(path string) bool {
+	dir, name := filepath.Split(path)
+	dir = filepath.Clean(dir)
+
+	var pkg string
+	switch {
+	case filepath.Base(dir) == "src" && (name == "lib.rs" || name == "main.rs"):
+		pkg = filepath.Dir(dir)
+	default:
+		for d := dir; d != filepath.Dir(d); d = filepath.Dir(d) {
+			if filepath.Base(d) == "bin" && filepath.Base(filepath.Dir(d)) == "src" {
+				pkg = filepath.Dir(filepath.Dir(d))
+				break
+			}
+		}
+	}
+
+	if pkg == "" {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(pkg, "Cargo.toml"))
+	return err == nil
+}
+
+// NightlyConfigured returns true if a nightly toolchain is selected, either with BP_RUST_VERSION or with a
+// rust-toolchain file in appDir
+func NightlyConfigured(appDir string, rustVersion string) (bool, error) {
+	if nightlyChannelPattern.MatchString(rustVersion) {
+		return true, nil
+	}
+
+	file, err := runner.ToolchainRequirements(appDir)
+	if err != nil {
+		return false, err
+	}
+
+	return file != nil && file.Nightly(), nil
+}
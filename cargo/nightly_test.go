@@ -0,0 +1,118 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testNightly(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.toml"), []byte("[package]\nname = \"app\"\n"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(appDir, "src"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "src", "main.rs"), []byte("fn main() {}\n"), 0644)).To(Succeed())
+	})
+
+	it("does not require nightly for stable projects", func() {
+		Expect(cargo.NightlyRequirement(appDir)).To(BeEmpty())
+	})
+
+	it("detects cargo-features in a member manifest", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, "member"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "member", "Cargo.toml"), []byte("cargo-features = [\"codegen-backend\"]\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(Equal("cargo-features in member/Cargo.toml"))
+	})
+
+	it("detects -Z flags in the cargo configuration", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, ".cargo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, ".cargo", "config.toml"), []byte("[build]\nrustflags = [\"-Zshare-generics\"]\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(Equal("-Z flags in .cargo/config.toml"))
+	})
+
+	it("detects an unstable table in the cargo configuration", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, ".cargo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, ".cargo", "config.toml"), []byte("[unstable]\nbuild-std = [\"std\"]\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(Equal("an [unstable] table in .cargo/config.toml"))
+	})
+
+	it("ignores build output", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, "target", "debug"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "target", "debug", "gen.rs"), []byte("#![feature(test)]\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(BeEmpty())
+	})
+
+	it("detects feature gates in the crate roots", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, "src", "bin", "tool"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "src", "bin", "tool", "main.rs"), []byte("#![feature(test)]\nfn main() {}\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(Equal(fmt.Sprintf("#![feature(...)] in %s", filepath.Join("src", "bin", "tool", "main.rs"))))
+	})
+
+	it("ignores files that are not crate roots", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, "build"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "build", "probe.rs"), []byte("#![feature(error_generic_member_access)]\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(BeEmpty())
+	})
+
+	it("ignores vendored dependencies", func() {
+		for _, dir := range []string{"vendor", "third-party"} {
+			crate := filepath.Join(appDir, dir, "anyhow")
+			Expect(os.MkdirAll(filepath.Join(crate, "build"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(crate, "src"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(crate, "Cargo.toml"), []byte("[package]\nname = \"anyhow\"\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(crate, "build", "probe.rs"), []byte("#![feature(error_generic_member_access)]\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(crate, "src", "lib.rs"), []byte("#![cfg_attr(backtrace, feature(error_generic_member_access))]\n#![feature(test)]\n"), 0644)).To(Succeed())
+		}
+
+		Expect(os.MkdirAll(filepath.Join(appDir, ".cargo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, ".cargo", "config.toml"), []byte(`[source.crates-io]
+replace-with = "vendored-sources"
+
+[source.vendored-sources]
+directory = "third-party"
+`), 0644)).To(Succeed())
+
+		Expect(cargo.NightlyRequirement(appDir)).To(BeEmpty())
+	})
+
+	it("detects nightly channels", func() {
+		Expect(cargo.NightlyConfigured(appDir, "nightly-2024-05-01")).To(BeTrue())
+		Expect(cargo.NightlyConfigured(appDir, "1.78.*")).To(BeFalse())
+
+		Expect(os.WriteFile(filepath.Join(appDir, "rust-toolchain"), []byte("nightly\n"), 0644)).To(Succeed())
+		Expect(cargo.NightlyConfigured(appDir, "")).To(BeTrue())
+	})
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	offlineConfigPattern     = regexp.MustCompile(`(?m)^\s*(net\.)?offline\s*=\s*true\b`)
+	sourceReplacementPattern = regexp.MustCompile(`(?m)^\s*replace-with\s*=`)
+)
+
+// MissingPackage is a package of Cargo.lock that an offline build cannot resolve from CARGO_HOME
+type MissingPackage struct {
+	Name    string
+	Version string
+	Source  string
+
+	// Missing describes what is missing, like `crate` or `index entry`
+	Missing []string
+}
+
+func (m MissingPackage) String() string {
+	return fmt.Sprintf("%s %s (%s)", m.Name, m.Version, strings.Join(m.Missing, ", "))
+}
+
+// OfflineConfigured returns a description of where offline mode is enabled, with `--offline` or `--frozen` in the
+// install arguments, with $CARGO_NET_OFFLINE or in the Cargo configuration of the project in appDir. Returns an empty
+// string if the build is allowed to access the network.
+func OfflineConfigured(appDir string, installArgs string) (string, error) {
+	for _, arg := range strings.Fields(installArgs) {
+		if arg == "--offline" || arg == "--frozen" {
+			return fmt.Sprintf("%s in $BP_CARGO_INSTALL_ARGS", arg), nil
+		}
+	}
+
+	if os.Getenv("CARGO_NET_OFFLINE") == "true" {
+		return "$CARGO_NET_OFFLINE", nil
+	}
+
+	for _, name := range []string{".cargo/config.toml", ".cargo/config"} {
+		b, err := os.ReadFile(filepath.Join(appDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("unable to read %s\n%w", name, err)
+		}
+
+		if offlineConfigPattern.Match(b) {
+			return fmt.Sprintf("net.offline in %s", name), nil
+		}
+	}
+
+	return "", nil
+}
+
+// MissingPackages returns the packages of the Cargo.lock file in appDir whose crate, registry index entry or git
+// checkout is not in CARGO_HOME. Returns nothing if there is no Cargo.lock file or if the sources of the project are
+// replaced in its Cargo configuration, like when they are vendored with `cargo vendor`.
+func MissingPackages(appDir string, cargoHome string) ([]MissingPackage, error) {
+	for _, name := range []string{".cargo/config.toml", ".cargo/config"} {
+		b, err := os.ReadFile(filepath.Join(appDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read %s\n%w", name, err)
+		}
+
+		if sourceReplacementPattern.Match(b) {
+			return nil, nil
+		}
+	}
+
+	var lock struct {
+		Packages []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  string `toml:"source"`
+		} `toml:"package"`
+	}
+
+	lockFile := filepath.Join(appDir, "Cargo.lock")
+	if _, err := toml.DecodeFile(lockFile, &lock); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", lockFile, err)
+	}
+
+	var missing []MissingPackage
+	for _, p := range lock.Packages {
+		m := MissingPackage{Name: p.Name, Version: p.Version, Source: p.Source}
+
+		switch {
+		case strings.HasPrefix(p.Source, "registry+"), strings.HasPrefix(p.Source, "sparse+"):
+			crate := fmt.Sprintf("%s-%s", p.Name, p.Version)
+			if found, err := anyExists(filepath.Join(cargoHome, "registry", "cache", "*", crate+".crate"),
+				filepath.Join(cargoHome, "registry", "src", "*", crate)); err != nil {
+				return nil, err
+			} else if !found {
+				m.Missing = append(m.Missing, "crate")
+			}
+
+			if found, err := anyExists(filepath.Join(cargoHome, "registry", "index", "*", ".cache", IndexPath(p.Name))); err != nil {
+				return nil, err
+			} else if !found {
+				m.Missing = append(m.Missing, "index entry")
+			}
+		case strings.HasPrefix(p.Source, "git+"):
+			_, rev, _ := strings.Cut(p.Source, "#")
+			if len(rev) > 7 {
+				rev = rev[:7]
+			}
+
+			// the git database is enough to check out any revision that was fetched
+			if found, err := anyExists(filepath.Join(cargoHome, "git", "checkouts", "*", rev),
+				filepath.Join(cargoHome, "git", "db", gitRepositoryName(p.Source)+"-*")); err != nil {
+				return nil, err
+			} else if !found {
+				m.Missing = append(m.Missing, "git checkout")
+			}
+		}
+
+		if len(m.Missing) > 0 {
+			missing = append(missing, m)
+		}
+	}
+
+	return missing, nil
+}
+
+// IndexPath returns the path of the entry of a crate in a registry index, like `se/rd/serde`
+func IndexPath(name string) string {
+	name = strings.ToLower(name)
+
+	switch len(name) {
+	case 1:
+		return filepath.Join("1", name)
+	case 2:
+		return filepath.Join("2", name)
+	case 3:
+		return filepath.Join("3", name[:1], name)
+	default:
+		return filepath.Join(name[:2], name[2:4], name)
+	}
+}
+
+// CheckOffline fails an offline build early if packages of Cargo.lock are missing from CARGO_HOME, naming them and
+// how to pre-populate CARGO_HOME, instead of cargo failing with a generic error after building everything else
+func CheckOffline(appDir string, installArgs string, cargoHome string) error {
+	offline, err := OfflineConfigured(appDir, installArgs)
+	if err != nil {
+		return fmt.Errorf("unable to determine if offline mode is enabled\n%w", err)
+	} else if offline == "" {
+		return nil
+	}
+
+	missing, err := MissingPackages(appDir, cargoHome)
+	if err != nil {
+		return fmt.Errorf("unable to find missing packages\n%w", err)
+	} else if len(missing) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, m := range missing {
+		names = append(names, m.String())
+	}
+
+	return fmt.Errorf("offline mode is enabled with %s but %d packages are missing from CARGO_HOME: %s, "+
+		"pre-populate CARGO_HOME by running `cargo fetch` into a directory provided with BP_CARGO_HOME_SEED or a "+
+		"cargo-home binding, vendor the dependencies with `cargo vendor` or disable offline mode",
+		offline, len(missing), strings.Join(names, ", "))
+}
+
+// anyExists returns whether any file matches one of the patterns
+func anyExists(patterns ...string) (bool, error) {
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return false, fmt.Errorf("unable to match %s\n%w", pattern, err)
+		}
+
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// gitRepositoryName returns the name cargo uses for the directories of a git source, the last segment of its URL
+func gitRepositoryName(source string) string {
+	u, err := url.Parse(strings.TrimPrefix(source, "git+"))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(path.Base(u.Path), ".git")
+}
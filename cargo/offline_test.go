@@ -0,0 +1,157 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testOffline(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		cargoHome string
+	)
+
+	write := func(path string, content string) {
+		t.Helper()
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		cargoHome = t.TempDir()
+
+		write(filepath.Join(appDir, "Cargo.lock"), `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+
+[[package]]
+name = "serde"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "log"
+version = "0.4.0"
+source = "sparse+https://mirror.example.com/index/"
+
+[[package]]
+name = "private"
+version = "0.2.0"
+source = "git+https://github.com/example/private.git?branch=main#0123456789abcdef0123456789abcdef01234567"
+`)
+	})
+
+	context("OfflineConfigured", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("CARGO_NET_OFFLINE")).To(Succeed())
+		})
+
+		it("is not configured by default", func() {
+			Expect(cargo.OfflineConfigured(appDir, "--locked")).To(BeEmpty())
+		})
+
+		it("is configured by install arguments", func() {
+			Expect(cargo.OfflineConfigured(appDir, "--locked --offline")).To(Equal("--offline in $BP_CARGO_INSTALL_ARGS"))
+			Expect(cargo.OfflineConfigured(appDir, "--frozen")).To(Equal("--frozen in $BP_CARGO_INSTALL_ARGS"))
+		})
+
+		it("is configured by the environment", func() {
+			Expect(os.Setenv("CARGO_NET_OFFLINE", "true")).To(Succeed())
+			Expect(cargo.OfflineConfigured(appDir, "")).To(Equal("$CARGO_NET_OFFLINE"))
+		})
+
+		it("is configured by the Cargo configuration", func() {
+			write(filepath.Join(appDir, ".cargo", "config.toml"), "[net]\noffline = true\n")
+			Expect(cargo.OfflineConfigured(appDir, "")).To(Equal("net.offline in .cargo/config.toml"))
+		})
+	})
+
+	context("MissingPackages", func() {
+		it("finds packages missing from CARGO_HOME", func() {
+			missing, err := cargo.MissingPackages(appDir, cargoHome)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, m := range missing {
+				names = append(names, m.String())
+			}
+			Expect(names).To(Equal([]string{
+				"serde 1.0.0 (crate, index entry)",
+				"log 0.4.0 (crate, index entry)",
+				"private 0.2.0 (git checkout)",
+			}))
+		})
+
+		it("finds nothing when CARGO_HOME is complete", func() {
+			write(filepath.Join(cargoHome, "registry", "cache", "index.crates.io-6f17d22bba15001f", "serde-1.0.0.crate"), "")
+			write(filepath.Join(cargoHome, "registry", "index", "index.crates.io-6f17d22bba15001f", ".cache", "se", "rd", "serde"), "")
+			write(filepath.Join(cargoHome, "registry", "src", "mirror.example.com-0123456789abcdef", "log-0.4.0", "Cargo.toml"), "")
+			write(filepath.Join(cargoHome, "registry", "index", "mirror.example.com-0123456789abcdef", ".cache", "3", "l", "log"), "")
+			write(filepath.Join(cargoHome, "git", "checkouts", "private-fedcba9876543210", "0123456", ".ok"), "")
+
+			Expect(cargo.MissingPackages(appDir, cargoHome)).To(BeEmpty())
+		})
+
+		it("accepts the git database instead of a checkout", func() {
+			write(filepath.Join(cargoHome, "git", "db", "private-fedcba9876543210", "HEAD"), "")
+
+			missing, err := cargo.MissingPackages(appDir, cargoHome)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(HaveLen(2))
+		})
+
+		it("ignores vendored sources", func() {
+			write(filepath.Join(appDir, ".cargo", "config.toml"), "[source.crates-io]\nreplace-with = \"vendored-sources\"\n")
+			Expect(cargo.MissingPackages(appDir, cargoHome)).To(BeEmpty())
+		})
+
+		it("tolerates a missing Cargo.lock", func() {
+			Expect(cargo.MissingPackages(t.TempDir(), cargoHome)).To(BeEmpty())
+		})
+	})
+
+	it("returns index paths", func() {
+		Expect(cargo.IndexPath("a")).To(Equal(filepath.Join("1", "a")))
+		Expect(cargo.IndexPath("ab")).To(Equal(filepath.Join("2", "ab")))
+		Expect(cargo.IndexPath("abc")).To(Equal(filepath.Join("3", "a", "abc")))
+		Expect(cargo.IndexPath("Serde")).To(Equal(filepath.Join("se", "rd", "serde")))
+	})
+
+	context("CheckOffline", func() {
+		it("passes when offline mode is not enabled", func() {
+			Expect(cargo.CheckOffline(appDir, "", cargoHome)).To(Succeed())
+		})
+
+		it("names the missing packages", func() {
+			err := cargo.CheckOffline(appDir, "--offline", cargoHome)
+			Expect(err).To(MatchError(ContainSubstring("3 packages are missing from CARGO_HOME: serde 1.0.0 (crate, index entry)")))
+			Expect(err).To(MatchError(ContainSubstring("`cargo fetch`")))
+		})
+	})
+}
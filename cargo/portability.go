@@ -0,0 +1,92 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	PortabilityCheckFail = "fail"
+	PortabilityCheckOff  = "off"
+	PortabilityCheckWarn = "warn"
+)
+
+// nonPortableFlagPattern matches codegen options that tune the binaries to the CPU of the builder, like
+// `-C target-cpu=native`
+var nonPortableFlagPattern = regexp.MustCompile(`target-cpu\s*=\s*["']?native\b`)
+
+// NonPortableFlags returns a description of each place where non-portable codegen options are set, in the rustflags
+// environment variables, the install arguments or the Cargo configuration of the project in appDir. Binaries built
+// with these options may crash with SIGILL on nodes with a different CPU than the builder.
+func NonPortableFlags(appDir string, installArgs string) ([]string, error) {
+	var found []string
+
+	for _, name := range []string{"RUSTFLAGS", "CARGO_ENCODED_RUSTFLAGS", "CARGO_BUILD_RUSTFLAGS"} {
+		if nonPortableFlagPattern.MatchString(os.Getenv(name)) {
+			found = append(found, fmt.Sprintf("$%s sets target-cpu=native", name))
+		}
+	}
+
+	if nonPortableFlagPattern.MatchString(installArgs) {
+		found = append(found, "$BP_CARGO_INSTALL_ARGS sets target-cpu=native")
+	}
+
+	for _, name := range []string{".cargo/config.toml", ".cargo/config"} {
+		b, err := os.ReadFile(filepath.Join(appDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read %s\n%w", name, err)
+		}
+
+		if nonPortableFlagPattern.Match(b) {
+			found = append(found, fmt.Sprintf("%s sets target-cpu=native", name))
+		}
+	}
+
+	return found, nil
+}
+
+// CheckPortability reports non-portable codegen options according to mode, which is one of `warn`, `fail` or `off`.
+// Returns the warnings to log, or an error if the build should fail.
+func CheckPortability(mode string, appDir string, installArgs string) ([]string, error) {
+	switch mode {
+	case PortabilityCheckOff:
+		return nil, nil
+	case "", PortabilityCheckWarn, PortabilityCheckFail:
+	default:
+		return nil, fmt.Errorf("BP_CARGO_PORTABILITY_CHECK must be %q, %q or %q, found %q",
+			PortabilityCheckWarn, PortabilityCheckFail, PortabilityCheckOff, mode)
+	}
+
+	found, err := NonPortableFlags(appDir, installArgs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check for non-portable flags\n%w", err)
+	}
+
+	if len(found) > 0 && mode == PortabilityCheckFail {
+		return nil, fmt.Errorf("non-portable codegen options are set, binaries may crash with SIGILL on nodes with a "+
+			"different CPU than the builder: %s, remove them or set BP_CARGO_PORTABILITY_CHECK=warn", strings.Join(found, ", "))
+	}
+
+	return found, nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testPortability(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		t.Setenv("RUSTFLAGS", "")
+	})
+
+	it("finds no non-portable flags", func() {
+		t.Setenv("RUSTFLAGS", "-C target-cpu=x86-64-v2")
+
+		Expect(cargo.NonPortableFlags(appDir, "--locked")).To(BeEmpty())
+	})
+
+	it("finds non-portable flags", func() {
+		t.Setenv("RUSTFLAGS", "-C target-cpu=native")
+		Expect(os.MkdirAll(filepath.Join(appDir, ".cargo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, ".cargo", "config.toml"), []byte("[build]\nrustflags = [\"-C\", \"target-cpu=native\"]\n"), 0644)).To(Succeed())
+
+		Expect(cargo.NonPortableFlags(appDir, `--config 'build.rustflags=["-Ctarget-cpu=native"]'`)).To(Equal([]string{
+			"$RUSTFLAGS sets target-cpu=native",
+			"$BP_CARGO_INSTALL_ARGS sets target-cpu=native",
+			".cargo/config.toml sets target-cpu=native",
+		}))
+	})
+
+	context("checks portability", func() {
+		it.Before(func() {
+			t.Setenv("RUSTFLAGS", "-C target-cpu=native")
+		})
+
+		it("warns by default", func() {
+			Expect(cargo.CheckPortability("", appDir, "")).To(Equal([]string{"$RUSTFLAGS sets target-cpu=native"}))
+		})
+
+		it("fails", func() {
+			_, err := cargo.CheckPortability(cargo.PortabilityCheckFail, appDir, "")
+			Expect(err).To(MatchError(ContainSubstring("non-portable codegen options are set")))
+		})
+
+		it("can be turned off", func() {
+			Expect(cargo.CheckPortability(cargo.PortabilityCheckOff, appDir, "")).To(BeEmpty())
+		})
+
+		it("rejects unknown modes", func() {
+			_, err := cargo.CheckPortability("maybe", appDir, "")
+			Expect(err).To(MatchError(ContainSubstring(`BP_CARGO_PORTABILITY_CHECK must be "warn", "fail" or "off", found "maybe"`)))
+		})
+	})
+}
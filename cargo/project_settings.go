@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/manifest"
+)
+
+// ProjectSettings are the settings of the buildpack that an application can also configure in the
+// [package.metadata.cargo-buildpack] table of its Cargo.toml
+type ProjectSettings struct {
+	Features          string
+	InstallArgs       string
+	NoDefaultFeatures bool
+	StaticType        string
+	WorkspaceMembers  string
+}
+
+// ResolveProjectSettings resolves the settings that an application can configure in Cargo.toml. An environment
+// variable takes precedence over Cargo.toml, which takes precedence over the default of the buildpack.
+func ResolveProjectSettings(cr libpak.ConfigurationResolver, manifestDir string, logger bard.Logger) (ProjectSettings, error) {
+	path := filepath.Join(manifestDir, "Cargo.toml")
+	config, err := manifest.LoadBuildpackConfiguration(path)
+	if err != nil {
+		return ProjectSettings{}, fmt.Errorf("unable to read buildpack configuration\n%w", err)
+	}
+
+	resolve := func(name string, value string) string {
+		v, set := cr.Resolve(name)
+		if set || value == "" {
+			return v
+		}
+
+		logger.Bodyf("Using %s=%s from [package.metadata.%s] of Cargo.toml", name, value, manifest.BuildpackKey)
+		return value
+	}
+
+	settings := ProjectSettings{
+		Features:         resolve("BP_CARGO_FEATURES", strings.Join(config.Features, ",")),
+		InstallArgs:      resolve("BP_CARGO_INSTALL_ARGS", config.InstallArgs),
+		StaticType:       resolve("BP_STATIC_BINARY_TYPE", config.StaticType),
+		WorkspaceMembers: resolve("BP_CARGO_WORKSPACE_MEMBERS", strings.Join(config.WorkspaceMembers, ",")),
+	}
+
+	noDefaultFeatures := ""
+	if config.NoDefaultFeatures != nil {
+		noDefaultFeatures = strconv.FormatBool(*config.NoDefaultFeatures)
+	}
+	if raw := resolve("BP_CARGO_NO_DEFAULT_FEATURES", noDefaultFeatures); raw != "" {
+		settings.NoDefaultFeatures, err = strconv.ParseBool(raw)
+		if err != nil {
+			return ProjectSettings{}, fmt.Errorf("BP_CARGO_NO_DEFAULT_FEATURES must be true or false, found %q", raw)
+		}
+	}
+
+	return settings, nil
+}
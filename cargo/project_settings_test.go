@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testProjectSettings(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+		buf    *bytes.Buffer
+		cr     libpak.ConfigurationResolver
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		buf = &bytes.Buffer{}
+		cr = libpak.ConfigurationResolver{Configurations: []libpak.BuildpackConfiguration{
+			{Name: "BP_CARGO_FEATURES"},
+			{Name: "BP_CARGO_INSTALL_ARGS", Default: "--locked"},
+			{Name: "BP_CARGO_NO_DEFAULT_FEATURES", Default: "false"},
+			{Name: "BP_CARGO_WORKSPACE_MEMBERS"},
+			{Name: "BP_STATIC_BINARY_TYPE"},
+		}}
+
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.toml"), []byte(`
+[package]
+name = "app"
+
+[package.metadata.cargo-buildpack]
+features = ["postgres"]
+install-args = "--locked --offline"
+no-default-features = true
+static-type = "gnulibc"
+workspace-members = ["api", "worker"]
+`), 0644)).To(Succeed())
+	})
+
+	it("uses the settings of Cargo.toml over the defaults", func() {
+		settings, err := cargo.ResolveProjectSettings(cr, appDir, bard.NewLogger(buf))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(settings).To(Equal(cargo.ProjectSettings{
+			Features:          "postgres",
+			InstallArgs:       "--locked --offline",
+			NoDefaultFeatures: true,
+			StaticType:        "gnulibc",
+			WorkspaceMembers:  "api,worker",
+		}))
+		Expect(buf.String()).To(ContainSubstring("Using BP_CARGO_INSTALL_ARGS=--locked --offline from [package.metadata.cargo-buildpack] of Cargo.toml"))
+		Expect(buf.String()).To(ContainSubstring("Using BP_CARGO_FEATURES=postgres"))
+	})
+
+	it("uses environment variables over Cargo.toml", func() {
+		t.Setenv("BP_CARGO_FEATURES", "tls")
+		t.Setenv("BP_CARGO_INSTALL_ARGS", "--frozen")
+		t.Setenv("BP_CARGO_NO_DEFAULT_FEATURES", "false")
+		t.Setenv("BP_CARGO_WORKSPACE_MEMBERS", "api")
+
+		settings, err := cargo.ResolveProjectSettings(cr, appDir, bard.NewLogger(buf))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(settings).To(Equal(cargo.ProjectSettings{
+			Features:         "tls",
+			InstallArgs:      "--frozen",
+			StaticType:       "gnulibc",
+			WorkspaceMembers: "api",
+		}))
+	})
+
+	it("uses the defaults without Cargo.toml configuration", func() {
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.toml"), []byte("[package]\nname = \"app\"\n"), 0644)).To(Succeed())
+
+		Expect(cargo.ResolveProjectSettings(cr, appDir, bard.NewLogger(buf))).To(Equal(cargo.ProjectSettings{InstallArgs: "--locked"}))
+		Expect(buf.String()).To(BeEmpty())
+	})
+
+	it("fails for invalid Cargo.toml configuration", func() {
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.toml"), []byte("[package.metadata.cargo-buildpack]\nstatic = true\n"), 0644)).To(Succeed())
+
+		_, err := cargo.ResolveProjectSettings(cr, appDir, bard.NewLogger(buf))
+		Expect(err).To(MatchError(ContainSubstring("unable to read buildpack configuration")))
+	})
+}
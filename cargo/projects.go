@@ -0,0 +1,150 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// ProjectsAuto discovers all Cargo projects in the application
+const ProjectsAuto = "auto"
+
+var projectNamePattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Project is an independent Cargo project, with its own Cargo.toml and Cargo.lock, in the application
+type Project struct {
+	// Name namespaces the layers and process types of the project, it is empty for a single project
+	Name string
+	Path string
+}
+
+// Projects returns the Cargo projects to build. Without configuration the application is a single project,
+// otherwise config is a comma separated list of project directories relative to the application or `auto` to
+// discover them.
+func Projects(appPath string, config string) ([]Project, error) {
+	config = strings.TrimSpace(config)
+	if config == "" {
+		return []Project{{Path: appPath}}, nil
+	}
+
+	var dirs []string
+	if config == ProjectsAuto {
+		var err error
+		if dirs, err = DiscoverProjects(appPath); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, d := range strings.Split(config, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				dirs = append(dirs, filepath.Clean(d))
+			}
+		}
+	}
+
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no Cargo projects found in %s", appPath)
+	}
+
+	var projects []Project
+	names := map[string]string{}
+	for _, d := range dirs {
+		if filepath.IsAbs(d) || strings.HasPrefix(d, "..") {
+			return nil, fmt.Errorf("project %s must be relative to the application", d)
+		}
+
+		found, err := isCargoProject(filepath.Join(appPath, d))
+		if err != nil {
+			return nil, err
+		} else if !found {
+			return nil, fmt.Errorf("project %s requires a Cargo.toml and a Cargo.lock", d)
+		}
+
+		name := strings.Trim(projectNamePattern.ReplaceAllString(filepath.ToSlash(d), "-"), "-")
+		if name == "" {
+			name = "root"
+		}
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("projects %s and %s have the same name %s", other, d, name)
+		}
+		names[name] = d
+
+		projects = append(projects, Project{Name: name, Path: filepath.Join(appPath, d)})
+	}
+
+	return projects, nil
+}
+
+// DiscoverProjects returns the directories, relative to appPath, that contain a Cargo.toml and a Cargo.lock. The
+// directories of a project are not searched for further projects, as they belong to its workspace.
+func DiscoverProjects(appPath string) ([]string, error) {
+	var dirs []string
+
+	err := filepath.WalkDir(appPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() || path == appPath {
+			return nil
+		}
+
+		if d.Name() == "target" || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		found, err := isCargoProject(path)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			rel, err := filepath.Rel(appPath, path)
+			if err != nil {
+				return fmt.Errorf("unable to resolve %s relative to %s\n%w", path, appPath, err)
+			}
+			dirs = append(dirs, rel)
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover projects in %s\n%w", appPath, err)
+	}
+
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func isCargoProject(dir string) (bool, error) {
+	for _, f := range []string{"Cargo.toml", "Cargo.lock"} {
+		if found, err := sherpa.FileExists(filepath.Join(dir, f)); err != nil {
+			return false, fmt.Errorf("unable to check for %s in %s\n%w", f, dir, err)
+		} else if !found {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testProjects(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath string
+	)
+
+	project := func(dir string) {
+		Expect(os.MkdirAll(filepath.Join(appPath, dir), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appPath, dir, "Cargo.toml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appPath, dir, "Cargo.lock"), []byte{}, 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		appPath = t.TempDir()
+	})
+
+	it("defaults to a single project", func() {
+		Expect(cargo.Projects(appPath, "")).To(Equal([]cargo.Project{{Path: appPath}}))
+	})
+
+	it("resolves configured projects", func() {
+		project("services/api")
+		project("worker")
+
+		Expect(cargo.Projects(appPath, "services/api, worker")).To(Equal([]cargo.Project{
+			{Name: "services-api", Path: filepath.Join(appPath, "services", "api")},
+			{Name: "worker", Path: filepath.Join(appPath, "worker")},
+		}))
+	})
+
+	it("discovers projects", func() {
+		project("services/api")
+		project("services/api/nested")
+		project("worker")
+		project("target/package")
+
+		Expect(cargo.DiscoverProjects(appPath)).To(Equal([]string{filepath.Join("services", "api"), "worker"}))
+		Expect(cargo.Projects(appPath, "auto")).To(HaveLen(2))
+	})
+
+	it("fails for invalid projects", func() {
+		project("worker")
+
+		_, err := cargo.Projects(appPath, "api")
+		Expect(err).To(MatchError("project api requires a Cargo.toml and a Cargo.lock"))
+
+		_, err = cargo.Projects(appPath, "../worker")
+		Expect(err).To(MatchError("project ../worker must be relative to the application"))
+
+		_, err = cargo.Projects(t.TempDir(), "auto")
+		Expect(err).To(MatchError(ContainSubstring("no Cargo projects found")))
+	})
+}
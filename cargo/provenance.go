@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/helper"
+)
+
+// Provenance records how the application was built in a launch layer, where the `provenance` exec.d helper reads it
+// to log it when the container starts
+type Provenance struct {
+	Logger     bard.Logger
+	Provenance helper.BuildProvenance
+}
+
+func (p Provenance) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(p.Provenance); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to encode provenance\n%w", err)
+	}
+
+	path := filepath.Join(layer.Path, "provenance.toml")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to write %s\n%w", path, err)
+	}
+
+	layer.LaunchEnvironment.Default(helper.ProvenancePathEnv, path)
+	p.Logger.Bodyf("Recording build provenance of version %s, git %s", p.Provenance.AppVersion, p.Provenance.GitSHA)
+
+	layer.Launch = true
+	return layer, nil
+}
+
+func (Provenance) Name() string {
+	return "Cargo Provenance"
+}
+
+// BuildTime returns the time of the build, taken from $SOURCE_DATE_EPOCH for reproducible builds, in RFC 3339 format
+func BuildTime() (string, error) {
+	t := time.Now()
+
+	if epoch, ok := os.LookupEnv("SOURCE_DATE_EPOCH"); ok && epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse SOURCE_DATE_EPOCH %q\n%w", epoch, err)
+		}
+		t = time.Unix(seconds, 0)
+	}
+
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// GitCommit returns the commit checked out in the git repository of the application in appDir. Returns an empty
+// string if the application is not a git repository, as source directories often exclude `.git`.
+func GitCommit(appDir string) (string, error) {
+	gitDir := filepath.Join(appDir, ".git")
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("unable to read git HEAD\n%w", err)
+	}
+
+	ref, symbolic := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !symbolic {
+		return ref, nil
+	}
+
+	if b, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(b)), nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("unable to read git ref %s\n%w", ref, err)
+	}
+
+	// refs are packed by `git gc`, like `<sha> refs/heads/main`
+	f, err := os.Open(filepath.Join(gitDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("unable to open packed git refs\n%w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if sha, name, ok := strings.Cut(scanner.Text(), " "); ok && name == ref {
+			return sha, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("unable to read packed git refs\n%w", err)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/helper"
+	"github.com/sclevine/spec"
+)
+
+func testProvenance(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+	})
+
+	it("records the provenance in a launch layer", func() {
+		layers := libcnb.Layers{Path: t.TempDir()}
+		layer, err := layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		provenance := helper.BuildProvenance{AppVersion: "1.2.3", GitSHA: "0123456789abcdef", BuildTime: "2024-05-01T10:00:00Z"}
+		layer, err = cargo.Provenance{Provenance: provenance}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Launch).To(BeTrue())
+		Expect(layer.Cache).To(BeFalse())
+		Expect(layer.LaunchEnvironment).To(Equal(libcnb.Environment{
+			"BPI_CARGO_PROVENANCE.default": filepath.Join(layer.Path, "provenance.toml"),
+		}))
+
+		var recorded helper.BuildProvenance
+		_, err = toml.DecodeFile(filepath.Join(layer.Path, "provenance.toml"), &recorded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorded).To(Equal(provenance))
+	})
+
+	it("takes the build time from SOURCE_DATE_EPOCH", func() {
+		t.Setenv("SOURCE_DATE_EPOCH", "1714557600")
+		Expect(cargo.BuildTime()).To(Equal("2024-05-01T10:00:00Z"))
+
+		t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+		_, err := cargo.BuildTime()
+		Expect(err).To(MatchError(ContainSubstring(`unable to parse SOURCE_DATE_EPOCH "yesterday"`)))
+	})
+
+	context("GitCommit", func() {
+		write := func(path string, content string) {
+			t.Helper()
+			Expect(os.MkdirAll(filepath.Dir(filepath.Join(appDir, ".git", path)), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(appDir, ".git", path), []byte(content), 0644)).To(Succeed())
+		}
+
+		it("returns nothing outside of a git repository", func() {
+			Expect(cargo.GitCommit(appDir)).To(BeEmpty())
+		})
+
+		it("returns a detached HEAD", func() {
+			write("HEAD", "0123456789abcdef\n")
+			Expect(cargo.GitCommit(appDir)).To(Equal("0123456789abcdef"))
+		})
+
+		it("resolves a branch", func() {
+			write("HEAD", "ref: refs/heads/main\n")
+			write("refs/heads/main", "fedcba9876543210\n")
+			Expect(cargo.GitCommit(appDir)).To(Equal("fedcba9876543210"))
+		})
+
+		it("resolves a packed branch", func() {
+			write("HEAD", "ref: refs/heads/main\n")
+			write("packed-refs", "# pack-refs with: peeled fully-peeled sorted\n0123456789abcdef refs/heads/feature\nfedcba9876543210 refs/heads/main\n")
+			Expect(cargo.GitCommit(appDir)).To(Equal("fedcba9876543210"))
+		})
+	})
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+// BindingTypeCargoRegistry is the type of a binding that configures a registry
+const BindingTypeCargoRegistry = runner.BindingTypeCargoRegistry
+
+// ValidateInstallSource ensures that the registry or index that `cargo install` uses, set with
+// BP_CARGO_INSTALL_REGISTRY and BP_CARGO_INSTALL_INDEX or with `--registry` and `--index` in the install arguments, is
+// valid. A registry must be configured by one of the credentials of bindings, with CARGO_REGISTRIES_<NAME>_INDEX or in
+// the Cargo configuration of the project in appDir or of CARGO_HOME.
+func ValidateInstallSource(appDir string, cargoHome string, installArgs string, registry string, index string, credentials []runner.RegistryCredential) error {
+	argRegistry, argIndex, err := runner.InstallSource(installArgs)
+	if err != nil {
+		return fmt.Errorf("unable to parse BP_CARGO_INSTALL_ARGS\n%w", err)
+	}
+
+	if argRegistry != "" && registry != "" || argIndex != "" && index != "" {
+		return fmt.Errorf("BP_CARGO_INSTALL_REGISTRY and BP_CARGO_INSTALL_INDEX cannot be combined with --registry and --index in BP_CARGO_INSTALL_ARGS")
+	}
+
+	if registry == "" {
+		registry = argRegistry
+	}
+	if index == "" {
+		index = argIndex
+	}
+
+	if registry != "" && index != "" {
+		return fmt.Errorf("a registry and an index cannot both be set for cargo install, found registry %q and index %q", registry, runner.RedactURLs(index))
+	}
+
+	if index != "" {
+		return runner.ValidateIndex(index)
+	}
+
+	if registry == "" || registry == "crates-io" {
+		return nil
+	}
+
+	if err := runner.ValidateRegistryName(registry); err != nil {
+		return err
+	}
+
+	for _, r := range credentials {
+		if r.Name == registry && r.Index != "" {
+			return nil
+		}
+	}
+
+	name := runner.RegistryEnvironmentName(registry, "index")
+	if _, ok := os.LookupEnv(name); ok {
+		return nil
+	}
+
+	for _, path := range []string{
+		filepath.Join(appDir, ".cargo", "config.toml"),
+		filepath.Join(appDir, ".cargo", "config"),
+		filepath.Join(cargoHome, "config.toml"),
+		filepath.Join(cargoHome, "config"),
+	} {
+		var config struct {
+			Registries map[string]interface{} `toml:"registries"`
+		}
+		if _, err := toml.DecodeFile(path, &config); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("unable to decode %s\n%w", path, err)
+		}
+
+		if _, ok := config.Registries[registry]; ok {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("registry %q is not configured, add a binding of type %s, set %s or declare it in .cargo/config.toml", registry, BindingTypeCargoRegistry, name)
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testRegistry(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		cargoHome string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		cargoHome = t.TempDir()
+	})
+
+	context("install source", func() {
+		it("accepts crates.io and no registry", func() {
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "--locked", "", "", nil)).To(Succeed())
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "crates-io", "", nil)).To(Succeed())
+		})
+
+		it("accepts registries that are configured", func() {
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "internal", "", []runner.RegistryCredential{
+				{Binding: "internal", Index: "sparse+https://registry.example.com/index/", Name: "internal"},
+			})).To(Succeed())
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "internal", "", []runner.RegistryCredential{
+				{Binding: "internal", Name: "internal", Token: "s3cr3t"},
+			})).NotTo(Succeed())
+
+			t.Setenv("CARGO_REGISTRIES_FROM_ENV_INDEX", "sparse+https://registry.example.com/index/")
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "--registry from-env", "", "", nil)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(appDir, ".cargo"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(appDir, ".cargo", "config.toml"), []byte("[registries.project]\nindex = \"sparse+https://registry.example.com/index/\"\n"), 0644)).To(Succeed())
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "project", "", nil)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(cargoHome, "config.toml"), []byte("[registries]\nhome = { index = \"sparse+https://registry.example.com/index/\" }\n"), 0644)).To(Succeed())
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "home", "", nil)).To(Succeed())
+		})
+
+		it("rejects registries that are not configured", func() {
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "unknown", "", nil)).
+				To(MatchError(ContainSubstring(`registry "unknown" is not configured, add a binding of type cargo-registry, set CARGO_REGISTRIES_UNKNOWN_INDEX`)))
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "in valid", "", nil)).
+				To(MatchError(ContainSubstring("invalid registry name")))
+		})
+
+		it("validates the index", func() {
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "", "", "sparse+https://registry.example.com/index/", nil)).To(Succeed())
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "--index https://token@registry.example.com", "", "", nil)).
+				To(MatchError(ContainSubstring("must not contain credentials")))
+		})
+
+		it("rejects conflicting sources", func() {
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "--registry internal", "other", "", nil)).
+				To(MatchError(ContainSubstring("cannot be combined with --registry and --index")))
+			Expect(cargo.ValidateInstallSource(appDir, cargoHome, "--registry internal", "", "https://github.com/example/index", nil)).
+				To(MatchError(ContainSubstring("a registry and an index cannot both be set")))
+		})
+	})
+}
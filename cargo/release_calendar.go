@@ -0,0 +1,174 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver/v3"
+	"github.com/heroku/color"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+const (
+	// DefaultMaxReleasesBehind is the number of releases, about nine months, that a Rust toolchain may be behind the
+	// latest release before it is considered outdated
+	DefaultMaxReleasesBehind = 6
+
+	// ToolchainCheckFail fails the build when the Rust toolchain is outdated or end-of-life
+	ToolchainCheckFail = "fail"
+
+	// ToolchainCheckOff disables the check of the Rust toolchain
+	ToolchainCheckOff = "off"
+
+	// ToolchainCheckWarn logs a warning when the Rust toolchain is outdated or end-of-life
+	ToolchainCheckWarn = "warn"
+)
+
+// Advisory is a security advisory for the Rust toolchain, toolchains older than the fixed release are end-of-life
+type Advisory struct {
+	Fixed string `toml:"fixed"`
+	ID    string `toml:"id"`
+}
+
+// ReleaseCalendar describes the releases of Rust. Releases after the latest listed release are extrapolated from the
+// six week release cadence, so that the calendar bundled in buildpack.toml only needs to be refreshed for advisories.
+type ReleaseCalendar struct {
+	Advisories  []Advisory `toml:"advisories"`
+	CadenceDays int        `toml:"cadence-days"`
+	Latest      string     `toml:"latest"`
+	LatestDate  string     `toml:"latest-date"`
+}
+
+// NewReleaseCalendar reads the `rust-release-calendar` of the buildpack metadata, returning false if there is none
+func NewReleaseCalendar(metadata map[string]interface{}) (ReleaseCalendar, bool, error) {
+	raw, ok := metadata["rust-release-calendar"]
+	if !ok {
+		return ReleaseCalendar{}, false, nil
+	}
+
+	b, err := toml.Marshal(raw)
+	if err != nil {
+		return ReleaseCalendar{}, false, fmt.Errorf("unable to encode rust-release-calendar\n%w", err)
+	}
+
+	var c ReleaseCalendar
+	if err := toml.Unmarshal(b, &c); err != nil {
+		return ReleaseCalendar{}, false, fmt.Errorf("unable to decode rust-release-calendar\n%w", err)
+	}
+
+	return c, true, nil
+}
+
+// ReadReleaseCalendar reads a release calendar from a TOML file, to use a calendar more recent than the bundled one
+func ReadReleaseCalendar(path string) (ReleaseCalendar, error) {
+	var c ReleaseCalendar
+	if _, err := toml.DecodeFile(path, &c); err != nil {
+		return ReleaseCalendar{}, fmt.Errorf("unable to read release calendar %s\n%w", path, err)
+	}
+
+	return c, nil
+}
+
+// LatestRelease returns the latest release of Rust at a point in time and the date it was released
+func (c ReleaseCalendar) LatestRelease(now time.Time) (*semver.Version, time.Time, error) {
+	latest, err := semver.NewVersion(c.Latest)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to parse latest release %q\n%w", c.Latest, err)
+	}
+
+	date, err := time.Parse(time.DateOnly, c.LatestDate)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to parse date %q of the latest release\n%w", c.LatestDate, err)
+	}
+
+	if c.CadenceDays <= 0 {
+		return latest, date, nil
+	}
+
+	cadence := time.Duration(c.CadenceDays) * 24 * time.Hour
+	releases := 0
+	if now.After(date) {
+		releases = int(now.Sub(date) / cadence)
+	}
+
+	v := semver.New(latest.Major(), latest.Minor()+uint64(releases), 0, "", "")
+	return v, date.Add(time.Duration(releases) * cadence), nil
+}
+
+// Findings returns why a Rust version is outdated, being more than maxBehind releases behind the latest release, or
+// end-of-life, being affected by an advisory
+func (c ReleaseCalendar) Findings(version *semver.Version, maxBehind int, now time.Time) ([]string, error) {
+	var findings []string
+
+	latest, date, err := c.LatestRelease(now)
+	if err != nil {
+		return nil, err
+	}
+
+	if version.Major() == latest.Major() && version.Minor() < latest.Minor() {
+		if behind := int(latest.Minor() - version.Minor()); behind > maxBehind {
+			findings = append(findings, fmt.Sprintf("rustc %s is %d releases behind Rust %d.%d, released on %s",
+				version, behind, latest.Major(), latest.Minor(), date.Format(time.DateOnly)))
+		}
+	}
+
+	for _, a := range c.Advisories {
+		fixed, err := semver.NewVersion(a.Fixed)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse fixed release %q of %s\n%w", a.Fixed, a.ID, err)
+		}
+
+		// pre-releases, like nightly, of the fixed release are considered fixed
+		v := semver.New(version.Major(), version.Minor(), version.Patch(), "", "")
+		if v.LessThan(fixed) {
+			findings = append(findings, fmt.Sprintf("rustc %s is end-of-life, affected by %s fixed in Rust %s", version, a.ID, fixed))
+		}
+	}
+
+	return findings, nil
+}
+
+// CheckToolchainAge warns or, with ToolchainCheckFail, fails when a Rust version is outdated or end-of-life
+func CheckToolchainAge(calendar ReleaseCalendar, version *semver.Version, maxBehind int, mode string, now time.Time, logger bard.Logger) error {
+	if mode == ToolchainCheckOff {
+		return nil
+	}
+
+	findings, err := calendar.Findings(version, maxBehind, now)
+	if err != nil {
+		return fmt.Errorf("unable to check rustc %s against the release calendar\n%w", version, err)
+	}
+
+	if len(findings) == 0 {
+		return nil
+	}
+
+	if mode == ToolchainCheckFail {
+		return fmt.Errorf("outdated Rust toolchain, update it or set BP_CARGO_RUST_TOOLCHAIN_CHECK=%s:\n  %s",
+			ToolchainCheckWarn, strings.Join(findings, "\n  "))
+	}
+
+	for _, f := range findings {
+		logger.Infof("%s: %s", color.YellowString("Warning"), f)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/cargo"
+)
+
+func testReleaseCalendar(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		calendar cargo.ReleaseCalendar
+		now      = time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	)
+
+	it.Before(func() {
+		calendar = cargo.ReleaseCalendar{
+			Advisories:  []cargo.Advisory{{Fixed: "1.77.2", ID: "CVE-2024-24576"}},
+			CadenceDays: 42,
+			Latest:      "1.90.0",
+			LatestDate:  "2025-09-18",
+		}
+	})
+
+	context("NewReleaseCalendar", func() {
+		it("reads the calendar from the buildpack metadata", func() {
+			c, ok, err := cargo.NewReleaseCalendar(map[string]interface{}{
+				"rust-release-calendar": map[string]interface{}{
+					"cadence-days": int64(42),
+					"latest":       "1.90.0",
+					"latest-date":  "2025-09-18",
+					"advisories": []map[string]interface{}{
+						{"fixed": "1.77.2", "id": "CVE-2024-24576"},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(c).To(Equal(calendar))
+		})
+
+		it("returns false without a calendar", func() {
+			_, ok, err := cargo.NewReleaseCalendar(map[string]interface{}{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	it("reads a refreshed calendar from a file", func() {
+		path := filepath.Join(t.TempDir(), "calendar.toml")
+		Expect(os.WriteFile(path, []byte(`cadence-days = 42
+latest = "1.90.0"
+latest-date = "2025-09-18"
+
+[[advisories]]
+  fixed = "1.77.2"
+  id = "CVE-2024-24576"
+`), 0644)).To(Succeed())
+
+		Expect(cargo.ReadReleaseCalendar(path)).To(Equal(calendar))
+	})
+
+	context("LatestRelease", func() {
+		it("extrapolates releases after the latest listed release", func() {
+			latest, date, err := calendar.LatestRelease(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latest.String()).To(Equal("1.92.0"))
+			Expect(date).To(Equal(time.Date(2025, 12, 11, 0, 0, 0, 0, time.UTC)))
+		})
+
+		it("returns the latest listed release before it is superseded", func() {
+			latest, _, err := calendar.LatestRelease(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latest.String()).To(Equal("1.90.0"))
+		})
+
+		it("fails with an invalid date", func() {
+			calendar.LatestDate = "September"
+
+			_, _, err := calendar.LatestRelease(now)
+			Expect(err).To(MatchError(ContainSubstring(`unable to parse date "September" of the latest release`)))
+		})
+	})
+
+	context("Findings", func() {
+		it("accepts a recent toolchain", func() {
+			Expect(calendar.Findings(semver.MustParse("1.88.0"), 6, now)).To(BeEmpty())
+		})
+
+		it("accepts a nightly toolchain ahead of the calendar", func() {
+			Expect(calendar.Findings(semver.MustParse("1.94.0-nightly"), 6, now)).To(BeEmpty())
+		})
+
+		it("reports an outdated toolchain", func() {
+			Expect(calendar.Findings(semver.MustParse("1.85.1"), 6, now)).To(Equal([]string{
+				"rustc 1.85.1 is 7 releases behind Rust 1.92, released on 2025-12-11",
+			}))
+		})
+
+		it("reports an end-of-life toolchain", func() {
+			Expect(calendar.Findings(semver.MustParse("1.77.1"), 20, now)).To(Equal([]string{
+				"rustc 1.77.1 is end-of-life, affected by CVE-2024-24576 fixed in Rust 1.77.2",
+			}))
+		})
+	})
+
+	context("CheckToolchainAge", func() {
+		var log bytes.Buffer
+
+		it.Before(func() {
+			log.Reset()
+		})
+
+		it("warns about an outdated toolchain", func() {
+			Expect(cargo.CheckToolchainAge(calendar, semver.MustParse("1.70.0"), 6, cargo.ToolchainCheckWarn, now, bard.NewLogger(&log))).To(Succeed())
+
+			Expect(log.String()).To(ContainSubstring("rustc 1.70.0 is 22 releases behind Rust 1.92"))
+			Expect(log.String()).To(ContainSubstring("rustc 1.70.0 is end-of-life, affected by CVE-2024-24576"))
+		})
+
+		it("fails with an outdated toolchain", func() {
+			err := cargo.CheckToolchainAge(calendar, semver.MustParse("1.70.0"), 6, cargo.ToolchainCheckFail, now, bard.NewLogger(&log))
+
+			Expect(err).To(MatchError(ContainSubstring("outdated Rust toolchain, update it or set BP_CARGO_RUST_TOOLCHAIN_CHECK=warn")))
+			Expect(err).To(MatchError(ContainSubstring("rustc 1.70.0 is 22 releases behind Rust 1.92")))
+			Expect(err).To(MatchError(ContainSubstring("affected by CVE-2024-24576")))
+		})
+
+		it("does nothing when disabled", func() {
+			Expect(cargo.CheckToolchainAge(calendar, semver.MustParse("1.70.0"), 6, cargo.ToolchainCheckOff, now, bard.NewLogger(&log))).To(Succeed())
+			Expect(log.String()).To(BeEmpty())
+		})
+	})
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+const (
+	LabelRecommendedRunImage = "io.paketo.cargo.run-image.recommendation"
+	LabelStaticBinary        = "io.paketo.cargo.static-binary"
+
+	// RunImageStatic recommends a run image without a distribution, like the Paketo static stack or scratch
+	RunImageStatic = "static"
+)
+
+// RunImageLabels describes statically linked binaries with labels, so that image pipelines can pick a matching run
+// image. A static run image is recommended if the binaries are built on top of a run image with a full distribution.
+func RunImageLabels(static bool, stack string, logger bard.Logger) []libcnb.Label {
+	if !static {
+		return nil
+	}
+
+	labels := []libcnb.Label{{Key: LabelStaticBinary, Value: "true"}}
+
+	if !libpak.IsStaticStack(stack) {
+		logger.Bodyf("Binaries are statically linked and do not need the distribution of the %s run image, "+
+			"consider a static run image like %s", stack, libpak.JammyStaticStackID)
+		labels = append(labels, libcnb.Label{Key: LabelRecommendedRunImage, Value: RunImageStatic})
+	}
+
+	return labels
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testRunImage(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf *bytes.Buffer
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	it("does not label dynamically linked binaries", func() {
+		Expect(cargo.RunImageLabels(false, libpak.JammyStackID, bard.NewLogger(buf))).To(BeEmpty())
+	})
+
+	it("recommends a static run image", func() {
+		Expect(cargo.RunImageLabels(true, libpak.JammyTinyStackID, bard.NewLogger(buf))).To(Equal([]libcnb.Label{
+			{Key: "io.paketo.cargo.static-binary", Value: "true"},
+			{Key: "io.paketo.cargo.run-image.recommendation", Value: "static"},
+		}))
+		Expect(buf.String()).To(ContainSubstring("consider a static run image like io.buildpacks.stacks.jammy.static"))
+	})
+
+	it("does not recommend a run image on the static stack", func() {
+		Expect(cargo.RunImageLabels(true, libpak.JammyStaticStackID, bard.NewLogger(buf))).To(Equal([]libcnb.Label{
+			{Key: "io.paketo.cargo.static-binary", Value: "true"},
+		}))
+	})
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+// RuntimeEnvironment sets defaults for the environment of the application at launch, like `RUST_BACKTRACE` so that
+// panics print a backtrace or the tuning of alternative allocators. The defaults are overridden by variables set when
+// the image is run.
+type RuntimeEnvironment struct {
+	Allocators []string
+	Backtrace  string
+	Log        string
+	Logger     bard.Logger
+}
+
+func (r RuntimeEnvironment) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	if r.Backtrace != "" {
+		layer.LaunchEnvironment.Default("RUST_BACKTRACE", r.Backtrace)
+		r.Logger.Bodyf("Setting RUST_BACKTRACE=%s at launch", r.Backtrace)
+	}
+
+	if r.Log != "" {
+		layer.LaunchEnvironment.Default("RUST_LOG", r.Log)
+		r.Logger.Bodyf("Setting RUST_LOG=%s at launch", r.Log)
+	}
+
+	allocatorEnv := AllocatorLaunchEnvironment(r.Allocators)
+	var names []string
+	for name := range allocatorEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		layer.LaunchEnvironment.Default(name, allocatorEnv[name])
+		r.Logger.Bodyf("Setting %s=%s at launch", name, allocatorEnv[name])
+	}
+
+	layer.Launch = true
+	return layer, nil
+}
+
+func (RuntimeEnvironment) Name() string {
+	return "Rust Runtime Environment"
+}
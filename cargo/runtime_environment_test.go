@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testRuntimeEnvironment(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx libcnb.BuildContext
+	)
+
+	it.Before(func() {
+		ctx.Layers.Path = t.TempDir()
+	})
+
+	it("sets defaults at launch", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.RuntimeEnvironment{Backtrace: "1", Log: "info"}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Launch).To(BeTrue())
+		Expect(layer.Build).To(BeFalse())
+		Expect(layer.Cache).To(BeFalse())
+		Expect(layer.LaunchEnvironment).To(Equal(libcnb.Environment{
+			"RUST_BACKTRACE.default": "1",
+			"RUST_LOG.default":       "info",
+		}))
+	})
+
+	it("tunes the allocators", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.RuntimeEnvironment{Allocators: []string{"jemalloc"}}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.LaunchEnvironment).To(Equal(libcnb.Environment{
+			"MALLOC_CONF.default":       "background_thread:true",
+			"_RJEM_MALLOC_CONF.default": "background_thread:true",
+		}))
+	})
+
+	it("skips empty defaults", func() {
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = cargo.RuntimeEnvironment{}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.LaunchEnvironment).To(BeEmpty())
+	})
+}
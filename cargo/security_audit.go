@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// SecurityAudit audits the dependencies of the application in appDir with cargo-audit and logs the vulnerabilities
+// and warnings that it finds. Returns an error listing the vulnerabilities with a severity of failSeverity or higher,
+// nothing fails the build if failSeverity is empty.
+func SecurityAudit(logger bard.Logger, service runner.CargoService, appDir string, failSeverity string) error {
+	report, err := service.SecurityAudit(appDir)
+	if err != nil {
+		return fmt.Errorf("unable to audit dependencies\n%w", err)
+	}
+
+	logger.Bodyf("Audited %d dependencies: %d vulnerabilities, %d warnings", report.Dependencies, len(report.Vulnerabilities), len(report.Warnings))
+	for _, v := range report.Vulnerabilities {
+		severity := v.Severity
+		if v.Severity != runner.SeverityUnknown {
+			severity = fmt.Sprintf("%s, %.1f", v.Severity, v.Score)
+		}
+
+		patched := "no patched version"
+		if len(v.Patched) > 0 {
+			patched = fmt.Sprintf("patched in %s", strings.Join(v.Patched, ", "))
+		}
+
+		logger.Bodyf("  %s %s %s (%s): %s, %s", v.ID, v.Package, v.Version, severity, v.Title, patched)
+	}
+	for _, w := range report.Warnings {
+		logger.Bodyf("  %s: %s %s %s", w.Kind, w.ID, w.Package, w.Version)
+	}
+
+	if failSeverity == "" {
+		return nil
+	}
+
+	failed := report.AtOrAbove(failSeverity)
+	if len(failed) == 0 {
+		return nil
+	}
+
+	var found []string
+	for _, v := range failed {
+		found = append(found, v.String())
+	}
+	return fmt.Errorf("found %d vulnerabilities with a severity of %s or higher, or of unknown severity, update the packages or ignore the advisories with BP_CARGO_AUDIT_IGNORE: %s",
+		len(failed), failSeverity, strings.Join(found, ", "))
+}
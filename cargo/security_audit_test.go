@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/paketo-community/cargo/runner/mocks"
+	"github.com/sclevine/spec"
+)
+
+func testSecurityAudit(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf     *bytes.Buffer
+		logger  bard.Logger
+		service *mocks.CargoService
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+		logger = bard.NewLogger(buf)
+		service = &mocks.CargoService{}
+
+		service.On("SecurityAudit", "/workspace").Return(runner.AuditReport{
+			Dependencies: 42,
+			Vulnerabilities: []runner.Vulnerability{
+				{ID: "RUSTSEC-2020-0071", Package: "time", Patched: []string{">=0.2.23"}, Score: 5.1, Severity: runner.SeverityMedium, Title: "Potential segfault", Version: "0.1.45"},
+				{ID: "RUSTSEC-2023-0001", Package: "tokio", Score: 9.8, Severity: runner.SeverityCritical, Title: "Remote code execution", Version: "1.0.0"},
+			},
+			Warnings: []runner.AuditWarning{{ID: "RUSTSEC-2021-0139", Kind: "unmaintained", Package: "ansi_term", Version: "0.12.1"}},
+		}, nil)
+	})
+
+	it("logs the vulnerabilities and warnings", func() {
+		Expect(cargo.SecurityAudit(logger, service, "/workspace", "")).To(Succeed())
+
+		Expect(buf.String()).To(ContainSubstring("Audited 42 dependencies: 2 vulnerabilities, 1 warnings"))
+		Expect(buf.String()).To(ContainSubstring("RUSTSEC-2020-0071 time 0.1.45 (medium, 5.1): Potential segfault, patched in >=0.2.23"))
+		Expect(buf.String()).To(ContainSubstring("RUSTSEC-2023-0001 tokio 1.0.0 (critical, 9.8): Remote code execution, no patched version"))
+		Expect(buf.String()).To(ContainSubstring("unmaintained: RUSTSEC-2021-0139 ansi_term 0.12.1"))
+	})
+
+	it("fails on vulnerabilities at or above the severity", func() {
+		err := cargo.SecurityAudit(logger, service, "/workspace", runner.SeverityHigh)
+		Expect(err).To(MatchError(And(
+			ContainSubstring("found 1 vulnerabilities with a severity of high or higher"),
+			ContainSubstring("RUSTSEC-2023-0001 (tokio 1.0.0, critical)"),
+			Not(ContainSubstring("RUSTSEC-2020-0071")),
+		)))
+	})
+
+	it("passes if no vulnerability reaches the severity", func() {
+		service = &mocks.CargoService{}
+		service.On("SecurityAudit", "/workspace").Return(runner.AuditReport{
+			Vulnerabilities: []runner.Vulnerability{{ID: "RUSTSEC-2020-0071", Score: 5.1, Severity: runner.SeverityMedium}},
+		}, nil)
+
+		Expect(cargo.SecurityAudit(logger, service, "/workspace", runner.SeverityHigh)).To(Succeed())
+	})
+
+	it("returns an error if the audit cannot run", func() {
+		service = &mocks.CargoService{}
+		service.On("SecurityAudit", "/workspace").Return(runner.AuditReport{}, errors.New("unable to install cargo-audit"))
+
+		Expect(cargo.SecurityAudit(logger, service, "/workspace", "")).To(MatchError(ContainSubstring("unable to audit dependencies")))
+	})
+}
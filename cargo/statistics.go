@@ -0,0 +1,194 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// BuildStatistics collects what happened while the application was built, so that it can be summarized at the end of
+// the build
+type BuildStatistics struct {
+	Crates *runner.Statistics
+	Logger bard.Logger
+
+	phases []buildPhase
+}
+
+type buildPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Record records the wall-clock time of a phase of the build
+func (b *BuildStatistics) Record(name string, start time.Time) {
+	b.phases = append(b.phases, buildPhase{Name: name, Duration: time.Since(start).Round(time.Millisecond)})
+}
+
+// Log writes the summary. binDir contains the installed binaries, downloads are the crates and git repositories
+// added to CARGO_HOME and packages is the number of packages in Cargo.lock.
+func (b *BuildStatistics) Log(binDir string, downloads Downloads, packages int) error {
+	b.Logger.Header("Build summary")
+
+	if b.Crates != nil {
+		compiled := fmt.Sprintf("Crates compiled: %d", b.Crates.Compiled)
+		if packages > 0 {
+			hits := max(packages-b.Crates.Compiled, 0)
+			compiled = fmt.Sprintf("%s of %d locked packages (cache hit ratio %d%%)", compiled, packages, hits*100/packages)
+		}
+		b.Logger.Body(compiled)
+		b.Logger.Bodyf("Crates downloaded: %d (%s)", b.Crates.Downloaded, runner.FormatBytes(downloads.Crates().Bytes))
+
+		// only counted when the messages of the compiler are summarized
+		if m := b.Crates.Messages; m.Warnings > 0 {
+			b.Logger.Bodyf("Compiler warnings: %d, deprecations: %d", m.Warnings, m.Deprecations)
+		}
+	}
+
+	var registries []string
+	for name := range downloads.Registries {
+		registries = append(registries, name)
+	}
+	sort.Strings(registries)
+
+	// by registry, so that the share served by a mirror can be told apart
+	for _, name := range registries {
+		r := downloads.Registries[name]
+		b.Logger.Bodyf("Downloaded from %s: %d crates (%s)", name, r.Count, runner.FormatBytes(r.Bytes))
+	}
+
+	if downloads.Git.Count > 0 {
+		b.Logger.Bodyf("Git repositories fetched: %d (%s)", downloads.Git.Count, runner.FormatBytes(downloads.Git.Bytes))
+	}
+
+	for _, p := range b.phases {
+		b.Logger.Bodyf("Phase %s: %s", p.Name, p.Duration)
+	}
+
+	return filepath.WalkDir(binDir, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		} else if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("unable to stat %s\n%w", path, err)
+		}
+
+		rel, err := filepath.Rel(binDir, path)
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s relative to %s\n%w", path, binDir, err)
+		}
+
+		b.Logger.Bodyf("Binary %s: %s", rel, runner.FormatBytes(info.Size()))
+		return nil
+	})
+}
+
+// DirectorySize returns the total size of the files in a directory, zero if it does not exist
+func DirectorySize(path string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		} else if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return fmt.Errorf("unable to stat %s\n%w", p, err)
+			}
+			size += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to determine size of %s\n%w", path, err)
+	}
+
+	return size, nil
+}
+
+// LockedPackages returns the number of packages in a Cargo.lock file, zero if it does not exist
+func LockedPackages(lockFile string) (int, error) {
+	f, err := os.Open(lockFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("unable to open %s\n%w", lockFile, err)
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "[[package]]" {
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("unable to read %s\n%w", lockFile, err)
+	}
+
+	return count, nil
+}
+
+// RegistryPackages returns the number of packages in a Cargo.lock file that are downloaded from a registry, zero if it
+// does not exist
+func RegistryPackages(lockFile string) (int, error) {
+	f, err := os.Open(lockFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("unable to open %s\n%w", lockFile, err)
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, `source = "registry+`) || strings.HasPrefix(line, `source = "sparse+`) {
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("unable to read %s\n%w", lockFile, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testStatistics(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+	)
+
+	it.Before(func() {
+		dir = t.TempDir()
+	})
+
+	it("logs the build summary", func() {
+		buf := &bytes.Buffer{}
+		Expect(os.MkdirAll(filepath.Join(dir, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "bin", "app"), make([]byte, 2048), 0755)).To(Succeed())
+
+		statistics := cargo.BuildStatistics{
+			Crates: &runner.Statistics{Compiled: 3, Downloaded: 2, Messages: runner.MessageSummary{Deprecations: 1, Warnings: 4}},
+			Logger: bard.NewLogger(buf),
+		}
+		statistics.Record("install", time.Now())
+
+		Expect(statistics.Log(filepath.Join(dir, "bin"), cargo.Downloads{
+			Git: cargo.Transfer{Bytes: 1 << 10, Count: 1},
+			Registries: map[string]cargo.Transfer{
+				"index.crates.io-6f17d22bba15001f":    {Bytes: 2 << 20, Count: 1},
+				"mirror.example.com-0123456789abcdef": {Bytes: 1 << 20, Count: 1},
+			},
+		}, 12)).To(Succeed())
+
+		Expect(buf.String()).To(ContainSubstring("Build summary"))
+		Expect(buf.String()).To(ContainSubstring("Crates compiled: 3 of 12 locked packages (cache hit ratio 75%)"))
+		Expect(buf.String()).To(ContainSubstring("Crates downloaded: 2 (3.0 MB)"))
+		Expect(buf.String()).To(ContainSubstring("Compiler warnings: 4, deprecations: 1"))
+		Expect(buf.String()).To(ContainSubstring("Downloaded from index.crates.io-6f17d22bba15001f: 1 crates (2.0 MB)"))
+		Expect(buf.String()).To(ContainSubstring("Downloaded from mirror.example.com-0123456789abcdef: 1 crates (1.0 MB)"))
+		Expect(buf.String()).To(ContainSubstring("Git repositories fetched: 1 (1.0 KB)"))
+		Expect(buf.String()).To(ContainSubstring("Phase install: "))
+		Expect(buf.String()).To(ContainSubstring("Binary app: 2.0 KB"))
+	})
+
+	it("tolerates missing binaries", func() {
+		statistics := cargo.BuildStatistics{Logger: bard.NewLogger(&bytes.Buffer{})}
+		Expect(statistics.Log(filepath.Join(dir, "bin"), cargo.Downloads{}, 0)).To(Succeed())
+	})
+
+	it("measures directories", func() {
+		Expect(cargo.DirectorySize(filepath.Join(dir, "missing"))).To(BeZero())
+
+		Expect(os.MkdirAll(filepath.Join(dir, "a", "b"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "a", "one"), make([]byte, 10), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "a", "b", "two"), make([]byte, 5), 0644)).To(Succeed())
+		Expect(cargo.DirectorySize(filepath.Join(dir, "a"))).To(Equal(int64(15)))
+	})
+
+	it("counts locked packages", func() {
+		Expect(cargo.LockedPackages(filepath.Join(dir, "Cargo.lock"))).To(BeZero())
+
+		Expect(os.WriteFile(filepath.Join(dir, "Cargo.lock"), []byte("version = 3\n\n[[package]]\nname = \"a\"\n\n[[package]]\nname = \"b\"\n"), 0644)).To(Succeed())
+		Expect(cargo.LockedPackages(filepath.Join(dir, "Cargo.lock"))).To(Equal(2))
+	})
+
+	it("counts registry packages", func() {
+		Expect(cargo.RegistryPackages(filepath.Join(dir, "Cargo.lock"))).To(BeZero())
+
+		Expect(os.WriteFile(filepath.Join(dir, "Cargo.lock"), []byte(`version = 3
+
+[[package]]
+name = "a"
+
+[[package]]
+name = "b"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "c"
+source = "git+https://github.com/example/c"
+`), 0644)).To(Succeed())
+		Expect(cargo.RegistryPackages(filepath.Join(dir, "Cargo.lock"))).To(Equal(1))
+	})
+}
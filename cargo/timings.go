@@ -0,0 +1,249 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// TimingsReportFile is the file of the timings layer that lists the time spent compiling each crate and unit
+const TimingsReportFile = "timings.json"
+
+var unitDataPattern = regexp.MustCompile(`(?s)const UNIT_DATA = (\[.*?\]);`)
+
+// CrateTiming is the time spent compiling a crate, including its build script
+type CrateTiming struct {
+	Name     string
+	Version  string
+	Duration time.Duration
+}
+
+type timingUnit struct {
+	Name     string  `json:"name"`
+	Version  string  `json:"version"`
+	Duration float64 `json:"duration"`
+}
+
+// TimingsPath returns the directory to which `cargo install --timings` writes its reports
+func TimingsPath(applicationPath string) string {
+	return filepath.Join(applicationPath, "target", "cargo-timings")
+}
+
+// SlowestCrates reads the reports written by `cargo install --timings` in dir and returns the n crates that took the
+// longest to compile, slowest first
+func SlowestCrates(dir string, n int) ([]CrateTiming, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "cargo-timing-*.html"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list timings in %s\n%w", dir, err)
+	}
+
+	durations := map[[2]string]float64{}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s\n%w", file, err)
+		}
+
+		m := unitDataPattern.FindSubmatch(b)
+		if m == nil {
+			continue
+		}
+
+		var units []timingUnit
+		if err := json.Unmarshal(m[1], &units); err != nil {
+			return nil, fmt.Errorf("unable to parse unit data in %s\n%w", file, err)
+		}
+
+		for _, u := range units {
+			durations[[2]string{u.Name, u.Version}] += u.Duration
+		}
+	}
+
+	var timings []CrateTiming
+	for k, d := range durations {
+		timings = append(timings, CrateTiming{
+			Name:     k[0],
+			Version:  k[1],
+			Duration: time.Duration(d * float64(time.Second)).Round(time.Millisecond),
+		})
+	}
+	sortTimings(timings)
+
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+
+	return timings, nil
+}
+
+// CrateTimings adds up the time spent compiling the units of each crate, collected with `--timings=json`, slowest
+// first
+func CrateTimings(units []runner.UnitTiming) []CrateTiming {
+	durations := map[[2]string]time.Duration{}
+	for _, u := range units {
+		name, version, _ := parsePackageID(u.PackageID, "")
+		durations[[2]string{name, version}] += u.Duration
+	}
+
+	var timings []CrateTiming
+	for k, d := range durations {
+		timings = append(timings, CrateTiming{Name: k[0], Version: k[1], Duration: d})
+	}
+	sortTimings(timings)
+
+	return timings
+}
+
+// LogSlowestCrates logs the n crates that took the longest to compile
+func LogSlowestCrates(logger bard.Logger, dir string, n int) error {
+	timings, err := SlowestCrates(dir, n)
+	if err != nil {
+		return err
+	}
+
+	if len(timings) == 0 {
+		return nil
+	}
+
+	logger.Header("Slowest crates")
+	for _, t := range timings {
+		logger.Bodyf("%s v%s: %s", t.Name, t.Version, t.Duration)
+	}
+
+	return nil
+}
+
+// Timings keeps the reports of `cargo install --timings=html,json` in a launch layer, so that the time spent
+// compiling each crate can be analyzed from the image. The HTML reports are copied as they are, the timings collected
+// from the JSON messages are written to TimingsReportFile.
+type Timings struct {
+	ApplicationPaths []string
+	Logger           bard.Logger
+	Statistics       *runner.Statistics
+}
+
+func (t Timings) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+
+	layer.Launch = true
+
+	var units []runner.UnitTiming
+	if t.Statistics != nil {
+		units = t.Statistics.Timings
+	}
+
+	// reports restored with the target cache belong to a previous build
+	if len(units) == 0 {
+		t.Logger.Body("No timings recorded, nothing was compiled")
+		return layer, nil
+	}
+
+	var reports []string
+	for _, path := range t.ApplicationPaths {
+		files, err := filepath.Glob(filepath.Join(TimingsPath(path), "cargo-timing-*.html"))
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to list timings in %s\n%w", TimingsPath(path), err)
+		}
+		reports = append(reports, files...)
+	}
+
+	for _, report := range reports {
+		dest := filepath.Join(layer.Path, filepath.Base(report))
+		in, err := os.Open(report)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to open %s\n%w", report, err)
+		}
+		err = sherpa.CopyFile(in, dest)
+		in.Close()
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to copy %s to %s\n%w", report, dest, err)
+		}
+	}
+
+	type unitReport struct {
+		Duration  float64 `json:"duration"`
+		Mode      string  `json:"mode"`
+		Package   string  `json:"package"`
+		RmetaTime float64 `json:"rmeta_time,omitempty"`
+		Target    string  `json:"target"`
+		Version   string  `json:"version"`
+	}
+	type crateReport struct {
+		Duration float64 `json:"duration"`
+		Name     string  `json:"name"`
+		Version  string  `json:"version"`
+	}
+	report := struct {
+		Crates []crateReport `json:"crates"`
+		Units  []unitReport  `json:"units"`
+	}{Crates: []crateReport{}, Units: []unitReport{}}
+
+	crates := CrateTimings(units)
+	for _, c := range crates {
+		report.Crates = append(report.Crates, crateReport{Duration: c.Duration.Seconds(), Name: c.Name, Version: c.Version})
+	}
+	for _, u := range units {
+		name, version, _ := parsePackageID(u.PackageID, "")
+		report.Units = append(report.Units, unitReport{
+			Duration:  u.Duration.Seconds(),
+			Mode:      u.Mode,
+			Package:   name,
+			RmetaTime: u.RmetaTime.Seconds(),
+			Target:    u.Target,
+			Version:   version,
+		})
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to encode timings\n%w", err)
+	}
+
+	file := filepath.Join(layer.Path, TimingsReportFile)
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+
+	t.Logger.Bodyf("Recording timings of %d crates and %d HTML reports in %s", len(crates), len(reports), layer.Path)
+	return layer, nil
+}
+
+func (Timings) Name() string {
+	return "Cargo Timings"
+}
+
+func sortTimings(timings []CrateTiming) {
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Duration != timings[j].Duration {
+			return timings[i].Duration > timings[j].Duration
+		}
+		return timings[i].Name < timings[j].Name
+	})
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testTimings(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+	)
+
+	it.Before(func() {
+		dir = cargo.TimingsPath(t.TempDir())
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(dir, "cargo-timing-20240501T120000Z.html"), []byte(`<html><script>
+const UNIT_DATA = [
+  {"i": 0, "name": "syn", "version": "2.0.60", "mode": "todo", "target": "", "start": 0.0, "duration": 4.5, "rmeta_time": 2.1, "unlocked_units": [1], "unlocked_rmeta_units": []},
+  {"i": 1, "name": "serde", "version": "1.0.200", "mode": "run-custom-build", "target": " build script (run)", "start": 0.1, "duration": 0.5, "rmeta_time": null, "unlocked_units": [], "unlocked_rmeta_units": []},
+  {"i": 2, "name": "serde", "version": "1.0.200", "mode": "todo", "target": "", "start": 0.6, "duration": 3.0, "rmeta_time": 1.0, "unlocked_units": [], "unlocked_rmeta_units": []},
+  {"i": 3, "name": "app", "version": "0.1.0", "mode": "todo", "target": "", "start": 4.5, "duration": 1.25, "rmeta_time": null, "unlocked_units": [], "unlocked_rmeta_units": []}
+];
+const CONCURRENCY_DATA = {};
+</script></html>`), 0644)).To(Succeed())
+	})
+
+	it("returns the slowest crates", func() {
+		Expect(cargo.SlowestCrates(dir, 2)).To(Equal([]cargo.CrateTiming{
+			{Name: "syn", Version: "2.0.60", Duration: 4500 * time.Millisecond},
+			{Name: "serde", Version: "1.0.200", Duration: 3500 * time.Millisecond},
+		}))
+	})
+
+	it("logs the slowest crates", func() {
+		buf := &bytes.Buffer{}
+
+		Expect(cargo.LogSlowestCrates(bard.NewLogger(buf), dir, 5)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("Slowest crates"))
+		Expect(buf.String()).To(ContainSubstring("syn v2.0.60: 4.5s"))
+		Expect(buf.String()).To(ContainSubstring("app v0.1.0: 1.25s"))
+	})
+
+	it("ignores missing reports", func() {
+		Expect(cargo.SlowestCrates(filepath.Join(dir, "missing"), 5)).To(BeEmpty())
+	})
+
+	context("timings of units", func() {
+		units := []runner.UnitTiming{
+			{Duration: 500 * time.Millisecond, Mode: "run-custom-build", PackageID: "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.200", Target: "build-script-build"},
+			{Duration: 3 * time.Second, Mode: "build", PackageID: "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.200", RmetaTime: time.Second, Target: "serde"},
+			{Duration: 4500 * time.Millisecond, Mode: "build", PackageID: "syn 2.0.60 (registry+https://github.com/rust-lang/crates.io-index)", Target: "syn"},
+		}
+
+		it("adds up the timings of each crate", func() {
+			Expect(cargo.CrateTimings(units)).To(Equal([]cargo.CrateTiming{
+				{Name: "syn", Version: "2.0.60", Duration: 4500 * time.Millisecond},
+				{Name: "serde", Version: "1.0.200", Duration: 3500 * time.Millisecond},
+			}))
+		})
+
+		it("keeps the reports in a launch layer", func() {
+			buf := &bytes.Buffer{}
+			layer := libcnb.Layer{Path: t.TempDir()}
+
+			layer, err := cargo.Timings{
+				ApplicationPaths: []string{filepath.Dir(filepath.Dir(dir))},
+				Logger:           bard.NewLogger(buf),
+				Statistics:       &runner.Statistics{Timings: units},
+			}.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(layer.Launch).To(BeTrue())
+
+			Expect(filepath.Join(layer.Path, "cargo-timing-20240501T120000Z.html")).To(BeARegularFile())
+			Expect(os.ReadFile(filepath.Join(layer.Path, cargo.TimingsReportFile))).To(MatchJSON(`{
+				"crates": [
+					{"duration": 4.5, "name": "syn", "version": "2.0.60"},
+					{"duration": 3.5, "name": "serde", "version": "1.0.200"}
+				],
+				"units": [
+					{"duration": 0.5, "mode": "run-custom-build", "package": "serde", "target": "build-script-build", "version": "1.0.200"},
+					{"duration": 3, "mode": "build", "package": "serde", "rmeta_time": 1, "target": "serde", "version": "1.0.200"},
+					{"duration": 4.5, "mode": "build", "package": "syn", "target": "syn", "version": "2.0.60"}
+				]
+			}`))
+			Expect(buf.String()).To(ContainSubstring("Recording timings of 2 crates and 1 HTML reports"))
+		})
+
+		it("does not keep reports of previous builds", func() {
+			layer, err := cargo.Timings{
+				ApplicationPaths: []string{filepath.Dir(filepath.Dir(dir))},
+				Logger:           bard.NewLogger(&bytes.Buffer{}),
+				Statistics:       &runner.Statistics{},
+			}.Contribute(libcnb.Layer{Path: t.TempDir()})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(layer.Path, "cargo-timing-20240501T120000Z.html")).NotTo(BeAnExistingFile())
+		})
+	})
+}
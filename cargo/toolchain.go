@@ -0,0 +1,123 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// LocateToolchain returns the directory containing the cargo binary provided by an upstream Rust toolchain
+// buildpack. Toolchains installed with rustup are found under CARGO_HOME, other distributions are found on PATH.
+// Returns an empty string if cargo cannot be found.
+func LocateToolchain(cargoHome string) (string, error) {
+	if cargoHome != "" {
+		bin := filepath.Join(cargoHome, "bin")
+		if found, err := sherpa.FileExists(filepath.Join(bin, "cargo")); err != nil {
+			return "", fmt.Errorf("unable to check for cargo in %s\n%w", bin, err)
+		} else if found {
+			return bin, nil
+		}
+	}
+
+	path, err := exec.LookPath("cargo")
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, exec.ErrDot) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("unable to look up cargo on PATH\n%w", err)
+	}
+
+	return filepath.Dir(path), nil
+}
+
+// CheckToolchain ensures that cargo and rustc are present in the directory returned by LocateToolchain, so that a
+// build without a Rust toolchain fails up front with a hint on how to provide one, rather than when cargo is run
+func CheckToolchain(toolchainPath string, rustupEnabled bool) error {
+	hint := "use a Rust toolchain buildpack, like paketo-community/rust-dist or paketo-community/rustup, before this buildpack, or set BP_CARGO_RUSTUP_ENABLED=true to install a toolchain with rustup"
+	if rustupEnabled {
+		hint = "the toolchain installed with rustup is incomplete, check the rustup output above"
+	}
+
+	if toolchainPath == "" {
+		return fmt.Errorf("unable to find cargo in CARGO_HOME or on PATH, %s", hint)
+	}
+
+	for _, name := range []string{"cargo", "rustc"} {
+		if found, err := sherpa.FileExists(filepath.Join(toolchainPath, name)); err != nil {
+			return fmt.Errorf("unable to check for %s in %s\n%w", name, toolchainPath, err)
+		} else if !found {
+			return fmt.Errorf("unable to find %s in %s, %s", name, toolchainPath, hint)
+		}
+	}
+
+	return nil
+}
+
+// ValidateRustVersion ensures that the installed rustc satisfies a semver constraint, like `1.78.*`. Toolchain
+// channels, like `stable` or `nightly`, are not constraints and are not validated.
+func ValidateRustVersion(service runner.CargoService, constraint string) error {
+	if constraint == "" {
+		return nil
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil
+	}
+
+	rustVersion, err := service.RustVersion()
+	if err != nil {
+		return fmt.Errorf("unable to determine rust version\n%w", err)
+	}
+
+	if !c.Check(rustVersion.Version) {
+		return fmt.Errorf("rustc %s does not satisfy BP_RUST_VERSION=%s, install a matching toolchain or change BP_RUST_VERSION", rustVersion, constraint)
+	}
+
+	return nil
+}
+
+// ValidateToolchainFile ensures that the installed toolchain matches the channel of a rust-toolchain file and that the
+// targets it lists are installed. Targets are only added if the toolchain is managed by rustup.
+func ValidateToolchainFile(service runner.CargoService, file *runner.ToolchainFile) error {
+	if file == nil {
+		return nil
+	}
+
+	info, err := service.RustVersionInfo()
+	if err != nil {
+		return fmt.Errorf("unable to determine rust version\n%w", err)
+	}
+
+	if err := file.Verify(info); err != nil {
+		return fmt.Errorf("%w, install a matching toolchain or enable rustup with BP_CARGO_RUSTUP_ENABLED", err)
+	}
+
+	for _, target := range file.Targets {
+		if err := service.EnsureTarget(target); err != nil {
+			return fmt.Errorf("unable to install target %s of %s\n%w", target, file.File, err)
+		}
+	}
+
+	return nil
+}
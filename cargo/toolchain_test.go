@@ -0,0 +1,151 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/paketo-community/cargo/runner/mocks"
+	"github.com/sclevine/spec"
+)
+
+func testToolchain(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cargoHome string
+		pathDir   string
+	)
+
+	it.Before(func() {
+		cargoHome = t.TempDir()
+		pathDir = t.TempDir()
+		t.Setenv("PATH", pathDir)
+	})
+
+	it("finds cargo in CARGO_HOME", func() {
+		Expect(os.MkdirAll(filepath.Join(cargoHome, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(cargoHome, "bin", "cargo"), []byte{}, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(pathDir, "cargo"), []byte{}, 0755)).To(Succeed())
+
+		Expect(cargo.LocateToolchain(cargoHome)).To(Equal(filepath.Join(cargoHome, "bin")))
+	})
+
+	it("finds cargo on PATH", func() {
+		Expect(os.WriteFile(filepath.Join(pathDir, "cargo"), []byte{}, 0755)).To(Succeed())
+
+		Expect(cargo.LocateToolchain(cargoHome)).To(Equal(pathDir))
+	})
+
+	it("returns nothing when cargo is missing", func() {
+		Expect(cargo.LocateToolchain(cargoHome)).To(BeEmpty())
+	})
+
+	context("checks the toolchain", func() {
+		it("accepts a toolchain with cargo and rustc", func() {
+			Expect(os.WriteFile(filepath.Join(pathDir, "cargo"), []byte{}, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(pathDir, "rustc"), []byte{}, 0755)).To(Succeed())
+
+			Expect(cargo.CheckToolchain(pathDir, false)).To(Succeed())
+		})
+
+		it("explains how to provide a toolchain when cargo is missing", func() {
+			err := cargo.CheckToolchain("", false)
+			Expect(err).To(MatchError(ContainSubstring("unable to find cargo in CARGO_HOME or on PATH")))
+			Expect(err).To(MatchError(ContainSubstring("use a Rust toolchain buildpack")))
+			Expect(err).To(MatchError(ContainSubstring("set BP_CARGO_RUSTUP_ENABLED=true")))
+		})
+
+		it("fails when rustc is missing", func() {
+			Expect(os.WriteFile(filepath.Join(pathDir, "cargo"), []byte{}, 0755)).To(Succeed())
+
+			Expect(cargo.CheckToolchain(pathDir, false)).To(MatchError(ContainSubstring(
+				fmt.Sprintf("unable to find rustc in %s, use a Rust toolchain buildpack", pathDir))))
+		})
+
+		it("points at rustup when the installed toolchain is incomplete", func() {
+			Expect(cargo.CheckToolchain(pathDir, true)).To(MatchError(ContainSubstring(
+				"the toolchain installed with rustup is incomplete")))
+		})
+	})
+
+	context("validates the Rust version", func() {
+		var service *mocks.CargoService
+
+		it.Before(func() {
+			service = &mocks.CargoService{}
+			version, err := runner.ParseVersion("rustc 1.78.2 (9b00956e5 2024-04-29)")
+			Expect(err).NotTo(HaveOccurred())
+			service.On("RustVersion").Return(version, nil)
+		})
+
+		it("accepts a matching version", func() {
+			Expect(cargo.ValidateRustVersion(service, "1.78.*")).To(Succeed())
+			Expect(cargo.ValidateRustVersion(service, ">= 1.70")).To(Succeed())
+		})
+
+		it("ignores channels", func() {
+			Expect(cargo.ValidateRustVersion(service, "")).To(Succeed())
+			Expect(cargo.ValidateRustVersion(service, "stable")).To(Succeed())
+			service.AssertNotCalled(t, "RustVersion")
+		})
+
+		it("fails on a mismatch", func() {
+			Expect(cargo.ValidateRustVersion(service, "1.80.*")).To(MatchError(
+				"rustc 1.78.2 does not satisfy BP_RUST_VERSION=1.80.*, install a matching toolchain or change BP_RUST_VERSION"))
+		})
+	})
+
+	context("ValidateToolchainFile", func() {
+		var service *mocks.CargoService
+
+		it.Before(func() {
+			service = &mocks.CargoService{}
+			info, err := runner.ParseVersionInfo("rustc 1.80.1 (3f5fd8dd4 2024-08-06)\nrelease: 1.80.1\nhost: x86_64-unknown-linux-gnu\n")
+			Expect(err).NotTo(HaveOccurred())
+			service.On("RustVersionInfo").Return(info, nil)
+		})
+
+		it("ignores projects without a toolchain file", func() {
+			Expect(cargo.ValidateToolchainFile(service, nil)).To(Succeed())
+			service.AssertNotCalled(t, "RustVersionInfo")
+		})
+
+		it("installs the targets of the toolchain file", func() {
+			service.On("EnsureTarget", "wasm32-unknown-unknown").Return(nil)
+
+			Expect(cargo.ValidateToolchainFile(service, &runner.ToolchainFile{
+				Channel: "1.80",
+				File:    "rust-toolchain.toml",
+				Targets: []string{"wasm32-unknown-unknown"},
+			})).To(Succeed())
+			service.AssertCalled(t, "EnsureTarget", "wasm32-unknown-unknown")
+		})
+
+		it("fails on a mismatch", func() {
+			Expect(cargo.ValidateToolchainFile(service, &runner.ToolchainFile{Channel: "nightly", File: "rust-toolchain"})).To(MatchError(
+				"rustc 1.80.1 is a stable toolchain, but rust-toolchain selects the nightly channel, " +
+					"install a matching toolchain or enable rustup with BP_CARGO_RUSTUP_ENABLED"))
+		})
+	})
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sbom"
+	"github.com/paketo-community/cargo/runner"
+)
+
+// Tool is a crate installed into CARGO_HOME with `cargo install`
+type Tool struct {
+	Name    string
+	Version string
+	Source  string
+}
+
+// AsSyftArtifact renders a bill of materials entry describing the tool as Syft
+func (t Tool) AsSyftArtifact(cargoHome string) (sbom.SyftArtifact, error) {
+	artifact := sbom.SyftArtifact{
+		Name:      t.Name,
+		Version:   t.Version,
+		Type:      "rust-crate",
+		FoundBy:   "cargo",
+		Locations: []sbom.SyftLocation{{Path: filepath.Join(cargoHome, ".crates.toml")}},
+		Language:  "rust",
+		PURL:      fmt.Sprintf("pkg:cargo/%s@%s", t.Name, t.Version),
+	}
+
+	var err error
+	artifact.ID, err = artifact.Hash()
+	if err != nil {
+		return sbom.SyftArtifact{}, fmt.Errorf("unable to generate hash\n%w", err)
+	}
+
+	return artifact, nil
+}
+
+// InstalledTools returns the crates installed into CARGO_HOME with `cargo install`, as tracked by cargo in
+// `.crates.toml`
+func InstalledTools(cargoHome string) ([]Tool, error) {
+	var crates struct {
+		V1 map[string][]string `toml:"v1"`
+	}
+
+	if _, err := toml.DecodeFile(filepath.Join(cargoHome, ".crates.toml"), &crates); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", filepath.Join(cargoHome, ".crates.toml"), err)
+	}
+
+	// keys are package ids, like `diesel_cli 2.1.0 (registry+https://github.com/rust-lang/crates.io-index)`
+	var tools []Tool
+	for id := range crates.V1 {
+		parts := strings.SplitN(id, " ", 3)
+
+		tool := Tool{Name: parts[0]}
+		if len(parts) > 1 {
+			tool.Version = parts[1]
+		}
+		if len(parts) > 2 {
+			tool.Source = strings.Trim(parts[2], "()")
+		}
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	return tools, nil
+}
+
+// WriteToolsSBOM records the requested tools installed into CARGO_HOME in a Syft SBOM
+func WriteToolsSBOM(path string, cargoHome string, requested []string) error {
+	installed, err := InstalledTools(cargoHome)
+	if err != nil {
+		return fmt.Errorf("unable to find installed tools\n%w", err)
+	}
+
+	keep := map[string]bool{}
+	for _, tool := range requested {
+		keep[toolName(tool)] = true
+	}
+
+	var artifacts []sbom.SyftArtifact
+	for _, tool := range installed {
+		if !keep[tool.Name] {
+			continue
+		}
+
+		artifact, err := tool.AsSyftArtifact(cargoHome)
+		if err != nil {
+			return fmt.Errorf("unable to get SBOM artifact %s\n%w", tool.Name, err)
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	if err := sbom.NewSyftDependency(cargoHome, artifacts).WriteTo(path); err != nil {
+		return fmt.Errorf("unable to write SBOM\n%w", err)
+	}
+
+	return nil
+}
+
+// PreviousTools returns the tools requested by the build that contributed a layer
+func PreviousTools(metadata map[string]interface{}) []string {
+	var tools []string
+
+	switch t := metadata["tools"].(type) {
+	case []string:
+		tools = t
+	case []interface{}:
+		for _, tool := range t {
+			if s, ok := tool.(string); ok {
+				tools = append(tools, s)
+			}
+		}
+	}
+
+	return tools
+}
+
+// PruneTools uninstalls tools that were requested by a previous build, but are no longer requested, so that stale
+// binaries do not accumulate in CARGO_HOME. Tools installed by something other than this buildpack are not touched.
+func PruneTools(service runner.CargoService, cargoHome string, previous []string, requested []string, logger bard.Logger) error {
+	installed, err := InstalledTools(cargoHome)
+	if err != nil {
+		return fmt.Errorf("unable to find installed tools\n%w", err)
+	}
+
+	keep := map[string]bool{}
+	for _, tool := range requested {
+		keep[toolName(tool)] = true
+	}
+
+	isInstalled := map[string]bool{}
+	for _, tool := range installed {
+		isInstalled[tool.Name] = true
+	}
+
+	for _, tool := range previous {
+		name := toolName(tool)
+		if keep[name] || !isInstalled[name] {
+			continue
+		}
+
+		logger.Bodyf("Removing tool %s which is no longer requested", name)
+		if err := service.UninstallTool(name); err != nil {
+			return fmt.Errorf("unable to prune tool %s\n%w", name, err)
+		}
+		isInstalled[name] = false
+	}
+
+	return nil
+}
+
+// toolName strips the version from tools pinned to a version, like `diesel_cli@2.1.0`
+func toolName(tool string) string {
+	name, _, _ := strings.Cut(tool, "@")
+	return name
+}
+
+// toolSpecs returns the tools as understood by `cargo install`, like `sqlx-cli@0.7`
+func toolSpecs(tools []runner.ToolRequest) []string {
+	var specs []string
+	for _, t := range tools {
+		specs = append(specs, t.String())
+	}
+	return specs
+}
+
+// perToolArgs returns the arguments of each tool that has any, by tool name
+func perToolArgs(tools []runner.ToolRequest) map[string][]string {
+	args := map[string][]string{}
+	for _, t := range tools {
+		if t.Customized() {
+			args[t.Name] = t.InstallArgs(nil)
+		}
+	}
+	return args
+}
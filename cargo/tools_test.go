@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sbom"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/paketo-community/cargo/runner/mocks"
+	"github.com/sclevine/spec"
+)
+
+func testTools(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cargoHome string
+		service   *mocks.CargoService
+	)
+
+	it.Before(func() {
+		cargoHome = t.TempDir()
+		service = &mocks.CargoService{}
+
+		Expect(os.WriteFile(filepath.Join(cargoHome, ".crates.toml"), []byte(`[v1]
+"cargo-bloat 0.11.1 (registry+https://github.com/rust-lang/crates.io-index)" = ["cargo-bloat"]
+"diesel_cli 2.1.0 (registry+https://github.com/rust-lang/crates.io-index)" = ["diesel"]
+"other 1.0.0 (registry+https://github.com/rust-lang/crates.io-index)" = ["other"]
+`), 0644)).To(Succeed())
+	})
+
+	it("lists installed tools", func() {
+		Expect(cargo.InstalledTools(cargoHome)).To(Equal([]cargo.Tool{
+			{Name: "cargo-bloat", Version: "0.11.1", Source: "registry+https://github.com/rust-lang/crates.io-index"},
+			{Name: "diesel_cli", Version: "2.1.0", Source: "registry+https://github.com/rust-lang/crates.io-index"},
+			{Name: "other", Version: "1.0.0", Source: "registry+https://github.com/rust-lang/crates.io-index"},
+		}))
+		Expect(cargo.InstalledTools(t.TempDir())).To(BeEmpty())
+	})
+
+	it("reads previous tools from metadata", func() {
+		Expect(cargo.PreviousTools(map[string]interface{}{"tools": []interface{}{"foo", "bar"}})).To(Equal([]string{"foo", "bar"}))
+		Expect(cargo.PreviousTools(map[string]interface{}{"tools": []string{"foo"}})).To(Equal([]string{"foo"}))
+		Expect(cargo.PreviousTools(nil)).To(BeEmpty())
+	})
+
+	it("uninstalls tools that are no longer requested", func() {
+		service.On("UninstallTool", "diesel_cli").Return(nil)
+
+		Expect(cargo.PruneTools(service, cargoHome,
+			[]string{"cargo-bloat", "diesel_cli@2.1.0", "removed-already"},
+			[]string{"cargo-bloat@0.11.1"},
+			bard.NewLogger(&bytes.Buffer{}))).To(Succeed())
+
+		service.AssertExpectations(t)
+		Expect(service.Calls).To(HaveLen(1))
+	})
+
+	it("records requested tools in an SBOM", func() {
+		path := filepath.Join(t.TempDir(), "build.sbom.syft.json")
+
+		Expect(cargo.WriteToolsSBOM(path, cargoHome, []string{"diesel_cli@2.1.0"})).To(Succeed())
+
+		var dependency sbom.SyftDependency
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(data, &dependency)).To(Succeed())
+
+		Expect(dependency.Artifacts).To(HaveLen(1))
+		Expect(dependency.Artifacts[0].Name).To(Equal("diesel_cli"))
+		Expect(dependency.Artifacts[0].Version).To(Equal("2.1.0"))
+		Expect(dependency.Artifacts[0].PURL).To(Equal("pkg:cargo/diesel_cli@2.1.0"))
+		Expect(dependency.Artifacts[0].ID).NotTo(BeEmpty())
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/heroku/color"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// DependencyVerifier ensures that the tools downloaded by this buildpack are verified against a SHA256 digest. The
+// digests in buildpack.toml can be replaced with configured digests, and in strict mode dependencies without a
+// digest are refused rather than downloaded unverified.
+type DependencyVerifier struct {
+	Digests map[string]string
+	Logger  bard.Logger
+	Strict  bool
+}
+
+// NewDependencyVerifier creates a verifier from a comma separated list of `id=sha256` digests
+func NewDependencyVerifier(digests string, strict bool, logger bard.Logger) (DependencyVerifier, error) {
+	v := DependencyVerifier{Digests: map[string]string{}, Logger: logger, Strict: strict}
+
+	for _, d := range strings.Split(digests, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+
+		id, digest, ok := strings.Cut(d, "=")
+		digest = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(digest), "sha256:"))
+		if !ok || strings.TrimSpace(id) == "" || !sha256Pattern.MatchString(digest) {
+			return DependencyVerifier{}, fmt.Errorf("invalid dependency digest %q, expected <id>=<sha256>", d)
+		}
+
+		v.Digests[strings.TrimSpace(id)] = digest
+	}
+
+	return v, nil
+}
+
+// Verify returns the dependency with its configured digest, the download is verified against this digest when the
+// dependency is contributed
+func (v DependencyVerifier) Verify(dependency libpak.BuildpackDependency) (libpak.BuildpackDependency, error) {
+	if digest, ok := v.Digests[dependency.ID]; ok {
+		if dependency.SHA256 != "" && dependency.SHA256 != digest {
+			v.Logger.Bodyf("Using configured SHA256 %s for %s instead of %s", digest, dependency.ID, dependency.SHA256)
+		}
+		dependency.SHA256 = digest
+	}
+
+	if dependency.SHA256 == "" {
+		if v.Strict {
+			return libpak.BuildpackDependency{}, fmt.Errorf("refusing to download %s %s without a SHA256 digest, "+
+				"configure one with BP_CARGO_DEPENDENCY_DIGESTS", dependency.ID, dependency.Version)
+		}
+		v.Logger.Infof("%s: %s %s has no SHA256 digest and cannot be verified", color.YellowString("Warning"), dependency.ID, dependency.Version)
+	}
+
+	return dependency, nil
+}
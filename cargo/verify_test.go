@@ -0,0 +1,76 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargo_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargo"
+	"github.com/sclevine/spec"
+)
+
+func testVerify(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf    *bytes.Buffer
+		digest = "6aeece6993e902708983b209d04c0d1dbb14ebb405ddb87def578d41f920f56d"
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	it("parses configured digests", func() {
+		v, err := cargo.NewDependencyVerifier("rustup-init=sha256:"+digest+", tini="+digest, false, bard.NewLogger(buf))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Digests).To(Equal(map[string]string{"rustup-init": digest, "tini": digest}))
+
+		_, err = cargo.NewDependencyVerifier("rustup-init=abc", false, bard.NewLogger(buf))
+		Expect(err).To(MatchError(`invalid dependency digest "rustup-init=abc", expected <id>=<sha256>`))
+	})
+
+	it("uses the configured digest", func() {
+		v, err := cargo.NewDependencyVerifier("rustup-init="+digest, false, bard.NewLogger(buf))
+		Expect(err).NotTo(HaveOccurred())
+
+		dep, err := v.Verify(libpak.BuildpackDependency{ID: "rustup-init", SHA256: "other"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dep.SHA256).To(Equal(digest))
+	})
+
+	it("warns about unverifiable dependencies", func() {
+		v, err := cargo.NewDependencyVerifier("", false, bard.NewLogger(buf))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = v.Verify(libpak.BuildpackDependency{ID: "rustup-init", Version: "1.27.1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).To(ContainSubstring("rustup-init 1.27.1 has no SHA256 digest and cannot be verified"))
+	})
+
+	it("refuses unverifiable dependencies in strict mode", func() {
+		v, err := cargo.NewDependencyVerifier("", true, bard.NewLogger(buf))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = v.Verify(libpak.BuildpackDependency{ID: "rustup-init", Version: "1.27.1"})
+		Expect(err).To(MatchError(ContainSubstring("refusing to download rustup-init 1.27.1 without a SHA256 digest")))
+	})
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// AssertExecuted fails the test unless a command was executed with all of args, in any order, and returns the first
+// matching execution
+func AssertExecuted(t testing.TB, executor *Executor, command string, args ...string) effect.Execution {
+	t.Helper()
+
+	for _, e := range executor.Executions {
+		if filepath.Base(e.Command) == command && containsAll(e.Args, args) {
+			return e
+		}
+	}
+
+	t.Fatalf("expected %s to be executed with %s, executions were:\n%s", command, strings.Join(args, " "), executions(executor))
+	return effect.Execution{}
+}
+
+// AssertNotExecuted fails the test if a command was executed with all of args
+func AssertNotExecuted(t testing.TB, executor *Executor, command string, args ...string) {
+	t.Helper()
+
+	for _, e := range executor.Executions {
+		if filepath.Base(e.Command) == command && containsAll(e.Args, args) {
+			t.Fatalf("expected %s not to be executed with %s, executions were:\n%s", command, strings.Join(args, " "), executions(executor))
+		}
+	}
+}
+
+// AssertBinaries fails the test unless the layer contains an executable in its bin directory for each name
+func AssertBinaries(t testing.TB, layer libcnb.Layer, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		path := filepath.Join(layer.Path, "bin", name)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected binary %s\n%s", path, err)
+		}
+
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			t.Fatalf("expected %s to be an executable file, found %s", path, info.Mode())
+		}
+	}
+}
+
+func containsAll(args []string, expected []string) bool {
+	for _, e := range expected {
+		found := false
+		for _, a := range args {
+			if a == e {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func executions(executor *Executor) string {
+	var lines []string
+	for _, e := range executor.Executions {
+		lines = append(lines, strings.TrimSpace(e.Command+" "+strings.Join(e.Args, " ")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cargotest provides fixtures for testing buildpacks that embed the cargo runner, like fake cargo metadata,
+// a scripted executor and temporary application and layer directories, so that tests can run the runner end to end
+// without a Rust toolchain.
+package cargotest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// Application creates a temporary application directory containing files, which maps paths relative to the
+// application, like `src/main.rs`, to their contents
+func Application(t testing.TB, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("unable to create %s\n%s", filepath.Dir(file), err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write %s\n%s", file, err)
+		}
+	}
+
+	return dir
+}
+
+// CargoHome creates a temporary CARGO_HOME and sets the environment variable for the duration of the test
+func CargoHome(t testing.TB) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("CARGO_HOME", dir)
+	return dir
+}
+
+// Layers creates a temporary layers directory
+func Layers(t testing.TB) libcnb.Layers {
+	t.Helper()
+
+	return libcnb.Layers{Path: t.TempDir()}
+}
+
+// Layer returns the layer with a name in the layers directory
+func Layer(t testing.TB, layers libcnb.Layers, name string) libcnb.Layer {
+	t.Helper()
+
+	layer, err := layers.Layer(name)
+	if err != nil {
+		t.Fatalf("unable to create layer %s\n%s", name, err)
+	}
+
+	return layer
+}
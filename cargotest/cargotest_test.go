@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testCargoTest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+	)
+
+	it.Before(func() {
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"my-app\"\nversion = \"0.1.0\"\n",
+			"Cargo.lock": "version = 3\n",
+		})
+		executor = &cargotest.Executor{}
+	})
+
+	it("runs the cargo runner against fake metadata", func() {
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).
+			WithMember("my-app", "0.1.0", ".", "my-app", "worker").
+			WithDependency("my-app", "serde", "1.0.0"))).To(Succeed())
+		executor.On("cargo", "build")
+
+		r := runner.NewCargoRunner(runner.WithExecutor(executor))
+
+		targets, err := r.ProjectTargets(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets).To(Equal([]string{"my-app", "worker"}))
+
+		Expect(r.BuildDependencies(appDir)).To(Succeed())
+		cargotest.AssertExecuted(t, executor, "cargo", "build", "-p", "serde@1.0.0")
+	})
+
+	it("installs scripted binaries into a layer", func() {
+		cargotest.CargoHome(t)
+		layer := cargotest.Layer(t, cargotest.Layers(t), "cargo")
+
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).WithMember("my-app", "0.1.0", ".", "my-app"))).To(Succeed())
+		executor.On("cargo", "install").Run = cargotest.InstallBinaries("my-app")
+
+		r := runner.NewCargoRunner(runner.WithExecutor(executor))
+		Expect(r.Install(appDir, layer)).To(Succeed())
+
+		cargotest.AssertExecuted(t, executor, "cargo", "install", fmt.Sprintf("--root=%s", layer.Path))
+		cargotest.AssertNotExecuted(t, executor, "cargo", "uninstall")
+		cargotest.AssertBinaries(t, layer, "my-app")
+	})
+
+	it("fails for executions without a script", func() {
+		r := runner.NewCargoRunner(runner.WithExecutor(executor))
+
+		_, err := r.CargoVersion()
+		Expect(err).To(MatchError(ContainSubstring("no script for cargo version")))
+	})
+}
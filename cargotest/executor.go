@@ -0,0 +1,173 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// Script is the scripted result of the executions of a command whose arguments start with the arguments of the script
+type Script struct {
+	Args    []string
+	Command string
+	Err     error
+	Run     func(execution effect.Execution) error
+	Stderr  string
+	Stdout  string
+}
+
+// Executor is an effect.Executor that replays scripts instead of running commands and records all executions.
+// Commands are matched by their base name, so that scripts match toolchains on any path, and the first matching
+// script wins.
+type Executor struct {
+	Executions []effect.Execution
+	Scripts    []*Script
+}
+
+// On adds a script for executions of a command whose arguments start with args and returns it to be configured, like
+// `executor.On("cargo", "install").Stdout = "..."`
+func (e *Executor) On(command string, args ...string) *Script {
+	s := &Script{Args: args, Command: command}
+	e.Scripts = append(e.Scripts, s)
+	return s
+}
+
+// OnMetadata adds a script that prints metadata for `cargo metadata`
+func (e *Executor) OnMetadata(m *Metadata) error {
+	out, err := m.JSON()
+	if err != nil {
+		return fmt.Errorf("unable to marshal metadata\n%w", err)
+	}
+
+	e.On("cargo", "metadata").Stdout = string(out)
+	return nil
+}
+
+// Execute runs the first script that matches the execution, which fails if there is none
+func (e *Executor) Execute(execution effect.Execution) error {
+	e.Executions = append(e.Executions, execution)
+
+	for _, s := range e.Scripts {
+		if !s.matches(execution) {
+			continue
+		}
+
+		if s.Stdout != "" && execution.Stdout != nil {
+			if _, err := execution.Stdout.Write([]byte(s.Stdout)); err != nil {
+				return fmt.Errorf("unable to write stdout\n%w", err)
+			}
+		}
+
+		if s.Stderr != "" && execution.Stderr != nil {
+			if _, err := execution.Stderr.Write([]byte(s.Stderr)); err != nil {
+				return fmt.Errorf("unable to write stderr\n%w", err)
+			}
+		}
+
+		if s.Run != nil {
+			if err := s.Run(execution); err != nil {
+				return err
+			}
+		}
+
+		return s.Err
+	}
+
+	return fmt.Errorf("no script for %s %s", execution.Command, strings.Join(execution.Args, " "))
+}
+
+func (s Script) matches(execution effect.Execution) bool {
+	if filepath.Base(execution.Command) != s.Command || len(execution.Args) < len(s.Args) {
+		return false
+	}
+
+	for i, arg := range s.Args {
+		if execution.Args[i] != arg {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InstallBinaries returns a script function that creates a binary for each name in the `--root` directory of
+// `cargo install`, like cargo does
+func InstallBinaries(names ...string) func(execution effect.Execution) error {
+	return func(execution effect.Execution) error {
+		var root string
+		for _, arg := range execution.Args {
+			if strings.HasPrefix(arg, "--root=") {
+				root = strings.TrimPrefix(arg, "--root=")
+			}
+		}
+
+		if root == "" {
+			return fmt.Errorf("no --root in %s", strings.Join(execution.Args, " "))
+		}
+
+		bin := filepath.Join(root, "bin")
+		if err := os.MkdirAll(bin, 0755); err != nil {
+			return fmt.Errorf("unable to create %s\n%w", bin, err)
+		}
+
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(bin, name), []byte(name), 0755); err != nil {
+				return fmt.Errorf("unable to write %s\n%w", name, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// BuildBinaries returns a script function that creates a binary for each name in the target directory of
+// `cargo build`, in the directory of the `--profile` and `--target` arguments, like cargo does
+func BuildBinaries(names ...string) func(execution effect.Execution) error {
+	return func(execution effect.Execution) error {
+		profile, triple := "release", ""
+		for _, arg := range execution.Args {
+			if strings.HasPrefix(arg, "--profile=") {
+				profile = strings.TrimPrefix(arg, "--profile=")
+			}
+			if strings.HasPrefix(arg, "--target=") {
+				triple = strings.TrimPrefix(arg, "--target=")
+			}
+		}
+
+		if profile == "dev" || profile == "test" {
+			profile = "debug"
+		}
+
+		out := filepath.Join(execution.Dir, "target", triple, profile)
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return fmt.Errorf("unable to create %s\n%w", out, err)
+		}
+
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(out, name), []byte(name), 0755); err != nil {
+				return fmt.Errorf("unable to write %s\n%w", name, err)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// UpdateGolden is the environment variable that rewrites golden files with the actual content, like
+// `UPDATE_GOLDEN=true go test ./...`, so that changes are reviewed as diffs of the golden files
+const UpdateGolden = "UPDATE_GOLDEN"
+
+// AssertGolden fails the test unless actual is equal to the content of a golden file
+func AssertGolden(t testing.TB, path string, actual string) {
+	t.Helper()
+
+	if update, _ := strconv.ParseBool(os.Getenv(UpdateGolden)); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create %s\n%s", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("unable to write %s\n%s", path, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file %s, run with %s=true to create it\n%s", path, UpdateGolden, err)
+	}
+
+	if string(expected) != actual {
+		t.Fatalf("%s does not match, run with %s=true to update it\nexpected:\n%s\nactual:\n%s", path, UpdateGolden, expected, actual)
+	}
+}
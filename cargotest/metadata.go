@@ -0,0 +1,177 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// Metadata builds the output of `cargo metadata --format-version=1` for a workspace rooted at a directory
+type Metadata struct {
+	Root     string
+	Packages []Package
+}
+
+// Package is a package in the metadata. Workspace members have a path relative to the root of the workspace, other
+// packages are dependencies from crates.io.
+type Package struct {
+	Binaries     []string
+	Dependencies []string
+	Member       bool
+	Name         string
+	Path         string
+	Version      string
+}
+
+// NewMetadata creates the metadata of a workspace rooted at a directory
+func NewMetadata(root string) *Metadata {
+	return &Metadata{Root: root}
+}
+
+// WithMember adds a workspace member at a path relative to the root, `.` for a single package, with binary targets
+func (m *Metadata) WithMember(name string, version string, path string, binaries ...string) *Metadata {
+	m.Packages = append(m.Packages, Package{Binaries: binaries, Member: true, Name: name, Path: path, Version: version})
+	return m
+}
+
+// WithDependency adds a crates.io dependency to a workspace member
+func (m *Metadata) WithDependency(member string, name string, version string) *Metadata {
+	for i, p := range m.Packages {
+		if p.Name == member {
+			m.Packages[i].Dependencies = append(m.Packages[i].Dependencies, name)
+		}
+	}
+
+	m.Packages = append(m.Packages, Package{Name: name, Version: version})
+	return m
+}
+
+// WithMemberDependency adds a path dependency of a workspace member on another member
+func (m *Metadata) WithMemberDependency(member string, dependency string) *Metadata {
+	for i, p := range m.Packages {
+		if p.Name == member {
+			m.Packages[i].Dependencies = append(m.Packages[i].Dependencies, dependency)
+		}
+	}
+
+	return m
+}
+
+// ID returns the package id of a package in the format of cargo 1.77+
+func (m Metadata) ID(p Package) string {
+	if p.Member {
+		return fmt.Sprintf("path+file://%s#%s@%s", filepath.Join(m.Root, p.Path), p.Name, p.Version)
+	}
+	return fmt.Sprintf("registry+https://github.com/rust-lang/crates.io-index#%s@%s", p.Name, p.Version)
+}
+
+// JSON returns the metadata as printed by cargo
+func (m Metadata) JSON() ([]byte, error) {
+	type target struct {
+		CrateTypes []string `json:"crate_types"`
+		Edition    string   `json:"edition"`
+		Kind       []string `json:"kind"`
+		Name       string   `json:"name"`
+		SrcPath    string   `json:"src_path"`
+	}
+
+	type pkg struct {
+		ID           string   `json:"id"`
+		ManifestPath string   `json:"manifest_path"`
+		Name         string   `json:"name"`
+		Targets      []target `json:"targets"`
+		Version      string   `json:"version"`
+	}
+
+	type depKind struct {
+		Kind   *string `json:"kind"`
+		Target *string `json:"target"`
+	}
+
+	type nodeDep struct {
+		DepKinds []depKind `json:"dep_kinds"`
+		Pkg      string    `json:"pkg"`
+	}
+
+	type node struct {
+		Deps []nodeDep `json:"deps"`
+		ID   string    `json:"id"`
+	}
+
+	type resolve struct {
+		Nodes []node `json:"nodes"`
+	}
+
+	out := struct {
+		Packages         []pkg    `json:"packages"`
+		Resolve          resolve  `json:"resolve"`
+		TargetDirectory  string   `json:"target_directory"`
+		Version          int      `json:"version"`
+		WorkspaceMembers []string `json:"workspace_members"`
+		WorkspaceRoot    string   `json:"workspace_root"`
+	}{
+		Packages:         []pkg{},
+		Resolve:          resolve{Nodes: []node{}},
+		TargetDirectory:  filepath.Join(m.Root, "target"),
+		Version:          1,
+		WorkspaceMembers: []string{},
+		WorkspaceRoot:    m.Root,
+	}
+
+	ids := map[string]string{}
+	for _, p := range m.Packages {
+		ids[p.Name] = m.ID(p)
+	}
+
+	for _, p := range m.Packages {
+		dir := filepath.Join(m.Root, p.Path)
+		if !p.Member {
+			dir = filepath.Join(m.Root, "registry", fmt.Sprintf("%s-%s", p.Name, p.Version))
+		}
+
+		targets := []target{}
+		for _, b := range p.Binaries {
+			src := filepath.Join(dir, "src", "bin", fmt.Sprintf("%s.rs", b))
+			if b == p.Name {
+				src = filepath.Join(dir, "src", "main.rs")
+			}
+			targets = append(targets, target{CrateTypes: []string{"bin"}, Edition: "2021", Kind: []string{"bin"}, Name: b, SrcPath: src})
+		}
+
+		out.Packages = append(out.Packages, pkg{
+			ID:           m.ID(p),
+			ManifestPath: filepath.Join(dir, "Cargo.toml"),
+			Name:         p.Name,
+			Targets:      targets,
+			Version:      p.Version,
+		})
+
+		deps := []nodeDep{}
+		for _, d := range p.Dependencies {
+			deps = append(deps, nodeDep{DepKinds: []depKind{{}}, Pkg: ids[d]})
+		}
+		out.Resolve.Nodes = append(out.Resolve.Nodes, node{Deps: deps, ID: m.ID(p)})
+
+		if p.Member {
+			out.WorkspaceMembers = append(out.WorkspaceMembers, m.ID(p))
+		}
+	}
+
+	return json.Marshal(out)
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// Recording is an execution captured by a Recorder
+type Recording struct {
+	Args    []string `json:"args"`
+	Command string   `json:"command"`
+	Dir     string   `json:"dir,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	Stderr  string   `json:"stderr,omitempty"`
+	Stdout  string   `json:"stdout,omitempty"`
+}
+
+// Recorder is an effect.Executor decorator that captures the executions of a delegate and their output, so that real
+// cargo output can be saved and replayed by a Replayer in hermetic tests.
+//
+// Placeholders map names, like `${APP}`, to paths that differ between machines. The paths are replaced by their name
+// in recordings and the names are replaced by their path when replayed.
+type Recorder struct {
+	Delegate     effect.Executor
+	Placeholders map[string]string
+	Recordings   []Recording
+}
+
+// Execute runs the execution with the delegate and records it
+func (r *Recorder) Execute(execution effect.Execution) error {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	recorded := execution
+	recorded.Stdout = tee(execution.Stdout, stdout)
+	recorded.Stderr = tee(execution.Stderr, stderr)
+
+	err := r.Delegate.Execute(recorded)
+
+	recording := Recording{
+		Args:    make([]string, len(execution.Args)),
+		Command: filepath.Base(execution.Command),
+		Dir:     replace(execution.Dir, r.Placeholders, false),
+		Stderr:  replace(stderr.String(), r.Placeholders, false),
+		Stdout:  replace(stdout.String(), r.Placeholders, false),
+	}
+	for i, a := range execution.Args {
+		recording.Args[i] = replace(a, r.Placeholders, false)
+	}
+	for _, e := range execution.Env {
+		recording.Env = append(recording.Env, replace(e, r.Placeholders, false))
+	}
+	if err != nil {
+		recording.Error = err.Error()
+	}
+
+	r.Recordings = append(r.Recordings, recording)
+	return err
+}
+
+// Save writes the recordings to a JSON file
+func (r *Recorder) Save(path string) error {
+	out, err := json.MarshalIndent(r.Recordings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal recordings\n%w", err)
+	}
+
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", path, err)
+	}
+
+	return nil
+}
+
+// Replayer is an effect.Executor that serves the output of recordings. Each execution is served by the first recording
+// with the same command and arguments that was not served yet, and fails if there is none.
+type Replayer struct {
+	Executions   []effect.Execution
+	Placeholders map[string]string
+	Recordings   []Recording
+
+	served map[int]bool
+}
+
+// LoadReplayer creates a Replayer for the recordings in a JSON file written by Recorder.Save
+func LoadReplayer(path string, placeholders map[string]string) (*Replayer, error) {
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	var recordings []Recording
+	if err := json.Unmarshal(in, &recordings); err != nil {
+		return nil, fmt.Errorf("unable to parse %s\n%w", path, err)
+	}
+
+	return &Replayer{Placeholders: placeholders, Recordings: recordings}, nil
+}
+
+// Execute writes the recorded output of the execution and returns its recorded error
+func (r *Replayer) Execute(execution effect.Execution) error {
+	r.Executions = append(r.Executions, execution)
+	if r.served == nil {
+		r.served = map[int]bool{}
+	}
+
+	for i, recording := range r.Recordings {
+		if r.served[i] || !r.matches(recording, execution) {
+			continue
+		}
+		r.served[i] = true
+
+		if recording.Stdout != "" && execution.Stdout != nil {
+			if _, err := io.WriteString(execution.Stdout, replace(recording.Stdout, r.Placeholders, true)); err != nil {
+				return fmt.Errorf("unable to write stdout\n%w", err)
+			}
+		}
+
+		if recording.Stderr != "" && execution.Stderr != nil {
+			if _, err := io.WriteString(execution.Stderr, replace(recording.Stderr, r.Placeholders, true)); err != nil {
+				return fmt.Errorf("unable to write stderr\n%w", err)
+			}
+		}
+
+		if recording.Error != "" {
+			return errors.New(recording.Error)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no recording for %s %s", execution.Command, strings.Join(execution.Args, " "))
+}
+
+func (r *Replayer) matches(recording Recording, execution effect.Execution) bool {
+	if recording.Command != filepath.Base(execution.Command) || len(recording.Args) != len(execution.Args) {
+		return false
+	}
+
+	for i, a := range recording.Args {
+		if replace(a, r.Placeholders, true) != execution.Args[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replace replaces the paths of placeholders with their names, or the names with their paths when expanding. Longer
+// paths are replaced first, so that nested paths keep their own placeholder.
+func replace(s string, placeholders map[string]string, expand bool) string {
+	names := make([]string, 0, len(placeholders))
+	for name := range placeholders {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(placeholders[names[i]]) > len(placeholders[names[j]])
+	})
+
+	for _, name := range names {
+		if placeholders[name] == "" {
+			continue
+		}
+
+		if expand {
+			s = strings.ReplaceAll(s, name, placeholders[name])
+		} else {
+			s = strings.ReplaceAll(s, placeholders[name], name)
+		}
+	}
+
+	return s
+}
+
+func tee(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(w, buf)
+}
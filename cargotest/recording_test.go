@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cargotest_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testRecording(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+	})
+
+	it("records and replays executions", func() {
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "version").Stdout = "cargo 1.81.0 (2dbb1af80 2024-08-20)\n"
+		executor.On("cargo", "install").Err = errors.New("exit status 101")
+		executor.On("cargo", "install").Stderr = "error: could not compile\n"
+
+		recorder := &cargotest.Recorder{Delegate: executor, Placeholders: map[string]string{"${APP}": appDir}}
+		r := runner.NewCargoRunner(runner.WithExecutor(recorder), runner.WithEnv(map[string]string{"SQLX_OFFLINE": "true"}))
+
+		_, err := r.CargoVersion()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Install(appDir, libcnb.Layer{Path: filepath.Join(appDir, "layer")})).NotTo(Succeed())
+
+		Expect(recorder.Recordings).To(HaveLen(2))
+		Expect(recorder.Recordings[1].Args).To(ContainElement("--root=${APP}/layer"))
+		Expect(recorder.Recordings[1].Dir).To(Equal("${APP}"))
+		Expect(recorder.Recordings[1].Env).To(ContainElement("SQLX_OFFLINE=true"))
+		Expect(recorder.Recordings[1].Error).To(Equal("exit status 101"))
+
+		path := filepath.Join(t.TempDir(), "recording.json")
+		Expect(recorder.Save(path)).To(Succeed())
+
+		otherDir := t.TempDir()
+		replayer, err := cargotest.LoadReplayer(path, map[string]string{"${APP}": otherDir})
+		Expect(err).NotTo(HaveOccurred())
+
+		r = runner.NewCargoRunner(runner.WithExecutor(replayer))
+
+		version, err := r.CargoVersion()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version.Version.String()).To(Equal("1.81.0"))
+
+		err = r.Install(otherDir, libcnb.Layer{Path: filepath.Join(otherDir, "layer")})
+		Expect(err).To(MatchError(ContainSubstring("exit status 101")))
+
+		_, err = r.CargoVersion()
+		Expect(err).To(MatchError(ContainSubstring("no recording for cargo version")))
+	})
+
+	it("replays captured cargo output", func() {
+		replayer, err := cargotest.LoadReplayer(filepath.Join("testdata", "single-package.json"), map[string]string{"${APP}": appDir})
+		Expect(err).NotTo(HaveOccurred())
+
+		r := runner.NewCargoRunner(runner.WithExecutor(replayer))
+
+		members, err := r.WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(HaveLen(1))
+		Expect(members[0].Path).To(Equal(appDir))
+
+		targets, err := r.ProjectTargets(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets).To(Equal([]string{"my-app", "worker"}))
+	})
+}
@@ -17,7 +17,10 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/buildpacks/libcnb"
 	"github.com/paketo-buildpacks/libpak/bard"
@@ -25,8 +28,12 @@ import (
 )
 
 func main() {
+	// the platform stops a build with SIGTERM, which terminates the running cargo command before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	libcnb.Main(
 		cargo.Detect{},
-		cargo.Build{Logger: bard.NewLogger(os.Stdout)},
+		cargo.Build{Context: ctx, Logger: bard.NewLogger(os.Stdout)},
 	)
 }
@@ -0,0 +1,414 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/heroku/color"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+const (
+	// DefaultBackoff is the time waited before the first retry of a failed download, it doubles with every retry
+	DefaultBackoff = time.Second
+
+	// DefaultRetries is the number of times a failed download is retried
+	DefaultRetries = 3
+)
+
+// Downloader downloads the dependencies of this buildpack. It honours the dependency mappings, mirrors and caches of
+// a libpak.DependencyCache, uses the proxy configured with HTTP_PROXY, HTTPS_PROXY and NO_PROXY, retries failed
+// downloads with a backoff, resumes interrupted downloads and verifies the SHA256 digest of every download.
+type Downloader struct {
+	AllowUnverified bool
+	Backoff         time.Duration
+	Cache           libpak.DependencyCache
+	Client          *http.Client
+	Logger          bard.Logger
+	Retries         int
+}
+
+// Option configures a Downloader
+type Option func(downloader Downloader) Downloader
+
+// WithAllowUnverified allows dependencies without a SHA256 digest to be downloaded unverified, by default they are
+// refused
+func WithAllowUnverified(allowUnverified bool) Option {
+	return func(downloader Downloader) Downloader {
+		downloader.AllowUnverified = allowUnverified
+		return downloader
+	}
+}
+
+// WithBackoff sets the time waited before the first retry of a failed download
+func WithBackoff(backoff time.Duration) Option {
+	return func(downloader Downloader) Downloader {
+		downloader.Backoff = backoff
+		return downloader
+	}
+}
+
+// WithClient sets the HTTP client, by default a client with the timeouts of the dependency cache is used
+func WithClient(client *http.Client) Option {
+	return func(downloader Downloader) Downloader {
+		downloader.Client = client
+		return downloader
+	}
+}
+
+// WithLogger sets the logger, by default the logger of the dependency cache is used
+func WithLogger(logger bard.Logger) Option {
+	return func(downloader Downloader) Downloader {
+		downloader.Logger = logger
+		return downloader
+	}
+}
+
+// WithRetries sets the number of times a failed download is retried
+func WithRetries(retries int) Option {
+	return func(downloader Downloader) Downloader {
+		downloader.Retries = retries
+		return downloader
+	}
+}
+
+// NewDownloader creates a Downloader for the dependencies of a dependency cache
+func NewDownloader(cache libpak.DependencyCache, options ...Option) Downloader {
+	downloader := Downloader{
+		Backoff: DefaultBackoff,
+		Cache:   cache,
+		Logger:  cache.Logger,
+		Retries: DefaultRetries,
+	}
+
+	for _, option := range options {
+		downloader = option(downloader)
+	}
+
+	return downloader
+}
+
+// Artifact returns the artifact of a dependency. Like libpak.DependencyCache.Artifact, artifacts cached by the
+// buildpack or downloaded earlier in the build are reused, and downloads are stored in the download path of the cache.
+func (d Downloader) Artifact(dependency libpak.BuildpackDependency) (*os.File, error) {
+	if dependency.SHA256 == "" && !d.AllowUnverified {
+		return nil, fmt.Errorf("refusing to download %s %s without a SHA256 digest", dependency.ID, dependency.Version)
+	}
+
+	uri, err := d.URI(dependency)
+	if err != nil {
+		return nil, err
+	}
+
+	// local files are copied by the dependency cache, there is nothing to retry or resume
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return d.Cache.Artifact(dependency)
+	}
+
+	if dependency.SHA256 == "" {
+		artifact := filepath.Join(d.Cache.DownloadPath, path.Base(uri.Path))
+		if err := d.download(uri, artifact, ""); err != nil {
+			return nil, fmt.Errorf("unable to download %s\n%w", uri.Redacted(), err)
+		}
+		return os.Open(artifact)
+	}
+
+	for _, dir := range []string{d.Cache.CachePath, d.Cache.DownloadPath} {
+		if cached, err := isCached(dir, dependency); err != nil {
+			return nil, err
+		} else if cached {
+			d.Logger.Bodyf("%s cached download", color.GreenString("Reusing"))
+			return os.Open(filepath.Join(dir, dependency.SHA256, path.Base(uri.Path)))
+		}
+	}
+
+	artifact := filepath.Join(d.Cache.DownloadPath, dependency.SHA256, path.Base(uri.Path))
+	if err := d.download(uri, artifact, dependency.SHA256); err != nil {
+		return nil, fmt.Errorf("unable to download %s\n%w", uri.Redacted(), err)
+	}
+
+	file := filepath.Join(d.Cache.DownloadPath, fmt.Sprintf("%s.toml", dependency.SHA256))
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file %s\n%w", file, err)
+	}
+	defer out.Close()
+
+	if err := toml.NewEncoder(out).Encode(dependency); err != nil {
+		return nil, fmt.Errorf("unable to write metadata %s\n%w", file, err)
+	}
+
+	return os.Open(artifact)
+}
+
+// URI returns the location a dependency is downloaded from, after applying the dependency-mapping bindings and the
+// dependency mirrors of the cache
+func (d Downloader) URI(dependency libpak.BuildpackDependency) (*url.URL, error) {
+	raw, mapped := dependency.URI, false
+	if mapping, ok := d.Cache.Mappings[dependency.SHA256]; ok && dependency.SHA256 != "" {
+		raw, mapped = mapping, true
+	}
+
+	uri, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse URI of %s %s\n%w", dependency.ID, dependency.Version, err)
+	}
+
+	// like the dependency cache, mappings take precedence over mirrors
+	if mapped {
+		return uri, nil
+	}
+
+	mirror := d.Cache.DependencyMirrors["default"]
+	if m := d.Cache.DependencyMirrors[uri.Hostname()]; m != "" {
+		mirror = m
+	}
+	if mirror == "" {
+		return uri, nil
+	}
+
+	return d.mirrored(uri, mirror), nil
+}
+
+// mirrored returns the URI on a mirror, configured as a URI or as `mirror=<uri>,skip-path=<prefix>`, where
+// `{originalHost}` in the mirror path is replaced by the host of the URI
+func (d Downloader) mirrored(uri *url.URL, mirror string) *url.URL {
+	base, skipPath := mirror, ""
+	for _, arg := range strings.Split(mirror, ",") {
+		key, value, ok := strings.Cut(arg, "=")
+		switch {
+		case !ok && (strings.HasPrefix(key, "https") || strings.HasPrefix(key, "file")):
+			base = key
+		case key == "mirror":
+			base = value
+		case key == "skip-path":
+			skipPath = value
+		}
+	}
+	if s, err := url.PathUnescape(base); err == nil {
+		base = s
+	}
+	if s, err := url.PathUnescape(skipPath); err == nil {
+		skipPath = s
+	}
+
+	override, err := url.ParseRequestURI(base)
+	if err != nil || (!strings.EqualFold(override.Scheme, "https") && !strings.EqualFold(override.Scheme, "file")) {
+		d.Logger.Bodyf("%s is ignored. Have you used one of the supported schemes https:// or file://?", color.YellowString("Invalid dependency mirror"))
+		return uri
+	}
+
+	m := *uri
+	m.Scheme = strings.ToLower(override.Scheme)
+	m.User = override.User
+	m.Host = override.Host
+	m.Path = strings.Replace(override.Path, "{originalHost}", uri.Hostname(), 1) + strings.Replace(uri.Path, skipPath, "", 1)
+	return &m
+}
+
+// permanentError is a failed download that is not retried, like a 404
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// download downloads a URI to a destination, retrying failed attempts. Downloads with a digest are verified and an
+// interrupted download is resumed by the next attempt.
+func (d Downloader) download(uri *url.URL, destination string, digest string) error {
+	partial := destination + ".partial"
+
+	// without a digest, a resumed download cannot be told apart from a corrupted one
+	if digest == "" {
+		if err := os.RemoveAll(partial); err != nil {
+			return fmt.Errorf("unable to remove %s\n%w", partial, err)
+		}
+	}
+
+	d.Logger.Bodyf("%s from %s", color.YellowString("Downloading"), uri.Redacted())
+	for attempt := 0; ; attempt++ {
+		err := d.fetch(uri, partial, digest != "")
+		if err == nil && digest != "" {
+			d.Logger.Body("Verifying checksum")
+			if err = verify(partial, digest); err != nil {
+				// a corrupted download must not be resumed
+				if rErr := os.Remove(partial); rErr != nil {
+					return fmt.Errorf("unable to remove %s\n%w", partial, rErr)
+				}
+			}
+		}
+
+		if err == nil {
+			break
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= d.Retries {
+			return err
+		}
+
+		wait := d.Backoff << attempt
+		d.Logger.Bodyf("%s in %s, attempt %d of %d failed: %s",
+			color.YellowString("Retrying"), wait, attempt+1, d.Retries+1, strings.SplitN(err.Error(), "\n", 2)[0])
+		time.Sleep(wait)
+	}
+
+	if err := os.Rename(partial, destination); err != nil {
+		return fmt.Errorf("unable to move %s to %s\n%w", partial, destination, err)
+	}
+
+	return nil
+}
+
+// fetch makes a single attempt to download a URI, appending to the destination if a partial download is resumed
+func (d Downloader) fetch(uri *url.URL, destination string, resume bool) error {
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return fmt.Errorf("unable to make directory %s\n%w", filepath.Dir(destination), err)
+	}
+
+	var offset int64
+	if resume {
+		if info, err := os.Stat(destination); err == nil {
+			offset = info.Size()
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("unable to stat %s\n%w", destination, err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, uri.String(), nil)
+	if err != nil {
+		return permanentError{fmt.Errorf("unable to create new GET request for %s\n%w", uri.Redacted(), err)}
+	}
+	if d.Cache.UserAgent != "" {
+		req.Header.Set("User-Agent", d.Cache.UserAgent)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to request %s\n%w", uri.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		d.Logger.Bodyf("Resuming download at %d bytes", offset)
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the partial download is already complete, or it is verified as corrupted and downloaded again
+		return nil
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("could not download %s: %d", uri.Redacted(), resp.StatusCode)
+	default:
+		return permanentError{fmt.Errorf("could not download %s: %d", uri.Redacted(), resp.StatusCode)}
+	}
+
+	out, err := os.OpenFile(destination, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open file %s\n%w", destination, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("unable to copy from %s to %s\n%w", uri.Redacted(), destination, err)
+	}
+
+	return nil
+}
+
+func (d Downloader) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+
+	timeouts := d.Cache.HttpClientTimeouts
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   timeouts.DialerTimeout,
+				KeepAlive: timeouts.DialerKeepAlive,
+			}).DialContext,
+			ExpectContinueTimeout: timeouts.ExpectContinueTimeout,
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: timeouts.ResponseHeaderTimeout,
+			TLSHandshakeTimeout:   timeouts.TLSHandshakeTimeout,
+		},
+	}
+}
+
+// isCached returns whether a dependency has been stored in a directory, as recorded by its `<sha256>.toml`
+func isCached(dir string, dependency libpak.BuildpackDependency) (bool, error) {
+	if dir == "" {
+		return false, nil
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("%s.toml", dependency.SHA256))
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+
+	var actual libpak.BuildpackDependency
+	if err := toml.Unmarshal(b, &actual); err != nil {
+		return false, fmt.Errorf("unable to decode download metadata %s\n%w", file, err)
+	}
+
+	return dependency.Equals(actual), nil
+}
+
+func verify(path string, expected string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to verify %s\n%w", path, err)
+	}
+	defer in.Close()
+
+	s := sha256.New()
+	if _, err := io.Copy(s, in); err != nil {
+		return fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	if actual := hex.EncodeToString(s.Sum(nil)); actual != expected {
+		return fmt.Errorf("sha256 for %s %s does not match expected %s", filepath.Base(path), actual, expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/download"
+	"github.com/sclevine/spec"
+)
+
+func testDownloader(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		content    = "test-artifact-content"
+		digest     string
+		dependency libpak.BuildpackDependency
+		downloader download.Downloader
+		handler    func(w http.ResponseWriter, r *http.Request)
+		log        bytes.Buffer
+		mutex      sync.Mutex
+		requests   []*http.Request
+		server     *httptest.Server
+	)
+
+	it.Before(func() {
+		s := sha256.Sum256([]byte(content))
+		digest = hex.EncodeToString(s[:])
+
+		requests = nil
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(content))
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			requests = append(requests, r)
+			mutex.Unlock()
+			handler(w, r)
+		}))
+
+		dependency = libpak.BuildpackDependency{
+			ID:      "test-id",
+			Name:    "test-name",
+			Version: "1.1.1",
+			URI:     server.URL + "/test-path/test-artifact",
+			SHA256:  digest,
+		}
+
+		log.Reset()
+		downloader = download.NewDownloader(
+			libpak.DependencyCache{CachePath: t.TempDir(), DownloadPath: t.TempDir(), UserAgent: "test-agent"},
+			download.WithBackoff(0),
+			download.WithLogger(bard.NewLogger(&log)))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	read := func(f *os.File, err error) string {
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+
+		b, err := io.ReadAll(f)
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	it("downloads and verifies a dependency", func() {
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Header.Get("User-Agent")).To(Equal("test-agent"))
+		Expect(filepath.Join(downloader.Cache.DownloadPath, digest, "test-artifact")).To(BeARegularFile())
+		Expect(filepath.Join(downloader.Cache.DownloadPath, digest+".toml")).To(BeARegularFile())
+		Expect(log.String()).To(ContainSubstring("Verifying checksum"))
+	})
+
+	it("reuses a previous download", func() {
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+
+		Expect(requests).To(HaveLen(1))
+		Expect(log.String()).To(ContainSubstring("cached download"))
+	})
+
+	it("reuses the download cached by the buildpack", func() {
+		Expect(os.MkdirAll(filepath.Join(downloader.Cache.CachePath, digest), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(downloader.Cache.CachePath, digest, "test-artifact"), []byte("cached"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(downloader.Cache.CachePath, digest+".toml"), []byte(fmt.Sprintf(
+			"id = \"test-id\"\nname = \"test-name\"\nversion = \"1.1.1\"\nuri = %q\nsha256 = %q\n", dependency.URI, digest)), 0644)).To(Succeed())
+
+		Expect(read(downloader.Artifact(dependency))).To(Equal("cached"))
+		Expect(requests).To(BeEmpty())
+	})
+
+	it("retries failed downloads", func() {
+		attempts := 0
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if attempts++; attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		}
+
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+		Expect(requests).To(HaveLen(3))
+		Expect(log.String()).To(ContainSubstring("attempt 2 of 4 failed"))
+	})
+
+	it("gives up after the configured retries", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		downloader = download.NewDownloader(downloader.Cache, download.WithBackoff(0), download.WithRetries(1))
+
+		_, err := downloader.Artifact(dependency)
+		Expect(err).To(MatchError(ContainSubstring("could not download")))
+		Expect(err).To(MatchError(ContainSubstring("502")))
+		Expect(requests).To(HaveLen(2))
+	})
+
+	it("does not retry missing artifacts", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+		_, err := downloader.Artifact(dependency)
+		Expect(err).To(MatchError(ContainSubstring("404")))
+		Expect(requests).To(HaveLen(1))
+	})
+
+	it("resumes an interrupted download", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "" {
+				w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+				_, _ = w.Write([]byte(content[:5]))
+				return
+			}
+
+			var offset int
+			_, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset)
+			Expect(err).NotTo(HaveOccurred())
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte(content[offset:]))
+		}
+
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].Header.Get("Range")).To(Equal("bytes=5-"))
+		Expect(log.String()).To(ContainSubstring("Resuming download at 5 bytes"))
+	})
+
+	it("downloads again when the checksum does not match", func() {
+		attempts := 0
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if attempts++; attempts == 1 {
+				_, _ = w.Write([]byte("corrupted"))
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		}
+
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].Header.Get("Range")).To(BeEmpty())
+		Expect(log.String()).To(ContainSubstring("does not match expected"))
+	})
+
+	it("fails when the checksum never matches", func() {
+		dependency.SHA256 = strings.Repeat("0", 64)
+
+		_, err := downloader.Artifact(dependency)
+		Expect(err).To(MatchError(ContainSubstring("does not match expected " + dependency.SHA256)))
+		Expect(requests).To(HaveLen(download.DefaultRetries + 1))
+	})
+
+	it("refuses dependencies without a digest", func() {
+		dependency.SHA256 = ""
+
+		_, err := downloader.Artifact(dependency)
+		Expect(err).To(MatchError("refusing to download test-id 1.1.1 without a SHA256 digest"))
+		Expect(requests).To(BeEmpty())
+	})
+
+	it("downloads dependencies without a digest when allowed", func() {
+		dependency.SHA256 = ""
+		downloader = download.NewDownloader(downloader.Cache, download.WithAllowUnverified(true))
+
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+		Expect(read(downloader.Artifact(dependency))).To(Equal(content))
+		Expect(requests).To(HaveLen(2))
+	})
+
+	context("URI", func() {
+		it("returns the URI of the dependency", func() {
+			uri, err := downloader.URI(dependency)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uri.String()).To(Equal(dependency.URI))
+		})
+
+		it("uses a dependency mapping", func() {
+			downloader.Cache.Mappings = map[string]string{digest: "https://mapped.example.com/artifact"}
+			downloader.Cache.DependencyMirrors = map[string]string{"default": "https://mirror.example.com"}
+
+			uri, err := downloader.URI(dependency)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uri.String()).To(Equal("https://mapped.example.com/artifact"))
+		})
+
+		it("uses the default mirror", func() {
+			dependency.URI = "https://github.com/krallin/tini/releases/download/v0.19.0/tini"
+			downloader.Cache.DependencyMirrors = map[string]string{"default": "https://mirror.example.com/{originalHost}"}
+
+			uri, err := downloader.URI(dependency)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uri.String()).To(Equal("https://mirror.example.com/github.com/krallin/tini/releases/download/v0.19.0/tini"))
+		})
+
+		it("uses a host specific mirror and skips a path prefix", func() {
+			dependency.URI = "https://github.com/krallin/tini/releases/download/v0.19.0/tini"
+			downloader.Cache.DependencyMirrors = map[string]string{
+				"default":    "https://mirror.example.com",
+				"github.com": "mirror=https://github.example.com/releases,skip-path=/krallin",
+			}
+
+			uri, err := downloader.URI(dependency)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uri.String()).To(Equal("https://github.example.com/releases/tini/releases/download/v0.19.0/tini"))
+		})
+
+		it("ignores a mirror with an unsupported scheme", func() {
+			downloader.Cache.DependencyMirrors = map[string]string{"default": "ftp://mirror.example.com"}
+
+			uri, err := downloader.URI(dependency)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uri.String()).To(Equal(dependency.URI))
+			Expect(log.String()).To(ContainSubstring("Invalid dependency mirror"))
+		})
+	})
+}
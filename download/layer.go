@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sbom"
+)
+
+// DependencyLayerContributor contributes a dependency to a layer like libpak.DependencyLayerContributor, with the
+// artifact downloaded by a Downloader
+type DependencyLayerContributor struct {
+	Dependency       libpak.BuildpackDependency
+	Downloader       Downloader
+	ExpectedMetadata interface{}
+	ExpectedTypes    libcnb.LayerTypes
+	Logger           bard.Logger
+}
+
+// NewDependencyLayerContributor creates a contributor whose layer is reused as long as the dependency is unchanged
+func NewDependencyLayerContributor(dependency libpak.BuildpackDependency, downloader Downloader, types libcnb.LayerTypes) DependencyLayerContributor {
+	return DependencyLayerContributor{
+		Dependency:       dependency,
+		Downloader:       downloader,
+		ExpectedMetadata: dependency,
+		ExpectedTypes:    types,
+	}
+}
+
+// Contribute downloads the dependency and calls f with the artifact, unless the layer can be reused
+func (d DependencyLayerContributor) Contribute(layer libcnb.Layer, f libpak.DependencyLayerFunc) (libcnb.Layer, error) {
+	lc := libpak.NewLayerContributor(d.Name(), d.ExpectedMetadata, d.ExpectedTypes)
+	lc.Logger = d.Logger
+
+	return lc.Contribute(layer, func() (libcnb.Layer, error) {
+		artifact, err := d.Downloader.Artifact(d.Dependency)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to get dependency %s\n%w", d.Dependency.Name, err)
+		}
+		defer artifact.Close()
+
+		sbomArtifact, err := d.Dependency.AsSyftArtifact()
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to get SBOM artifact %s\n%w", d.Dependency.ID, err)
+		}
+
+		sbomPath := layer.SBOMPath(libcnb.SyftJSON)
+		if err := sbom.NewSyftDependency(layer.Path, []sbom.SyftArtifact{sbomArtifact}).WriteTo(sbomPath); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to write SBOM\n%w", err)
+		}
+
+		return f(artifact)
+	})
+}
+
+// LayerName returns the name of the layer, the ID of the dependency
+func (d DependencyLayerContributor) LayerName() string {
+	return d.Dependency.ID
+}
+
+// Name returns the human readable name of the layer
+func (d DependencyLayerContributor) Name() string {
+	return fmt.Sprintf("%s %s", d.Dependency.Name, d.Dependency.Version)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-community/cargo/download"
+	"github.com/sclevine/spec"
+)
+
+func testDependencyLayerContributor(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dependency  libpak.BuildpackDependency
+		contributor download.DependencyLayerContributor
+		layer       libcnb.Layer
+	)
+
+	it.Before(func() {
+		var err error
+
+		dependency = libpak.BuildpackDependency{
+			ID:      "test-id",
+			Name:    "test-name",
+			Version: "1.1.1",
+			URI:     "https://localhost/stub-dependency",
+			SHA256:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		}
+
+		contributor = download.NewDependencyLayerContributor(dependency,
+			download.NewDownloader(libpak.DependencyCache{CachePath: "testdata"}),
+			libcnb.LayerTypes{Launch: true})
+
+		layers := libcnb.Layers{Path: t.TempDir()}
+		layer, err = layers.Layer(contributor.LayerName())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it("contributes the artifact of the dependency", func() {
+		called := false
+		layer, err := contributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
+			called = true
+			Expect(filepath.Base(artifact.Name())).To(Equal("stub-dependency"))
+			return layer, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(called).To(BeTrue())
+		Expect(layer.Launch).To(BeTrue())
+		Expect(layer.Metadata).To(HaveKeyWithValue("id", "test-id"))
+		Expect(layer.SBOMPath(libcnb.SyftJSON)).To(BeARegularFile())
+	})
+
+	it("reuses a layer with the same dependency", func() {
+		layer, err := contributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
+			return layer, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		called := false
+		_, err = contributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
+			called = true
+			return layer, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(called).To(BeFalse())
+	})
+
+	it("names the layer after the dependency", func() {
+		Expect(contributor.LayerName()).To(Equal("test-id"))
+		Expect(contributor.Name()).To(Equal("test-name 1.1.1"))
+	})
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package helper contains the exec.d helpers that run when the application container starts
+package helper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+// ProvenancePathEnv is the launch variable holding the path of the build provenance recorded at build time
+const ProvenancePathEnv = "BPI_CARGO_PROVENANCE"
+
+// BuildProvenance describes how the application binaries were built
+type BuildProvenance struct {
+	AppVersion   string `toml:"app-version"`
+	BuildTime    string `toml:"build-time"`
+	CargoVersion string `toml:"cargo-version"`
+	GitSHA       string `toml:"git-sha"`
+	RustVersion  string `toml:"rust-version"`
+}
+
+// Provenance logs the build provenance when the container starts, so that it is known exactly what is running
+type Provenance struct {
+	Logger bard.Logger
+}
+
+// Execute logs the build provenance, it does not change the environment
+func (p Provenance) Execute() (map[string]string, error) {
+	path, ok := os.LookupEnv(ProvenancePathEnv)
+	if !ok {
+		return nil, nil
+	}
+
+	var b BuildProvenance
+	if _, err := toml.DecodeFile(path, &b); err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+
+	p.Logger.Infof("Build provenance: version %s, git %s, %s, %s, built at %s",
+		orUnknown(b.AppVersion), orUnknown(b.GitSHA), orUnknown(b.RustVersion), orUnknown(b.CargoVersion), orUnknown(b.BuildTime))
+
+	return nil, nil
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helper_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/helper"
+	"github.com/sclevine/spec"
+)
+
+func testProvenance(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf *bytes.Buffer
+		p   helper.Provenance
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+		p = helper.Provenance{Logger: bard.NewLogger(buf)}
+	})
+
+	it("does nothing without recorded provenance", func() {
+		Expect(p.Execute()).To(BeNil())
+		Expect(buf.String()).To(BeEmpty())
+	})
+
+	it("logs the recorded provenance", func() {
+		path := filepath.Join(t.TempDir(), "provenance.toml")
+		Expect(os.WriteFile(path, []byte(`app-version = "1.2.3"
+build-time = "2024-05-01T10:00:00Z"
+cargo-version = "cargo 1.78.0"
+git-sha = "0123456789abcdef"
+rust-version = "rustc 1.78.0"
+`), 0644)).To(Succeed())
+		t.Setenv(helper.ProvenancePathEnv, path)
+
+		Expect(p.Execute()).To(BeNil())
+		Expect(buf.String()).To(ContainSubstring("Build provenance: version 1.2.3, git 0123456789abcdef, rustc 1.78.0, cargo 1.78.0, built at 2024-05-01T10:00:00Z"))
+	})
+
+	it("marks missing values as unknown", func() {
+		path := filepath.Join(t.TempDir(), "provenance.toml")
+		Expect(os.WriteFile(path, []byte(`app-version = "1.2.3"`), 0644)).To(Succeed())
+		t.Setenv(helper.ProvenancePathEnv, path)
+
+		Expect(p.Execute()).To(BeNil())
+		Expect(buf.String()).To(ContainSubstring("version 1.2.3, git unknown"))
+	})
+}
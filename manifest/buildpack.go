@@ -0,0 +1,93 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// BuildpackKey is the key of the tables of [package.metadata] and [workspace.metadata] that configure the buildpack
+const BuildpackKey = "cargo-buildpack"
+
+// BuildpackConfiguration is a [package.metadata.cargo-buildpack] or [workspace.metadata.cargo-buildpack] table
+type BuildpackConfiguration struct {
+	Features          []string `toml:"features"`
+	InstallArgs       string   `toml:"install-args"`
+	NoDefaultFeatures *bool    `toml:"no-default-features"`
+	StaticType        string   `toml:"static-type"`
+	WorkspaceMembers  []string `toml:"workspace-members"`
+}
+
+// LoadBuildpackConfiguration reads the configuration of the buildpack from the manifest at path. The values of the
+// package table take precedence over those of the workspace table. Returns an empty configuration if the manifest
+// does not exist, and fails for unknown keys, so that typos do not go unnoticed.
+func LoadBuildpackConfiguration(path string) (BuildpackConfiguration, error) {
+	var m struct {
+		Package struct {
+			Metadata struct {
+				Buildpack BuildpackConfiguration `toml:"cargo-buildpack"`
+			} `toml:"metadata"`
+		} `toml:"package"`
+		Workspace struct {
+			Metadata struct {
+				Buildpack BuildpackConfiguration `toml:"cargo-buildpack"`
+			} `toml:"metadata"`
+		} `toml:"workspace"`
+	}
+
+	md, err := toml.DecodeFile(path, &m)
+	if os.IsNotExist(err) {
+		return BuildpackConfiguration{}, nil
+	} else if err != nil {
+		return BuildpackConfiguration{}, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		if len(key) > 3 && (key[0] == "package" || key[0] == "workspace") && key[1] == "metadata" && key[2] == BuildpackKey {
+			unknown = append(unknown, key.String())
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return BuildpackConfiguration{}, fmt.Errorf("unknown keys in %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	c, w := m.Package.Metadata.Buildpack, m.Workspace.Metadata.Buildpack
+	if c.Features == nil {
+		c.Features = w.Features
+	}
+	if c.InstallArgs == "" {
+		c.InstallArgs = w.InstallArgs
+	}
+	if c.NoDefaultFeatures == nil {
+		c.NoDefaultFeatures = w.NoDefaultFeatures
+	}
+	if c.StaticType == "" {
+		c.StaticType = w.StaticType
+	}
+	if c.WorkspaceMembers == nil {
+		c.WorkspaceMembers = w.WorkspaceMembers
+	}
+
+	return c, nil
+}
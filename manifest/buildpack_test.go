@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/manifest"
+	"github.com/sclevine/spec"
+)
+
+func testBuildpack(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "Cargo.toml")
+	})
+
+	it("reads the configuration of a package", func() {
+		Expect(os.WriteFile(path, []byte(`
+[package]
+name = "app"
+version = "1.2.3"
+
+[package.metadata.cargo-buildpack]
+features = ["postgres", "tls"]
+install-args = "--locked"
+static-type = "muslc"
+`), 0644)).To(Succeed())
+
+		Expect(manifest.LoadBuildpackConfiguration(path)).To(Equal(manifest.BuildpackConfiguration{
+			Features:    []string{"postgres", "tls"},
+			InstallArgs: "--locked",
+			StaticType:  "muslc",
+		}))
+	})
+
+	it("prefers the package table over the workspace table", func() {
+		Expect(os.WriteFile(path, []byte(`
+[package]
+name = "app"
+
+[package.metadata.cargo-buildpack]
+install-args = "--locked --offline"
+
+[workspace]
+members = ["api", "worker"]
+
+[workspace.metadata.cargo-buildpack]
+install-args = "--locked"
+workspace-members = ["api"]
+`), 0644)).To(Succeed())
+
+		Expect(manifest.LoadBuildpackConfiguration(path)).To(Equal(manifest.BuildpackConfiguration{
+			InstallArgs:      "--locked --offline",
+			WorkspaceMembers: []string{"api"},
+		}))
+	})
+
+	it("returns an empty configuration without a manifest", func() {
+		Expect(manifest.LoadBuildpackConfiguration(path)).To(BeZero())
+	})
+
+	it("fails for unknown keys", func() {
+		Expect(os.WriteFile(path, []byte(`
+[package]
+name = "app"
+
+[package.metadata.docs]
+all-features = true
+
+[package.metadata.cargo-buildpack]
+feature = ["postgres"]
+`), 0644)).To(Succeed())
+
+		_, err := manifest.LoadBuildpackConfiguration(path)
+		Expect(err).To(MatchError(ContainSubstring("unknown keys in")))
+		Expect(err).To(MatchError(ContainSubstring("package.metadata.cargo-buildpack.feature")))
+	})
+}
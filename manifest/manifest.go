@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package manifest reads Cargo.toml files without invoking cargo, which needs a toolchain and possibly network
+// access, so that manifests can be inspected during detection.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Manifest is a Cargo.toml file
+type Manifest struct {
+	Bins          []Target            `toml:"bin"`
+	CargoFeatures []string            `toml:"cargo-features"`
+	Features      map[string][]string `toml:"features"`
+	Lib           *Target             `toml:"lib"`
+	Package       *Package            `toml:"package"`
+	Workspace     *Workspace          `toml:"workspace"`
+}
+
+// Package is the [package] table of a manifest
+type Package struct {
+	Autobins    *bool                  `toml:"autobins"`
+	Edition     Inheritable            `toml:"edition"`
+	Metadata    map[string]interface{} `toml:"metadata"`
+	Name        string                 `toml:"name"`
+	RustVersion Inheritable            `toml:"rust-version"`
+	Version     Inheritable            `toml:"version"`
+}
+
+// Workspace is the [workspace] table of a manifest
+type Workspace struct {
+	DefaultMembers []string               `toml:"default-members"`
+	Exclude        []string               `toml:"exclude"`
+	Members        []string               `toml:"members"`
+	Metadata       map[string]interface{} `toml:"metadata"`
+	Package        *WorkspacePackage      `toml:"package"`
+}
+
+// WorkspacePackage is the [workspace.package] table, with the values that members can inherit
+type WorkspacePackage struct {
+	Edition     string `toml:"edition"`
+	RustVersion string `toml:"rust-version"`
+	Version     string `toml:"version"`
+}
+
+// Target is a [lib] or [[bin]] table
+type Target struct {
+	Name             string   `toml:"name"`
+	Path             string   `toml:"path"`
+	RequiredFeatures []string `toml:"required-features"`
+}
+
+// Inheritable is a package value that is either set or inherited from the workspace with `{ workspace = true }`
+type Inheritable struct {
+	Value     string
+	Workspace bool
+}
+
+func (i *Inheritable) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		i.Value = v
+	case map[string]interface{}:
+		w, ok := v["workspace"].(bool)
+		if !ok {
+			return fmt.Errorf("expected a string or { workspace = true }, found %v", data)
+		}
+		i.Workspace = w
+	default:
+		return fmt.Errorf("expected a string or { workspace = true }, found %v", data)
+	}
+
+	return nil
+}
+
+// Load reads the manifest at path
+func Load(path string) (Manifest, error) {
+	var m Manifest
+	if _, err := toml.DecodeFile(path, &m); err != nil {
+		return Manifest{}, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+
+	return m, nil
+}
+
+// Version returns the version of the package, resolved against the workspace if it is inherited. Returns an empty
+// string if the manifest has no package or no version.
+func (m Manifest) Version(workspace Manifest) string {
+	if m.Package == nil {
+		return ""
+	}
+
+	if m.Package.Version.Workspace {
+		if workspace.Workspace == nil || workspace.Workspace.Package == nil {
+			return ""
+		}
+		return workspace.Workspace.Package.Version
+	}
+
+	return m.Package.Version.Value
+}
+
+// RustVersion returns the minimum supported Rust version of the package, resolved against the workspace if it is
+// inherited. Returns the rust-version of the workspace if the manifest has no package, or an empty string if none is
+// set.
+func (m Manifest) RustVersion(workspace Manifest) string {
+	if m.Package == nil || m.Package.RustVersion.Workspace {
+		if workspace.Workspace == nil || workspace.Workspace.Package == nil {
+			return ""
+		}
+		return workspace.Workspace.Package.RustVersion
+	}
+
+	return m.Package.RustVersion.Value
+}
+
+// Binaries returns the names of the binary targets of the package in dir, including the targets that cargo discovers
+// from src/main.rs and src/bin
+func (m Manifest) Binaries(dir string) ([]string, error) {
+	if m.Package == nil {
+		return nil, nil
+	}
+
+	names := map[string]bool{}
+	for _, b := range m.Bins {
+		if b.Name != "" {
+			names[b.Name] = true
+		}
+	}
+
+	if m.Package.Autobins == nil || *m.Package.Autobins {
+		if _, err := os.Stat(filepath.Join(dir, "src", "main.rs")); err == nil {
+			names[m.Package.Name] = true
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("unable to stat src/main.rs\n%w", err)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(dir, "src", "bin"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("unable to read src/bin\n%w", err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				if _, err := os.Stat(filepath.Join(dir, "src", "bin", e.Name(), "main.rs")); err == nil {
+					names[e.Name()] = true
+				}
+			} else if strings.HasSuffix(e.Name(), ".rs") {
+				names[strings.TrimSuffix(e.Name(), ".rs")] = true
+			}
+		}
+	}
+
+	var binaries []string
+	for n := range names {
+		binaries = append(binaries, n)
+	}
+	sort.Strings(binaries)
+
+	return binaries, nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/manifest"
+	"github.com/sclevine/spec"
+)
+
+func testManifest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+	)
+
+	it.Before(func() {
+		dir = t.TempDir()
+	})
+
+	it("loads a package manifest", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(`
+[package]
+name = "app"
+version = "1.2.3"
+edition = "2021"
+
+[package.metadata.docs]
+all-features = true
+
+[features]
+default = ["json"]
+json = []
+
+[[bin]]
+name = "server"
+path = "src/server.rs"
+required-features = ["json"]
+`), 0644)).To(Succeed())
+
+		m, err := manifest.Load(filepath.Join(dir, "Cargo.toml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(m.Package.Name).To(Equal("app"))
+		Expect(m.Package.Edition).To(Equal(manifest.Inheritable{Value: "2021"}))
+		Expect(m.Package.Metadata).To(HaveKey("docs"))
+		Expect(m.Version(manifest.Manifest{})).To(Equal("1.2.3"))
+		Expect(m.Features).To(Equal(map[string][]string{"default": {"json"}, "json": {}}))
+		Expect(m.Bins).To(Equal([]manifest.Target{{Name: "server", Path: "src/server.rs", RequiredFeatures: []string{"json"}}}))
+	})
+
+	it("resolves versions inherited from the workspace", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(`
+[workspace]
+members = ["member"]
+
+[workspace.package]
+version = "2.0.0"
+`), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "member"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "member", "Cargo.toml"), []byte(`
+[package]
+name = "member"
+version.workspace = true
+`), 0644)).To(Succeed())
+
+		workspace, err := manifest.Load(filepath.Join(dir, "Cargo.toml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(workspace.Workspace.Members).To(Equal([]string{"member"}))
+
+		member, err := manifest.Load(filepath.Join(dir, "member", "Cargo.toml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(member.Package.Version).To(Equal(manifest.Inheritable{Workspace: true}))
+		Expect(member.Version(workspace)).To(Equal("2.0.0"))
+	})
+
+	it("resolves rust versions inherited from the workspace", func() {
+		workspace := manifest.Manifest{Workspace: &manifest.Workspace{Package: &manifest.WorkspacePackage{RustVersion: "1.74"}}}
+
+		Expect(workspace.RustVersion(workspace)).To(Equal("1.74"))
+		Expect(manifest.Manifest{Package: &manifest.Package{RustVersion: manifest.Inheritable{Workspace: true}}}.RustVersion(workspace)).To(Equal("1.74"))
+		Expect(manifest.Manifest{Package: &manifest.Package{RustVersion: manifest.Inheritable{Value: "1.78"}}}.RustVersion(workspace)).To(Equal("1.78"))
+		Expect(manifest.Manifest{Package: &manifest.Package{}}.RustVersion(manifest.Manifest{})).To(BeEmpty())
+	})
+
+	it("discovers binaries", func() {
+		Expect(os.MkdirAll(filepath.Join(dir, "src", "bin", "tool"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "src", "main.rs"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "src", "bin", "migrate.rs"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "src", "bin", "tool", "main.rs"), []byte{}, 0644)).To(Succeed())
+
+		m := manifest.Manifest{
+			Package: &manifest.Package{Name: "app"},
+			Bins:    []manifest.Target{{Name: "server"}},
+		}
+		Expect(m.Binaries(dir)).To(Equal([]string{"app", "migrate", "server", "tool"}))
+
+		autobins := false
+		m.Package.Autobins = &autobins
+		Expect(m.Binaries(dir)).To(Equal([]string{"server"}))
+	})
+
+	it("fails on invalid manifests", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]\nversion = 1\n"), 0644)).To(Succeed())
+
+		_, err := manifest.Load(filepath.Join(dir, "Cargo.toml"))
+		Expect(err).To(MatchError(ContainSubstring("unable to decode")))
+	})
+}
@@ -0,0 +1,29 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import "regexp"
+
+// ansiPattern matches the escape sequences of colored output, like `\x1b[1m\x1b[32m`, and the hyperlinks cargo writes
+// to terminals that support them
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// stripANSI removes the escape sequences of colored output, so that the output of cargo with `--color=always` can be
+// matched like its plain output
+func stripANSI(b []byte) []byte {
+	return ansiPattern.ReplaceAll(b, nil)
+}
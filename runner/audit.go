@@ -0,0 +1,323 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	// SeverityLow is the severity of vulnerabilities with a CVSS score below 4.0
+	SeverityLow = "low"
+
+	// SeverityMedium is the severity of vulnerabilities with a CVSS score from 4.0 to 6.9
+	SeverityMedium = "medium"
+
+	// SeverityHigh is the severity of vulnerabilities with a CVSS score from 7.0 to 8.9
+	SeverityHigh = "high"
+
+	// SeverityCritical is the severity of vulnerabilities with a CVSS score of 9.0 or more
+	SeverityCritical = "critical"
+
+	// SeverityUnknown is the severity of vulnerabilities without a CVSS v3 vector, which cannot be ranked
+	SeverityUnknown = "unknown"
+)
+
+// Severities are the severity thresholds that a build can fail on, from the lowest to the highest
+var Severities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// Vulnerability is an advisory of the RustSec database that affects a locked package
+type Vulnerability struct {
+	ID       string
+	Package  string
+	Patched  []string
+	Score    float64
+	Severity string
+	Title    string
+	URL      string
+	Version  string
+}
+
+func (v Vulnerability) String() string {
+	return fmt.Sprintf("%s (%s %s, %s)", v.ID, v.Package, v.Version, v.Severity)
+}
+
+// AuditWarning is an informational finding of cargo-audit, like an unmaintained or yanked package, that is not a
+// vulnerability
+type AuditWarning struct {
+	ID      string
+	Kind    string
+	Package string
+	Version string
+}
+
+// AuditReport is the result of auditing Cargo.lock against the RustSec advisory database
+type AuditReport struct {
+	Dependencies    int
+	Vulnerabilities []Vulnerability
+	Warnings        []AuditWarning
+}
+
+// AtOrAbove returns the vulnerabilities with a severity of threshold or higher. Vulnerabilities of unknown severity
+// are always returned, since they cannot be ranked, unless they are ignored with WithAuditIgnore.
+func (r AuditReport) AtOrAbove(threshold string) []Vulnerability {
+	rank := severityRank(threshold)
+
+	var found []Vulnerability
+	for _, v := range r.Vulnerabilities {
+		if v.Severity == SeverityUnknown || severityRank(v.Severity) >= rank {
+			found = append(found, v)
+		}
+	}
+	return found
+}
+
+// ValidateSeverity returns an error if severity is not one of Severities
+func ValidateSeverity(severity string) error {
+	if severityRank(severity) < 0 {
+		return fmt.Errorf("unsupported severity %q, use one of %s", severity, strings.Join(Severities, ", "))
+	}
+	return nil
+}
+
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// SeverityOf returns the severity of a CVSS base score
+func SeverityOf(score float64) string {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// CVSSScore returns the base score of a CVSS v3.0 or v3.1 vector, like
+// `CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H`. Other versions are not supported.
+func CVSSScore(vector string) (float64, error) {
+	parts := strings.Split(vector, "/")
+	if parts[0] != "CVSS:3.0" && parts[0] != "CVSS:3.1" {
+		return 0, fmt.Errorf("unsupported CVSS vector %q", vector)
+	}
+
+	metrics := map[string]string{}
+	for _, p := range parts[1:] {
+		name, value, ok := strings.Cut(p, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid metric %q in CVSS vector %q", p, vector)
+		}
+		metrics[name] = value
+	}
+
+	changed := metrics["S"] == "C"
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	impacts := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+
+	weights := []struct {
+		metric  string
+		weights map[string]float64
+	}{
+		{"AV", map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}},
+		{"AC", map[string]float64{"L": 0.77, "H": 0.44}},
+		{"PR", privileges},
+		{"UI", map[string]float64{"N": 0.85, "R": 0.62}},
+		{"C", impacts},
+		{"I", impacts},
+		{"A", impacts},
+	}
+
+	values := map[string]float64{}
+	for _, w := range weights {
+		value, ok := w.weights[metrics[w.metric]]
+		if !ok {
+			return 0, fmt.Errorf("invalid or missing metric %s in CVSS vector %q", w.metric, vector)
+		}
+		values[w.metric] = value
+	}
+	if metrics["S"] != "U" && !changed {
+		return 0, fmt.Errorf("invalid or missing metric S in CVSS vector %q", vector)
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+
+	exploitability := 8.22 * values["AV"] * values["AC"] * values["PR"] * values["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp rounds up to one decimal, as specified by CVSS v3.1, avoiding floating point errors
+func roundUp(value float64) float64 {
+	i := int64(math.Round(value * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+type auditAdvisory struct {
+	CVSS  *string `json:"cvss"`
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	URL   string  `json:"url"`
+}
+
+type auditPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ParseAuditReport parses the JSON report of `cargo audit --json`
+func ParseAuditReport(data []byte) (AuditReport, error) {
+	var raw struct {
+		Lockfile struct {
+			DependencyCount int `json:"dependency-count"`
+		} `json:"lockfile"`
+		Vulnerabilities struct {
+			List []struct {
+				Advisory auditAdvisory `json:"advisory"`
+				Package  auditPackage  `json:"package"`
+				Versions struct {
+					Patched []string `json:"patched"`
+				} `json:"versions"`
+			} `json:"list"`
+		} `json:"vulnerabilities"`
+		Warnings map[string][]struct {
+			Advisory *auditAdvisory `json:"advisory"`
+			Kind     string         `json:"kind"`
+			Package  auditPackage   `json:"package"`
+		} `json:"warnings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return AuditReport{}, fmt.Errorf("unable to parse cargo audit report\n%w", err)
+	}
+
+	report := AuditReport{Dependencies: raw.Lockfile.DependencyCount}
+	for _, v := range raw.Vulnerabilities.List {
+		vulnerability := Vulnerability{
+			ID:       v.Advisory.ID,
+			Package:  v.Package.Name,
+			Patched:  v.Versions.Patched,
+			Severity: SeverityUnknown,
+			Title:    v.Advisory.Title,
+			URL:      v.Advisory.URL,
+			Version:  v.Package.Version,
+		}
+
+		// CVSS v4 vectors, which cargo-audit may report for newer advisories, are not ranked
+		if v.Advisory.CVSS != nil {
+			if score, err := CVSSScore(*v.Advisory.CVSS); err == nil {
+				vulnerability.Score = score
+				vulnerability.Severity = SeverityOf(score)
+			}
+		}
+
+		report.Vulnerabilities = append(report.Vulnerabilities, vulnerability)
+	}
+
+	for kind, warnings := range raw.Warnings {
+		for _, w := range warnings {
+			warning := AuditWarning{Kind: w.Kind, Package: w.Package.Name, Version: w.Package.Version}
+			if warning.Kind == "" {
+				warning.Kind = kind
+			}
+			if w.Advisory != nil {
+				warning.ID = w.Advisory.ID
+			}
+			report.Warnings = append(report.Warnings, warning)
+		}
+	}
+	sort.Slice(report.Warnings, func(i, j int) bool {
+		a, b := report.Warnings[i], report.Warnings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Package < b.Package
+	})
+
+	return report, nil
+}
+
+// SecurityAudit audits the Cargo.lock of the working directory against the RustSec advisory database with cargo-audit,
+// which is installed into CARGO_HOME with `cargo install` if it is missing. The advisory database is fetched into
+// CARGO_HOME, unless the build is offline. Advisories set with WithAuditIgnore are not reported.
+func (c CargoRunner) SecurityAudit(srcDir string) (AuditReport, error) {
+	if path := c.installedTool("cargo-audit"); path != "" {
+		c.Logger.Bodyf("Using cargo-audit from %s", path)
+	} else if err := c.InstallTool(ToolRequest{Locked: true, Name: "cargo-audit"}, nil); err != nil {
+		return AuditReport{}, fmt.Errorf("unable to install cargo-audit\n%w", err)
+	}
+
+	args := []string{"audit", "--json"}
+	if c.OfflineBuild {
+		args = append(args, "--no-fetch")
+	}
+	for _, id := range c.AuditIgnore {
+		args = append(args, "--ignore", id)
+	}
+
+	c.Logger.Bodyf("cargo %s", strings.Join(args, " "))
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	execErr := c.captured().execute(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
+		Args:    args,
+		Dir:     c.workingDir(srcDir),
+		Env:     c.environment(),
+		Stdout:  stdout,
+		Stderr:  stderr,
+	})
+
+	// cargo-audit exits with an error if it finds vulnerabilities, the report tells whether it ran
+	report, err := ParseAuditReport(stdout.Bytes())
+	if err != nil {
+		if execErr != nil {
+			return AuditReport{}, fmt.Errorf("error executing 'cargo audit':\n Combined Output: %s%s: \n%w", stdout, stderr, execErr)
+		}
+		return AuditReport{}, err
+	}
+
+	return report, nil
+}
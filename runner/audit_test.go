@@ -0,0 +1,231 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+const auditReport = `{
+  "database": {"advisory-count": 800},
+  "lockfile": {"dependency-count": 42},
+  "vulnerabilities": {
+    "found": true,
+    "count": 2,
+    "list": [
+      {
+        "advisory": {
+          "id": "RUSTSEC-2020-0071",
+          "title": "Potential segfault in the time crate",
+          "url": "https://github.com/time-rs/time/issues/293",
+          "cvss": "CVSS:3.1/AV:L/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:H"
+        },
+        "versions": {"patched": [">=0.2.23"], "unaffected": ["=0.2.0"]},
+        "package": {"name": "time", "version": "0.1.45"}
+      },
+      {
+        "advisory": {"id": "RUSTSEC-2024-0001", "title": "Unsound API", "url": "", "cvss": null},
+        "versions": {"patched": []},
+        "package": {"name": "foo", "version": "1.0.0"}
+      }
+    ]
+  },
+  "warnings": {
+    "yanked": [{"kind": "yanked", "package": {"name": "bar", "version": "0.3.1"}, "advisory": null}],
+    "unmaintained": [{"kind": "unmaintained", "package": {"name": "baz", "version": "2.0.0"}, "advisory": {"id": "RUSTSEC-2021-0139"}}]
+  }
+}`
+
+func testAudit(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("CVSS", func() {
+		it("scores CVSS v3 vectors", func() {
+			for vector, score := range map[string]float64{
+				"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+				"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H": 10.0,
+				"CVSS:3.0/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N": 6.1,
+				"CVSS:3.1/AV:L/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:H": 5.1,
+				"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:L/I:N/A:N": 3.3,
+				"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N": 0,
+			} {
+				Expect(runner.CVSSScore(vector)).To(Equal(score), vector)
+			}
+		})
+
+		it("rejects other versions and invalid vectors", func() {
+			_, err := runner.CVSSScore("CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N")
+			Expect(err).To(MatchError(ContainSubstring("unsupported CVSS vector")))
+
+			_, err = runner.CVSSScore("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H")
+			Expect(err).To(MatchError(ContainSubstring("invalid or missing metric S")))
+
+			_, err = runner.CVSSScore("CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+			Expect(err).To(MatchError(ContainSubstring("invalid or missing metric AV")))
+		})
+
+		it("ranks scores by severity", func() {
+			Expect(runner.SeverityOf(3.9)).To(Equal(runner.SeverityLow))
+			Expect(runner.SeverityOf(4.0)).To(Equal(runner.SeverityMedium))
+			Expect(runner.SeverityOf(7.0)).To(Equal(runner.SeverityHigh))
+			Expect(runner.SeverityOf(9.0)).To(Equal(runner.SeverityCritical))
+
+			Expect(runner.ValidateSeverity("high")).To(Succeed())
+			Expect(runner.ValidateSeverity("severe")).To(MatchError(`unsupported severity "severe", use one of low, medium, high, critical`))
+		})
+	})
+
+	context("report", func() {
+		it("parses the report of cargo audit", func() {
+			report, err := runner.ParseAuditReport([]byte(auditReport))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(Equal(runner.AuditReport{
+				Dependencies: 42,
+				Vulnerabilities: []runner.Vulnerability{
+					{
+						ID:       "RUSTSEC-2020-0071",
+						Package:  "time",
+						Patched:  []string{">=0.2.23"},
+						Score:    5.1,
+						Severity: runner.SeverityMedium,
+						Title:    "Potential segfault in the time crate",
+						URL:      "https://github.com/time-rs/time/issues/293",
+						Version:  "0.1.45",
+					},
+					{
+						ID:       "RUSTSEC-2024-0001",
+						Package:  "foo",
+						Patched:  []string{},
+						Severity: runner.SeverityUnknown,
+						Title:    "Unsound API",
+						Version:  "1.0.0",
+					},
+				},
+				Warnings: []runner.AuditWarning{
+					{ID: "RUSTSEC-2021-0139", Kind: "unmaintained", Package: "baz", Version: "2.0.0"},
+					{Kind: "yanked", Package: "bar", Version: "0.3.1"},
+				},
+			}))
+		})
+
+		it("returns the vulnerabilities at or above a severity and those of unknown severity", func() {
+			report, err := runner.ParseAuditReport([]byte(auditReport))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report.AtOrAbove(runner.SeverityLow)).To(HaveLen(2))
+			Expect(report.AtOrAbove(runner.SeverityMedium)).To(HaveLen(2))
+
+			high := report.AtOrAbove(runner.SeverityHigh)
+			Expect(high).To(HaveLen(1))
+			Expect(high[0].String()).To(Equal("RUSTSEC-2024-0001 (foo 1.0.0, unknown)"))
+		})
+	})
+
+	context("SecurityAudit", func() {
+		var (
+			appDir    string
+			cargoHome string
+			executor  *cargotest.Executor
+		)
+
+		it.Before(func() {
+			t.Setenv("PATH", t.TempDir())
+
+			appDir = cargotest.Application(t, map[string]string{"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n"})
+			cargoHome = cargotest.CargoHome(t)
+			executor = &cargotest.Executor{}
+		})
+
+		install := func() {
+			path := filepath.Join(cargoHome, "bin", "cargo-audit")
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte{}, 0755)).To(Succeed())
+		}
+
+		newRunner := func(options ...runner.Option) runner.CargoRunner {
+			return runner.NewCargoRunner(append([]runner.Option{
+				runner.WithCargoHome(cargoHome),
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+			}, options...)...)
+		}
+
+		it("installs cargo-audit if it is missing and audits the dependencies", func() {
+			executor.On("cargo", "install", "cargo-audit")
+			executor.On("cargo", "audit").Stdout = `{"lockfile": {"dependency-count": 3}, "vulnerabilities": {"list": []}, "warnings": {}}`
+
+			report, err := newRunner().SecurityAudit(appDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report).To(Equal(runner.AuditReport{Dependencies: 3}))
+
+			Expect(cargotest.AssertExecuted(t, executor, "cargo", "install", "cargo-audit").Args).To(ContainElement("--locked"))
+
+			execution := cargotest.AssertExecuted(t, executor, "cargo", "audit")
+			Expect(execution.Args).To(Equal([]string{"audit", "--json"}))
+			Expect(execution.Dir).To(Equal(appDir))
+		})
+
+		it("uses an installed cargo-audit, ignores advisories and does not fetch the database offline", func() {
+			install()
+			executor.On("cargo", "audit").Stdout = `{"lockfile": {"dependency-count": 3}}`
+
+			_, err := newRunner(
+				runner.WithAuditIgnore([]string{"RUSTSEC-2020-0071", "RUSTSEC-2021-0139"}),
+				runner.WithOfflineBuild(true),
+			).SecurityAudit(appDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			cargotest.AssertNotExecuted(t, executor, "cargo", "install")
+			execution := cargotest.AssertExecuted(t, executor, "cargo", "audit")
+			Expect(execution.Args).To(Equal([]string{"audit", "--json", "--no-fetch", "--ignore", "RUSTSEC-2020-0071", "--ignore", "RUSTSEC-2021-0139"}))
+		})
+
+		it("returns the report when cargo audit fails because of vulnerabilities", func() {
+			install()
+			script := executor.On("cargo", "audit")
+			script.Stdout = auditReport
+			script.Err = errors.New("exit status 1")
+
+			report, err := newRunner().SecurityAudit(appDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Vulnerabilities).To(HaveLen(2))
+		})
+
+		it("returns an error when cargo audit fails without a report", func() {
+			install()
+			script := executor.On("cargo", "audit")
+			script.Stdout = "error: couldn't fetch advisory database"
+			script.Err = errors.New("exit status 1")
+
+			_, err := newRunner().SecurityAudit(appDir)
+			Expect(err).To(MatchError(And(ContainSubstring("error executing 'cargo audit'"), ContainSubstring("couldn't fetch advisory database"))))
+		})
+	})
+}
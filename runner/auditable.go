@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// installedTool returns the path of a tool, installed into CARGO_HOME or found on PATH, or an empty string if it is not
+// installed
+func (c CargoRunner) installedTool(name string) string {
+	if c.CargoHome != "" {
+		path := filepath.Join(c.CargoHome, "bin", name)
+		if found, err := sherpa.FileExists(path); err == nil && found {
+			return path
+		}
+	}
+
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+
+	return ""
+}
+
+// EnsureAuditable installs cargo-auditable into CARGO_HOME with `cargo install`, unless it is already installed, for
+// example as a tool or by another buildpack
+func (c CargoRunner) EnsureAuditable() error {
+	if path := c.installedTool("cargo-auditable"); path != "" {
+		c.Logger.Bodyf("Using cargo-auditable from %s", path)
+		return nil
+	}
+
+	if err := c.InstallTool(ToolRequest{Locked: true, Name: "cargo-auditable"}, nil); err != nil {
+		return fmt.Errorf("unable to install cargo-auditable\n%w", err)
+	}
+
+	return nil
+}
+
+// auditableArgs runs a cargo subcommand through `cargo auditable`, which embeds the dependency list into the binaries,
+// if enabled with WithAuditable
+func (c CargoRunner) auditableArgs(args []string) []string {
+	if !c.Auditable {
+		return args
+	}
+	return append([]string{"auditable"}, args...)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testAuditable(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		cargoHome string
+		executor  *cargotest.Executor
+		layer     libcnb.Layer
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("PATH", t.TempDir())
+
+		appDir = cargotest.Application(t, map[string]string{"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n"})
+		cargoHome = cargotest.CargoHome(t)
+		executor = &cargotest.Executor{}
+		layer = libcnb.Layer{Path: "/layers/cargo"}
+	})
+
+	newRunner := func(options ...runner.Option) runner.CargoRunner {
+		return runner.NewCargoRunner(append([]runner.Option{
+			runner.WithCargoHome(cargoHome),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+		}, options...)...)
+	}
+
+	it("installs and builds through cargo auditable", func() {
+		plan, err := newRunner(runner.WithAuditable(true)).InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args[:2]).To(Equal([]string{"auditable", "install"}))
+
+		plan, err = newRunner(runner.WithAuditable(true)).BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args[:2]).To(Equal([]string{"auditable", "build"}))
+	})
+
+	it("does not use cargo auditable by default", func() {
+		plan, err := newRunner().InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).NotTo(ContainElement("auditable"))
+	})
+
+	it("installs cargo-auditable if it is missing", func() {
+		executor.On("cargo", "install", "cargo-auditable")
+
+		Expect(newRunner().EnsureAuditable()).To(Succeed())
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "install", "cargo-auditable")
+		Expect(execution.Args).To(ContainElement("--locked"))
+	})
+
+	it("uses an installed cargo-auditable", func() {
+		path := filepath.Join(cargoHome, "bin", "cargo-auditable")
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte{}, 0755)).To(Succeed())
+
+		Expect(newRunner().EnsureAuditable()).To(Succeed())
+		Expect(executor.Executions).To(BeEmpty())
+	})
+
+	it("returns an error if cargo-auditable cannot be installed", func() {
+		executor.On("cargo", "install", "cargo-auditable").Err = errors.New("test-error")
+
+		Expect(newRunner().EnsureAuditable()).To(MatchError(ContainSubstring("unable to install cargo-auditable")))
+	})
+}
@@ -0,0 +1,305 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	// BuildCommandBuild builds the binaries with `cargo build` and copies them from the target directory
+	BuildCommandBuild = "build"
+
+	// BuildCommandInstall builds the binaries with `cargo install`
+	BuildCommandInstall = "install"
+)
+
+// installOnlyArgs are the arguments of `cargo install` that select what is installed and have no equivalent in
+// `cargo build`, which builds the workspace members selected with BP_CARGO_WORKSPACE_MEMBERS
+var installOnlyArgs = []string{"--branch", "--force", "--git", "--index", "--list", "--no-track", "--path", "--registry", "--rev", "--tag", "--vers", "--version", "-f"}
+
+// InstallOnlyArgs returns the install arguments that are only supported by `cargo install`, not by `cargo build`
+func InstallOnlyArgs(installArgs string) ([]string, error) {
+	args, err := FilterInstallArgs(installArgs)
+	if err != nil {
+		return nil, fmt.Errorf("filter failed: %w", err)
+	}
+
+	var found []string
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		for _, a := range installOnlyArgs {
+			if name == a {
+				found = append(found, name)
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// BuildPlan resolves the invocation that Build runs to build the workspace members, without running it. The install
+// arguments are passed to `cargo build`, except those selecting the profile and target, which are resolved like for
+// `cargo install`.
+func (c CargoRunner) BuildPlan(srcDir string) (Invocation, error) {
+	c = c.targetDirRunner(srcDir)
+
+	if c.OfflineBuild {
+		if err := c.ValidateVendoredSources(srcDir); err != nil {
+			return Invocation{}, err
+		}
+	}
+
+	if err := c.ValidateProfile(srcDir); err != nil {
+		return Invocation{}, err
+	}
+
+	unsupported, err := InstallOnlyArgs(c.installArgs())
+	if err != nil {
+		return Invocation{}, err
+	}
+	if len(unsupported) > 0 {
+		return Invocation{}, fmt.Errorf("cargo build does not support %s from the install arguments", strings.Join(unsupported, ", "))
+	}
+
+	envArgs, err := FilterInstallArgs(c.installArgs())
+	if err != nil {
+		return Invocation{}, fmt.Errorf("filter failed: %w", err)
+	}
+
+	// a target is built with the cross compilation backend, which may not be cargo itself
+	subcommand, err := c.crossSubcommand(envArgs)
+	if err != nil {
+		return Invocation{}, err
+	}
+
+	args := []string{subcommand}
+	args = append(args, c.UnstableFlags...)
+
+	for i := 0; i < len(envArgs); i++ {
+		name, _, hasValue := strings.Cut(envArgs[i], "=")
+		switch name {
+		case "--debug":
+		case "--profile", "--target":
+			if !hasValue {
+				i++
+			}
+		default:
+			args = append(args, envArgs[i])
+		}
+	}
+
+	profile, err := c.Profile()
+	if err != nil {
+		return Invocation{}, fmt.Errorf("unable to determine profile\n%w", err)
+	}
+
+	color := c.Color
+	if color == "" {
+		color = ColorNever
+	}
+	args = append(args, fmt.Sprintf("--color=%s", color), fmt.Sprintf("--profile=%s", profile))
+
+	triple, err := ResolveTargetTriple(c.installArgs(), c.Stack, c.StaticType)
+	if err != nil {
+		return Invocation{}, fmt.Errorf("unable to resolve target triple\n%w", err)
+	}
+	if triple != "" {
+		args = append(args, fmt.Sprintf("--target=%s", triple))
+	}
+
+	args = append(args, c.timingsArgs()...)
+	args = append(args, c.FeatureArgs()...)
+	args = append(args, c.offlineArgs(envArgs)...)
+	args = append(args, c.jobsArgs(envArgs)...)
+	args = append(args, c.messageArgs(envArgs)...)
+
+	members, err := c.workspaceArgs(c.workingDir(srcDir))
+	if err != nil {
+		return Invocation{}, err
+	}
+	args = append(args, members...)
+	args = append(args, "--bins")
+
+	return Invocation{
+		Args:    c.auditableArgs(args),
+		Command: c.toolchainCommand("cargo"),
+		Dir:     c.workingDir(srcDir),
+		Env:     c.WithEnv(c.ProfileEnvironment()).compileRunner().addedEnvironment(),
+	}, nil
+}
+
+// Build builds the binaries of the workspace members with `cargo build` and copies them to the bin directory of the
+// layer. Unlike `cargo install`, which builds in a temporary directory, the build reuses the target directory of the
+// application, so that crates which did not change are not compiled again.
+func (c CargoRunner) Build(srcDir string, destLayer libcnb.Layer) error {
+	if err := c.CopyTargetSpec(srcDir); err != nil {
+		return fmt.Errorf("unable to copy target specification\n%w", err)
+	}
+
+	plan, err := c.BuildPlan(srcDir)
+	if err != nil {
+		return fmt.Errorf("unable to plan build\n%w", err)
+	}
+
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(plan.Args, " ")))
+	output, flush := c.output()
+	stdout, stderr, flush := c.messageOutput(output, flush)
+	stdout, flush = c.timingOutput(stdout, flush)
+	if err := c.messageRunner().executeRetrying(effect.Execution{
+		Command: plan.Command,
+		Args:    plan.Args,
+		Dir:     plan.Dir,
+		Env:     c.inheritEnvironment(plan.Env),
+		Stdout:  stdout,
+		Stderr:  stderr,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to build\n%w", err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write build output\n%w", err)
+	}
+
+	binaries, err := c.BuiltBinaries(srcDir)
+	if err != nil {
+		return fmt.Errorf("unable to find binaries\n%w", err)
+	}
+
+	bin := filepath.Join(destLayer.Path, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", bin, err)
+	}
+
+	for _, source := range binaries {
+		destination := filepath.Join(bin, filepath.Base(source))
+
+		// a hard link to an earlier build must not be overwritten in place, it shares its content with the target
+		// directory
+		if err := os.RemoveAll(destination); err != nil {
+			return fmt.Errorf("unable to remove %s\n%w", destination, err)
+		}
+		if err := LinkFile(source, destination); err != nil {
+			return fmt.Errorf("unable to copy %s\n%w", filepath.Base(source), err)
+		}
+	}
+	c.Logger.Bodyf("Copied %d binaries to %s", len(binaries), bin)
+
+	if err := c.cleanAfterBuild(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BuiltBinaries returns the paths of the binaries that `cargo build` writes to the target directory for the workspace
+// members, in the directory of the profile and target triple
+func (c CargoRunner) BuiltBinaries(srcDir string) ([]string, error) {
+	c = c.targetDirRunner(srcDir)
+
+	dir := c.workingDir(srcDir)
+
+	m, err := c.fetchCargoMetadata(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load cargo metadata\n%w", err)
+	}
+
+	profile, err := c.Profile()
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine profile\n%w", err)
+	}
+
+	triple, err := ResolveTargetTriple(c.installArgs(), c.Stack, c.StaticType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve target triple\n%w", err)
+	}
+
+	targetDir := m.TargetDirectory
+	if targetDir == "" {
+		targetDir = c.targetDir(srcDir)
+	}
+	if targetDir == "" {
+		targetDir = filepath.Join(dir, "target")
+	}
+	// cargo-zigbuild builds a target with a pinned glibc version into the directory of the target
+	triple, _ = SplitGlibcVersion(triple)
+	out := filepath.Join(targetDir, triple, ProfileDir(profile))
+
+	suffix := ""
+	if strings.Contains(triple, "windows") {
+		suffix = ".exe"
+	}
+
+	filterMap, err := c.selectedMembers(m)
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]bool{}
+	for _, member := range m.WorkspaceMembers {
+		members[member] = true
+	}
+
+	var binaries []string
+	for _, pkg := range m.Packages {
+		if !members[pkg.ID] || (len(filterMap) > 0 && !filterMap[pkg.Name]) {
+			continue
+		}
+
+		for _, target := range pkg.Targets {
+			for _, kind := range target.Kind {
+				if kind == "bin" {
+					binaries = append(binaries, filepath.Join(out, target.Name+suffix))
+				}
+			}
+		}
+	}
+	sort.Strings(binaries)
+
+	return binaries, nil
+}
+
+// ProfileDir returns the directory of the target directory that cargo writes the output of a profile to
+func ProfileDir(profile string) string {
+	switch profile {
+	case "dev", "test":
+		return "debug"
+	case "bench":
+		return "release"
+	default:
+		return profile
+	}
+}
+
+// workingDir returns the directory cargo runs in, the working directory if set or otherwise the source directory
+func (c CargoRunner) workingDir(srcDir string) string {
+	if c.WorkingDir == "" {
+		return srcDir
+	}
+
+	if filepath.IsAbs(c.WorkingDir) {
+		return c.WorkingDir
+	}
+	return filepath.Join(srcDir, c.WorkingDir)
+}
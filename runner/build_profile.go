@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// BuiltinProfiles are the profiles that Cargo defines without any configuration
+var BuiltinProfiles = []string{"bench", "dev", "release", "test"}
+
+// ExplicitProfile returns the profile selected with `--profile` or `--debug` in the install arguments, or an empty
+// string if none is selected
+func ExplicitProfile(installArgs string) (string, error) {
+	args, err := FilterInstallArgs(installArgs)
+	if err != nil {
+		return "", fmt.Errorf("filter failed: %w", err)
+	}
+
+	var profile string
+	for i, arg := range args {
+		switch {
+		case arg == "--debug":
+			profile = "dev"
+		case arg == "--profile" && i+1 < len(args):
+			profile = args[i+1]
+		case strings.HasPrefix(arg, "--profile="):
+			profile = strings.TrimPrefix(arg, "--profile=")
+		}
+	}
+
+	return profile, nil
+}
+
+// ValidateProfile fails if the profile selected with WithProfile is neither built into Cargo nor defined in a
+// `[profile.<name>]` table of the manifest in the working directory, of a Cargo configuration in the working directory
+// or its parents or in CARGO_HOME, or with a `BP_CARGO_PROFILE_<NAME>_INHERITS` variable
+func (c CargoRunner) ValidateProfile(srcDir string) error {
+	if c.BuildProfile == "" {
+		return nil
+	}
+
+	for _, p := range BuiltinProfiles {
+		if c.BuildProfile == p {
+			return nil
+		}
+	}
+
+	if _, ok := c.ProfileVariables[profileVariableName(c.BuildProfile, "INHERITS")]; ok {
+		return nil
+	}
+
+	dir := c.workingDir(srcDir)
+	files := []string{filepath.Join(dir, "Cargo.toml")}
+	for d := dir; ; d = filepath.Dir(d) {
+		files = append(files, filepath.Join(d, ".cargo", "config.toml"), filepath.Join(d, ".cargo", "config"))
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if c.CargoHome != "" {
+		files = append(files, filepath.Join(c.CargoHome, "config.toml"), filepath.Join(c.CargoHome, "config"))
+	}
+
+	for _, file := range files {
+		defined, err := definesProfile(file, c.BuildProfile)
+		if err != nil {
+			return err
+		}
+		if defined {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("profile %s is not defined, add a [profile.%s] table to %s or select one of %s",
+		c.BuildProfile, c.BuildProfile, filepath.Join(dir, "Cargo.toml"), strings.Join(BuiltinProfiles, ", "))
+}
+
+// definesProfile returns whether the manifest or Cargo configuration at path has a `[profile.<name>]` table
+func definesProfile(path string, name string) (bool, error) {
+	if found, err := sherpa.FileExists(path); err != nil {
+		return false, fmt.Errorf("unable to check %s\n%w", path, err)
+	} else if !found {
+		return false, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	var config struct {
+		Profile map[string]interface{} `toml:"profile"`
+	}
+	if _, err := toml.Decode(string(b), &config); err != nil {
+		return false, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+
+	_, ok := config.Profile[name]
+	return ok, nil
+}
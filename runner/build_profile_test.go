@@ -0,0 +1,132 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testBuildProfile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+		layer  = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n\n[profile.release-lto]\ninherits = \"release\"\nlto = true\n",
+		})
+	})
+
+	it("passes the profile to cargo install and cargo build", func() {
+		r := runner.NewCargoRunner(runner.WithProfile("release-lto"))
+
+		plan, err := r.InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--profile=release-lto"))
+
+		plan, err = r.BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--profile=release-lto"))
+
+		Expect(r.Profile()).To(Equal("release-lto"))
+	})
+
+	it("prefers a profile selected in the install arguments", func() {
+		r := runner.NewCargoRunner(runner.WithProfile("release-lto"), runner.WithCargoInstallArgs("--debug"))
+
+		plan, err := r.InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).NotTo(ContainElement("--profile=release-lto"))
+		Expect(r.Profile()).To(Equal("dev"))
+	})
+
+	it("returns the profile selected in the install arguments", func() {
+		Expect(runner.ExplicitProfile("--locked --profile small")).To(Equal("small"))
+		Expect(runner.ExplicitProfile("--profile=small")).To(Equal("small"))
+		Expect(runner.ExplicitProfile("--debug")).To(Equal("dev"))
+		Expect(runner.ExplicitProfile("--locked")).To(BeEmpty())
+	})
+
+	context("validation", func() {
+		it("accepts the built in profiles", func() {
+			for _, profile := range runner.BuiltinProfiles {
+				Expect(runner.NewCargoRunner(runner.WithProfile(profile)).ValidateProfile(appDir)).To(Succeed())
+			}
+		})
+
+		it("accepts profiles defined in Cargo.toml", func() {
+			Expect(runner.NewCargoRunner(runner.WithProfile("release-lto")).ValidateProfile(appDir)).To(Succeed())
+		})
+
+		it("accepts profiles defined in the working directory", func() {
+			appDir = cargotest.Application(t, map[string]string{
+				"Cargo.toml":        "[package]\nname = \"outer\"\nversion = \"0.1.0\"\n",
+				"crates/Cargo.toml": "[workspace]\nmembers = [\"app\"]\n\n[profile.small]\ninherits = \"release\"\nopt-level = \"z\"\n",
+			})
+
+			r := runner.NewCargoRunner(runner.WithProfile("small"), runner.WithWorkingDir("crates"))
+			Expect(r.ValidateProfile(appDir)).To(Succeed())
+		})
+
+		it("accepts profiles defined in a Cargo configuration", func() {
+			appDir = cargotest.Application(t, map[string]string{
+				"Cargo.toml":         "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+				".cargo/config.toml": "[profile.small]\ninherits = \"release\"\n",
+			})
+			Expect(runner.NewCargoRunner(runner.WithProfile("small")).ValidateProfile(appDir)).To(Succeed())
+
+			cargoHome := cargotest.Application(t, map[string]string{"config.toml": "[profile.tiny]\ninherits = \"release\"\n"})
+			Expect(runner.NewCargoRunner(runner.WithProfile("tiny"), runner.WithCargoHome(cargoHome)).ValidateProfile(appDir)).To(Succeed())
+		})
+
+		it("accepts profiles defined with profile variables", func() {
+			r := runner.NewCargoRunner(
+				runner.WithProfile("small"),
+				runner.WithProfileVariables(map[string]string{"CARGO_PROFILE_SMALL_INHERITS": "release"}))
+			Expect(r.ValidateProfile(appDir)).To(Succeed())
+		})
+
+		it("fails before compiling if the profile is not defined", func() {
+			r := runner.NewCargoRunner(runner.WithProfile("small"))
+
+			err := r.ValidateProfile(appDir)
+			Expect(err).To(MatchError(And(
+				ContainSubstring("profile small is not defined"),
+				ContainSubstring("add a [profile.small] table to "+filepath.Join(appDir, "Cargo.toml")),
+			)))
+
+			_, err = r.InstallPlan(".", appDir, layer)
+			Expect(err).To(MatchError(ContainSubstring("profile small is not defined")))
+
+			_, err = r.BuildPlan(appDir)
+			Expect(err).To(MatchError(ContainSubstring("profile small is not defined")))
+		})
+	})
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testBuild(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[workspace]\nmembers = [\"api\", \"worker\"]\n",
+			"Cargo.lock": "version = 3\n",
+		})
+		executor = &cargotest.Executor{}
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).
+			WithMember("api", "0.1.0", "api", "api").
+			WithMember("worker", "0.1.0", "worker", "worker", "cleanup"))).To(Succeed())
+	})
+
+	context("BuildPlan", func() {
+		it("builds the whole workspace", func() {
+			plan, err := runner.NewCargoRunner(runner.WithCargoInstallArgs("--locked")).BuildPlan(appDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.Args).To(Equal([]string{"build", "--locked", "--color=never", "--profile=release", "--workspace", "--bins"}))
+			Expect(plan.Dir).To(Equal(appDir))
+		})
+
+		it("builds the selected members with the profile and target of the install arguments", func() {
+			plan, err := runner.NewCargoRunner(
+				runner.WithCargoInstallArgs("--debug --target x86_64-unknown-linux-musl --features=postgres"),
+				runner.WithCargoWorkspaceMembers("worker, api"),
+				runner.WithWorkingDir("crates"),
+			).BuildPlan(appDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.Args).To(Equal([]string{"build", "--features=postgres", "--color=never", "--profile=dev",
+				"--target=x86_64-unknown-linux-musl", "-p", "api", "-p", "worker", "--bins"}))
+			Expect(plan.Dir).To(Equal(filepath.Join(appDir, "crates")))
+		})
+
+		it("fails for arguments only supported by cargo install", func() {
+			_, err := runner.NewCargoRunner(runner.WithCargoInstallArgs("--path=./api --git https://example.com/repo")).BuildPlan(appDir)
+			Expect(err).To(MatchError("cargo build does not support --path, --git from the install arguments"))
+		})
+	})
+
+	it("returns the install arguments only supported by cargo install", func() {
+		Expect(runner.InstallOnlyArgs("--locked --path=./api --version 1.0.0")).To(Equal([]string{"--path", "--version"}))
+		Expect(runner.InstallOnlyArgs("--locked")).To(BeEmpty())
+	})
+
+	it("maps profiles to their output directory", func() {
+		Expect(runner.ProfileDir("dev")).To(Equal("debug"))
+		Expect(runner.ProfileDir("test")).To(Equal("debug"))
+		Expect(runner.ProfileDir("bench")).To(Equal("release"))
+		Expect(runner.ProfileDir("release")).To(Equal("release"))
+		Expect(runner.ProfileDir("production")).To(Equal("production"))
+	})
+
+	it("finds the binaries of the selected members", func() {
+		binaries, err := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("worker"),
+			runner.WithExecutor(executor),
+		).BuiltBinaries(appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(binaries).To(Equal([]string{
+			filepath.Join(appDir, "target", "release", "cleanup"),
+			filepath.Join(appDir, "target", "release", "worker"),
+		}))
+	})
+
+	it("copies the built binaries into the layer", func() {
+		cargotest.CargoHome(t)
+		layer := cargotest.Layer(t, cargotest.Layers(t), "cargo")
+
+		executor.On("cargo", "build").Run = cargotest.BuildBinaries("api", "worker", "cleanup")
+
+		r := runner.NewCargoRunner(runner.WithExecutor(executor))
+		Expect(r.Build(appDir, layer)).To(Succeed())
+
+		cargotest.AssertExecuted(t, executor, "cargo", "build", "--workspace", "--bins")
+		cargotest.AssertNotExecuted(t, executor, "cargo", "install")
+		cargotest.AssertBinaries(t, layer, "api", "cleanup", "worker")
+
+		// a second build into the same layer replaces the binaries of the first one
+		Expect(r.Build(appDir, layer)).To(Succeed())
+		Expect(os.ReadFile(filepath.Join(layer.Path, "bin", "api"))).To(Equal([]byte("api")))
+	})
+
+	it("compresses the built binaries without changing the target directory", func() {
+		cargoHome := cargotest.CargoHome(t)
+		layer := cargotest.Layer(t, cargotest.Layers(t), "cargo")
+
+		upx := filepath.Join(cargoHome, "bin", "upx")
+		Expect(os.MkdirAll(filepath.Dir(upx), 0755)).To(Succeed())
+		Expect(os.WriteFile(upx, []byte{}, 0755)).To(Succeed())
+
+		executor.On("cargo", "build").Run = func(execution effect.Execution) error {
+			dir := filepath.Join(appDir, "target", "release")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			for _, name := range []string{"api", "worker", "cleanup"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("\x7fELF-"+name), 0755); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// upx compresses in place, like `upx --best -q <path>`
+		executor.On("upx").Run = func(execution effect.Execution) error {
+			f, err := os.OpenFile(execution.Args[len(execution.Args)-1], os.O_WRONLY|os.O_TRUNC, 0)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.Write([]byte("\x7fELF"))
+			return err
+		}
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithCompressBinaries(true),
+			runner.WithExecutor(executor))
+		Expect(r.Build(appDir, layer)).To(Succeed())
+
+		sizes, err := r.PostProcess([]string{filepath.Join(layer.Path, "bin", "api")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sizes).To(HaveLen(1))
+
+		Expect(os.ReadFile(filepath.Join(layer.Path, "bin", "api"))).To(Equal([]byte("\x7fELF")))
+		Expect(os.ReadFile(filepath.Join(appDir, "target", "release", "api"))).To(Equal([]byte("\x7fELF-api")))
+	})
+
+	it("fails when the build fails", func() {
+		cargotest.CargoHome(t)
+		layer := cargotest.Layer(t, cargotest.Layers(t), "cargo")
+
+		executor.On("cargo", "build").Err = fmt.Errorf("exit status 101")
+
+		err := runner.NewCargoRunner(runner.WithExecutor(executor)).Build(appDir, layer)
+		Expect(err).To(MatchError(ContainSubstring("unable to build")))
+	})
+}
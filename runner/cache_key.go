@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cacheKeyFiles are the files that determine the dependencies and toolchain of a build, in the order they are hashed
+var cacheKeyFiles = []string{"Cargo.lock", "rust-toolchain.toml", "rust-toolchain"}
+
+// CacheKey returns a key that changes whenever Cargo.lock or the toolchain file of the application changes, so that
+// cached layers holding the registry and compiled dependencies can be reused without resolving the dependencies
+// again. The files are looked up in the working directory, then in the source directory, which is the root of the
+// workspace. An empty key is returned if there is no Cargo.lock, since the dependencies are not locked then.
+func (c CargoRunner) CacheKey(srcDir string) (string, error) {
+	dirs := []string{c.workingDir(srcDir)}
+	if dirs[0] != srcDir {
+		dirs = append(dirs, srcDir)
+	}
+
+	h := sha256.New()
+	locked := false
+	for _, name := range cacheKeyFiles {
+		for _, dir := range dirs {
+			b, err := os.ReadFile(filepath.Join(dir, name))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return "", fmt.Errorf("unable to read %s\n%w", name, err)
+			}
+
+			if name == "Cargo.lock" {
+				locked = true
+			}
+
+			// the name and length separate the files, so that moving content between them changes the key
+			_, _ = fmt.Fprintf(h, "%s:%d:", name, len(b))
+			_, _ = h.Write(b)
+			break
+		}
+	}
+
+	if !locked {
+		return "", nil
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
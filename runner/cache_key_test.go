@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testCacheKey(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.lock"), []byte("version = 3\n"), 0644)).To(Succeed())
+	})
+
+	it("changes with Cargo.lock", func() {
+		key, err := runner.NewCargoRunner().CacheKey(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key).To(HaveLen(64))
+
+		Expect(runner.NewCargoRunner().CacheKey(appDir)).To(Equal(key))
+
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.lock"), []byte("version = 4\n"), 0644)).To(Succeed())
+		Expect(runner.NewCargoRunner().CacheKey(appDir)).NotTo(Equal(key))
+	})
+
+	it("changes with the toolchain file", func() {
+		key, err := runner.NewCargoRunner().CacheKey(appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(appDir, "rust-toolchain.toml"), []byte("[toolchain]\nchannel = \"1.80.0\"\n"), 0644)).To(Succeed())
+		Expect(runner.NewCargoRunner().CacheKey(appDir)).NotTo(Equal(key))
+	})
+
+	it("does not change with the sources", func() {
+		key, err := runner.NewCargoRunner().CacheKey(appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(appDir, "main.rs"), []byte("fn main() {}\n"), 0644)).To(Succeed())
+		Expect(runner.NewCargoRunner().CacheKey(appDir)).To(Equal(key))
+	})
+
+	it("finds Cargo.lock at the root of the workspace of the working directory", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, "api"), 0755)).To(Succeed())
+
+		key, err := runner.NewCargoRunner().CacheKey(appDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(runner.NewCargoRunner(runner.WithWorkingDir("api")).CacheKey(appDir)).To(Equal(key))
+	})
+
+	it("returns no key without Cargo.lock", func() {
+		Expect(os.Remove(filepath.Join(appDir, "Cargo.lock"))).To(Succeed())
+
+		Expect(runner.NewCargoRunner().CacheKey(appDir)).To(BeEmpty())
+	})
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// cleanProgressThreshold is the number of entries from which the progress of cleaning CARGO_HOME is logged
+const cleanProgressThreshold = 100
+
+// CleanStatistics describes what CleanCargoHomeCache removed
+type CleanStatistics struct {
+	Bytes   int64
+	Entries int
+}
+
+// CleanCargoHomeCache clears out unnecessary files from under $CARGO_HOME. Directories are split into their entries,
+// which are removed in parallel because extracted sources in the registry can be several gigabytes.
+func (c CargoRunner) CleanCargoHomeCache() (CleanStatistics, error) {
+	candidates, err := cleanCandidates(c.CargoHome)
+	if err != nil {
+		return CleanStatistics{}, err
+	}
+
+	var entries []string
+	for _, candidate := range candidates {
+		// two levels cover the crates in `registry/src/<index>/` and the checkouts in `git/checkouts/<repo>/`
+		e, err := expandEntries(candidate, 2)
+		if err != nil {
+			return CleanStatistics{}, err
+		}
+		entries = append(entries, e...)
+	}
+
+	if len(entries) >= cleanProgressThreshold {
+		c.Logger.Bodyf("Cleaning %d entries from CARGO_HOME", len(entries))
+	}
+
+	var (
+		first error
+		mu    sync.Mutex
+		stats CleanStatistics
+		wg    sync.WaitGroup
+	)
+
+	jobs := make(chan string)
+	for i := 0; i < min(runtime.NumCPU(), len(entries)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for path := range jobs {
+				size, err := removeEntry(path)
+
+				mu.Lock()
+				if err != nil && first == nil {
+					first = err
+				}
+				stats.Bytes += size
+				stats.Entries++
+
+				// logged for every quarter of the entries
+				if len(entries) >= cleanProgressThreshold && stats.Entries*4/len(entries) > (stats.Entries-1)*4/len(entries) {
+					c.Logger.Bodyf("Removed %d of %d entries", stats.Entries, len(entries))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, e := range entries {
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+
+	if first != nil {
+		return stats, fmt.Errorf("unable to remove files\n%w", first)
+	}
+
+	// removes the directories left once their entries are gone
+	for _, candidate := range candidates {
+		if err := os.RemoveAll(candidate); err != nil {
+			return stats, fmt.Errorf("unable to remove files\n%w", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// cleanCandidates returns the paths under CARGO_HOME that are not needed by later builds. Only the binaries, the
+// registry index and cache, and the git databases are kept.
+func cleanCandidates(cargoHome string) ([]string, error) {
+	// custom target specifications are kept for subsequent buildpacks, which find them with RUST_TARGET_PATH
+	keep := map[string][]string{
+		cargoHome:                            {"bin", "registry", "git", "target-specs"},
+		filepath.Join(cargoHome, "registry"): {"index", "cache"},
+		filepath.Join(cargoHome, "git"):      {"db"},
+	}
+
+	var candidates []string
+	for _, dir := range []string{cargoHome, filepath.Join(cargoHome, "registry"), filepath.Join(cargoHome, "git")} {
+		files, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read directory\n%w", err)
+		}
+
+	files:
+		for _, file := range files {
+			for _, k := range keep[dir] {
+				if file.IsDir() && file.Name() == k {
+					continue files
+				}
+			}
+			candidates = append(candidates, filepath.Join(dir, file.Name()))
+		}
+	}
+
+	return candidates, nil
+}
+
+// expandEntries replaces a directory with its entries, up to a depth, so that they can be removed in parallel
+func expandEntries(path string, depth int) ([]string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat %s\n%w", path, err)
+	}
+
+	if depth == 0 || !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory\n%w", err)
+	}
+
+	var entries []string
+	for _, file := range files {
+		e, err := expandEntries(filepath.Join(path, file.Name()), depth-1)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e...)
+	}
+
+	return entries, nil
+}
+
+// removeEntry removes a file or directory and returns the size of the files removed
+func removeEntry(path string) (int64, error) {
+	var size int64
+	if err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("unable to measure %s\n%w", path, err)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return 0, fmt.Errorf("unable to remove %s\n%w", path, err)
+	}
+
+	return size, nil
+}
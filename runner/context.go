@@ -0,0 +1,137 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// DefaultGracePeriod is how long a ProcessExecutor waits for an aborted command to exit before it is killed
+const DefaultGracePeriod = 10 * time.Second
+
+// ContextExecutor is an effect.Executor that can abort a running execution when its context is done
+type ContextExecutor interface {
+	effect.Executor
+
+	// ExecuteContext executes the command described in the Execution, aborting it when ctx is done
+	ExecuteContext(ctx context.Context, execution effect.Execution) error
+}
+
+// ProcessExecutor runs commands as child processes, like effect.CommandExecutor or, with TTY, like
+// effect.TTYExecutor. When the context of an execution is done, the process group of the command is terminated, so
+// that rustc and build scripts stop together with cargo, and killed if it does not exit within the grace period. A
+// CargoRunner only uses the TTY for commands that stream build output, see captured.
+type ProcessExecutor struct {
+	GracePeriod time.Duration
+	TTY         bool
+}
+
+// Execute executes the command described in the Execution, without a way to abort it
+func (p ProcessExecutor) Execute(execution effect.Execution) error {
+	return p.ExecuteContext(context.Background(), execution)
+}
+
+// ExecuteContext executes the command described in the Execution, terminating it when ctx is done
+func (p ProcessExecutor) ExecuteContext(ctx context.Context, execution effect.Execution) error {
+	cmd := exec.CommandContext(ctx, execution.Command, execution.Args...)
+	cmd.Dir = execution.Dir
+	if len(execution.Env) > 0 {
+		cmd.Env = execution.Env
+	}
+	cmd.Stdin = execution.Stdin
+
+	cmd.Cancel = func() error {
+		return terminate(cmd)
+	}
+	cmd.WaitDelay = p.GracePeriod
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = DefaultGracePeriod
+	}
+
+	if p.TTY {
+		return runTTY(cmd, execution.Stdout)
+	}
+
+	cmd.Stdout = execution.Stdout
+	cmd.Stderr = execution.Stderr
+	newProcessGroup(cmd)
+	return cmd.Run()
+}
+
+// execute runs an execution with the executor of the runner, aborting it when the context of the runner is done or the
+// command timeout elapses. Executions that have not started yet are not run once the context is done, but executors
+// that do not implement ContextExecutor finish running commands that already started.
+func (c CargoRunner) execute(execution effect.Execution) error {
+	ctx := c.context()
+
+	if c.CommandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.CommandTimeout)
+		defer cancel()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return c.aborted(execution, err)
+	}
+
+	var err error
+	if e, ok := c.Executor.(ContextExecutor); ok {
+		err = e.ExecuteContext(ctx, execution)
+	} else {
+		err = c.Executor.Execute(execution)
+	}
+
+	if err != nil && ctx.Err() != nil {
+		return c.aborted(execution, errors.Join(ctx.Err(), err))
+	}
+	return err
+}
+
+// captured returns a copy of the runner whose ProcessExecutor runs commands without a TTY, for commands whose output is
+// parsed. A TTY is only meant for the colored output of builds, it merges stderr, like warnings or the progress of
+// index updates, into stdout.
+func (c CargoRunner) captured() CargoRunner {
+	if p, ok := c.Executor.(ProcessExecutor); ok && p.TTY {
+		p.TTY = false
+		c.Executor = p
+	}
+	return c
+}
+
+// context returns the context of the runner, or the background context if none is set
+func (c CargoRunner) context() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+func (c CargoRunner) aborted(execution effect.Execution, err error) error {
+	command := strings.TrimSpace(fmt.Sprintf("%s %s", execution.Command, strings.Join(execution.Args, " ")))
+
+	if c.CommandTimeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("'%s' did not finish within %s\n%w", command, c.CommandTimeout, err)
+	}
+	return fmt.Errorf("'%s' was aborted\n%w", command, err)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testContext(t *testing.T, _ spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("runs commands", func() {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+		Expect(runner.ProcessExecutor{}.Execute(effect.Execution{
+			Command: "sh",
+			Args:    []string{"-c", "echo out; echo err >&2"},
+			Stdout:  stdout,
+			Stderr:  stderr,
+		})).To(Succeed())
+		Expect(stdout.String()).To(Equal("out\n"))
+		Expect(stderr.String()).To(Equal("err\n"))
+	})
+
+	it("terminates the process group of a command when the context is done", func() {
+		for _, tty := range []bool{false, true} {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := runner.ProcessExecutor{GracePeriod: time.Second, TTY: tty}.ExecuteContext(ctx, effect.Execution{
+				Command: "sh",
+				Args:    []string{"-c", "sleep 30 & wait"},
+				Stdout:  &bytes.Buffer{},
+				Stderr:  &bytes.Buffer{},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		}
+	})
+
+	it("parses the output of commands without a TTY when colored output is enabled", func() {
+		toolchain := t.TempDir()
+		Expect(os.WriteFile(filepath.Join(toolchain, "cargo"), []byte("#!/bin/sh\n"+
+			"echo 'warning: unused manifest key: package.metadata' >&2\n"+
+			"echo '    Updating crates.io index' >&2\n"+
+			"echo '{\"packages\":[],\"workspace_members\":[],\"target_directory\":\"/workspace/target\"}'\n"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(toolchain, "rustc"), []byte("#!/bin/sh\n"+
+			"echo 'warning: the default toolchain is outdated' >&2\n"+
+			"echo 'x86_64-unknown-linux-gnu'\n"), 0755)).To(Succeed())
+
+		r := runner.NewCargoRunner(
+			runner.WithExecutor(runner.ProcessExecutor{GracePeriod: time.Second, TTY: true}),
+			runner.WithToolchainPath(toolchain))
+
+		out, err := r.Metadata(t.TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Valid(out)).To(BeTrue(), string(out))
+
+		Expect(r.TargetList()).To(Equal([]string{"x86_64-unknown-linux-gnu"}))
+	})
+
+	it("aborts commands that do not finish within the timeout", func() {
+		toolchain := t.TempDir()
+		Expect(os.WriteFile(filepath.Join(toolchain, "cargo"), []byte("#!/bin/sh\nsleep 30\n"), 0755)).To(Succeed())
+
+		_, err := runner.NewCargoRunner(
+			runner.WithCommandTimeout(100*time.Millisecond),
+			runner.WithExecutor(runner.ProcessExecutor{GracePeriod: time.Second}),
+			runner.WithToolchainPath(toolchain)).CargoVersion()
+		Expect(err).To(MatchError(ContainSubstring("version --verbose' did not finish within 100ms")))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	it("does not run commands once the context is done", func() {
+		executor := &cargotest.Executor{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := runner.NewCargoRunner(
+			runner.WithContext(ctx),
+			runner.WithExecutor(executor)).CargoVersion()
+		Expect(err).To(MatchError(ContainSubstring("'cargo version --verbose' was aborted")))
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(executor.Executions).To(BeEmpty())
+	})
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/heroku/color"
+)
+
+// glibcVersionPattern matches a gnu target triple with the version of glibc that cargo-zigbuild links against, like
+// `x86_64-unknown-linux-gnu.2.17`
+var glibcVersionPattern = regexp.MustCompile(`^(.+-gnu[a-z]*)\.(\d+\.\d+)$`)
+
+// CrossBackend builds the binaries for a target triple other than the one of the host
+type CrossBackend interface {
+	// Ensure installs the tools that the backend builds with, unless they are already installed
+	Ensure(c CargoRunner) error
+
+	// Name returns the cross tool that selects the backend, like CrossToolZigbuild
+	Name() string
+
+	// Subcommand returns the cargo subcommand that builds for a target triple, like `build`
+	Subcommand() string
+}
+
+// CargoBackend cross compiles with cargo itself, which needs a linker for the target, configured with
+// `CARGO_TARGET_<TRIPLE>_LINKER` or in `.cargo/config.toml`
+type CargoBackend struct{}
+
+func (CargoBackend) Ensure(CargoRunner) error {
+	return nil
+}
+
+func (CargoBackend) Name() string {
+	return CrossToolCargo
+}
+
+func (CargoBackend) Subcommand() string {
+	return "build"
+}
+
+// ZigbuildBackend cross compiles with cargo-zigbuild, which links with zig and can pin the version of glibc that gnu
+// targets link against, see SplitGlibcVersion
+type ZigbuildBackend struct{}
+
+// Ensure installs cargo-zigbuild into CARGO_HOME with `cargo install`, unless it is already installed, for example as
+// a tool or by another buildpack. zig itself must be installed by another buildpack or a tool.
+func (ZigbuildBackend) Ensure(c CargoRunner) error {
+	if _, err := exec.LookPath("zig"); err != nil {
+		c.Logger.Bodyf("%s: zig is not on PATH, cargo-zigbuild fails to link unless it finds zig otherwise, like with the ziglang Python package", color.YellowString("Warning"))
+	}
+
+	if path := c.installedTool("cargo-zigbuild"); path != "" {
+		c.Logger.Bodyf("Using cargo-zigbuild from %s", path)
+		return nil
+	}
+
+	if err := c.InstallTool(ToolRequest{Locked: true, Name: "cargo-zigbuild"}, nil); err != nil {
+		return fmt.Errorf("unable to install cargo-zigbuild\n%w", err)
+	}
+
+	return nil
+}
+
+func (ZigbuildBackend) Name() string {
+	return CrossToolZigbuild
+}
+
+func (ZigbuildBackend) Subcommand() string {
+	return "zigbuild"
+}
+
+// LookupCrossBackend returns the backend of a cross tool, CrossToolCargo if it is empty
+func LookupCrossBackend(crossTool string) (CrossBackend, error) {
+	switch crossTool {
+	case "", CrossToolCargo:
+		return CargoBackend{}, nil
+	case CrossToolZigbuild:
+		return ZigbuildBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cross tool %q, must be %q or %q", crossTool, CrossToolCargo, CrossToolZigbuild)
+	}
+}
+
+// EnsureCrossTool installs the tools of the cross compilation backend selected with WithCrossTool
+func (c CargoRunner) EnsureCrossTool() error {
+	backend, err := LookupCrossBackend(c.CrossTool)
+	if err != nil {
+		return err
+	}
+
+	return backend.Ensure(c)
+}
+
+// SplitGlibcVersion splits the version of glibc from a gnu target triple like `x86_64-unknown-linux-gnu.2.17`, which
+// cargo-zigbuild links against instead of the glibc of the builder. Returns the triple unchanged and an empty version
+// if it does not pin one.
+func SplitGlibcVersion(triple string) (string, string) {
+	if m := glibcVersionPattern.FindStringSubmatch(triple); m != nil {
+		return m[1], m[2]
+	}
+	return triple, ""
+}
+
+// crossSubcommand returns the cargo subcommand that builds the binaries, which is the one of the cross compilation
+// backend when a target is set, see WithTarget
+func (c CargoRunner) crossSubcommand(args []string) (string, error) {
+	if explicitTarget(args) == "" {
+		return "build", nil
+	}
+
+	backend, err := LookupCrossBackend(c.CrossTool)
+	if err != nil {
+		return "", err
+	}
+	return backend.Subcommand(), nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testCross(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		cargoHome string
+		executor  *cargotest.Executor
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("PATH", t.TempDir())
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+			"Cargo.lock": "version = 3\n",
+		})
+		cargoHome = cargotest.CargoHome(t)
+		executor = &cargotest.Executor{}
+	})
+
+	newRunner := func(options ...runner.Option) runner.CargoRunner {
+		return runner.NewCargoRunner(append([]runner.Option{
+			runner.WithCargoHome(cargoHome),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+		}, options...)...)
+	}
+
+	it("splits the glibc version from a target triple", func() {
+		triple, glibc := runner.SplitGlibcVersion("x86_64-unknown-linux-gnu.2.17")
+		Expect(triple).To(Equal("x86_64-unknown-linux-gnu"))
+		Expect(glibc).To(Equal("2.17"))
+
+		triple, glibc = runner.SplitGlibcVersion("armv7-unknown-linux-gnueabihf.2.28")
+		Expect(triple).To(Equal("armv7-unknown-linux-gnueabihf"))
+		Expect(glibc).To(Equal("2.28"))
+
+		triple, glibc = runner.SplitGlibcVersion("x86_64-unknown-linux-musl")
+		Expect(triple).To(Equal("x86_64-unknown-linux-musl"))
+		Expect(glibc).To(BeEmpty())
+	})
+
+	it("looks up the backend of a cross tool", func() {
+		Expect(runner.LookupCrossBackend("")).To(Equal(runner.CargoBackend{}))
+		Expect(runner.LookupCrossBackend(runner.CrossToolCargo)).To(Equal(runner.CargoBackend{}))
+		Expect(runner.LookupCrossBackend(runner.CrossToolZigbuild)).To(Equal(runner.ZigbuildBackend{}))
+
+		_, err := runner.LookupCrossBackend("cross")
+		Expect(err).To(MatchError(`unsupported cross tool "cross", must be "cargo" or "zigbuild"`))
+	})
+
+	context("zigbuild", func() {
+		it("builds a target with cargo zigbuild", func() {
+			plan, err := newRunner(
+				runner.WithCrossTool(runner.CrossToolZigbuild),
+				runner.WithTarget("x86_64-unknown-linux-gnu.2.17")).BuildPlan(appDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Args).To(Equal([]string{"zigbuild", "--color=never", "--profile=release", "--target=x86_64-unknown-linux-gnu.2.17", "--workspace", "--bins"}))
+		})
+
+		it("builds without a target with cargo build", func() {
+			plan, err := newRunner(runner.WithCrossTool(runner.CrossToolZigbuild)).BuildPlan(appDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Args[0]).To(Equal("build"))
+		})
+
+		it("finds the binaries of a target with a glibc version in the directory of the target", func() {
+			Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).WithMember(".", "0.1.0", "app", "app"))).To(Succeed())
+
+			binaries, err := newRunner(
+				runner.WithCrossTool(runner.CrossToolZigbuild),
+				runner.WithTarget("x86_64-unknown-linux-gnu.2.17")).BuiltBinaries(appDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binaries).To(Equal([]string{filepath.Join(appDir, "target", "x86_64-unknown-linux-gnu", "release", "app")}))
+		})
+
+		it("installs cargo-zigbuild if it is missing", func() {
+			executor.On("cargo", "install", "cargo-zigbuild")
+
+			Expect(newRunner(runner.WithCrossTool(runner.CrossToolZigbuild)).EnsureCrossTool()).To(Succeed())
+
+			execution := cargotest.AssertExecuted(t, executor, "cargo", "install", "cargo-zigbuild")
+			Expect(execution.Args).To(ContainElement("--locked"))
+		})
+
+		it("uses an installed cargo-zigbuild", func() {
+			path := filepath.Join(cargoHome, "bin", "cargo-zigbuild")
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte{}, 0755)).To(Succeed())
+
+			Expect(newRunner(runner.WithCrossTool(runner.CrossToolZigbuild)).EnsureCrossTool()).To(Succeed())
+			Expect(executor.Executions).To(BeEmpty())
+		})
+
+		it("returns an error if cargo-zigbuild cannot be installed", func() {
+			executor.On("cargo", "install", "cargo-zigbuild").Err = errors.New("test-error")
+
+			Expect(newRunner(runner.WithCrossTool(runner.CrossToolZigbuild)).EnsureCrossTool()).
+				To(MatchError(ContainSubstring("unable to install cargo-zigbuild")))
+		})
+	})
+
+	it("installs nothing for the cargo backend", func() {
+		Expect(newRunner().EnsureCrossTool()).To(Succeed())
+		Expect(executor.Executions).To(BeEmpty())
+	})
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cleanAfterBuild clears out unnecessary files from under $CARGO_HOME after a build. Debug builds keep them, so that
+// the next rebuild does not extract the sources of the dependencies again.
+func (c CargoRunner) cleanAfterBuild() error {
+	if c.DebugBuild {
+		return nil
+	}
+
+	cleaned, err := c.CleanCargoHomeCache()
+	if err != nil {
+		return fmt.Errorf("unable to cleanup: %w", err)
+	}
+	if cleaned.Bytes > 0 {
+		c.Logger.Bodyf("Freed %s from CARGO_HOME", FormatBytes(cleaned.Bytes))
+	}
+
+	return nil
+}
+
+// debugRunner returns a copy of the runner that builds in the target directory of the application for debug builds,
+// instead of the temporary directory that `cargo install` uses, so that incremental artifacts are kept in the cache.
+// A target directory selected with WithTargetDir is kept.
+func (c CargoRunner) debugRunner(srcDir string) CargoRunner {
+	if !c.DebugBuild || c.TargetDir != "" {
+		return c
+	}
+
+	if _, ok := c.Env["CARGO_TARGET_DIR"]; ok {
+		return c
+	}
+	if _, ok := os.LookupEnv("CARGO_TARGET_DIR"); ok {
+		return c
+	}
+
+	return c.WithEnv(map[string]string{"CARGO_TARGET_DIR": filepath.Join(srcDir, "target")})
+}
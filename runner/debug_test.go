@@ -0,0 +1,131 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testDebug(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+		layer  = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+		})
+	})
+
+	it("builds with the dev profile", func() {
+		r := runner.NewCargoRunner(runner.WithDebugBuild(true))
+
+		plan, err := r.InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--debug"))
+
+		plan, err = r.BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--profile=dev"))
+
+		Expect(r.Profile()).To(Equal("dev"))
+	})
+
+	it("prefers a selected profile", func() {
+		r := runner.NewCargoRunner(runner.WithDebugBuild(true), runner.WithProfile("test"))
+
+		plan, err := r.InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--profile=test"))
+		Expect(plan.Args).NotTo(ContainElement("--debug"))
+
+		r = runner.NewCargoRunner(runner.WithDebugBuild(true), runner.WithCargoInstallArgs("--profile=release"))
+		Expect(r.Profile()).To(Equal("release"))
+	})
+
+	it("enables incremental compilation", func() {
+		r := runner.NewCargoRunner(runner.WithDebugBuild(true))
+		Expect(r.ProfileEnvironment()).To(HaveKeyWithValue("CARGO_PROFILE_DEV_INCREMENTAL", "true"))
+
+		r = runner.NewCargoRunner(
+			runner.WithDebugBuild(true),
+			runner.WithProfileVariables(map[string]string{"CARGO_PROFILE_DEV_INCREMENTAL": "false"}))
+		Expect(r.ProfileEnvironment()).To(HaveKeyWithValue("CARGO_PROFILE_DEV_INCREMENTAL", "false"))
+
+		r = runner.NewCargoRunner()
+		Expect(r.ProfileEnvironment()).NotTo(HaveKey("CARGO_PROFILE_RELEASE_INCREMENTAL"))
+	})
+
+	it("installs from the target directory of the application", func() {
+		plan, err := runner.NewCargoRunner(runner.WithDebugBuild(true)).InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Env).To(ContainElement("CARGO_TARGET_DIR=" + filepath.Join(appDir, "target")))
+
+		plan, err = runner.NewCargoRunner(
+			runner.WithDebugBuild(true),
+			runner.WithEnv(map[string]string{"CARGO_TARGET_DIR": "/tmp/target"})).InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Env).To(ContainElement("CARGO_TARGET_DIR=/tmp/target"))
+		Expect(plan.Env).NotTo(ContainElement("CARGO_TARGET_DIR=" + filepath.Join(appDir, "target")))
+
+		plan, err = runner.NewCargoRunner().InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Env).NotTo(ContainElement(HavePrefix("CARGO_TARGET_DIR=")))
+	})
+
+	it("does not clean CARGO_HOME", func() {
+		cargoHome := cargotest.CargoHome(t)
+		crate := filepath.Join(cargoHome, "registry", "src", "index.crates.io-6f17d22bba15001f", "serde-1.0.1")
+		Expect(os.MkdirAll(crate, 0755)).To(Succeed())
+
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "install")
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithDebugBuild(true),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+		Expect(r.Install(appDir, libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+		Expect(crate).To(BeADirectory())
+
+		r = runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+		Expect(r.Install(appDir, libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+		Expect(crate).NotTo(BeADirectory())
+	})
+}
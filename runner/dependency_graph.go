@@ -0,0 +1,250 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+const (
+	// DependencyKindNormal is the kind of dependencies that are linked into the binaries
+	DependencyKindNormal = "normal"
+
+	// DependencyKindBuild is the kind of dependencies of build scripts
+	DependencyKindBuild = "build"
+
+	// DependencyKindDev is the kind of dependencies of tests, examples and benchmarks
+	DependencyKindDev = "dev"
+)
+
+// GraphPackage is a package of the dependency graph, as described by `cargo metadata`
+type GraphPackage struct {
+	ID          string
+	License     string
+	LicenseFile string
+	Manifest    string
+	Name        string
+	Repository  string
+	Source      string
+	Version     string
+}
+
+// GraphDependency is an edge of the dependency graph, from a package to one of its dependencies
+type GraphDependency struct {
+	// Kind is the kind of the dependency, one of DependencyKindNormal, DependencyKindBuild or DependencyKindDev
+	Kind string
+
+	// Name is the name that the dependency is imported with, which differs from the package name if it is renamed
+	Name string
+
+	// Package is the id of the dependency
+	Package string
+
+	// Target is the platform that the dependency is limited to, like `cfg(unix)`, or empty for all platforms
+	Target string
+}
+
+// DependencyGraph is the resolved dependency graph of a workspace, as printed by `cargo metadata` without
+// `--no-deps`, for consumers like the SBOM, license reports and audits
+type DependencyGraph struct {
+	// Dependencies are the edges from a package to its dependencies, keyed by the id of the package and sorted by the
+	// id of the dependency and kind
+	Dependencies map[string][]GraphDependency
+
+	// Features are the features enabled for a package, keyed by its id
+	Features map[string][]string
+
+	// Packages are the packages of the workspace and all of their dependencies, sorted by name, version and id
+	Packages []GraphPackage
+
+	// Root is the id of the root package, or empty for a virtual workspace
+	Root string
+
+	// WorkspaceMembers are the ids of the workspace members
+	WorkspaceMembers []string
+}
+
+// Package returns the package with an id
+func (g DependencyGraph) Package(id string) (GraphPackage, bool) {
+	for _, p := range g.Packages {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return GraphPackage{}, false
+}
+
+// DependenciesOf returns the ids of the direct dependencies of a package, of any of the kinds or of all kinds if none
+// are given, sorted and without duplicates
+func (g DependencyGraph) DependenciesOf(id string, kinds ...string) []string {
+	var ids []string
+	for _, d := range g.Dependencies[id] {
+		if len(kinds) > 0 && !slices.Contains(kinds, d.Kind) {
+			continue
+		}
+		if len(ids) == 0 || ids[len(ids)-1] != d.Package {
+			ids = append(ids, d.Package)
+		}
+	}
+	return ids
+}
+
+// Reachable returns the ids of the workspace members and the packages they depend on, directly or transitively,
+// through dependencies of any of the kinds or of all kinds if none are given, sorted
+func (g DependencyGraph) Reachable(kinds ...string) []string {
+	reachable := map[string]bool{}
+	queue := append([]string{}, g.WorkspaceMembers...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if reachable[id] {
+			continue
+		}
+		reachable[id] = true
+		queue = append(queue, g.DependenciesOf(id, kinds...)...)
+	}
+
+	ids := make([]string, 0, len(reachable))
+	for id := range reachable {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ParseDependencyGraph returns the dependency graph of the output of `cargo metadata --format-version=1`, which must
+// not be run with `--no-deps`
+func ParseDependencyGraph(b []byte) (DependencyGraph, error) {
+	var m struct {
+		Packages []struct {
+			ID           string  `json:"id"`
+			License      *string `json:"license"`
+			LicenseFile  *string `json:"license_file"`
+			ManifestPath string  `json:"manifest_path"`
+			Name         string  `json:"name"`
+			Repository   *string `json:"repository"`
+			Source       *string `json:"source"`
+			Version      string  `json:"version"`
+		} `json:"packages"`
+		Resolve *struct {
+			Nodes []struct {
+				Deps []struct {
+					DepKinds []metadataDepKind `json:"dep_kinds"`
+					Name     string            `json:"name"`
+					Pkg      string            `json:"pkg"`
+				} `json:"deps"`
+				Features []string `json:"features"`
+				ID       string   `json:"id"`
+			} `json:"nodes"`
+			Root *string `json:"root"`
+		} `json:"resolve"`
+		WorkspaceMembers []string `json:"workspace_members"`
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return DependencyGraph{}, fmt.Errorf("unable to parse cargo metadata\n%w", err)
+	}
+
+	if m.Resolve == nil {
+		return DependencyGraph{}, fmt.Errorf("cargo metadata has no resolved dependencies, it must not be run with --no-deps")
+	}
+
+	g := DependencyGraph{
+		Dependencies:     map[string][]GraphDependency{},
+		Features:         map[string][]string{},
+		WorkspaceMembers: m.WorkspaceMembers,
+	}
+	if m.Resolve.Root != nil {
+		g.Root = *m.Resolve.Root
+	}
+
+	for _, p := range m.Packages {
+		g.Packages = append(g.Packages, GraphPackage{
+			ID:          p.ID,
+			License:     stringValue(p.License),
+			LicenseFile: stringValue(p.LicenseFile),
+			Manifest:    p.ManifestPath,
+			Name:        p.Name,
+			Repository:  stringValue(p.Repository),
+			Source:      stringValue(p.Source),
+			Version:     p.Version,
+		})
+	}
+	sort.Slice(g.Packages, func(i, j int) bool {
+		if g.Packages[i].Name != g.Packages[j].Name {
+			return g.Packages[i].Name < g.Packages[j].Name
+		}
+		if g.Packages[i].Version != g.Packages[j].Version {
+			return g.Packages[i].Version < g.Packages[j].Version
+		}
+		return g.Packages[i].ID < g.Packages[j].ID
+	})
+
+	for _, node := range m.Resolve.Nodes {
+		if len(node.Features) > 0 {
+			g.Features[node.ID] = node.Features
+		}
+
+		var deps []GraphDependency
+		for _, dep := range node.Deps {
+			for _, kind := range dep.DepKinds {
+				d := GraphDependency{Kind: DependencyKindNormal, Name: dep.Name, Package: dep.Pkg}
+				if kind.Kind != nil {
+					d.Kind = *kind.Kind
+				}
+				if kind.Target != nil {
+					d.Target = *kind.Target
+				}
+				deps = append(deps, d)
+			}
+		}
+		if len(deps) == 0 {
+			continue
+		}
+
+		sort.SliceStable(deps, func(i, j int) bool {
+			if deps[i].Package != deps[j].Package {
+				return deps[i].Package < deps[j].Package
+			}
+			return deps[i].Kind < deps[j].Kind
+		})
+		g.Dependencies[node.ID] = deps
+	}
+
+	return g, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// DependencyGraph returns the resolved dependency graph of the application, with `cargo metadata` including all
+// dependencies, unlike the metadata of the workspace members that is read with `--no-deps`
+func (c CargoRunner) DependencyGraph(srcDir string) (DependencyGraph, error) {
+	b, err := c.Metadata(srcDir)
+	if err != nil {
+		return DependencyGraph{}, fmt.Errorf("unable to run cargo metadata\n%w", err)
+	}
+
+	return ParseDependencyGraph(b)
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+const dependencyMetadata = `{
+  "packages": [
+    {"id": "path+file:///workspace#app@0.1.0", "name": "app", "version": "0.1.0", "source": null, "license": "MIT", "manifest_path": "/workspace/Cargo.toml"},
+    {"id": "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.210", "name": "serde", "version": "1.0.210", "source": "registry+https://github.com/rust-lang/crates.io-index", "license": "MIT OR Apache-2.0", "repository": "https://github.com/serde-rs/serde"},
+    {"id": "registry+https://github.com/rust-lang/crates.io-index#libc@0.2.158", "name": "libc", "version": "0.2.158", "source": "registry+https://github.com/rust-lang/crates.io-index", "license": null, "license_file": "LICENSE"},
+    {"id": "registry+https://github.com/rust-lang/crates.io-index#cc@1.1.0", "name": "cc", "version": "1.1.0", "source": "registry+https://github.com/rust-lang/crates.io-index"},
+    {"id": "registry+https://github.com/rust-lang/crates.io-index#tempfile@3.10.0", "name": "tempfile", "version": "3.10.0", "source": "registry+https://github.com/rust-lang/crates.io-index"}
+  ],
+  "resolve": {
+    "nodes": [
+      {
+        "id": "path+file:///workspace#app@0.1.0",
+        "deps": [
+          {"name": "serde", "pkg": "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.210", "dep_kinds": [{"kind": null, "target": null}]},
+          {"name": "tempfile", "pkg": "registry+https://github.com/rust-lang/crates.io-index#tempfile@3.10.0", "dep_kinds": [{"kind": "dev", "target": null}]},
+          {"name": "sys", "pkg": "registry+https://github.com/rust-lang/crates.io-index#libc@0.2.158", "dep_kinds": [{"kind": null, "target": "cfg(unix)"}, {"kind": "build", "target": null}]}
+        ],
+        "features": ["default"]
+      },
+      {
+        "id": "registry+https://github.com/rust-lang/crates.io-index#libc@0.2.158",
+        "deps": [{"name": "cc", "pkg": "registry+https://github.com/rust-lang/crates.io-index#cc@1.1.0", "dep_kinds": [{"kind": "build", "target": null}]}]
+      },
+      {"id": "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.210", "deps": [], "features": ["derive", "std"]},
+      {"id": "registry+https://github.com/rust-lang/crates.io-index#cc@1.1.0", "deps": []},
+      {"id": "registry+https://github.com/rust-lang/crates.io-index#tempfile@3.10.0", "deps": []}
+    ],
+    "root": "path+file:///workspace#app@0.1.0"
+  },
+  "workspace_members": ["path+file:///workspace#app@0.1.0"]
+}`
+
+func testDependencyGraph(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		app   = "path+file:///workspace#app@0.1.0"
+		cc    = "registry+https://github.com/rust-lang/crates.io-index#cc@1.1.0"
+		libc  = "registry+https://github.com/rust-lang/crates.io-index#libc@0.2.158"
+		serde = "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.210"
+		temp  = "registry+https://github.com/rust-lang/crates.io-index#tempfile@3.10.0"
+	)
+
+	it("parses the packages and the resolve graph", func() {
+		g, err := runner.ParseDependencyGraph([]byte(dependencyMetadata))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(g.Root).To(Equal(app))
+		Expect(g.WorkspaceMembers).To(Equal([]string{app}))
+
+		var names []string
+		for _, p := range g.Packages {
+			names = append(names, p.Name)
+		}
+		Expect(names).To(Equal([]string{"app", "cc", "libc", "serde", "tempfile"}))
+
+		pkg, ok := g.Package(serde)
+		Expect(ok).To(BeTrue())
+		Expect(pkg).To(Equal(runner.GraphPackage{
+			ID:         serde,
+			License:    "MIT OR Apache-2.0",
+			Name:       "serde",
+			Repository: "https://github.com/serde-rs/serde",
+			Source:     "registry+https://github.com/rust-lang/crates.io-index",
+			Version:    "1.0.210",
+		}))
+		pkg, _ = g.Package(libc)
+		Expect(pkg.LicenseFile).To(Equal("LICENSE"))
+		pkg, _ = g.Package(app)
+		Expect(pkg.Manifest).To(Equal("/workspace/Cargo.toml"))
+
+		Expect(g.Dependencies[app]).To(Equal([]runner.GraphDependency{
+			{Kind: runner.DependencyKindBuild, Name: "sys", Package: libc},
+			{Kind: runner.DependencyKindNormal, Name: "sys", Package: libc, Target: "cfg(unix)"},
+			{Kind: runner.DependencyKindNormal, Name: "serde", Package: serde},
+			{Kind: runner.DependencyKindDev, Name: "tempfile", Package: temp},
+		}))
+		Expect(g.Features).To(Equal(map[string][]string{app: {"default"}, serde: {"derive", "std"}}))
+	})
+
+	it("selects dependencies by kind", func() {
+		g, err := runner.ParseDependencyGraph([]byte(dependencyMetadata))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(g.DependenciesOf(app)).To(Equal([]string{libc, serde, temp}))
+		Expect(g.DependenciesOf(app, runner.DependencyKindNormal)).To(Equal([]string{libc, serde}))
+		Expect(g.DependenciesOf(app, runner.DependencyKindDev)).To(Equal([]string{temp}))
+
+		Expect(g.Reachable(runner.DependencyKindNormal)).To(Equal([]string{app, libc, serde}))
+		Expect(g.Reachable(runner.DependencyKindNormal, runner.DependencyKindBuild)).To(Equal([]string{app, cc, libc, serde}))
+		Expect(g.Reachable()).To(Equal([]string{app, cc, libc, serde, temp}))
+	})
+
+	it("fails without resolved dependencies", func() {
+		_, err := runner.ParseDependencyGraph([]byte(`{"packages": [], "resolve": null, "workspace_members": []}`))
+		Expect(err).To(MatchError(ContainSubstring("--no-deps")))
+	})
+
+	it("runs cargo metadata with the dependencies", func() {
+		appDir := t.TempDir()
+		executor := &cargotest.Executor{}
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).
+			WithMember("app", "0.1.0", ".", "app").
+			WithDependency("app", "serde", "1.0.0"))).To(Succeed())
+
+		g, err := runner.NewCargoRunner(runner.WithExecutor(executor)).DependencyGraph(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(g.Packages).To(HaveLen(2))
+		Expect(g.Reachable(runner.DependencyKindNormal)).To(HaveLen(2))
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "metadata")
+		Expect(execution.Args).NotTo(ContainElement("--no-deps"))
+		Expect(execution.Dir).To(Equal(appDir))
+	})
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFeatures splits a comma or space separated list of features, like `postgres, tls`, dropping empty and
+// duplicate entries
+func ParseFeatures(features string) []string {
+	var parsed []string
+	seen := map[string]bool{}
+
+	for _, f := range strings.FieldsFunc(features, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		if !seen[f] {
+			seen[f] = true
+			parsed = append(parsed, f)
+		}
+	}
+
+	return parsed
+}
+
+// FeatureArgs returns the arguments of cargo that select the features set with WithCargoFeatures and
+// WithNoDefaultFeatures
+func (c CargoRunner) FeatureArgs() []string {
+	var args []string
+
+	if len(c.CargoFeatures) > 0 {
+		args = append(args, fmt.Sprintf("--features=%s", strings.Join(c.CargoFeatures, ",")))
+	}
+	if c.NoDefaultFeatures {
+		args = append(args, "--no-default-features")
+	}
+
+	return args
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testFeatures(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layer = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+	})
+
+	it("parses comma and space separated features", func() {
+		Expect(runner.ParseFeatures("postgres, tls  json,,postgres")).To(Equal([]string{"postgres", "tls", "json"}))
+		Expect(runner.ParseFeatures(" ")).To(BeEmpty())
+	})
+
+	it("selects the features with cargo install", func() {
+		args, err := runner.NewCargoRunner(
+			runner.WithCargoFeatures("postgres tls"),
+			runner.WithCargoInstallArgs("--locked"),
+			runner.WithNoDefaultFeatures(true),
+		).BuildArgs(layer, ".")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(args).To(ContainElements("--features=postgres,tls", "--no-default-features"))
+	})
+
+	it("selects the features with cargo build", func() {
+		plan, err := runner.NewCargoRunner(runner.WithCargoFeatures("postgres")).BuildPlan(t.TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(plan.Args).To(ContainElement("--features=postgres"))
+		Expect(plan.Args).NotTo(ContainElement("--no-default-features"))
+	})
+
+	it("adds no arguments by default", func() {
+		Expect(runner.NewCargoRunner().FeatureArgs()).To(BeEmpty())
+	})
+}
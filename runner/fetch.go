@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// FetchPlan resolves the invocation that Fetch runs to download the dependencies of the project, without running it.
+// The lock file is enforced like for compiling, with `--locked` or `--frozen` from the install arguments, and only the
+// dependencies of the target of the build are fetched when the target is known.
+func (c CargoRunner) FetchPlan(srcDir string) (Invocation, error) {
+	installArgs, err := FilterInstallArgs(c.installArgs())
+	if err != nil {
+		return Invocation{}, fmt.Errorf("filter failed: %w", err)
+	}
+
+	args := []string{"fetch"}
+	args = append(args, c.UnstableFlags...)
+
+	color := c.Color
+	if color == "" {
+		color = ColorNever
+	}
+	args = append(args, fmt.Sprintf("--color=%s", color))
+
+	for _, arg := range installArgs {
+		if arg == "--locked" || arg == "--frozen" || arg == "--offline" {
+			args = append(args, arg)
+		}
+	}
+	args = append(args, c.offlineArgs(installArgs)...)
+
+	triple, err := ResolveTargetTriple(c.installArgs(), c.Stack, c.StaticType)
+	if err != nil {
+		return Invocation{}, fmt.Errorf("unable to resolve target triple\n%w", err)
+	}
+	if triple != "" {
+		args = append(args, fmt.Sprintf("--target=%s", triple))
+	}
+
+	return Invocation{
+		Args:    args,
+		Command: c.toolchainCommand("cargo"),
+		Dir:     c.workingDir(srcDir),
+		Env:     c.addedEnvironment(),
+	}, nil
+}
+
+// Fetch downloads the dependencies of the project into CARGO_HOME with `cargo fetch`, so that downloading is a phase
+// of its own before compiling, and a failing download is not mistaken for a failing compilation
+func (c CargoRunner) Fetch(srcDir string) error {
+	plan, err := c.FetchPlan(srcDir)
+	if err != nil {
+		return fmt.Errorf("unable to plan fetch\n%w", err)
+	}
+
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(plan.Args, " ")))
+	output, flush := c.output()
+	if err := c.executeRetrying(effect.Execution{
+		Command: plan.Command,
+		Args:    plan.Args,
+		Dir:     plan.Dir,
+		Env:     c.inheritEnvironment(plan.Env),
+		Stdout:  output,
+		Stderr:  output,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to fetch dependencies\n%w", err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write fetch output\n%w", err)
+	}
+
+	return nil
+}
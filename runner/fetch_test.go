@@ -0,0 +1,97 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testFetch(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+
+		appDir = t.TempDir()
+		executor = &cargotest.Executor{}
+	})
+
+	it("fetches the dependencies with the lock file enforced", func() {
+		executor.On("cargo", "fetch")
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--locked"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+			runner.WithWorkingDir("crates"))
+		Expect(r.Fetch(appDir)).To(Succeed())
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "fetch")
+		Expect(execution.Args).To(Equal([]string{"fetch", "--color=never", "--locked"}))
+		Expect(execution.Dir).To(Equal(filepath.Join(appDir, "crates")))
+	})
+
+	it("fetches the dependencies of the target", func() {
+		plan, err := runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--frozen --target=aarch64-unknown-linux-musl")).FetchPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(Equal([]string{"fetch", "--color=never", "--frozen", "--target=aarch64-unknown-linux-musl"}))
+
+		plan, err = runner.NewCargoRunner(
+			runner.WithStack("io.paketo.stacks.tiny"),
+			runner.WithStaticType(runner.StaticTypeMUSLC)).FetchPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--target=x86_64-unknown-linux-musl"))
+	})
+
+	it("does not enforce the lock file unless the install arguments do", func() {
+		plan, err := runner.NewCargoRunner(runner.WithCargoInstallArgs("--jobs=2")).FetchPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(Equal([]string{"fetch", "--color=never"}))
+	})
+
+	it("fetches offline for an offline build", func() {
+		plan, err := runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--locked"),
+			runner.WithOfflineBuild(true)).FetchPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(Equal([]string{"fetch", "--color=never", "--locked", "--offline"}))
+	})
+
+	it("fails when fetching fails", func() {
+		executor.On("cargo", "fetch").Err = errors.New("exit status 101")
+
+		err := runner.NewCargoRunner(
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{}))).Fetch(appDir)
+		Expect(err).To(MatchError(ContainSubstring("unable to fetch dependencies")))
+	})
+}
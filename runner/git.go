@@ -0,0 +1,192 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bindings"
+)
+
+// BindingTypeGitCredentials is the type of a binding with the credentials of git dependencies, either a `password`,
+// like a token, for the `url` of a git host or an `ssh-privatekey`
+const BindingTypeGitCredentials = "git-credentials"
+
+// DefaultGitUsername is the username of git credentials that only have a token
+const DefaultGitUsername = "git"
+
+// GitCredential authenticates the fetches of git dependencies, with a username and password for a URL or with an SSH
+// key
+type GitCredential struct {
+	Binding    string
+	KnownHosts string
+	Password   string
+	SSHKey     string
+	URL        string
+	Username   string
+}
+
+// GitCredentials reads the credentials of the bindings of type git-credentials, ordered by the name of the binding
+func GitCredentials(binds libcnb.Bindings) ([]GitCredential, error) {
+	var credentials []GitCredential
+
+	for _, b := range bindings.Resolve(binds, bindings.OfType(BindingTypeGitCredentials)) {
+		g := GitCredential{
+			Binding:    b.Name,
+			KnownHosts: b.Secret["known_hosts"],
+			Password:   strings.TrimSpace(b.Secret["password"]),
+			SSHKey:     b.Secret["ssh-privatekey"],
+			URL:        strings.TrimSpace(b.Secret["url"]),
+			Username:   strings.TrimSpace(b.Secret["username"]),
+		}
+
+		if g.Password == "" && strings.TrimSpace(g.SSHKey) == "" {
+			return nil, fmt.Errorf("binding %s of type %s requires a password or an ssh-privatekey", b.Name, BindingTypeGitCredentials)
+		}
+
+		if g.Password != "" {
+			u, err := url.Parse(g.URL)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return nil, fmt.Errorf("binding %s of type %s requires the https url of the git host for its password, found %q", b.Name, BindingTypeGitCredentials, RedactURLs(g.URL))
+			}
+			if u.User != nil {
+				return nil, fmt.Errorf("invalid binding %s, the url must not contain credentials, set a username and a password instead", b.Name)
+			}
+			if g.Username == "" {
+				g.Username = DefaultGitUsername
+			}
+		}
+
+		credentials = append(credentials, g)
+	}
+
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].Binding < credentials[j].Binding })
+	return credentials, nil
+}
+
+// GitEnvironment returns the environment that makes cargo fetch git dependencies with the git CLI, which uses the
+// credentials written to dir by WriteGitCredentials, instead of its built-in git client. Nothing is written, so that
+// the environment can be configured before the credentials exist. Returns nil without credentials.
+func GitEnvironment(dir string, credentials []GitCredential) map[string]string {
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	var ssh []string
+	knownHosts := false
+	for _, g := range credentials {
+		if g.SSHKey != "" {
+			ssh = append(ssh, "-i", filepath.Join(dir, fmt.Sprintf("id_%s", g.Binding)))
+		}
+		if strings.TrimSpace(g.KnownHosts) != "" {
+			knownHosts = true
+		}
+	}
+
+	env := map[string]string{
+		"CARGO_NET_GIT_FETCH_WITH_CLI": "true",
+		"GIT_CONFIG_GLOBAL":            filepath.Join(dir, "gitconfig"),
+		"GIT_TERMINAL_PROMPT":          "0",
+	}
+
+	if len(ssh) > 0 {
+		// hosts are only trusted on first use if no known hosts are provided
+		options := []string{"-o", "StrictHostKeyChecking=accept-new"}
+		if knownHosts {
+			options = []string{"-o", fmt.Sprintf("UserKnownHostsFile=%s", filepath.Join(dir, "known_hosts"))}
+		}
+
+		env["GIT_SSH_COMMAND"] = strings.Join(append(append([]string{"ssh", "-o", "IdentitiesOnly=yes"}, options...), ssh...), " ")
+	}
+
+	return env
+}
+
+// WriteGitCredentials writes a git configuration, with a credential helper for the passwords, and the SSH keys of
+// credentials to dir, which should be removed as soon as they are not needed anymore. The directory is created and
+// must not exist, so that credentials are never written to a directory that someone else controls. It returns the
+// environment of GitEnvironment. Returns nil without credentials.
+func WriteGitCredentials(dir string, credentials []GitCredential) (map[string]string, error) {
+	if len(credentials) == 0 {
+		return nil, nil
+	}
+
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create %s\n%w", dir, err)
+	}
+
+	// credentials that were written in part are removed
+	if err := writeGitFiles(dir, credentials); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return GitEnvironment(dir, credentials), nil
+}
+
+func writeGitFiles(dir string, credentials []GitCredential) error {
+	var stored, knownHosts []string
+	for _, g := range credentials {
+		if g.Password != "" {
+			u, err := url.Parse(g.URL)
+			if err != nil {
+				return fmt.Errorf("unable to parse url of binding %s\n%w", g.Binding, err)
+			}
+			u.User = url.UserPassword(g.Username, g.Password)
+			stored = append(stored, u.String())
+		}
+
+		if g.SSHKey != "" {
+			key := filepath.Join(dir, fmt.Sprintf("id_%s", g.Binding))
+			if err := os.WriteFile(key, []byte(strings.TrimSpace(g.SSHKey)+"\n"), 0600); err != nil {
+				return fmt.Errorf("unable to write ssh key of binding %s\n%w", g.Binding, err)
+			}
+		}
+
+		if strings.TrimSpace(g.KnownHosts) != "" {
+			knownHosts = append(knownHosts, strings.TrimSpace(g.KnownHosts))
+		}
+	}
+
+	config := filepath.Join(dir, "gitconfig")
+	content := ""
+	if len(stored) > 0 {
+		store := filepath.Join(dir, "credentials")
+		if err := os.WriteFile(store, []byte(strings.Join(stored, "\n")+"\n"), 0600); err != nil {
+			return fmt.Errorf("unable to write git credentials\n%w", err)
+		}
+		content = fmt.Sprintf("[credential]\n\thelper = store --file=%s\n", store)
+	}
+	if err := os.WriteFile(config, []byte(content), 0600); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", config, err)
+	}
+
+	if len(knownHosts) > 0 {
+		file := filepath.Join(dir, "known_hosts")
+		if err := os.WriteFile(file, []byte(strings.Join(knownHosts, "\n")+"\n"), 0600); err != nil {
+			return fmt.Errorf("unable to write known hosts\n%w", err)
+		}
+	}
+
+	return nil
+}
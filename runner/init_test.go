@@ -25,6 +25,48 @@ import (
 
 func TestUnitRunner(t *testing.T) {
 	suite := spec.New("Runners", spec.Report(report.Terminal{}))
+	suite("Audit", testAudit)
+	suite("Auditable", testAuditable)
+	suite("Build", testBuild)
+	suite("BuildProfile", testBuildProfile)
+	suite("CacheKey", testCacheKey)
+	suite("Context", testContext)
+	suite("Cross", testCross)
+	suite("Plan", testPlan)
+	suite("Registry", testRegistry)
+	suite("Retry", testRetry)
+	suite("Debug", testDebug)
+	suite("DependencyGraph", testDependencyGraph)
+	suite("Features", testFeatures)
+	suite("Fetch", testFetch)
+	suite("Git", testGit)
+	suite("InstallAll", testInstallAll)
+	suite("Jobs", testJobs)
+	suite("Licenses", testLicenses)
+	suite("Linker", testLinker)
+	suite("Link", testLink)
+	suite("Locked", testLocked)
+	suite("MemberFilter", testMemberFilter)
+	suite("Members", testMembers)
+	suite("Messages", testMessages)
+	suite("MSRV", testMSRV)
+	suite("Offline", testOffline)
+	suite("Platforms", testPlatforms)
+	suite("Processes", testProcesses)
+	suite("PostProcess", testPostProcess)
+	suite("Profile", testProfile)
 	suite("Runner", testRunners)
+	suite("Sccache", testSccache)
+	suite("Seed", testSeed)
+	suite("Stacks", testStacks)
+	suite("Summary", testSummary)
+	suite("Sweep", testSweep)
+	suite("TargetDir", testTargetDir)
+	suite("TargetSpec", testTargetSpec)
+	suite("ToolchainFile", testToolchainFile)
+	suite("Tests", testTests)
+	suite("Timings", testTimings)
+	suite("Tools", testTools)
+	suite("Version", testVersion)
 	suite.Run(t)
 }
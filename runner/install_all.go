@@ -0,0 +1,144 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// InstallAll builds and installs the selected workspace members with `cargo install`, each after the members it
+// depends on. The members share one target directory, so that the dependencies they have in common, like path
+// dependencies on other members, are compiled once instead of once per member. Without a target directory selected
+// with WithTargetDir, a temporary one is shared, like the one that `cargo install` uses for a single member.
+func (c CargoRunner) InstallAll(srcDir string, destLayer libcnb.Layer) error {
+	members, err := c.WorkspaceMembers(srcDir, destLayer)
+	if err != nil {
+		return fmt.Errorf("unable to fetch members\n%w", err)
+	}
+
+	graph, err := c.DependencyGraph(srcDir)
+	if err != nil {
+		return fmt.Errorf("unable to resolve dependency graph\n%w", err)
+	}
+
+	order, err := MemberOrder(graph, members)
+	if err != nil {
+		return err
+	}
+
+	_, targetDirSet := c.Env["CARGO_TARGET_DIR"]
+	if c.TargetDir == "" && !targetDirSet {
+		if c.DebugBuild {
+			c.TargetDir = "target"
+		} else {
+			dir, err := os.MkdirTemp("", "cargo-install")
+			if err != nil {
+				return fmt.Errorf("unable to create target directory\n%w", err)
+			}
+			defer os.RemoveAll(dir)
+			c.TargetDir = dir
+		}
+	}
+
+	for _, path := range order {
+		name := path
+		if rel, err := filepath.Rel(srcDir, path); err == nil {
+			name = rel
+		}
+
+		c.Logger.Bodyf("Installing member %s", name)
+		if err := c.installMember(path, srcDir, destLayer); err != nil {
+			return fmt.Errorf("unable to install member %s\n%w", path, err)
+		}
+	}
+
+	return c.cleanAfterBuild()
+}
+
+// MemberOrder returns the paths of the workspace members, ordered so that each member comes after the members it
+// depends on, directly or through other packages, with normal or build dependencies. Members that do not depend on
+// each other keep their order.
+func MemberOrder(graph DependencyGraph, members []url.URL) ([]string, error) {
+	ids := map[string]string{}
+	for _, id := range graph.WorkspaceMembers {
+		if p, ok := graph.Package(id); ok && p.Manifest != "" {
+			ids[filepath.Dir(p.Manifest)] = id
+		}
+	}
+
+	selected := map[string]string{}
+	for _, member := range members {
+		if id, ok := ids[filepath.Clean(member.Path)]; ok {
+			selected[id] = member.Path
+		}
+	}
+
+	var (
+		order    []string
+		state    = map[string]int{}
+		visiting = 1
+		visited  = 2
+	)
+
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("workspace members depend on each other in a cycle: %s", strings.Join(append(path, id), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[id] = visiting
+		for _, dependency := range graph.DependenciesOf(id, DependencyKindNormal, DependencyKindBuild) {
+			if err := visit(dependency, append(path, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+
+		if p, ok := selected[id]; ok {
+			order = append(order, p)
+		}
+		return nil
+	}
+
+	for _, member := range members {
+		id, ok := ids[filepath.Clean(member.Path)]
+		if !ok {
+			// members that are not in the graph are installed last, in their order
+			continue
+		}
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, member := range members {
+		if _, ok := ids[filepath.Clean(member.Path)]; !ok {
+			order = append(order, member.Path)
+		}
+	}
+
+	return order, nil
+}
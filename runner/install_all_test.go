@@ -0,0 +1,159 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testInstallAll(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+		metadata *cargotest.Metadata
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+		cargotest.CargoHome(t)
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[workspace]\nmembers = [\"crates/*\"]\n",
+		})
+
+		metadata = cargotest.NewMetadata(appDir).
+			WithMember("api", "0.1.0", "crates/api", "api").
+			WithMember("cli", "0.1.0", "crates/cli", "cli").
+			WithMember("core", "0.1.0", "crates/core").
+			WithMemberDependency("api", "core").
+			WithMemberDependency("cli", "api").
+			WithDependency("core", "serde", "1.0.0")
+
+		executor = &cargotest.Executor{}
+		Expect(executor.OnMetadata(metadata)).To(Succeed())
+		executor.On("cargo", "install")
+	})
+
+	// installed returns the member paths and target directories of the `cargo install` executions
+	installed := func() ([]string, []string) {
+		var paths, targetDirs []string
+		for _, e := range executor.Executions {
+			if len(e.Args) == 0 || e.Args[0] != "install" {
+				continue
+			}
+
+			for _, arg := range e.Args {
+				if p, ok := strings.CutPrefix(arg, "--path="); ok {
+					paths = append(paths, p)
+				}
+			}
+			for _, env := range e.Env {
+				if dir, ok := strings.CutPrefix(env, "CARGO_TARGET_DIR="); ok {
+					targetDirs = append(targetDirs, dir)
+				}
+			}
+		}
+		return paths, targetDirs
+	}
+
+	it("installs the members in the order of their dependencies in one target directory", func() {
+		buf := &bytes.Buffer{}
+		r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithLogger(bard.NewLogger(buf)))
+
+		Expect(r.InstallAll(appDir, libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		paths, targetDirs := installed()
+		Expect(paths).To(Equal([]string{
+			filepath.Join(appDir, "crates", "core"),
+			filepath.Join(appDir, "crates", "api"),
+			filepath.Join(appDir, "crates", "cli"),
+		}))
+		Expect(targetDirs).To(HaveLen(3))
+		Expect(targetDirs[1]).To(Equal(targetDirs[0]))
+		Expect(targetDirs[2]).To(Equal(targetDirs[0]))
+		Expect(targetDirs[0]).NotTo(BeAnExistingFile())
+
+		Expect(buf.String()).To(ContainSubstring("Installing member crates/core"))
+	})
+
+	it("shares the selected target directory", func() {
+		r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithTargetDir("target"))
+
+		Expect(r.InstallAll(appDir, libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		_, targetDirs := installed()
+		Expect(targetDirs).To(HaveEach(filepath.Join(appDir, "target")))
+	})
+
+	it("installs the selected members only", func() {
+		r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithCargoWorkspaceMembers("cli,core"))
+
+		Expect(r.InstallAll(appDir, libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		paths, _ := installed()
+		Expect(paths).To(Equal([]string{
+			filepath.Join(appDir, "crates", "core"),
+			filepath.Join(appDir, "crates", "cli"),
+		}))
+	})
+
+	it("orders members that do not depend on each other as given", func() {
+		b, err := metadata.JSON()
+		Expect(err).NotTo(HaveOccurred())
+		graph, err := runner.ParseDependencyGraph(b)
+		Expect(err).NotTo(HaveOccurred())
+
+		members := []url.URL{
+			{Path: filepath.Join(appDir, "crates", "core")},
+			{Path: filepath.Join(appDir, "crates", "cli")},
+			{Path: filepath.Join(appDir, "tools", "unknown")},
+			{Path: filepath.Join(appDir, "crates", "api")},
+		}
+		Expect(runner.MemberOrder(graph, members)).To(Equal([]string{
+			filepath.Join(appDir, "crates", "core"),
+			filepath.Join(appDir, "crates", "api"),
+			filepath.Join(appDir, "crates", "cli"),
+			filepath.Join(appDir, "tools", "unknown"),
+		}))
+	})
+
+	it("fails if members depend on each other in a cycle", func() {
+		metadata.WithMemberDependency("core", "cli")
+
+		b, err := metadata.JSON()
+		Expect(err).NotTo(HaveOccurred())
+		graph, err := runner.ParseDependencyGraph(b)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = runner.MemberOrder(graph, []url.URL{{Path: filepath.Join(appDir, "crates", "api")}})
+		Expect(err).To(MatchError(ContainSubstring("workspace members depend on each other in a cycle")))
+	})
+}
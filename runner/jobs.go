@@ -0,0 +1,196 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultMemoryPerJob is the memory that a job of cargo is assumed to need when the number of jobs is derived from the
+// available memory, enough for rustc to compile and link most crates
+const DefaultMemoryPerJob int64 = 2 << 30
+
+// cgroup v1 reports no memory limit as a value close to the maximum of int64
+const unlimitedMemory int64 = 1 << 62
+
+// Resources are the CPUs and the memory available to the build, within the limits of its cgroup
+type Resources struct {
+	CPUs   int
+	Memory int64
+}
+
+// AvailableResources reads the resources available to the build from /proc/meminfo and the cgroup v2 or v1 files in
+// /sys/fs/cgroup, both below root. Memory is zero if it is unknown.
+func AvailableResources(root string) (Resources, error) {
+	r := Resources{CPUs: runtime.NumCPU()}
+
+	if cpus, err := cgroupCPUs(root); err != nil {
+		return Resources{}, err
+	} else if cpus > 0 && cpus < r.CPUs {
+		r.CPUs = cpus
+	}
+
+	available, err := availableMemory(filepath.Join(root, "proc", "meminfo"))
+	if err != nil {
+		return Resources{}, err
+	}
+	r.Memory = available
+
+	limit, err := cgroupMemory(root)
+	if err != nil {
+		return Resources{}, err
+	}
+	if limit > 0 && (r.Memory == 0 || limit < r.Memory) {
+		r.Memory = limit
+	}
+
+	return r, nil
+}
+
+// Jobs returns the number of jobs that the memory suffices for with memoryPerJob each, at least one. Zero means that
+// there is enough memory for a job on every CPU, which cargo runs by default.
+func (r Resources) Jobs(memoryPerJob int64) int {
+	if r.Memory == 0 || memoryPerJob <= 0 {
+		return 0
+	}
+
+	jobs := int(r.Memory / memoryPerJob)
+	if jobs >= r.CPUs {
+		return 0
+	}
+	return max(jobs, 1)
+}
+
+// jobsArgs returns the number of jobs of cargo, unless the arguments already set it
+func (c CargoRunner) jobsArgs(args []string) []string {
+	if c.Jobs <= 0 {
+		return nil
+	}
+
+	for _, arg := range args {
+		if arg == "-j" || arg == "--jobs" || strings.HasPrefix(arg, "--jobs=") || (strings.HasPrefix(arg, "-j") && len(arg) > 2) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("--jobs=%d", c.Jobs)}
+}
+
+// cgroupCPUs returns the CPUs that the CPU quota of the cgroup allows for, rounded up, or zero without a quota
+func cgroupCPUs(root string) (int, error) {
+	var quota, period string
+
+	if b, err := os.ReadFile(filepath.Join(root, "sys", "fs", "cgroup", "cpu.max")); err == nil {
+		quota, period, _ = strings.Cut(strings.TrimSpace(string(b)), " ")
+	} else if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("unable to read cgroup CPU quota\n%w", err)
+	} else {
+		dir := filepath.Join(root, "sys", "fs", "cgroup", "cpu")
+		q, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+		if os.IsNotExist(err) {
+			return 0, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("unable to read cgroup CPU quota\n%w", err)
+		}
+		p, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err != nil {
+			return 0, fmt.Errorf("unable to read cgroup CPU period\n%w", err)
+		}
+		quota, period = strings.TrimSpace(string(q)), strings.TrimSpace(string(p))
+	}
+
+	if quota == "max" || quota == "-1" {
+		return 0, nil
+	}
+
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse cgroup CPU quota %q\n%w", quota, err)
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, fmt.Errorf("unable to parse cgroup CPU period %q", period)
+	}
+
+	return int((q + p - 1) / p), nil
+}
+
+// cgroupMemory returns the memory limit of the cgroup, or zero without a limit
+func cgroupMemory(root string) (int64, error) {
+	for _, file := range []string{
+		filepath.Join(root, "sys", "fs", "cgroup", "memory.max"),
+		filepath.Join(root, "sys", "fs", "cgroup", "memory", "memory.limit_in_bytes"),
+	} {
+		b, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, fmt.Errorf("unable to read %s\n%w", file, err)
+		}
+
+		value := strings.TrimSpace(string(b))
+		if value == "max" {
+			return 0, nil
+		}
+
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse cgroup memory limit %q\n%w", value, err)
+		}
+		if limit >= unlimitedMemory {
+			return 0, nil
+		}
+		return limit, nil
+	}
+
+	return 0, nil
+}
+
+// availableMemory returns MemAvailable of /proc/meminfo, or zero if it is not reported
+func availableMemory(file string) (int64, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("unable to open %s\n%w", file, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse MemAvailable %q\n%w", fields[1], err)
+		}
+		return kb << 10, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testJobs(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		root = t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(root, "proc"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "proc", "meminfo"),
+			[]byte("MemTotal:       16384000 kB\nMemFree:         1024000 kB\nMemAvailable:    8388608 kB\n"), 0644)).To(Succeed())
+	})
+
+	write := func(path string, content string) {
+		t.Helper()
+		file := filepath.Join(root, "sys", "fs", "cgroup", path)
+		Expect(os.MkdirAll(filepath.Dir(file), 0755)).To(Succeed())
+		Expect(os.WriteFile(file, []byte(content), 0644)).To(Succeed())
+	}
+
+	context("available resources", func() {
+		it("reads the available memory without a cgroup", func() {
+			resources, err := runner.AvailableResources(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources.Memory).To(Equal(int64(8 << 30)))
+			Expect(resources.CPUs).To(BeNumerically(">", 0))
+		})
+
+		it("reads the limits of a cgroup v2", func() {
+			write("memory.max", "2147483648\n")
+			write("cpu.max", "100000 100000\n")
+
+			Expect(runner.AvailableResources(root)).To(Equal(runner.Resources{CPUs: 1, Memory: 2 << 30}))
+		})
+
+		it("ignores unlimited cgroup v2 limits", func() {
+			write("memory.max", "max\n")
+			write("cpu.max", "max 100000\n")
+
+			resources, err := runner.AvailableResources(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources.Memory).To(Equal(int64(8 << 30)))
+		})
+
+		it("reads the limits of a cgroup v1", func() {
+			write("memory/memory.limit_in_bytes", "1073741824\n")
+			write("cpu/cpu.cfs_quota_us", "50000\n")
+			write("cpu/cpu.cfs_period_us", "100000\n")
+
+			Expect(runner.AvailableResources(root)).To(Equal(runner.Resources{CPUs: 1, Memory: 1 << 30}))
+		})
+
+		it("ignores unlimited cgroup v1 limits", func() {
+			write("memory/memory.limit_in_bytes", "9223372036854771712\n")
+			write("cpu/cpu.cfs_quota_us", "-1\n")
+			write("cpu/cpu.cfs_period_us", "100000\n")
+
+			resources, err := runner.AvailableResources(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources.Memory).To(Equal(int64(8 << 30)))
+		})
+
+		it("fails for a malformed limit", func() {
+			write("memory.max", "lots\n")
+
+			_, err := runner.AvailableResources(root)
+			Expect(err).To(MatchError(ContainSubstring(`unable to parse cgroup memory limit "lots"`)))
+		})
+	})
+
+	context("jobs", func() {
+		it("runs fewer jobs than CPUs when the memory does not suffice", func() {
+			Expect(runner.Resources{CPUs: 8, Memory: 6 << 30}.Jobs(2 << 30)).To(Equal(3))
+		})
+
+		it("runs at least one job", func() {
+			Expect(runner.Resources{CPUs: 8, Memory: 1 << 30}.Jobs(2 << 30)).To(Equal(1))
+		})
+
+		it("keeps the default of cargo when the memory suffices", func() {
+			Expect(runner.Resources{CPUs: 4, Memory: 16 << 30}.Jobs(2 << 30)).To(Equal(0))
+		})
+
+		it("keeps the default of cargo when the memory is unknown", func() {
+			Expect(runner.Resources{CPUs: 4}.Jobs(2 << 30)).To(Equal(0))
+		})
+	})
+
+	context("arguments", func() {
+		it("passes the number of jobs", func() {
+			plan, err := runner.NewCargoRunner(runner.WithJobs(3)).
+				InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Args).To(ContainElement("--jobs=3"))
+		})
+
+		it("keeps the default of cargo", func() {
+			plan, err := runner.NewCargoRunner().
+				InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Args).NotTo(ContainElement(HavePrefix("--jobs")))
+		})
+
+		it("keeps the jobs of the install arguments", func() {
+			plan, err := runner.NewCargoRunner(
+				runner.WithCargoInstallArgs("-j2"),
+				runner.WithJobs(3)).
+				InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Args).To(ContainElement("-j2"))
+			Expect(plan.Args).NotTo(ContainElement("--jobs=3"))
+		})
+	})
+}
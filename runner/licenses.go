@@ -0,0 +1,116 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NoAssertion is the license of packages that do not declare an SPDX license expression, following SPDX
+const NoAssertion = "NOASSERTION"
+
+// PackageLicense is the license of a dependency
+type PackageLicense struct {
+	License     string `json:"license"`
+	LicenseFile string `json:"license_file,omitempty"`
+	Name        string `json:"name"`
+	Repository  string `json:"repository,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Version     string `json:"version"`
+}
+
+// LicenseUsage is a license expression and the dependencies that are licensed under it, as `name@version`
+type LicenseUsage struct {
+	License  string   `json:"license"`
+	Packages []string `json:"packages"`
+}
+
+// LicenseReport lists the licenses of the dependencies that are linked into the binaries, by license expression and
+// by package
+type LicenseReport struct {
+	Licenses []LicenseUsage   `json:"licenses"`
+	Packages []PackageLicense `json:"packages"`
+}
+
+// Unasserted returns the dependencies that do not declare a license expression, which have to be reviewed by hand
+func (r LicenseReport) Unasserted() []PackageLicense {
+	var found []PackageLicense
+	for _, p := range r.Packages {
+		if p.License == NoAssertion {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// NewLicenseReport returns the licenses of the packages that the workspace members depend on through normal
+// dependencies, which are linked into the binaries. The workspace members are not listed.
+func NewLicenseReport(g DependencyGraph) LicenseReport {
+	members := map[string]bool{}
+	for _, id := range g.WorkspaceMembers {
+		members[id] = true
+	}
+
+	reachable := map[string]bool{}
+	for _, id := range g.Reachable(DependencyKindNormal) {
+		reachable[id] = !members[id]
+	}
+
+	report := LicenseReport{Licenses: []LicenseUsage{}, Packages: []PackageLicense{}}
+	usages := map[string][]string{}
+	for _, p := range g.Packages {
+		if !reachable[p.ID] {
+			continue
+		}
+
+		license := p.License
+		if license == "" {
+			license = NoAssertion
+		}
+
+		report.Packages = append(report.Packages, PackageLicense{
+			License:     license,
+			LicenseFile: p.LicenseFile,
+			Name:        p.Name,
+			Repository:  p.Repository,
+			Source:      p.Source,
+			Version:     p.Version,
+		})
+		usages[license] = append(usages[license], fmt.Sprintf("%s@%s", p.Name, p.Version))
+	}
+
+	for license, packages := range usages {
+		report.Licenses = append(report.Licenses, LicenseUsage{License: license, Packages: packages})
+	}
+	sort.Slice(report.Licenses, func(i, j int) bool {
+		return report.Licenses[i].License < report.Licenses[j].License
+	})
+
+	return report
+}
+
+// Licenses returns the licenses of the dependencies of the application, as declared in their manifests and resolved
+// by `cargo metadata`
+func (c CargoRunner) Licenses(srcDir string) (LicenseReport, error) {
+	g, err := c.DependencyGraph(srcDir)
+	if err != nil {
+		return LicenseReport{}, fmt.Errorf("unable to resolve dependency graph\n%w", err)
+	}
+
+	return NewLicenseReport(g), nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testLicenses(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("lists the licenses of the dependencies linked into the binaries", func() {
+		g, err := runner.ParseDependencyGraph([]byte(dependencyMetadata))
+		Expect(err).NotTo(HaveOccurred())
+
+		report := runner.NewLicenseReport(g)
+		Expect(report).To(Equal(runner.LicenseReport{
+			Licenses: []runner.LicenseUsage{
+				{License: "MIT OR Apache-2.0", Packages: []string{"serde@1.0.210"}},
+				{License: runner.NoAssertion, Packages: []string{"libc@0.2.158"}},
+			},
+			Packages: []runner.PackageLicense{
+				{
+					License:     runner.NoAssertion,
+					LicenseFile: "LICENSE",
+					Name:        "libc",
+					Source:      "registry+https://github.com/rust-lang/crates.io-index",
+					Version:     "0.2.158",
+				},
+				{
+					License:    "MIT OR Apache-2.0",
+					Name:       "serde",
+					Repository: "https://github.com/serde-rs/serde",
+					Source:     "registry+https://github.com/rust-lang/crates.io-index",
+					Version:    "1.0.210",
+				},
+			},
+		}))
+
+		Expect(report.Unasserted()).To(HaveLen(1))
+		Expect(report.Unasserted()[0].Name).To(Equal("libc"))
+	})
+
+	it("resolves the licenses with cargo metadata", func() {
+		appDir := t.TempDir()
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "metadata").Stdout = dependencyMetadata
+
+		report, err := runner.NewCargoRunner(runner.WithExecutor(executor)).Licenses(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Packages).To(HaveLen(2))
+
+		cargotest.AssertExecuted(t, executor, "cargo", "metadata")
+	})
+
+	it("returns an error if cargo metadata fails", func() {
+		executor := &cargotest.Executor{}
+
+		_, err := runner.NewCargoRunner(runner.WithExecutor(executor)).Licenses(t.TempDir())
+		Expect(err).To(MatchError(ContainSubstring("unable to resolve dependency graph")))
+	})
+}
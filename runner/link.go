@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// LinkFile places source at destination without copying its bytes where possible, with a hard link or a reflink
+// when both are on the same filesystem, or otherwise with a copy. Only use it for files that are replaced rather than
+// modified in place, like the binaries built by cargo, because a hard link shares all changes with the source.
+func LinkFile(source string, destination string) error {
+	if err := os.Link(source, destination); err == nil {
+		return nil
+	}
+
+	return CloneFile(source, destination)
+}
+
+// Unlink replaces path with a clone of its own, see CloneFile, so that a file hard linked by LinkFile, like a binary
+// of the target directory placed into a layer, can be modified in place without modifying the other links
+func Unlink(path string) error {
+	tmp := path + ".unlink"
+	if err := CloneFile(path, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to replace %s\n%w", path, err)
+	}
+
+	return nil
+}
+
+// CloneFile copies source to destination with a reflink on filesystems that support them, like btrfs and xfs, so
+// that the files share their blocks until either is modified, or otherwise with a byte copy. The mode is preserved.
+func CloneFile(source string, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("unable to open %s\n%w", source, err)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat %s\n%w", source, err)
+	}
+
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", destination, err)
+	}
+	defer out.Close()
+
+	if err := reflink(out, in); err != nil {
+		if _, err := io.Copy(out, in); err != nil {
+			return fmt.Errorf("unable to copy %s to %s\n%w", source, destination, err)
+		}
+	}
+
+	// the umask applies to the mode when the file is created
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("unable to chmod %s\n%w", destination, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testLink(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir    string
+		source string
+	)
+
+	it.Before(func() {
+		dir = t.TempDir()
+		source = filepath.Join(dir, "my-app")
+		Expect(os.WriteFile(source, []byte("binary"), 0755)).To(Succeed())
+	})
+
+	it("hard links files on the same filesystem", func() {
+		destination := filepath.Join(dir, "linked")
+		Expect(runner.LinkFile(source, destination)).To(Succeed())
+
+		sourceInfo, err := os.Stat(source)
+		Expect(err).NotTo(HaveOccurred())
+		destinationInfo, err := os.Stat(destination)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.SameFile(sourceInfo, destinationInfo)).To(BeTrue())
+	})
+
+	it("falls back to a copy if the destination exists", func() {
+		destination := filepath.Join(dir, "existing")
+		Expect(os.WriteFile(destination, []byte("a much longer old binary"), 0644)).To(Succeed())
+
+		Expect(runner.LinkFile(source, destination)).To(Succeed())
+		Expect(os.ReadFile(destination)).To(Equal([]byte("binary")))
+	})
+
+	it("clones files independently of the source", func() {
+		destination := filepath.Join(dir, "cloned")
+		Expect(runner.CloneFile(source, destination)).To(Succeed())
+
+		info, err := os.Stat(destination)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+		Expect(os.WriteFile(destination, []byte("changed"), 0755)).To(Succeed())
+		Expect(os.ReadFile(source)).To(Equal([]byte("binary")))
+	})
+
+	it("unlinks a hard linked file", func() {
+		destination := filepath.Join(dir, "linked")
+		Expect(runner.LinkFile(source, destination)).To(Succeed())
+
+		Expect(runner.Unlink(destination)).To(Succeed())
+		Expect(os.ReadFile(destination)).To(Equal([]byte("binary")))
+		Expect(filepath.Join(dir, "linked.unlink")).NotTo(BeAnExistingFile())
+
+		info, err := os.Stat(destination)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+		Expect(os.WriteFile(destination, []byte("changed"), 0755)).To(Succeed())
+		Expect(os.ReadFile(source)).To(Equal([]byte("binary")))
+	})
+}
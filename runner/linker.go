@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	LinkerLLD  = "lld"
+	LinkerMold = "mold"
+)
+
+// linkerExecutables maps the linkers to the executable that the C compiler runs for `-fuse-ld`
+var linkerExecutables = map[string]string{
+	LinkerLLD:  "ld.lld",
+	LinkerMold: "mold",
+}
+
+// ResolveLinker returns the absolute path of the executable of a linker, which is LinkerLLD or LinkerMold. Returns an
+// error if the linker is not supported or cannot be found on PATH.
+func ResolveLinker(linker string) (string, error) {
+	if linker == "" {
+		return "", nil
+	}
+
+	executable, ok := linkerExecutables[linker]
+	if !ok {
+		return "", fmt.Errorf("unsupported linker %q, must be %q or %q", linker, LinkerLLD, LinkerMold)
+	}
+
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return "", fmt.Errorf("unable to find linker %s\n%w", executable, err)
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve linker %s\n%w", executable, err)
+	}
+
+	return path, nil
+}
+
+// LinkerFlags returns the rustflags that make rustc link through the C compiler with a linker
+func LinkerFlags(linker string) []string {
+	return []string{"-C", fmt.Sprintf("link-arg=-fuse-ld=%s", linker)}
+}
+
+// linkerEnvironment returns the rustflags of the runner or the buildpack with the flags of the linker appended.
+// CARGO_ENCODED_RUSTFLAGS takes precedence over RUSTFLAGS in cargo, so the flags are appended to it when it is set.
+func (c CargoRunner) linkerEnvironment() map[string]string {
+	if c.Linker == "" {
+		return nil
+	}
+
+	flags := LinkerFlags(c.Linker)
+	if encoded, ok := c.lookupEnv("CARGO_ENCODED_RUSTFLAGS"); ok {
+		var existing []string
+		if encoded != "" {
+			existing = strings.Split(encoded, "\x1f")
+		}
+		return map[string]string{"CARGO_ENCODED_RUSTFLAGS": strings.Join(append(existing, flags...), "\x1f")}
+	}
+
+	rustFlags, _ := c.lookupEnv("RUSTFLAGS")
+	return map[string]string{"RUSTFLAGS": strings.Join(append(strings.Fields(rustFlags), flags...), " ")}
+}
+
+// lookupEnv returns a variable that the runner adds to the environment, or else the variable of the buildpack
+func (c CargoRunner) lookupEnv(name string) (string, bool) {
+	if value, ok := c.Env[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testLinker(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("resolving the linker", func() {
+		var bin string
+
+		it.Before(func() {
+			bin = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(bin, "mold"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			t.Setenv("PATH", bin)
+		})
+
+		it("finds the executable of the linker on PATH", func() {
+			Expect(runner.ResolveLinker("")).To(BeEmpty())
+			Expect(runner.ResolveLinker(runner.LinkerMold)).To(Equal(filepath.Join(bin, "mold")))
+		})
+
+		it("fails for a missing linker", func() {
+			_, err := runner.ResolveLinker(runner.LinkerLLD)
+			Expect(err).To(MatchError(ContainSubstring("unable to find linker ld.lld")))
+		})
+
+		it("fails for an unsupported linker", func() {
+			_, err := runner.ResolveLinker("gold")
+			Expect(err).To(MatchError(`unsupported linker "gold", must be "lld" or "mold"`))
+		})
+	})
+
+	context("rustflags", func() {
+		plan := func(options ...runner.Option) runner.Invocation {
+			t.Helper()
+			p, err := runner.NewCargoRunner(options...).InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+			Expect(err).NotTo(HaveOccurred())
+			return p
+		}
+
+		it("appends the flags of the linker to RUSTFLAGS", func() {
+			t.Setenv("RUSTFLAGS", "-C opt-level=3")
+
+			Expect(plan(runner.WithLinker(runner.LinkerMold)).Env).
+				To(ContainElement("RUSTFLAGS=-C opt-level=3 -C link-arg=-fuse-ld=mold"))
+		})
+
+		it("appends the flags of the linker to CARGO_ENCODED_RUSTFLAGS", func() {
+			t.Setenv("CARGO_ENCODED_RUSTFLAGS", "-Copt-level=3")
+
+			Expect(plan(runner.WithLinker(runner.LinkerLLD)).Env).
+				To(ContainElement("CARGO_ENCODED_RUSTFLAGS=-Copt-level=3\x1f-C\x1flink-arg=-fuse-ld=lld"))
+		})
+
+		it("does not change the rustflags without a linker", func() {
+			Expect(plan().Env).NotTo(ContainElement(HavePrefix("RUSTFLAGS=")))
+		})
+	})
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// outdatedLockFile is printed by cargo when Cargo.lock does not match the manifests and `--locked` prevents updating it
+const outdatedLockFile = "needs to be updated but --locked was passed"
+
+// lockedArgs returns `--locked` for a locked build, unless the arguments already lock the dependencies
+func (c CargoRunner) lockedArgs(args []string) []string {
+	if !c.LockedBuild || slices.Contains(args, "--locked") || slices.Contains(args, "--frozen") {
+		return nil
+	}
+
+	return []string{"--locked"}
+}
+
+// ValidateLockFile checks that a locked build has a Cargo.lock, looked up in the working directory, then in the source
+// directory, which is the root of the workspace. Whether Cargo.lock is up to date is checked by cargo, see
+// lockFileFailure.
+func (c CargoRunner) ValidateLockFile(srcDir string) error {
+	if !c.LockedBuild {
+		return nil
+	}
+
+	dirs := []string{c.workingDir(srcDir)}
+	if dirs[0] != srcDir {
+		dirs = append(dirs, srcDir)
+	}
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "Cargo.lock")); err == nil {
+			return nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to read Cargo.lock\n%w", err)
+		}
+	}
+
+	return fmt.Errorf("no Cargo.lock in %s, a locked build only uses the dependencies locked in Cargo.lock\n"+
+		"run `cargo generate-lockfile` and add Cargo.lock to the application", strings.Join(dirs, " or "))
+}
+
+// lockFileFailure explains a command that failed because Cargo.lock does not match the manifests, or returns err
+// unchanged if it failed otherwise
+func lockFileFailure(output string, err error) error {
+	if !strings.Contains(output, outdatedLockFile) {
+		return err
+	}
+
+	return fmt.Errorf("Cargo.lock is out of date with the manifests of the application, which a locked build does not "+
+		"update\nrun `cargo update --workspace` and add the updated Cargo.lock to the application\n%w", err)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testLocked(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+		layer  = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+		})
+	})
+
+	it("adds --locked to the arguments of a locked build", func() {
+		plan, err := runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--jobs=2"),
+			runner.WithLockedBuild(true)).InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElements("--jobs=2", "--locked"))
+
+		plan, err = runner.NewCargoRunner(runner.WithLockedBuild(true)).FetchPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--locked"))
+	})
+
+	it("keeps --frozen from the install arguments", func() {
+		plan, err := runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--frozen"),
+			runner.WithLockedBuild(true)).InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--frozen"))
+		Expect(plan.Args).NotTo(ContainElement("--locked"))
+	})
+
+	it("does not lock the dependencies by default", func() {
+		plan, err := runner.NewCargoRunner(runner.WithCargoInstallArgs("--jobs=2")).InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).NotTo(ContainElement("--locked"))
+	})
+
+	it("requires a Cargo.lock for a locked build", func() {
+		r := runner.NewCargoRunner(runner.WithLockedBuild(true), runner.WithWorkingDir("api"))
+		Expect(r.ValidateLockFile(appDir)).To(MatchError(ContainSubstring("no Cargo.lock in " + filepath.Join(appDir, "api") + " or " + appDir)))
+		Expect(runner.NewCargoRunner().ValidateLockFile(appDir)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(appDir, "Cargo.lock"), []byte("version = 3\n"), 0644)).To(Succeed())
+		Expect(r.ValidateLockFile(appDir)).To(Succeed())
+	})
+
+	it("explains a failure caused by an outdated Cargo.lock", func() {
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "fetch").Run = func(execution effect.Execution) error {
+			_, _ = execution.Stderr.Write([]byte("error: the lock file " + filepath.Join(appDir, "Cargo.lock") +
+				" needs to be updated but --locked was passed to prevent this\n"))
+			return errors.New("exit status 101")
+		}
+
+		err := runner.NewCargoRunner(
+			runner.WithExecutor(executor),
+			runner.WithLockedBuild(true),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{}))).Fetch(appDir)
+		Expect(err).To(MatchError(ContainSubstring("Cargo.lock is out of date with the manifests of the application")))
+		Expect(err).To(MatchError(ContainSubstring("exit status 101")))
+	})
+}
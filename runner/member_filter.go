@@ -0,0 +1,185 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// MemberFilter selects workspace members by name, with comma separated patterns that include members, like `api` or
+// `svc-*`, and patterns prefixed with `!` that exclude them, like `!integration-tests`. Without an including pattern,
+// all members that are not excluded are selected.
+type MemberFilter struct {
+	Excludes []string
+	Includes []string
+}
+
+// ParseMemberFilter parses a comma separated list of member patterns
+func ParseMemberFilter(s string) MemberFilter {
+	var f MemberFilter
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if exclude, ok := strings.CutPrefix(p, "!"); ok {
+			if exclude = strings.TrimSpace(exclude); exclude != "" {
+				f.Excludes = append(f.Excludes, exclude)
+			}
+		} else if p != "" {
+			f.Includes = append(f.Includes, p)
+		}
+	}
+	return f
+}
+
+// Empty returns whether the filter has no patterns, which selects all members
+func (f MemberFilter) Empty() bool {
+	return len(f.Excludes) == 0 && len(f.Includes) == 0
+}
+
+// Names returns whether the filter only includes members by their names, without globs or exclusions
+func (f MemberFilter) Names() bool {
+	if len(f.Excludes) > 0 {
+		return false
+	}
+	for _, p := range f.Includes {
+		if strings.ContainsAny(p, `*?[\`) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns whether the filter selects a member
+func (f MemberFilter) Matches(name string) bool {
+	for _, p := range f.Excludes {
+		if matchMember(p, name) {
+			return false
+		}
+	}
+
+	if len(f.Includes) == 0 {
+		return true
+	}
+	for _, p := range f.Includes {
+		if matchMember(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the patterns are valid globs
+func (f MemberFilter) Validate() error {
+	for _, p := range append(append([]string{}, f.Includes...), f.Excludes...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid workspace member pattern %q\n%w", p, err)
+		}
+	}
+	return nil
+}
+
+// Select returns the names of the members that the filter selects, sorted. Every pattern must match at least one
+// member, so that a typo does not silently build something else, and at least one member must be selected.
+func (f MemberFilter) Select(names []string) ([]string, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	if unmatched := f.Unmatched(names); len(unmatched) > 0 {
+		return nil, UnmatchedMembersError{Patterns: unmatched, Available: names}
+	}
+
+	return f.Matching(names)
+}
+
+// Matching returns the names of the members that the filter selects, sorted, ignoring patterns that do not match any
+// member. At least one member must be selected.
+func (f MemberFilter) Matching(names []string) ([]string, error) {
+	var selected []string
+	for _, name := range names {
+		if f.Matches(name) {
+			selected = append(selected, name)
+		}
+	}
+	if len(selected) == 0 {
+		if len(f.Unmatched(names)) > 0 {
+			return nil, fmt.Errorf("workspace member patterns %s do not select any workspace member, available members are %s", f, strings.Join(sortedNames(names), ", "))
+		}
+		return nil, fmt.Errorf("workspace member patterns %s exclude all workspace members", f)
+	}
+
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// Unmatched returns the patterns that do not match any member, with exclusions prefixed by `!`
+func (f MemberFilter) Unmatched(names []string) []string {
+	var unmatched []string
+	for _, p := range f.Includes {
+		if !matchesAny(p, names) {
+			unmatched = append(unmatched, p)
+		}
+	}
+	for _, p := range f.Excludes {
+		if !matchesAny(p, names) {
+			unmatched = append(unmatched, "!"+p)
+		}
+	}
+	return unmatched
+}
+
+// String returns the patterns of the filter, comma separated
+func (f MemberFilter) String() string {
+	patterns := append([]string{}, f.Includes...)
+	for _, p := range f.Excludes {
+		patterns = append(patterns, "!"+p)
+	}
+	return strings.Join(patterns, ",")
+}
+
+// UnmatchedMembersError is returned when patterns of a MemberFilter do not match any workspace member
+type UnmatchedMembersError struct {
+	Patterns  []string
+	Available []string
+}
+
+func (e UnmatchedMembersError) Error() string {
+	return fmt.Sprintf("workspace member patterns %s do not match any workspace member, available members are %s",
+		strings.Join(e.Patterns, ", "), strings.Join(sortedNames(e.Available), ", "))
+}
+
+func matchMember(pattern string, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
+
+func matchesAny(pattern string, names []string) bool {
+	for _, name := range names {
+		if matchMember(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(names []string) []string {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	return sorted
+}
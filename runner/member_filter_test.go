@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testMemberFilter(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		names = []string{"api", "integration-tests", "svc-billing", "svc-orders", "worker"}
+	)
+
+	it("parses includes and exclusions", func() {
+		f := runner.ParseMemberFilter(" svc-*, !svc-orders ,worker,, ! ")
+		Expect(f).To(Equal(runner.MemberFilter{Excludes: []string{"svc-orders"}, Includes: []string{"svc-*", "worker"}}))
+		Expect(f.String()).To(Equal("svc-*,worker,!svc-orders"))
+		Expect(f.Names()).To(BeFalse())
+
+		Expect(runner.ParseMemberFilter("api, worker").Names()).To(BeTrue())
+		Expect(runner.ParseMemberFilter("").Empty()).To(BeTrue())
+	})
+
+	it("selects members by name", func() {
+		Expect(runner.ParseMemberFilter("worker,api").Select(names)).To(Equal([]string{"api", "worker"}))
+	})
+
+	it("selects members with globs", func() {
+		Expect(runner.ParseMemberFilter("svc-*").Select(names)).To(Equal([]string{"svc-billing", "svc-orders"}))
+		Expect(runner.ParseMemberFilter("svc-*,!svc-orders").Select(names)).To(Equal([]string{"svc-billing"}))
+	})
+
+	it("selects all other members with only exclusions", func() {
+		Expect(runner.ParseMemberFilter("!integration-tests").Select(names)).To(Equal([]string{"api", "svc-billing", "svc-orders", "worker"}))
+	})
+
+	it("fails if a pattern does not match any member", func() {
+		_, err := runner.ParseMemberFilter("api,web,!legacy-*").Select(names)
+		Expect(err).To(MatchError("workspace member patterns web, !legacy-* do not match any workspace member, available members are api, integration-tests, svc-billing, svc-orders, worker"))
+
+		var unmatched runner.UnmatchedMembersError
+		Expect(errors.As(err, &unmatched)).To(BeTrue())
+		Expect(unmatched.Patterns).To(Equal([]string{"web", "!legacy-*"}))
+	})
+
+	it("selects the matching members ignoring unmatched patterns", func() {
+		f := runner.ParseMemberFilter("api,web,!legacy-*")
+		Expect(f.Unmatched(names)).To(Equal([]string{"web", "!legacy-*"}))
+		Expect(f.Matching(names)).To(Equal([]string{"api"}))
+
+		_, err := runner.ParseMemberFilter("web").Matching(names)
+		Expect(err).To(MatchError("workspace member patterns web do not select any workspace member, available members are api, integration-tests, svc-billing, svc-orders, worker"))
+	})
+
+	it("fails if all members are excluded", func() {
+		_, err := runner.ParseMemberFilter("svc-*,!svc-*").Select(names)
+		Expect(err).To(MatchError("workspace member patterns svc-*,!svc-* exclude all workspace members"))
+	})
+
+	it("fails on an invalid glob", func() {
+		Expect(runner.ParseMemberFilter("svc-[").Validate()).To(MatchError(ContainSubstring(`invalid workspace member pattern "svc-["`)))
+	})
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/heroku/color"
+	"github.com/paketo-community/cargo/manifest"
+)
+
+// DefaultMembers returns the patterns of `workspace.default-members` in the manifest of the workspace rooted at dir,
+// or nothing if the workspace does not declare default members
+func DefaultMembers(dir string) ([]string, error) {
+	path := filepath.Join(dir, "Cargo.toml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	m, err := manifest.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Workspace == nil {
+		return nil, nil
+	}
+	return m.Workspace.DefaultMembers, nil
+}
+
+// selectedMembers returns the names of the workspace members that are built, which are the members selected by
+// BP_CARGO_WORKSPACE_MEMBERS or otherwise the default members of the workspace, like cargo selects them. Returns an
+// empty map if all members are built. Patterns of BP_CARGO_WORKSPACE_MEMBERS that do not match any member fail the
+// build, unless UnmatchedMembers is UnmatchedMembersWarn.
+func (c CargoRunner) selectedMembers(m metadata) (map[string]bool, error) {
+	selected := map[string]bool{}
+
+	if filter := ParseMemberFilter(c.CargoWorkspaceMembers); !filter.Empty() {
+		var names []string
+		for _, member := range m.WorkspaceMembers {
+			name, _, _, err := ParseWorkspaceMember(member)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse: %w", err)
+			}
+			names = append(names, name)
+		}
+
+		if err := filter.Validate(); err != nil {
+			return nil, err
+		}
+
+		if unmatched := filter.Unmatched(names); len(unmatched) > 0 {
+			err := UnmatchedMembersError{Patterns: unmatched, Available: names}
+			if c.UnmatchedMembers != UnmatchedMembersWarn {
+				return nil, err
+			}
+			c.Logger.Bodyf("%s: %s", color.YellowString("Warning"), err)
+		}
+
+		filtered, err := filter.Matching(names)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range filtered {
+			selected[name] = true
+		}
+		return selected, nil
+	}
+
+	if m.WorkspaceRoot == "" {
+		return selected, nil
+	}
+
+	patterns, err := DefaultMembers(m.WorkspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read default members\n%w", err)
+	} else if len(patterns) == 0 {
+		return selected, nil
+	}
+
+	for _, member := range m.WorkspaceMembers {
+		name, _, pathURL, err := ParseWorkspaceMember(member)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse: %w", err)
+		}
+
+		u, err := url.Parse(pathURL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse path URL %s: %w", member, err)
+		}
+
+		rel, err := filepath.Rel(m.WorkspaceRoot, u.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %s relative to %s\n%w", u.Path, m.WorkspaceRoot, err)
+		}
+
+		for _, pattern := range patterns {
+			if ok, err := filepath.Match(filepath.Clean(pattern), rel); err != nil {
+				return nil, fmt.Errorf("invalid default member %q\n%w", pattern, err)
+			} else if ok {
+				selected[name] = true
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("default-members %v of %s do not match any workspace member", patterns, filepath.Join(m.WorkspaceRoot, "Cargo.toml"))
+	}
+
+	return selected, nil
+}
+
+// workspaceArgs returns the arguments that select the workspace members to build or test, `-p` for each member
+// selected by BP_CARGO_WORKSPACE_MEMBERS or otherwise `--workspace`, unless the workspace declares default members,
+// which cargo selects by itself. Members are only resolved with the metadata of the workspace for globs and exclusions,
+// or to leave out unmatched names with UnmatchedMembersWarn.
+func (c CargoRunner) workspaceArgs(dir string) ([]string, error) {
+	if filter := ParseMemberFilter(c.CargoWorkspaceMembers); !filter.Empty() {
+		names := append([]string{}, filter.Includes...)
+		if !filter.Names() || c.UnmatchedMembers == UnmatchedMembersWarn {
+			m, err := c.fetchCargoMetadata(dir)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load cargo metadata\n%w", err)
+			}
+
+			selected, err := c.selectedMembers(m)
+			if err != nil {
+				return nil, err
+			}
+
+			names = nil
+			for name := range selected {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		names = slices.Compact(names)
+
+		var args []string
+		for _, name := range names {
+			args = append(args, "-p", name)
+		}
+		return args, nil
+	}
+
+	defaultMembers, err := DefaultMembers(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read default members\n%w", err)
+	} else if len(defaultMembers) > 0 {
+		return nil, nil
+	}
+
+	return []string{"--workspace"}, nil
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testMembers(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[workspace]\nmembers = [\"crates/*\", \"tools/xtask\"]\ndefault-members = [\"crates/*\"]\n",
+			"Cargo.lock": "version = 3\n",
+		})
+		executor = &cargotest.Executor{}
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).
+			WithMember("api", "0.1.0", "crates/api", "api").
+			WithMember("worker", "0.1.0", "crates/worker", "worker").
+			WithMember("xtask", "0.1.0", "tools/xtask", "xtask"))).To(Succeed())
+	})
+
+	it("reads the default members of the workspace", func() {
+		Expect(runner.DefaultMembers(appDir)).To(Equal([]string{"crates/*"}))
+		Expect(runner.DefaultMembers(t.TempDir())).To(BeEmpty())
+	})
+
+	it("installs the default members", func() {
+		members, err := runner.NewCargoRunner(runner.WithExecutor(executor)).WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(members).To(HaveLen(2))
+		Expect(members[0].Path).To(Equal(filepath.Join(appDir, "crates", "api")))
+		Expect(members[1].Path).To(Equal(filepath.Join(appDir, "crates", "worker")))
+	})
+
+	it("contributes processes for the default members", func() {
+		Expect(runner.NewCargoRunner(runner.WithExecutor(executor)).ProjectTargets(appDir)).To(Equal([]string{"api", "worker"}))
+	})
+
+	it("lets cargo build and test the default members", func() {
+		plan, err := runner.NewCargoRunner().BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).NotTo(ContainElement("--workspace"))
+		Expect(plan.Args).NotTo(ContainElement("-p"))
+
+		plan, err = runner.NewCargoRunner().TestPlan(appDir, runner.TestRunnerCargo, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).NotTo(ContainElement("--workspace"))
+
+		binaries, err := runner.NewCargoRunner(runner.WithExecutor(executor)).BuiltBinaries(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(binaries).To(Equal([]string{
+			filepath.Join(appDir, "target", "release", "api"),
+			filepath.Join(appDir, "target", "release", "worker"),
+		}))
+	})
+
+	it("prefers the members of BP_CARGO_WORKSPACE_MEMBERS", func() {
+		r := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("xtask"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+		Expect(r.ProjectTargets(appDir)).To(Equal([]string{"xtask"}))
+
+		plan, err := r.BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElements("-p", "xtask"))
+	})
+
+	it("selects members with globs and exclusions", func() {
+		r := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("*,!xtask"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+		Expect(r.ProjectTargets(appDir)).To(Equal([]string{"api", "worker"}))
+
+		members, err := r.WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(HaveLen(2))
+
+		plan, err := r.BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElements("-p", "api", "-p", "worker"))
+		Expect(plan.Args).NotTo(ContainElement("xtask"))
+	})
+
+	it("fails when a member pattern does not match any member", func() {
+		r := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("api,svc-*,!legacy"),
+			runner.WithExecutor(executor))
+
+		_, err := r.ProjectTargets(appDir)
+		Expect(err).To(MatchError(ContainSubstring("workspace member patterns svc-*, !legacy do not match any workspace member")))
+
+		_, err = r.BuildPlan(appDir)
+		Expect(err).To(MatchError(ContainSubstring("workspace member patterns svc-*, !legacy do not match any workspace member")))
+	})
+
+	it("lists the available members when a member pattern does not match", func() {
+		_, err := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("web"),
+			runner.WithExecutor(executor)).ProjectTargets(appDir)
+		Expect(err).To(MatchError(ContainSubstring("available members are api, worker, xtask")))
+	})
+
+	it("warns when a member pattern does not match any member", func() {
+		buf := &bytes.Buffer{}
+		r := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("api,web"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(buf)),
+			runner.WithUnmatchedMembers(runner.UnmatchedMembersWarn))
+
+		Expect(r.ProjectTargets(appDir)).To(Equal([]string{"api"}))
+		Expect(buf.String()).To(ContainSubstring("workspace member patterns web do not match any workspace member, available members are api, worker, xtask"))
+
+		plan, err := r.BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElements("-p", "api"))
+		Expect(plan.Args).NotTo(ContainElement("web"))
+
+		_, err = runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("web"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(buf)),
+			runner.WithUnmatchedMembers(runner.UnmatchedMembersWarn)).ProjectTargets(appDir)
+		Expect(err).To(MatchError(ContainSubstring("workspace member patterns web do not select any workspace member")))
+	})
+
+	it("fails when the default members do not match any member", func() {
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[workspace]\nmembers = [\"api\"]\ndefault-members = [\"missing\"]\n",
+		})
+		executor = &cargotest.Executor{}
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).WithMember("api", "0.1.0", "api", "api"))).To(Succeed())
+
+		_, err := runner.NewCargoRunner(runner.WithExecutor(executor)).ProjectTargets(appDir)
+		Expect(err).To(MatchError(ContainSubstring("default-members [missing] of " + filepath.Join(appDir, "Cargo.toml") + " do not match any workspace member")))
+	})
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MessageFormat is the message format of cargo when compiler messages are summarized. Cargo renders the diagnostics of
+// rustc to stderr, like without a message format, and writes JSON messages about the build to stdout.
+const MessageFormat = "json-render-diagnostics"
+
+var (
+	renderedHeaderPattern   = regexp.MustCompile(`^(warning|error)(?:\[(\w+)\])?: (.*)$`)
+	renderedLocationPattern = regexp.MustCompile(`^\s*--> (.+):(\d+):(\d+)$`)
+)
+
+// CompilerMessage is a warning or an error of the compiler
+type CompilerMessage struct {
+	Code    string
+	Column  int
+	File    string
+	Level   string
+	Line    int
+	Message string
+}
+
+// Deprecation returns whether the message reports the use of a deprecated item
+func (m CompilerMessage) Deprecation() bool {
+	return m.Code == "deprecated" || strings.HasPrefix(m.Message, "use of deprecated")
+}
+
+// String formats the message like `error[E0425] src/main.rs:2:13: cannot find value`
+func (m CompilerMessage) String() string {
+	s := m.Level
+	if m.Code != "" && m.Code != "deprecated" {
+		s = fmt.Sprintf("%s[%s]", s, m.Code)
+	}
+	if m.File != "" {
+		s = fmt.Sprintf("%s %s:%d:%d", s, m.File, m.Line, m.Column)
+	}
+	return fmt.Sprintf("%s: %s", s, m.Message)
+}
+
+// MessageSummary summarizes the messages of the compiler
+type MessageSummary struct {
+	Deprecations int
+	Errors       []CompilerMessage
+	Warnings     int
+}
+
+// Add adds a message to the summary
+func (s *MessageSummary) Add(message CompilerMessage) {
+	switch message.Level {
+	case "error":
+		s.Errors = append(s.Errors, message)
+	case "warning":
+		s.Warnings++
+		if message.Deprecation() {
+			s.Deprecations++
+		}
+	}
+}
+
+// Merge adds the messages of another summary
+func (s *MessageSummary) Merge(other MessageSummary) {
+	s.Deprecations += other.Deprecations
+	s.Errors = append(s.Errors, other.Errors...)
+	s.Warnings += other.Warnings
+}
+
+// String formats the counts of the summary, like `3 warnings (1 deprecation), 1 error`
+func (s MessageSummary) String() string {
+	warnings := plural(s.Warnings, "warning")
+	if s.Deprecations > 0 {
+		warnings = fmt.Sprintf("%s (%s)", warnings, plural(s.Deprecations, "deprecation"))
+	}
+	return fmt.Sprintf("%s, %s", warnings, plural(len(s.Errors), "error"))
+}
+
+// MessageCollector collects the compiler messages of cargo with MessageFormat. The diagnostics rendered to stderr are
+// written to the log unchanged, while the JSON messages on stdout are not, except for compiler messages that were not
+// rendered, like with `--message-format=json`.
+type MessageCollector struct {
+	mu      sync.Mutex
+	pending *CompilerMessage
+	summary MessageSummary
+}
+
+// JSON returns a writer for the JSON messages of cargo, which writes the rendered compiler messages to w
+func (m *MessageCollector) JSON(w io.Writer) *LineWriter {
+	return &LineWriter{Line: func(line []byte) error {
+		var message struct {
+			Message struct {
+				Code *struct {
+					Code string `json:"code"`
+				} `json:"code"`
+				Level    string `json:"level"`
+				Message  string `json:"message"`
+				Rendered string `json:"rendered"`
+				Spans    []struct {
+					ColumnStart int    `json:"column_start"`
+					FileName    string `json:"file_name"`
+					IsPrimary   bool   `json:"is_primary"`
+					LineStart   int    `json:"line_start"`
+				} `json:"spans"`
+			} `json:"message"`
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(line, &message); err != nil {
+			// not a message of cargo, like the output of a build script or diagnostics rendered to stderr that a TTY
+			// merged into stdout
+			m.rendered(line)
+			_, err := w.Write(line)
+			return err
+		}
+		if message.Reason != "compiler-message" {
+			return nil
+		}
+
+		c := CompilerMessage{Level: message.Message.Level, Message: message.Message.Message}
+		if message.Message.Code != nil {
+			c.Code = message.Message.Code.Code
+		}
+		for _, span := range message.Message.Spans {
+			if span.IsPrimary {
+				c.File, c.Line, c.Column = span.FileName, span.LineStart, span.ColumnStart
+				break
+			}
+		}
+		m.add(c)
+
+		_, err := io.WriteString(w, message.Message.Rendered)
+		return err
+	}}
+}
+
+// Rendered returns a writer for the diagnostics rendered by cargo, which writes them to w. Only diagnostics with a
+// location in the sources are collected, which leaves out messages of cargo itself, like `could not compile`.
+func (m *MessageCollector) Rendered(w io.Writer) *LineWriter {
+	return &LineWriter{Line: func(line []byte) error {
+		m.rendered(line)
+		_, err := w.Write(line)
+		return err
+	}}
+}
+
+// Summary returns the summary of the messages collected so far
+func (m *MessageCollector) Summary() MessageSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.summary
+}
+
+// rendered collects a line of a rendered diagnostic, which is colored with `--color=always`
+func (m *MessageCollector) rendered(line []byte) {
+	text := strings.TrimRight(string(stripANSI(line)), "\r\n")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pending != nil {
+		if l := renderedLocationPattern.FindStringSubmatch(text); l != nil {
+			m.pending.File = l[1]
+			m.pending.Line, _ = strconv.Atoi(l[2])
+			m.pending.Column, _ = strconv.Atoi(l[3])
+			m.summary.Add(*m.pending)
+		}
+		m.pending = nil
+	}
+	if h := renderedHeaderPattern.FindStringSubmatch(text); h != nil {
+		m.pending = &CompilerMessage{Code: h[2], Level: h[1], Message: h[3]}
+	}
+}
+
+func (m *MessageCollector) add(message CompilerMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.summary.Add(message)
+}
+
+// LineWriter calls Line for every line written to it, including the trailing newline
+type LineWriter struct {
+	Line func(line []byte) error
+
+	buf []byte
+}
+
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := l.Line(l.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		l.buf = l.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush calls Line for a partial line that was written last
+func (l *LineWriter) Flush() error {
+	if len(l.buf) == 0 {
+		return nil
+	}
+
+	line := append(l.buf, '\n')
+	l.buf = nil
+	return l.Line(line)
+}
+
+// messageArgs returns the message format of cargo when compiler messages are summarized, unless the arguments already
+// select one
+func (c CargoRunner) messageArgs(args []string) []string {
+	if !c.MessageSummary {
+		return nil
+	}
+
+	for _, arg := range args {
+		if arg == "--message-format" || strings.HasPrefix(arg, "--message-format=") {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("--message-format=%s", MessageFormat)}
+}
+
+// messageRunner returns the runner for a compiling command, which runs without a TTY when compiler messages are
+// summarized, as a TTY merges the diagnostics rendered to stderr into the JSON messages on stdout
+func (c CargoRunner) messageRunner() CargoRunner {
+	if !c.MessageSummary {
+		return c
+	}
+	return c.captured()
+}
+
+// messageOutput returns the writers of stdout and stderr for a compiling command that writes its output to w, and a
+// function that flushes the output with flush and logs the summary of the compiler messages. Without MessageSummary,
+// output is written to w unchanged.
+func (c CargoRunner) messageOutput(w io.Writer, flush func() error) (io.Writer, io.Writer, func() error) {
+	if !c.MessageSummary {
+		return w, w, flush
+	}
+
+	collector := &MessageCollector{}
+	stdout, stderr := collector.JSON(w), collector.Rendered(w)
+
+	return stdout, stderr, func() error {
+		if err := errors.Join(stdout.Flush(), stderr.Flush(), flush()); err != nil {
+			return err
+		}
+
+		summary := collector.Summary()
+		if c.Statistics != nil {
+			c.Statistics.Messages.Merge(summary)
+		}
+
+		c.Logger.Bodyf("Compiler messages: %s", summary)
+		for _, e := range summary.Errors {
+			c.Logger.Bodyf("  %s", e)
+		}
+		return nil
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
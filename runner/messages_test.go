@@ -0,0 +1,188 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+const renderedMessages = `   Compiling app v0.1.0 (/workspace)
+warning: use of deprecated function ` + "`old::call`" + `: use new::call
+ --> src/main.rs:3:5
+  |
+3 |     old::call();
+  |     ^^^^^^^^^
+  |
+  = note: ` + "`#[warn(deprecated)]`" + ` on by default
+
+warning: unused variable: ` + "`x`" + `
+ --> src/main.rs:4:9
+  |
+
+error[E0425]: cannot find value ` + "`y`" + ` in this scope
+ --> src/main.rs:5:13
+  |
+
+warning: ` + "`app` (bin \"app\")" + ` generated 2 warnings
+error: could not compile ` + "`app` (bin \"app\")" + ` due to 1 previous error; 2 warnings emitted
+`
+
+func testMessages(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("collects the diagnostics rendered by cargo", func() {
+		out := &bytes.Buffer{}
+		collector := &runner.MessageCollector{}
+		w := collector.Rendered(out)
+
+		_, err := io.WriteString(w, renderedMessages)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Flush()).To(Succeed())
+		Expect(out.String()).To(Equal(renderedMessages))
+
+		summary := collector.Summary()
+		Expect(summary.Warnings).To(Equal(2))
+		Expect(summary.Deprecations).To(Equal(1))
+		Expect(summary.Errors).To(Equal([]runner.CompilerMessage{
+			{Code: "E0425", Column: 13, File: "src/main.rs", Level: "error", Line: 5, Message: "cannot find value `y` in this scope"},
+		}))
+		Expect(summary.String()).To(Equal("2 warnings (1 deprecation), 1 error"))
+		Expect(summary.Errors[0].String()).To(Equal("error[E0425] src/main.rs:5:13: cannot find value `y` in this scope"))
+	})
+
+	it("collects colored diagnostics", func() {
+		colored := "\x1b[0m\x1b[1m\x1b[33mwarning\x1b[0m\x1b[0m\x1b[1m: unused variable: `x`\x1b[0m\r\n" +
+			"\x1b[0m \x1b[0m\x1b[0m\x1b[1m\x1b[38;5;12m--> \x1b[0m\x1b[0msrc/main.rs:4:9\x1b[0m\r\n" +
+			"\x1b[0m\x1b[1m\x1b[38;5;9merror[E0425]\x1b[0m\x1b[0m\x1b[1m: cannot find value `y` in this scope\x1b[0m\r\n" +
+			"\x1b[0m \x1b[0m\x1b[0m\x1b[1m\x1b[38;5;12m--> \x1b[0m\x1b[0msrc/main.rs:5:13\x1b[0m\r\n"
+
+		for _, writer := range []func(*runner.MessageCollector, io.Writer) *runner.LineWriter{
+			(*runner.MessageCollector).Rendered,
+			(*runner.MessageCollector).JSON,
+		} {
+			out := &bytes.Buffer{}
+			collector := &runner.MessageCollector{}
+			w := writer(collector, out)
+
+			_, err := io.WriteString(w, colored)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(w.Flush()).To(Succeed())
+			Expect(out.String()).To(Equal(colored))
+
+			summary := collector.Summary()
+			Expect(summary.Warnings).To(Equal(1))
+			Expect(summary.Errors).To(Equal([]runner.CompilerMessage{
+				{Code: "E0425", Column: 13, File: "src/main.rs", Level: "error", Line: 5, Message: "cannot find value `y` in this scope"},
+			}))
+		}
+	})
+
+	it("collects JSON compiler messages and leaves out other JSON messages", func() {
+		out := &bytes.Buffer{}
+		collector := &runner.MessageCollector{}
+		w := collector.JSON(out)
+
+		_, err := io.WriteString(w, strings.Join([]string{
+			`{"reason":"compiler-artifact","package_id":"app 0.1.0","fresh":false}`,
+			`{"reason":"compiler-message","message":{"code":{"code":"deprecated"},"level":"warning","message":"use of deprecated function` + "`old::call`" + `","rendered":"warning: use of deprecated\n","spans":[{"column_start":5,"file_name":"src/main.rs","is_primary":true,"line_start":3}]}}`,
+			`{"reason":"build-finished","success":true}`,
+			`plain output`,
+		}, "\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(out.String()).To(Equal("warning: use of deprecated\nplain output\n"))
+		Expect(collector.Summary()).To(Equal(runner.MessageSummary{Deprecations: 1, Warnings: 1}))
+	})
+
+	it("summarizes the messages of the compiler after installing", func() {
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "install").Run = func(execution effect.Execution) error {
+			if _, err := io.WriteString(execution.Stdout, `{"reason":"build-finished","success":true}`+"\n"); err != nil {
+				return err
+			}
+			_, err := io.WriteString(execution.Stderr, "warning: unused variable: `x`\n --> src/main.rs:4:9\n")
+			return err
+		}
+
+		buf := &bytes.Buffer{}
+		statistics := &runner.Statistics{}
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(t.TempDir()),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(buf)),
+			runner.WithMessageSummary(true),
+			runner.WithStatistics(statistics))
+		Expect(r.InstallMember(".", t.TempDir(), libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "install")
+		Expect(execution.Args).To(ContainElement("--message-format=json-render-diagnostics"))
+		Expect(buf.String()).To(ContainSubstring("warning: unused variable"))
+		Expect(buf.String()).NotTo(ContainSubstring("build-finished"))
+		Expect(buf.String()).To(ContainSubstring("Compiler messages: 1 warning, 0 errors"))
+		Expect(statistics.Messages.Warnings).To(Equal(1))
+	})
+
+	it("summarizes the messages of the compiler without a TTY when colored output is enabled", func() {
+		toolchain := t.TempDir()
+		Expect(os.WriteFile(filepath.Join(toolchain, "cargo"), []byte("#!/bin/sh\n"+
+			"echo '{\"reason\":\"build-finished\",\"success\":true}'\n"+
+			"printf '\\033[1m\\033[33mwarning\\033[0m\\033[1m: unused variable: `x`\\033[0m\\n' >&2\n"+
+			"printf '\\033[1m\\033[38;5;12m --> \\033[0msrc/main.rs:4:9\\n' >&2\n"), 0755)).To(Succeed())
+
+		buf := &bytes.Buffer{}
+		statistics := &runner.Statistics{}
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(t.TempDir()),
+			runner.WithCargoInstallArgs("--color=always"),
+			runner.WithExecutor(runner.ProcessExecutor{GracePeriod: time.Second, TTY: true}),
+			runner.WithLogger(bard.NewLogger(buf)),
+			runner.WithMessageSummary(true),
+			runner.WithStatistics(statistics),
+			runner.WithToolchainPath(toolchain))
+		Expect(r.InstallMember(".", t.TempDir(), libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		Expect(buf.String()).NotTo(ContainSubstring("build-finished"))
+		Expect(buf.String()).To(ContainSubstring("Compiler messages: 1 warning, 0 errors"))
+		Expect(statistics.Messages.Warnings).To(Equal(1))
+	})
+
+	it("keeps the message format of the install arguments", func() {
+		plan, err := runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--message-format=short"),
+			runner.WithMessageSummary(true)).InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--message-format=short"))
+		Expect(plan.Args).NotTo(ContainElement("--message-format=json-render-diagnostics"))
+	})
+}
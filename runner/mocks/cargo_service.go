@@ -6,6 +6,8 @@ import (
 	libcnb "github.com/buildpacks/libcnb"
 	mock "github.com/stretchr/testify/mock"
 
+	runner "github.com/paketo-community/cargo/runner"
+
 	url "net/url"
 )
 
@@ -14,15 +16,85 @@ type CargoService struct {
 	mock.Mock
 }
 
+// Build provides a mock function with given fields: srcDir, destLayer
+func (_m *CargoService) Build(srcDir string, destLayer libcnb.Layer) error {
+	ret := _m.Called(srcDir, destLayer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, libcnb.Layer) error); ok {
+		r0 = rf(srcDir, destLayer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BuildDependencies provides a mock function with given fields: srcDir
+func (_m *CargoService) BuildDependencies(srcDir string) error {
+	ret := _m.Called(srcDir)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CacheKey provides a mock function with given fields: srcDir
+func (_m *CargoService) CacheKey(srcDir string) (string, error) {
+	ret := _m.Called(srcDir)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(srcDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CargoVersion provides a mock function with given fields:
-func (_m *CargoService) CargoVersion() (string, error) {
+func (_m *CargoService) CargoVersion() (runner.Version, error) {
 	ret := _m.Called()
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func() string); ok {
+	var r0 runner.Version
+	if rf, ok := ret.Get(0).(func() runner.Version); ok {
 		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(runner.Version)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CargoVersionInfo provides a mock function with given fields:
+func (_m *CargoService) CargoVersionInfo() (runner.VersionInfo, error) {
+	ret := _m.Called()
+
+	var r0 runner.VersionInfo
+	if rf, ok := ret.Get(0).(func() runner.VersionInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(runner.VersionInfo)
 	}
 
 	var r1 error
@@ -36,7 +108,105 @@ func (_m *CargoService) CargoVersion() (string, error) {
 }
 
 // CleanCargoHomeCache provides a mock function with given fields:
-func (_m *CargoService) CleanCargoHomeCache() error {
+func (_m *CargoService) CleanCargoHomeCache() (runner.CleanStatistics, error) {
+	ret := _m.Called()
+
+	var r0 runner.CleanStatistics
+	if rf, ok := ret.Get(0).(func() runner.CleanStatistics); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(runner.CleanStatistics)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CrossInstallMember provides a mock function with given fields: memberPath, srcDir, destDir, platform
+func (_m *CargoService) CrossInstallMember(memberPath string, srcDir string, destDir string, platform runner.Platform) error {
+	ret := _m.Called(memberPath, srcDir, destDir, platform)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, runner.Platform) error); ok {
+		r0 = rf(memberPath, srcDir, destDir, platform)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DependencyGraph provides a mock function with given fields: srcDir
+func (_m *CargoService) DependencyGraph(srcDir string) (runner.DependencyGraph, error) {
+	ret := _m.Called(srcDir)
+
+	var r0 runner.DependencyGraph
+	if rf, ok := ret.Get(0).(func(string) runner.DependencyGraph); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Get(0).(runner.DependencyGraph)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(srcDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnsureAuditable provides a mock function with given fields:
+func (_m *CargoService) EnsureAuditable() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnsureCrossTool provides a mock function with given fields:
+func (_m *CargoService) EnsureCrossTool() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnsureSccache provides a mock function with given fields:
+func (_m *CargoService) EnsureSccache() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnsureDefaultTarget provides a mock function with given fields:
+func (_m *CargoService) EnsureDefaultTarget() error {
 	ret := _m.Called()
 
 	var r0 error
@@ -49,6 +219,34 @@ func (_m *CargoService) CleanCargoHomeCache() error {
 	return r0
 }
 
+// EnsureTarget provides a mock function with given fields: triple
+func (_m *CargoService) EnsureTarget(triple string) error {
+	ret := _m.Called(triple)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(triple)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Fetch provides a mock function with given fields: srcDir
+func (_m *CargoService) Fetch(srcDir string) error {
+	ret := _m.Called(srcDir)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Install provides a mock function with given fields: srcDir, destLayer
 func (_m *CargoService) Install(srcDir string, destLayer libcnb.Layer) error {
 	ret := _m.Called(srcDir, destLayer)
@@ -63,6 +261,20 @@ func (_m *CargoService) Install(srcDir string, destLayer libcnb.Layer) error {
 	return r0
 }
 
+// InstallAll provides a mock function with given fields: srcDir, destLayer
+func (_m *CargoService) InstallAll(srcDir string, destLayer libcnb.Layer) error {
+	ret := _m.Called(srcDir, destLayer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, libcnb.Layer) error); ok {
+		r0 = rf(srcDir, destLayer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // InstallMember provides a mock function with given fields: memberPath, srcDir, destLayer
 func (_m *CargoService) InstallMember(memberPath string, srcDir string, destLayer libcnb.Layer) error {
 	ret := _m.Called(memberPath, srcDir, destLayer)
@@ -77,13 +289,13 @@ func (_m *CargoService) InstallMember(memberPath string, srcDir string, destLaye
 	return r0
 }
 
-// InstallTool provides a mock function with given fields: name, additionalArgs
-func (_m *CargoService) InstallTool(name string, additionalArgs []string) error {
-	ret := _m.Called(name, additionalArgs)
+// InstallTool provides a mock function with given fields: tool, sharedArgs
+func (_m *CargoService) InstallTool(tool runner.ToolRequest, sharedArgs []string) error {
+	ret := _m.Called(tool, sharedArgs)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
-		r0 = rf(name, additionalArgs)
+	if rf, ok := ret.Get(0).(func(runner.ToolRequest, []string) error); ok {
+		r0 = rf(tool, sharedArgs)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -91,6 +303,73 @@ func (_m *CargoService) InstallTool(name string, additionalArgs []string) error
 	return r0
 }
 
+// Licenses provides a mock function with given fields: srcDir
+func (_m *CargoService) Licenses(srcDir string) (runner.LicenseReport, error) {
+	ret := _m.Called(srcDir)
+
+	var r0 runner.LicenseReport
+	if rf, ok := ret.Get(0).(func(string) runner.LicenseReport); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Get(0).(runner.LicenseReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(srcDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Metadata provides a mock function with given fields: srcDir
+func (_m *CargoService) Metadata(srcDir string) ([]byte, error) {
+	ret := _m.Called(srcDir)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(srcDir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(srcDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostProcess provides a mock function with given fields: binaries
+func (_m *CargoService) PostProcess(binaries []string) ([]runner.BinarySize, error) {
+	ret := _m.Called(binaries)
+
+	var r0 []runner.BinarySize
+	if rf, ok := ret.Get(0).(func([]string) []runner.BinarySize); ok {
+		r0 = rf(binaries)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]runner.BinarySize)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(binaries)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ProjectTargets provides a mock function with given fields: srcDir
 func (_m *CargoService) ProjectTargets(srcDir string) ([]string, error) {
 	ret := _m.Called(srcDir)
@@ -114,15 +393,113 @@ func (_m *CargoService) ProjectTargets(srcDir string) ([]string, error) {
 	return r0, r1
 }
 
+// PruneTargetDir provides a mock function with given fields: srcDir, maxSize
+func (_m *CargoService) PruneTargetDir(srcDir string, maxSize int64) (runner.CleanStatistics, error) {
+	ret := _m.Called(srcDir, maxSize)
+
+	var r0 runner.CleanStatistics
+	if rf, ok := ret.Get(0).(func(string, int64) runner.CleanStatistics); ok {
+		r0 = rf(srcDir, maxSize)
+	} else {
+		r0 = ret.Get(0).(runner.CleanStatistics)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(srcDir, maxSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RustVersion provides a mock function with given fields:
-func (_m *CargoService) RustVersion() (string, error) {
+func (_m *CargoService) RustVersion() (runner.Version, error) {
 	ret := _m.Called()
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func() string); ok {
+	var r0 runner.Version
+	if rf, ok := ret.Get(0).(func() runner.Version); ok {
 		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(runner.Version)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RustVersionInfo provides a mock function with given fields:
+func (_m *CargoService) RustVersionInfo() (runner.VersionInfo, error) {
+	ret := _m.Called()
+
+	var r0 runner.VersionInfo
+	if rf, ok := ret.Get(0).(func() runner.VersionInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(runner.VersionInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SecurityAudit provides a mock function with given fields: srcDir
+func (_m *CargoService) SecurityAudit(srcDir string) (runner.AuditReport, error) {
+	ret := _m.Called(srcDir)
+
+	var r0 runner.AuditReport
+	if rf, ok := ret.Get(0).(func(string) runner.AuditReport); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Get(0).(runner.AuditReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(srcDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StampTargetDir provides a mock function with given fields: srcDir
+func (_m *CargoService) StampTargetDir(srcDir string) error {
+	ret := _m.Called(srcDir)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StopSccache provides a mock function with given fields:
+func (_m *CargoService) StopSccache() (runner.SccacheStatistics, error) {
+	ret := _m.Called()
+
+	var r0 runner.SccacheStatistics
+	if rf, ok := ret.Get(0).(func() runner.SccacheStatistics); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(runner.SccacheStatistics)
 	}
 
 	var r1 error
@@ -135,6 +512,97 @@ func (_m *CargoService) RustVersion() (string, error) {
 	return r0, r1
 }
 
+// SweepTargetDir provides a mock function with given fields: srcDir, policy
+func (_m *CargoService) SweepTargetDir(srcDir string, policy runner.RetentionPolicy) (runner.CleanStatistics, error) {
+	ret := _m.Called(srcDir, policy)
+
+	var r0 runner.CleanStatistics
+	if rf, ok := ret.Get(0).(func(string, runner.RetentionPolicy) runner.CleanStatistics); ok {
+		r0 = rf(srcDir, policy)
+	} else {
+		r0 = ret.Get(0).(runner.CleanStatistics)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, runner.RetentionPolicy) error); ok {
+		r1 = rf(srcDir, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Test provides a mock function with given fields: srcDir, args
+func (_m *CargoService) Test(srcDir string, args []string) error {
+	ret := _m.Called(srcDir, args)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(srcDir, args)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UninstallTool provides a mock function with given fields: name
+func (_m *CargoService) UninstallTool(name string) error {
+	ret := _m.Called(name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateLockFile provides a mock function with given fields: srcDir
+func (_m *CargoService) ValidateLockFile(srcDir string) error {
+	ret := _m.Called(srcDir)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateTarget provides a mock function with given fields: triple
+func (_m *CargoService) ValidateTarget(triple string) error {
+	ret := _m.Called(triple)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(triple)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateToolchain provides a mock function with given fields: srcDir
+func (_m *CargoService) ValidateToolchain(srcDir string) error {
+	ret := _m.Called(srcDir)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(srcDir)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WorkspaceMembers provides a mock function with given fields: srcDir, destLayer
 func (_m *CargoService) WorkspaceMembers(srcDir string, destLayer libcnb.Layer) ([]url.URL, error) {
 	ret := _m.Called(srcDir, destLayer)
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-community/cargo/manifest"
+)
+
+// RustVersionRequirement is the minimum supported Rust version declared with `rust-version` in a manifest
+type RustVersionRequirement struct {
+	Manifest string
+	Version  *semver.Version
+}
+
+func (r RustVersionRequirement) String() string {
+	return fmt.Sprintf("rust-version %s of %s", r.Version.Original(), r.Manifest)
+}
+
+// RequiredRustVersion returns the highest `rust-version` of the package in dir, the workspace rooted at dir and its
+// members, with the path of the manifest that declares it relative to dir. Returns nil if no rust-version is set.
+func RequiredRustVersion(dir string) (*RustVersionRequirement, error) {
+	root, err := manifest.Load(filepath.Join(dir, "Cargo.toml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var required *RustVersionRequirement
+	add := func(m manifest.Manifest, path string) error {
+		raw := m.RustVersion(root)
+		if raw == "" {
+			return nil
+		}
+
+		v, err := semver.NewVersion(raw)
+		if err != nil {
+			return fmt.Errorf("invalid rust-version %q in %s\n%w", raw, path, err)
+		}
+
+		if required == nil || v.GreaterThan(required.Version) {
+			required = &RustVersionRequirement{Manifest: path, Version: v}
+		}
+		return nil
+	}
+
+	if err := add(root, "Cargo.toml"); err != nil {
+		return nil, err
+	}
+
+	if root.Workspace != nil {
+		for _, pattern := range root.Workspace.Members {
+			paths, err := filepath.Glob(filepath.Join(dir, pattern, "Cargo.toml"))
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve workspace member %s\n%w", pattern, err)
+			}
+
+			for _, path := range paths {
+				// the root package may be a member of its own workspace
+				if path == filepath.Join(dir, "Cargo.toml") {
+					continue
+				}
+
+				m, err := manifest.Load(path)
+				if err != nil {
+					return nil, err
+				}
+
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return nil, fmt.Errorf("unable to resolve %s relative to %s\n%w", path, dir, err)
+				}
+				if err := add(m, rel); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return required, nil
+}
+
+// ValidateToolchain fails if the installed rustc is older than the rust-version of the application, before cargo
+// fails deep into the build. Pre-releases, like nightly toolchains, satisfy the rust-version of their release, like
+// cargo does, and nothing is validated if the install arguments contain `--ignore-rust-version`.
+func (c CargoRunner) ValidateToolchain(srcDir string) error {
+	args, err := FilterInstallArgs(c.installArgs())
+	if err != nil {
+		return fmt.Errorf("filter failed: %w", err)
+	}
+	for _, arg := range args {
+		if arg == "--ignore-rust-version" {
+			return nil
+		}
+	}
+
+	required, err := RequiredRustVersion(c.workingDir(srcDir))
+	if err != nil {
+		return fmt.Errorf("unable to determine rust-version\n%w", err)
+	} else if required == nil {
+		return nil
+	}
+
+	rustVersion, err := c.RustVersion()
+	if err != nil {
+		return fmt.Errorf("unable to determine rust version\n%w", err)
+	}
+
+	release, err := rustVersion.SetPrerelease("")
+	if err != nil {
+		return fmt.Errorf("unable to remove pre-release of %s\n%w", rustVersion, err)
+	}
+
+	if release.LessThan(required.Version) {
+		return fmt.Errorf("rustc %s is older than the %s, use Rust %s or newer, for example with BP_RUST_VERSION, "+
+			"or add --ignore-rust-version to BP_CARGO_INSTALL_ARGS", rustVersion, required, required.Version.Original())
+	}
+
+	return nil
+}
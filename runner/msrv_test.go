@@ -0,0 +1,107 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testMSRV(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+	)
+
+	it.Before(func() {
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\n\n[workspace.package]\nrust-version = \"1.70\"\n",
+			"crates/api/Cargo.toml":  "[package]\nname = \"api\"\nversion = \"0.1.0\"\nrust-version.workspace = true\n",
+			"crates/cli/Cargo.toml":  "[package]\nname = \"cli\"\nversion = \"0.1.0\"\nrust-version = \"1.80.1\"\n",
+			"crates/core/Cargo.toml": "[package]\nname = \"core\"\nversion = \"0.1.0\"\n",
+		})
+		executor = &cargotest.Executor{}
+	})
+
+	rustc := func(version string) {
+		executor.On("rustc", "-vV").Stdout = "rustc " + version + "\nbinary: rustc\nrelease: " + version + "\n"
+	}
+
+	it("resolves the highest rust-version of the workspace", func() {
+		required, err := runner.RequiredRustVersion(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(required.String()).To(Equal("rust-version 1.80.1 of crates/cli/Cargo.toml"))
+	})
+
+	it("does not require a rust-version", func() {
+		Expect(runner.RequiredRustVersion(t.TempDir())).To(BeNil())
+
+		appDir = cargotest.Application(t, map[string]string{"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n"})
+		Expect(runner.RequiredRustVersion(appDir)).To(BeNil())
+		Expect(runner.NewCargoRunner(runner.WithExecutor(executor)).ValidateToolchain(appDir)).To(Succeed())
+		cargotest.AssertNotExecuted(t, executor, "rustc")
+	})
+
+	it("fails on an invalid rust-version", func() {
+		appDir = cargotest.Application(t, map[string]string{"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\nrust-version = \"latest\"\n"})
+
+		_, err := runner.RequiredRustVersion(appDir)
+		Expect(err).To(MatchError(ContainSubstring(`invalid rust-version "latest" in Cargo.toml`)))
+	})
+
+	it("accepts a rustc that supports the rust-version", func() {
+		rustc("1.80.1")
+		Expect(runner.NewCargoRunner(runner.WithExecutor(executor)).ValidateToolchain(appDir)).To(Succeed())
+	})
+
+	it("accepts a nightly of the rust-version", func() {
+		rustc("1.80.1-nightly")
+		Expect(runner.NewCargoRunner(runner.WithExecutor(executor)).ValidateToolchain(appDir)).To(Succeed())
+	})
+
+	it("fails early when rustc is older than the rust-version", func() {
+		rustc("1.79.0")
+
+		err := runner.NewCargoRunner(runner.WithExecutor(executor)).ValidateToolchain(appDir)
+		Expect(err).To(MatchError(ContainSubstring("rustc 1.79.0 is older than the rust-version 1.80.1 of crates/cli/Cargo.toml, use Rust 1.80.1 or newer")))
+	})
+
+	it("does not validate with --ignore-rust-version", func() {
+		rustc("1.79.0")
+
+		Expect(runner.NewCargoRunner(
+			runner.WithCargoInstallArgs("--ignore-rust-version"),
+			runner.WithExecutor(executor)).ValidateToolchain(appDir)).To(Succeed())
+	})
+
+	it("validates the working directory", func() {
+		rustc("1.60.0")
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml":     "[package]\nname = \"app\"\nversion = \"0.1.0\"\nrust-version = \"1.75\"\n",
+			"api/Cargo.toml": "[package]\nname = \"api\"\nversion = \"0.1.0\"\nrust-version = \"1.56\"\n",
+		})
+
+		Expect(runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithWorkingDir("api")).ValidateToolchain(appDir)).To(Succeed())
+		Expect(runner.NewCargoRunner(runner.WithExecutor(executor)).ValidateToolchain(appDir)).To(MatchError(ContainSubstring("rust-version 1.75 of Cargo.toml")))
+	})
+}
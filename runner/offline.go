@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+)
+
+// vendorHint tells how to vendor the dependencies of an application for an offline build
+const vendorHint = "run `cargo vendor` and add the source replacement it prints to .cargo/config.toml"
+
+// offlineArgs returns `--offline` for an offline build, unless the arguments already disable network access
+func (c CargoRunner) offlineArgs(args []string) []string {
+	if !c.OfflineBuild || slices.Contains(args, "--offline") || slices.Contains(args, "--frozen") {
+		return nil
+	}
+
+	return []string{"--offline"}
+}
+
+// ValidateVendoredSources checks that the dependencies of an offline build are vendored, with a Cargo configuration
+// in the working directory or the source directory that replaces crates.io with a directory that exists
+func (c CargoRunner) ValidateVendoredSources(srcDir string) error {
+	dirs := []string{c.workingDir(srcDir)}
+	if dirs[0] != srcDir {
+		dirs = append(dirs, srcDir)
+	}
+
+	for _, dir := range dirs {
+		for _, name := range []string{".cargo/config.toml", ".cargo/config"} {
+			path := filepath.Join(dir, name)
+
+			var config struct {
+				Source map[string]struct {
+					Directory   string `toml:"directory"`
+					ReplaceWith string `toml:"replace-with"`
+				} `toml:"source"`
+			}
+			if _, err := toml.DecodeFile(path, &config); os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("unable to decode %s\n%w", path, err)
+			}
+
+			replacement := config.Source["crates-io"].ReplaceWith
+			if replacement == "" {
+				return fmt.Errorf("offline build requires vendored dependencies, but %s does not replace crates-io, %s", name, vendorHint)
+			}
+
+			vendor := config.Source[replacement].Directory
+			if vendor == "" {
+				return fmt.Errorf("offline build requires vendored dependencies, but source %s of %s is not a directory, %s", replacement, name, vendorHint)
+			}
+
+			// relative directories are resolved against the parent of the .cargo directory
+			if !filepath.IsAbs(vendor) {
+				vendor = filepath.Join(dir, vendor)
+			}
+			if fi, err := os.Stat(vendor); err != nil || !fi.IsDir() {
+				return fmt.Errorf("offline build requires vendored dependencies, but %s configured in %s does not exist, %s", vendor, name, vendorHint)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("offline build requires vendored dependencies, but there is no .cargo/config.toml, %s", vendorHint)
+}
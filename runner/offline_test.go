@@ -0,0 +1,120 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testOffline(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+		layer  = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		appDir = t.TempDir()
+	})
+
+	vendor := func(dir string, config string) {
+		Expect(os.MkdirAll(filepath.Join(dir, ".cargo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".cargo", "config.toml"), []byte(config), 0644)).To(Succeed())
+	}
+
+	const vendored = `
+[source.crates-io]
+replace-with = "vendored-sources"
+
+[source.vendored-sources]
+directory = "vendor"
+`
+
+	it("passes --offline to cargo", func() {
+		args, err := runner.NewCargoRunner(runner.WithOfflineBuild(true)).BuildArgs(layer, ".")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).To(ContainElement("--offline"))
+
+		args, err = runner.NewCargoRunner(runner.WithOfflineBuild(true), runner.WithCargoInstallArgs("--frozen")).BuildArgs(layer, ".")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).NotTo(ContainElement("--offline"))
+
+		args, err = runner.NewCargoRunner().BuildArgs(layer, ".")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).NotTo(ContainElement("--offline"))
+	})
+
+	it("accepts vendored dependencies", func() {
+		vendor(appDir, vendored)
+		Expect(os.MkdirAll(filepath.Join(appDir, "vendor"), 0755)).To(Succeed())
+
+		r := runner.NewCargoRunner(runner.WithOfflineBuild(true))
+		Expect(r.ValidateVendoredSources(appDir)).To(Succeed())
+
+		plan, err := r.InstallPlan(".", appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElement("--offline"))
+	})
+
+	it("accepts vendored dependencies of the working directory", func() {
+		vendor(filepath.Join(appDir, "crates"), vendored)
+		Expect(os.MkdirAll(filepath.Join(appDir, "crates", "vendor"), 0755)).To(Succeed())
+
+		Expect(runner.NewCargoRunner(runner.WithWorkingDir("crates")).ValidateVendoredSources(appDir)).To(Succeed())
+	})
+
+	it("fails without a cargo configuration", func() {
+		_, err := runner.NewCargoRunner(runner.WithOfflineBuild(true)).InstallPlan(".", appDir, layer)
+		Expect(err).To(MatchError(ContainSubstring("there is no .cargo/config.toml, run `cargo vendor`")))
+	})
+
+	it("fails when crates-io is not replaced", func() {
+		vendor(appDir, "[net]\noffline = true\n")
+
+		Expect(runner.NewCargoRunner().ValidateVendoredSources(appDir)).
+			To(MatchError(ContainSubstring(".cargo/config.toml does not replace crates-io")))
+	})
+
+	it("fails when the replacement is not a directory", func() {
+		vendor(appDir, `
+[source.crates-io]
+replace-with = "mirror"
+
+[source.mirror]
+registry = "sparse+https://mirror.example.com/index/"
+`)
+
+		Expect(runner.NewCargoRunner().ValidateVendoredSources(appDir)).
+			To(MatchError(ContainSubstring("source mirror of .cargo/config.toml is not a directory")))
+	})
+
+	it("fails when the vendor directory does not exist", func() {
+		vendor(appDir, vendored)
+
+		_, err := runner.NewCargoRunner(runner.WithOfflineBuild(true)).BuildPlan(appDir)
+		Expect(err).To(MatchError(ContainSubstring(filepath.Join(appDir, "vendor") + " configured in .cargo/config.toml does not exist")))
+	})
+}
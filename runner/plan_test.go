@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testPlan(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layer = libcnb.Layer{Name: "Cargo", Path: "/layers/paketo-community_cargo/Cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+	})
+
+	// a slice, not a map, because spec evaluates the test function once per test and needs a stable order
+	plans := []struct {
+		name    string
+		options []runner.Option
+	}{
+		{"default", nil},
+		{"tiny", []runner.Option{
+			runner.WithStack(libpak.JammyTinyStackID),
+		}},
+		{"tiny-gnulibc", []runner.Option{
+			runner.WithStack(libpak.JammyTinyStackID),
+			runner.WithStaticType(runner.StaticTypeGNULIBC),
+		}},
+		{"install-args", []runner.Option{
+			runner.WithCargoInstallArgs("--path=./todo --locked --features=postgres"),
+			runner.WithColor(runner.ColorAlways),
+			runner.WithTimings(true),
+		}},
+		{"environment", []runner.Option{
+			runner.WithEnv(map[string]string{"SQLX_OFFLINE": "true"}),
+			runner.WithProfileOverrides(map[string]string{"overflow-checks": "true"}),
+			runner.WithRustcWrapper("/usr/bin/sccache"),
+			runner.WithUnstable(true, []string{"-Zbuild-std=std"}),
+		}},
+		{"working-dir", []runner.Option{
+			runner.WithWorkingDir("crates"),
+			runner.WithToolchainPath("/layers/rust/bin"),
+		}},
+	}
+
+	for _, p := range plans {
+		p := p
+
+		it("renders the install plan for "+p.name, func() {
+			plan, err := runner.NewCargoRunner(p.options...).InstallPlan(".", "/workspace", layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			cargotest.AssertGolden(t, filepath.Join("testdata", "plans", p.name+".txt"), plan.String())
+		})
+	}
+}
@@ -0,0 +1,469 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+const (
+	CrossToolCargo    = "cargo"
+	CrossToolZigbuild = "zigbuild"
+)
+
+// Platform is an operating system and architecture, like `linux/arm64`, that binaries are cross compiled for. The
+// target triple is derived from the stack, unless Target is set.
+type Platform struct {
+	OS     string
+	Arch   string
+	Target string
+}
+
+// SupportedPlatforms maps the operating systems that binaries can be cross compiled for to their architectures. Only
+// linux binaries can be run by the image, the other platforms are built as artifacts for release pipelines. Windows
+// is experimental and limited to the gnu target, msvc needs the windows sdk to link.
+var SupportedPlatforms = map[string][]string{
+	"freebsd": {"amd64"},
+	"illumos": {"amd64"},
+	"linux":   {"amd64", "arm64"},
+	"windows": {"amd64"},
+}
+
+// ParsePlatforms parses a comma separated list of platforms, like `linux/amd64,linux/arm64`
+func ParsePlatforms(raw string) ([]Platform, error) {
+	var platforms []Platform
+
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		goos, arch, _ := strings.Cut(p, "/")
+		if !supportedPlatform(goos, arch) {
+			return nil, fmt.Errorf("unsupported platform %q, valid platforms are %s", p, strings.Join(validPlatforms(), ", "))
+		}
+
+		platforms = append(platforms, Platform{OS: goos, Arch: arch})
+	}
+
+	return platforms, nil
+}
+
+// ParseTargets parses a comma separated list of target triples, like
+// `x86_64-unknown-linux-musl,aarch64-unknown-linux-musl`, into the platforms they build for. Each platform may only
+// be built for once, as the binaries of a platform are installed into its directory.
+func ParseTargets(raw string) ([]Platform, error) {
+	var platforms []Platform
+	built := map[string]string{}
+
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+
+		platform, err := TargetPlatform(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := built[platform.Dir()]; ok {
+			return nil, fmt.Errorf("targets %s and %s both build for %s, only one target per platform is supported", other, t, platform)
+		}
+		built[platform.Dir()] = t
+
+		platforms = append(platforms, platform)
+	}
+
+	return platforms, nil
+}
+
+// TargetPlatform returns the platform that a target triple builds for, like `linux/arm64` for
+// `aarch64-unknown-linux-musl`
+func TargetPlatform(triple string) (Platform, error) {
+	parts := strings.Split(triple, "-")
+
+	var arch string
+	for a, t := range SupportedArchitectures {
+		if t == parts[0] && a != t {
+			arch = a
+		}
+	}
+
+	var goos string
+	for _, part := range parts[1:] {
+		if _, ok := SupportedPlatforms[part]; ok {
+			goos = part
+		}
+	}
+
+	if !supportedPlatform(goos, arch) {
+		return Platform{}, fmt.Errorf("unsupported target %q, targets must build for one of the platforms %s", triple, strings.Join(validPlatforms(), ", "))
+	}
+
+	return Platform{OS: goos, Arch: arch, Target: triple}, nil
+}
+
+func supportedPlatform(goos string, arch string) bool {
+	for _, a := range SupportedPlatforms[goos] {
+		if a == arch {
+			return true
+		}
+	}
+	return false
+}
+
+func validPlatforms() []string {
+	var valid []string
+	for goos, archs := range SupportedPlatforms {
+		for _, arch := range archs {
+			valid = append(valid, Platform{OS: goos, Arch: arch}.String())
+		}
+	}
+	sort.Strings(valid)
+	return valid
+}
+
+// HostPlatform returns the platform that the buildpack builds for without cross compilation
+func HostPlatform() Platform {
+	arch, ok := os.LookupEnv("BP_ARCH")
+	if !ok {
+		arch = runtime.GOARCH
+	}
+
+	return Platform{OS: "linux", Arch: arch}
+}
+
+func (p Platform) String() string {
+	return fmt.Sprintf("%s/%s", p.OS, p.Arch)
+}
+
+// Dir returns the directory name of the platform, like `linux-arm64`
+func (p Platform) Dir() string {
+	return fmt.Sprintf("%s-%s", p.OS, p.Arch)
+}
+
+// TargetTriple returns the target triple to compile for the platform, Target if it is set. Otherwise linux uses the
+// default target of the stack policy, musl on tiny and static stacks unless the static type is gnulibc, and windows
+// always uses the gnu target.
+func (p Platform) TargetTriple(stack string, staticType string) string {
+	if p.Target != "" {
+		return p.Target
+	}
+
+	arch, ok := SupportedArchitectures[p.Arch]
+	if !ok {
+		arch = p.Arch
+	}
+
+	switch p.OS {
+	case "linux":
+	case "windows":
+		return fmt.Sprintf("%s-pc-windows-gnu", arch)
+	default:
+		return fmt.Sprintf("%s-unknown-%s", arch, p.OS)
+	}
+
+	if policy, ok := LookupStackPolicy(stack); ok {
+		return policy.Target(arch, staticType)
+	}
+
+	return fmt.Sprintf("%s-unknown-%s-gnu", arch, p.OS)
+}
+
+// CrossInstallMember cross compiles a workspace member, or the project if the member path is `.`, for a platform and
+// installs the binaries into `destDir/bin`. With the cargo backend, the binaries are installed with
+// `cargo install --target`, otherwise they are built with the subcommand of the backend, like `cargo zigbuild`, and
+// copied from the target directory.
+func (c CargoRunner) CrossInstallMember(memberPath string, srcDir string, destDir string, platform Platform) error {
+	c = c.targetDirRunner(srcDir)
+	triple := platform.TargetTriple(c.Stack, c.StaticType)
+
+	if err := c.AddTarget(triple); err != nil {
+		return fmt.Errorf("unable to add target %s\n%w", triple, err)
+	}
+
+	backend, err := LookupCrossBackend(c.CrossTool)
+	if err != nil {
+		return err
+	}
+
+	if backend.Name() == CrossToolCargo {
+		c.CargoInstallArgs = strings.TrimSpace(fmt.Sprintf("%s --target=%s", c.CargoInstallArgs, triple))
+		return c.InstallMember(memberPath, srcDir, libcnb.Layer{Path: destDir})
+	}
+
+	profile, err := c.Profile()
+	if err != nil {
+		return fmt.Errorf("unable to determine profile\n%w", err)
+	}
+
+	color := c.Color
+	if color == "" {
+		color = ColorNever
+	}
+
+	args := []string{backend.Subcommand()}
+	args = append(args, c.UnstableFlags...)
+	args = append(args, fmt.Sprintf("--color=%s", color), fmt.Sprintf("--profile=%s", profile), fmt.Sprintf("--target=%s", triple))
+	args = append(args, c.FeatureArgs()...)
+	args = append(args, c.offlineArgs(nil)...)
+	args = append(args, c.jobsArgs(nil)...)
+	if memberPath != "." {
+		if !filepath.IsAbs(memberPath) {
+			memberPath = filepath.Join(srcDir, memberPath)
+		}
+		args = append(args, fmt.Sprintf("--manifest-path=%s", filepath.Join(memberPath, "Cargo.toml")))
+	}
+
+	dir := srcDir
+	if c.WorkingDir != "" {
+		dir = c.WorkingDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(srcDir, dir)
+		}
+	}
+
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(args, " ")))
+	output, flush := c.output()
+	if err := c.executeRetrying(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
+		Args:    args,
+		Dir:     dir,
+		Env:     c.WithEnv(c.ProfileEnvironment()).compileEnvironment(),
+		Stdout:  output,
+		Stderr:  output,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to build for %s\n%w", platform, err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write build output\n%w", err)
+	}
+
+	// the dev profile is built into the debug directory for historical reasons
+	profileDir := profile
+	if profile == "dev" {
+		profileDir = "debug"
+	}
+
+	targetDir := c.targetDir(srcDir)
+	if targetDir == "" {
+		targetDir = filepath.Join(srcDir, "target")
+	}
+
+	dirTriple, _ := SplitGlibcVersion(triple)
+	return copyExecutables(filepath.Join(targetDir, dirTriple, profileDir), filepath.Join(destDir, "bin"))
+}
+
+// AddTarget installs the standard library of a target with `rustup target add`. Toolchains that are not managed by
+// rustup must already include the target, so nothing is installed if rustup cannot be found. A pinned glibc version
+// is not part of the target of rustup, see SplitGlibcVersion.
+func (c CargoRunner) AddTarget(triple string) error {
+	triple, _ = SplitGlibcVersion(triple)
+
+	rustup, found, err := c.rustup()
+	if err != nil || !found {
+		return err
+	}
+
+	args := []string{"target", "add", triple}
+	c.Logger.Bodyf("rustup %s", strings.Join(args, " "))
+
+	output, flush := c.output()
+	if err := c.execute(effect.Execution{
+		Command: rustup,
+		Args:    args,
+		Env:     c.environment(),
+		Stdout:  output,
+		Stderr:  output,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to run rustup\n%w", err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write rustup output\n%w", err)
+	}
+
+	return nil
+}
+
+// EnsureTarget installs the standard library of a target with `rustup target add`, unless
+// `rustup target list --installed` shows that it is already installed. Like AddTarget, toolchains that are not
+// managed by rustup are expected to include the target.
+func (c CargoRunner) EnsureTarget(triple string) error {
+	triple, _ = SplitGlibcVersion(triple)
+
+	rustup, found, err := c.rustup()
+	if err != nil || !found {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.captured().execute(effect.Execution{
+		Command: rustup,
+		Args:    []string{"target", "list", "--installed"},
+		Env:     c.environment(),
+		Stdout:  buf,
+		Stderr:  buf,
+	}); err != nil {
+		return fmt.Errorf("error executing 'rustup target list --installed':\n Combined Output: %s: \n%w", buf.String(), err)
+	}
+
+	for _, installed := range strings.Fields(buf.String()) {
+		if installed == triple {
+			c.Logger.Bodyf("Target %s is installed", triple)
+			return nil
+		}
+	}
+
+	return c.AddTarget(triple)
+}
+
+// EnsureDefaultTarget installs the default target of the stack policy, that AddDefaultTargetForTinyOrStatic adds to the
+// arguments of cargo, with EnsureTarget. Nothing is installed if the arguments pick a target, if RUSTFLAGS already
+// build statically or if the stack has no policy.
+func (c CargoRunner) EnsureDefaultTarget() error {
+	args, err := FilterInstallArgs(c.installArgs())
+	if err != nil {
+		return fmt.Errorf("unable to filter: %w", err)
+	}
+
+	if explicitTarget(args) != "" || strings.Contains(os.Getenv("RUSTFLAGS"), "target-feature=+crt-static") {
+		return nil
+	}
+
+	triple, _, err := DefaultTarget(c.Stack, c.StaticType)
+	if err != nil {
+		return fmt.Errorf("unable to determine default target\n%w", err)
+	} else if triple == "" {
+		return nil
+	}
+
+	return c.EnsureTarget(triple)
+}
+
+// TargetList returns the targets that rustc can compile for, from `rustc --print target-list`
+func (c CargoRunner) TargetList() ([]string, error) {
+	buf := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	if err := c.captured().execute(effect.Execution{
+		Command: c.toolchainCommand("rustc"),
+		Args:    []string{"--print", "target-list"},
+		Env:     c.environment(),
+		Stdout:  buf,
+		Stderr:  stderr,
+	}); err != nil {
+		return nil, fmt.Errorf("error executing 'rustc --print target-list':\n Error Output: %s: \n%w", stderr.String(), err)
+	}
+
+	return strings.Fields(buf.String()), nil
+}
+
+// ValidateTarget fails if rustc does not know the target triple, like a misspelt vendor or abi, so that the build
+// stops before anything is compiled instead of failing in cargo. A pinned glibc version is not part of the target of
+// rustc, see SplitGlibcVersion, and custom target specifications are not listed by rustc, so they are not validated.
+func (c CargoRunner) ValidateTarget(triple string) error {
+	if isTargetSpec(triple) {
+		return nil
+	}
+	triple, _ = SplitGlibcVersion(triple)
+
+	targets, err := c.TargetList()
+	if err != nil {
+		return fmt.Errorf("unable to list targets\n%w", err)
+	}
+
+	var similar []string
+	arch, _, _ := strings.Cut(triple, "-")
+	for _, target := range targets {
+		if target == triple {
+			return nil
+		}
+		if strings.HasPrefix(target, arch+"-") {
+			similar = append(similar, target)
+		}
+	}
+
+	if len(similar) == 0 {
+		return fmt.Errorf("unknown target %s, it is not listed by rustc --print target-list", triple)
+	}
+
+	return fmt.Errorf("unknown target %s, it is not listed by rustc --print target-list, targets of %s are %s", triple, arch, strings.Join(similar, ", "))
+}
+
+// rustup returns the rustup command of the toolchain, and whether it exists
+func (c CargoRunner) rustup() (string, bool, error) {
+	rustup := c.toolchainCommand("rustup")
+	if c.ToolchainPath != "" {
+		found, err := sherpa.FileExists(rustup)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to check for rustup in %s\n%w", c.ToolchainPath, err)
+		}
+		return rustup, found, nil
+	}
+
+	if _, err := exec.LookPath(rustup); err != nil {
+		return "", false, nil
+	}
+	return rustup, true, nil
+}
+
+// copyExecutables places the executable files in the top level of a directory, which are the binaries built by cargo
+func copyExecutables(source string, destination string) error {
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", source, err)
+	}
+
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", destination, err)
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("unable to stat %s\n%w", entry.Name(), err)
+		}
+
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		// cargo replaces the binaries in the target directory when it rebuilds them, so they can be hard linked
+		if err := LinkFile(filepath.Join(source, entry.Name()), filepath.Join(destination, entry.Name())); err != nil {
+			return fmt.Errorf("unable to place %s\n%w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/mock"
+)
+
+func testPlatforms(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("parses platforms", func() {
+		it("parses a list of platforms", func() {
+			platforms, err := runner.ParsePlatforms("linux/amd64, linux/arm64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platforms).To(Equal([]runner.Platform{{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}}))
+		})
+
+		it("parses the experimental windows platform", func() {
+			platforms, err := runner.ParsePlatforms("windows/amd64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platforms).To(Equal([]runner.Platform{{OS: "windows", Arch: "amd64"}}))
+		})
+
+		it("returns nothing when unset", func() {
+			Expect(runner.ParsePlatforms("")).To(BeEmpty())
+		})
+
+		it("fails for unsupported platforms", func() {
+			_, err := runner.ParsePlatforms("darwin/arm64")
+			Expect(err).To(MatchError(`unsupported platform "darwin/arm64", valid platforms are ` +
+				"freebsd/amd64, illumos/amd64, linux/amd64, linux/arm64, windows/amd64"))
+
+			_, err = runner.ParsePlatforms("windows/arm64")
+			Expect(err).To(MatchError(ContainSubstring(`unsupported platform "windows/arm64"`)))
+
+			_, err = runner.ParsePlatforms("linux")
+			Expect(err).To(MatchError(ContainSubstring(`unsupported platform "linux"`)))
+		})
+	})
+
+	context("parses targets", func() {
+		it("parses a list of targets into their platforms", func() {
+			platforms, err := runner.ParseTargets("x86_64-unknown-linux-musl, aarch64-unknown-linux-musl")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platforms).To(Equal([]runner.Platform{
+				{OS: "linux", Arch: "amd64", Target: "x86_64-unknown-linux-musl"},
+				{OS: "linux", Arch: "arm64", Target: "aarch64-unknown-linux-musl"},
+			}))
+		})
+
+		it("parses targets of other operating systems", func() {
+			platforms, err := runner.ParseTargets("x86_64-pc-windows-gnu,x86_64-unknown-freebsd,aarch64-unknown-linux-gnu.2.17")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platforms).To(Equal([]runner.Platform{
+				{OS: "windows", Arch: "amd64", Target: "x86_64-pc-windows-gnu"},
+				{OS: "freebsd", Arch: "amd64", Target: "x86_64-unknown-freebsd"},
+				{OS: "linux", Arch: "arm64", Target: "aarch64-unknown-linux-gnu.2.17"},
+			}))
+		})
+
+		it("returns nothing when unset", func() {
+			Expect(runner.ParseTargets("")).To(BeEmpty())
+		})
+
+		it("fails for targets of unsupported platforms", func() {
+			_, err := runner.ParseTargets("aarch64-apple-darwin")
+			Expect(err).To(MatchError(`unsupported target "aarch64-apple-darwin", targets must build for one of the platforms ` +
+				"freebsd/amd64, illumos/amd64, linux/amd64, linux/arm64, windows/amd64"))
+
+			_, err = runner.ParseTargets("wasm32-wasip1")
+			Expect(err).To(MatchError(ContainSubstring(`unsupported target "wasm32-wasip1"`)))
+		})
+
+		it("fails for two targets of the same platform", func() {
+			_, err := runner.ParseTargets("x86_64-unknown-linux-musl,x86_64-unknown-linux-gnu")
+			Expect(err).To(MatchError("targets x86_64-unknown-linux-musl and x86_64-unknown-linux-gnu both build for linux/amd64, only one target per platform is supported"))
+		})
+	})
+
+	it("names the platform directory", func() {
+		Expect(runner.Platform{OS: "linux", Arch: "arm64"}.Dir()).To(Equal("linux-arm64"))
+	})
+
+	context("resolves target triples", func() {
+		it("uses the unknown vendor on other unix platforms", func() {
+			Expect(runner.Platform{OS: "illumos", Arch: "amd64"}.TargetTriple(libpak.TinyStackID, "")).
+				To(Equal("x86_64-unknown-illumos"))
+		})
+
+		it("uses the gnu target on windows", func() {
+			Expect(runner.Platform{OS: "windows", Arch: "amd64"}.TargetTriple(libpak.TinyStackID, "")).
+				To(Equal("x86_64-pc-windows-gnu"))
+		})
+
+		it("uses gnu on full stacks", func() {
+			Expect(runner.Platform{OS: "linux", Arch: "arm64"}.TargetTriple("io.buildpacks.stacks.jammy", "")).
+				To(Equal("aarch64-unknown-linux-gnu"))
+		})
+
+		it("uses the target of the platform", func() {
+			Expect(runner.Platform{OS: "linux", Arch: "arm64", Target: "aarch64-unknown-linux-musl"}.TargetTriple("io.buildpacks.stacks.jammy", "")).
+				To(Equal("aarch64-unknown-linux-musl"))
+		})
+
+		it("uses musl on tiny stacks", func() {
+			Expect(runner.Platform{OS: "linux", Arch: "amd64"}.TargetTriple(libpak.TinyStackID, "")).
+				To(Equal("x86_64-unknown-linux-musl"))
+			Expect(runner.Platform{OS: "linux", Arch: "amd64"}.TargetTriple(libpak.TinyStackID, runner.StaticTypeGNULIBC)).
+				To(Equal("x86_64-unknown-linux-gnu"))
+		})
+	})
+
+	context("cross installs", func() {
+		var (
+			executor  *mocks.Executor
+			srcDir    string
+			destDir   string
+			toolchain string
+		)
+
+		it.Before(func() {
+			executor = &mocks.Executor{}
+			srcDir = t.TempDir()
+			destDir = t.TempDir()
+			toolchain = t.TempDir()
+		})
+
+		it("installs with cargo install --target", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+			Expect(r.CrossInstallMember(".", srcDir, destDir, runner.Platform{OS: "linux", Arch: "arm64"})).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(ContainElement("--target=aarch64-unknown-linux-gnu"))
+			Expect(e.Args).To(ContainElement("--root=" + destDir))
+		})
+
+		it("adds the target with rustup", func() {
+			Expect(os.WriteFile(filepath.Join(toolchain, "rustup"), []byte{}, 0755)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+			Expect(r.CrossInstallMember(".", srcDir, destDir, runner.Platform{OS: "freebsd", Arch: "amd64"})).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Command).To(Equal(filepath.Join(toolchain, "rustup")))
+			Expect(e.Args).To(Equal([]string{"target", "add", "x86_64-unknown-freebsd"}))
+
+			e = executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(ContainElement("--target=x86_64-unknown-freebsd"))
+		})
+
+		it("builds with cargo zigbuild and copies the binaries", func() {
+			executor.On("Execute", mock.Anything).Return(func(ex effect.Execution) error {
+				out := filepath.Join(srcDir, "target", "aarch64-unknown-linux-gnu", "release")
+				Expect(os.MkdirAll(filepath.Join(out, "deps"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(out, "my-app"), []byte("binary"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(out, "my-app.d"), []byte("deps"), 0644)).To(Succeed())
+				return nil
+			})
+
+			r := runner.NewCargoRunner(
+				runner.WithExecutor(executor),
+				runner.WithCrossTool(runner.CrossToolZigbuild),
+				runner.WithToolchainPath(toolchain))
+			Expect(r.CrossInstallMember(".", srcDir, destDir, runner.Platform{OS: "linux", Arch: "arm64"})).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(Equal([]string{"zigbuild", "--color=never", "--profile=release", "--target=aarch64-unknown-linux-gnu"}))
+
+			Expect(filepath.Join(destDir, "bin", "my-app")).To(BeARegularFile())
+			Expect(filepath.Join(destDir, "bin", "my-app.d")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(destDir, "bin", "deps")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("targets", func() {
+		var (
+			executor  *cargotest.Executor
+			toolchain string
+		)
+
+		it.Before(func() {
+			t.Setenv("BP_ARCH", "amd64")
+			executor = &cargotest.Executor{}
+			toolchain = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(toolchain, "rustup"), []byte{}, 0755)).To(Succeed())
+		})
+
+		it("builds for the target instead of the default target of the stack", func() {
+			args, err := runner.NewCargoRunner(
+				runner.WithStack("io.paketo.stacks.tiny"),
+				runner.WithTarget("wasm32-wasip1"),
+			).BuildArgs(libcnb.Layer{Path: "/layers/cargo"}, ".")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(args).To(ContainElement("--target=wasm32-wasip1"))
+			Expect(args).NotTo(ContainElement("--target=x86_64-unknown-linux-musl"))
+		})
+
+		it("overrides the whole triple and flags of the stack policy", func() {
+			t.Setenv("RUSTFLAGS", "")
+
+			args, err := runner.NewCargoRunner(
+				runner.WithStack(libpak.TinyStackID),
+				runner.WithTarget("aarch64-unknown-linux-musl"),
+			).BuildArgs(libcnb.Layer{Path: "/layers/cargo"}, ".")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(args).To(ContainElement("--target=aarch64-unknown-linux-musl"))
+			Expect(args).NotTo(ContainElement("--target=x86_64-unknown-linux-musl"))
+
+			args, err = runner.NewCargoRunner(
+				runner.WithStack(libpak.JammyStaticStackID),
+				runner.WithStaticType(runner.StaticTypeGNULIBC),
+				runner.WithTarget("aarch64-unknown-linux-musl"),
+			).BuildArgs(libcnb.Layer{Path: "/layers/cargo"}, ".")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(args).To(ContainElement("--target=aarch64-unknown-linux-musl"))
+			Expect(args).NotTo(ContainElement("--target=x86_64-unknown-linux-gnu"))
+			Expect(os.Getenv("RUSTFLAGS")).To(BeEmpty())
+		})
+
+		it("does not add a target that is installed", func() {
+			executor.On("rustup", "target", "list", "--installed").Stdout = "wasm32-wasip1\nx86_64-unknown-linux-gnu\n"
+
+			r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+			Expect(r.EnsureTarget("wasm32-wasip1")).To(Succeed())
+
+			cargotest.AssertNotExecuted(t, executor, "rustup", "target", "add")
+		})
+
+		it("adds a target that is missing", func() {
+			executor.On("rustup", "target", "list", "--installed").Stdout = "x86_64-unknown-linux-gnu\n"
+			executor.On("rustup", "target", "add", "wasm32-wasip1")
+
+			r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+			Expect(r.EnsureTarget("wasm32-wasip1")).To(Succeed())
+
+			cargotest.AssertExecuted(t, executor, "rustup", "target", "add", "wasm32-wasip1")
+		})
+
+		it("skips toolchains that are not managed by rustup", func() {
+			r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(t.TempDir()))
+			Expect(r.EnsureTarget("wasm32-wasip1")).To(Succeed())
+
+			Expect(executor.Executions).To(BeEmpty())
+		})
+
+		context("ensures the default target", func() {
+			it.Before(func() {
+				t.Setenv("RUSTFLAGS", "")
+			})
+
+			it("adds the default target of the stack", func() {
+				executor.On("rustup", "target", "list", "--installed").Stdout = "x86_64-unknown-linux-gnu\n"
+				executor.On("rustup", "target", "add", "x86_64-unknown-linux-musl")
+
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain), runner.WithStack(libpak.TinyStackID))
+				Expect(r.EnsureDefaultTarget()).To(Succeed())
+
+				cargotest.AssertExecuted(t, executor, "rustup", "target", "add", "x86_64-unknown-linux-musl")
+			})
+
+			it("adds the gnu target of the gnulibc static type", func() {
+				executor.On("rustup", "target", "list", "--installed").Stdout = "x86_64-unknown-linux-musl\n"
+				executor.On("rustup", "target", "add", "x86_64-unknown-linux-gnu")
+
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain),
+					runner.WithStack(libpak.JammyStaticStackID), runner.WithStaticType(runner.StaticTypeGNULIBC))
+				Expect(r.EnsureDefaultTarget()).To(Succeed())
+
+				cargotest.AssertExecuted(t, executor, "rustup", "target", "add", "x86_64-unknown-linux-gnu")
+			})
+
+			it("does nothing when a target is picked", func() {
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain),
+					runner.WithStack(libpak.TinyStackID), runner.WithTarget("wasm32-wasip1"))
+				Expect(r.EnsureDefaultTarget()).To(Succeed())
+
+				r = runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain),
+					runner.WithStack(libpak.TinyStackID), runner.WithCargoInstallArgs("--target=aarch64-unknown-linux-musl"))
+				Expect(r.EnsureDefaultTarget()).To(Succeed())
+
+				Expect(executor.Executions).To(BeEmpty())
+			})
+
+			it("does nothing on stacks without a policy", func() {
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain), runner.WithStack(libpak.JammyStackID))
+				Expect(r.EnsureDefaultTarget()).To(Succeed())
+
+				Expect(executor.Executions).To(BeEmpty())
+			})
+
+			it("does nothing when RUSTFLAGS build statically", func() {
+				t.Setenv("RUSTFLAGS", "-C target-feature=+crt-static")
+
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain), runner.WithStack(libpak.TinyStackID))
+				Expect(r.EnsureDefaultTarget()).To(Succeed())
+
+				Expect(executor.Executions).To(BeEmpty())
+			})
+		})
+
+		context("validates targets", func() {
+			it.Before(func() {
+				executor.On("rustc", "--print", "target-list").Stdout = "aarch64-unknown-linux-gnu\nwasm32-wasip1\nx86_64-unknown-linux-gnu\nx86_64-unknown-linux-musl\n"
+			})
+
+			it("accepts targets listed by rustc", func() {
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+				Expect(r.ValidateTarget("wasm32-wasip1")).To(Succeed())
+				Expect(r.ValidateTarget("aarch64-unknown-linux-gnu.2.17")).To(Succeed())
+			})
+
+			it("lists the targets of the architecture of an unknown target", func() {
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+				Expect(r.ValidateTarget("x86_64-unknwon-linux-musl")).To(MatchError("unknown target x86_64-unknwon-linux-musl, it is not listed by " +
+					"rustc --print target-list, targets of x86_64 are x86_64-unknown-linux-gnu, x86_64-unknown-linux-musl"))
+
+				Expect(r.ValidateTarget("riscv64gc-unknown-linux-gnu")).To(MatchError("unknown target riscv64gc-unknown-linux-gnu, it is not listed by " +
+					"rustc --print target-list"))
+			})
+
+			it("does not validate custom target specifications", func() {
+				r := runner.NewCargoRunner(runner.WithExecutor(executor), runner.WithToolchainPath(toolchain))
+				Expect(r.ValidateTarget("targets/custom.json")).To(Succeed())
+
+				Expect(executor.Executions).To(BeEmpty())
+			})
+		})
+	})
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// elfMagic starts the binaries that UPX compresses, other binaries, like WebAssembly modules, are left as they are
+var elfMagic = []byte{0x7f, 'E', 'L', 'F'}
+
+// BinarySize is the size of a binary before and after it was post-processed
+type BinarySize struct {
+	After  int64
+	Before int64
+	Path   string
+}
+
+// Saved returns the share of the size that post-processing saved, in percent
+func (b BinarySize) Saved() float64 {
+	if b.Before == 0 {
+		return 0
+	}
+	return float64(b.Before-b.After) / float64(b.Before) * 100
+}
+
+// PostProcess shrinks the installed binaries after they were built and returns their sizes before and after. Binaries
+// are compressed with UPX if enabled with WithCompressBinaries, symbols are already stripped when linking if enabled
+// with WithStripSymbols. Only ELF binaries are compressed. As UPX compresses in place and refuses files with several
+// links, binaries hard linked from the target directory are unlinked first.
+func (c CargoRunner) PostProcess(binaries []string) ([]BinarySize, error) {
+	if !c.CompressBinaries {
+		return nil, nil
+	}
+
+	upx, err := c.upx()
+	if err != nil {
+		return nil, err
+	}
+
+	var sizes []BinarySize
+	for _, path := range binaries {
+		if elf, err := isELF(path); err != nil {
+			return nil, err
+		} else if !elf {
+			continue
+		}
+
+		before, err := fileSize(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Unlink(path); err != nil {
+			return nil, fmt.Errorf("unable to unlink %s\n%w", path, err)
+		}
+
+		buf := &bytes.Buffer{}
+		if err := c.execute(effect.Execution{
+			Command: upx,
+			Args:    []string{"--best", "-q", path},
+			Env:     c.environment(),
+			Stdout:  buf,
+			Stderr:  buf,
+		}); err != nil {
+			return nil, fmt.Errorf("error executing 'upx --best -q %s':\n Combined Output: %s: \n%w", path, buf.String(), err)
+		}
+
+		after, err := fileSize(path)
+		if err != nil {
+			return nil, err
+		}
+
+		sizes = append(sizes, BinarySize{After: after, Before: before, Path: path})
+	}
+
+	return sizes, nil
+}
+
+// upx returns the upx command, found in CARGO_HOME or on PATH
+func (c CargoRunner) upx() (string, error) {
+	if c.CargoHome != "" {
+		path := filepath.Join(c.CargoHome, "bin", "upx")
+		if found, err := sherpa.FileExists(path); err != nil {
+			return "", fmt.Errorf("unable to check for upx in %s\n%w", path, err)
+		} else if found {
+			return path, nil
+		}
+	}
+
+	path, err := exec.LookPath("upx")
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("unable to find upx in CARGO_HOME or on PATH, which compresses the binaries, provide it with the build image or another buildpack")
+	} else if err != nil {
+		return "", fmt.Errorf("unable to look up upx on PATH\n%w", err)
+	}
+
+	return path, nil
+}
+
+func isELF(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elfMagic))
+	if _, err := io.ReadFull(f, magic); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	return bytes.Equal(magic, elfMagic), nil
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("unable to stat %s\n%w", path, err)
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testPostProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		binDir    string
+		cargoHome string
+		executor  *cargotest.Executor
+	)
+
+	it.Before(func() {
+		t.Setenv("PATH", t.TempDir())
+
+		binDir = t.TempDir()
+		cargoHome = cargotest.CargoHome(t)
+		executor = &cargotest.Executor{}
+	})
+
+	binary := func(name string, content string) string {
+		path := filepath.Join(binDir, name)
+		Expect(os.WriteFile(path, []byte(content), 0755)).To(Succeed())
+		return path
+	}
+
+	context("strip symbols", func() {
+		it("strips the symbols with the profile used by cargo install", func() {
+			Expect(runner.NewCargoRunner(runner.WithStripSymbols(true)).ProfileEnvironment()).
+				To(Equal(map[string]string{"CARGO_PROFILE_RELEASE_STRIP": "symbols"}))
+
+			Expect(runner.NewCargoRunner(runner.WithStripSymbols(true), runner.WithCargoInstallArgs("--profile dist")).ProfileEnvironment()).
+				To(Equal(map[string]string{"CARGO_PROFILE_DIST_STRIP": "symbols"}))
+		})
+
+		it("does not change a configured strip setting", func() {
+			Expect(runner.NewCargoRunner(
+				runner.WithStripSymbols(true),
+				runner.WithProfileVariables(map[string]string{"CARGO_PROFILE_RELEASE_STRIP": "debuginfo"}),
+			).ProfileEnvironment()).To(Equal(map[string]string{"CARGO_PROFILE_RELEASE_STRIP": "debuginfo"}))
+
+			Expect(runner.NewCargoRunner(
+				runner.WithStripSymbols(true),
+				runner.WithProfileOverrides(map[string]string{"strip": "none", "debug-assertions": "true"}),
+			).ProfileEnvironment()).To(Equal(map[string]string{
+				"CARGO_PROFILE_RELEASE_STRIP":            "none",
+				"CARGO_PROFILE_RELEASE_DEBUG_ASSERTIONS": "true",
+			}))
+		})
+
+		it("does not strip by default", func() {
+			Expect(runner.NewCargoRunner().ProfileEnvironment()).To(BeNil())
+		})
+	})
+
+	context("compress binaries", func() {
+		it.Before(func() {
+			upx := filepath.Join(cargoHome, "bin", "upx")
+			Expect(os.MkdirAll(filepath.Dir(upx), 0755)).To(Succeed())
+			Expect(os.WriteFile(upx, []byte{}, 0755)).To(Succeed())
+
+			executor.On("upx").Run = func(execution effect.Execution) error {
+				return os.WriteFile(execution.Args[len(execution.Args)-1], []byte("\x7fELF"), 0755)
+			}
+		})
+
+		it("compresses ELF binaries with upx", func() {
+			app := binary("app", "\x7fELF-uncompressed")
+			binary("module.wasm", "\x00asm")
+
+			sizes, err := runner.NewCargoRunner(
+				runner.WithCargoHome(cargoHome),
+				runner.WithCompressBinaries(true),
+				runner.WithExecutor(executor)).PostProcess([]string{app, filepath.Join(binDir, "module.wasm")})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sizes).To(Equal([]runner.BinarySize{{After: 4, Before: 17, Path: app}}))
+			Expect(sizes[0].Saved()).To(BeNumerically("~", 76.5, 0.1))
+
+			execution := cargotest.AssertExecuted(t, executor, "upx", "--best", "-q", app)
+			Expect(execution.Command).To(Equal(filepath.Join(cargoHome, "bin", "upx")))
+			Expect(executor.Executions).To(HaveLen(1))
+		})
+
+		it("does not compress by default", func() {
+			sizes, err := runner.NewCargoRunner(runner.WithExecutor(executor)).PostProcess([]string{binary("app", "\x7fELF")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sizes).To(BeEmpty())
+			Expect(executor.Executions).To(BeEmpty())
+		})
+
+		it("requires upx", func() {
+			_, err := runner.NewCargoRunner(runner.WithCompressBinaries(true), runner.WithExecutor(executor)).PostProcess([]string{binary("app", "\x7fELF")})
+			Expect(err).To(MatchError(ContainSubstring("unable to find upx in CARGO_HOME or on PATH")))
+		})
+	})
+}
@@ -0,0 +1,38 @@
+//go:build !unix
+
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"io"
+	"os/exec"
+)
+
+func newProcessGroup(*exec.Cmd) {}
+
+func terminate(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// runTTY runs the command without a TTY, which is not supported on this platform, writing its combined output to
+// stdout
+func runTTY(cmd *exec.Cmd, stdout io.Writer) error {
+	cmd.Stdout = stdout
+	cmd.Stderr = stdout
+	return cmd.Run()
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+func newProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminate sends SIGTERM to the process group of the command, which is the group of its own, see newProcessGroup
+// and runTTY
+func terminate(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// runTTY runs the command with a TTY, in a session of its own, writing its combined output to stdout
+func runTTY(cmd *exec.Cmd, stdout io.Writer) error {
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return fmt.Errorf("unable to start PTY\n%w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(stdout, f); err != nil {
+		var pathErr *os.PathError
+		if !errors.As(err, &pathErr) || !errors.Is(pathErr.Err, syscall.EIO) {
+			return fmt.Errorf("unable to write output\n%w", err)
+		}
+	}
+
+	return cmd.Wait()
+}
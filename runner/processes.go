@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// DefaultProcessType is the process type that is the default, if the application has a binary of that name and no
+// other default is configured
+const DefaultProcessType = "web"
+
+// Processes returns a launch process for each binary target of the project, which run the binaries installed into
+// `destLayer/bin`
+func (c CargoRunner) Processes(srcDir string, destLayer libcnb.Layer) ([]libcnb.Process, error) {
+	targets, err := c.ProjectTargets(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find project targets\n%w", err)
+	}
+
+	return ProcessTypes(targets, filepath.Join(destLayer.Path, "bin"), c.DefaultProcess)
+}
+
+// ProcessTypes maps binary targets to launch processes that run the binaries in binDir. The default process is the
+// one named defaultProcess, or otherwise `web`, or the first binary target.
+func ProcessTypes(targets []string, binDir string, defaultProcess string) ([]libcnb.Process, error) {
+	processes := []libcnb.Process{}
+	for _, target := range targets {
+		processes = append(processes, libcnb.Process{
+			Type:      target,
+			Command:   filepath.Join(binDir, target),
+			Arguments: []string{},
+			Direct:    true,
+		})
+	}
+
+	if len(processes) == 0 {
+		return processes, nil
+	}
+
+	if defaultProcess != "" {
+		if err := SelectDefaultProcess(processes, defaultProcess); err != nil {
+			return nil, err
+		}
+		return processes, nil
+	}
+
+	if err := SelectDefaultProcess(processes, DefaultProcessType); err != nil {
+		processes[0].Default = true
+	}
+
+	return processes, nil
+}
+
+// SelectDefaultProcess makes the process of a type the default, and all other processes not. Returns an error
+// listing the process types if there is no process of the type.
+func SelectDefaultProcess(processes []libcnb.Process, processType string) error {
+	found := false
+	for i := range processes {
+		if processes[i].Type == processType {
+			found = true
+		}
+	}
+
+	if !found {
+		var types []string
+		for _, p := range processes {
+			types = append(types, p.Type)
+		}
+		return fmt.Errorf("unable to find default process %s, the process types are %s", processType, strings.Join(types, ", "))
+	}
+
+	for i := range processes {
+		processes[i].Default = processes[i].Type == processType
+	}
+
+	return nil
+}
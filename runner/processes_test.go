@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testProcesses(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+		layer    = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[workspace]\nmembers = [\"api\", \"worker\"]\n",
+		})
+		executor = &cargotest.Executor{}
+		Expect(executor.OnMetadata(cargotest.NewMetadata(appDir).
+			WithMember("api", "0.1.0", "api", "api").
+			WithMember("worker", "0.1.0", "worker", "worker", "cleanup"))).To(Succeed())
+	})
+
+	it("contributes a process for each binary target with the first as default", func() {
+		processes, err := runner.NewCargoRunner(runner.WithExecutor(executor)).Processes(appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes).To(Equal([]libcnb.Process{
+			{Type: "api", Command: filepath.Join(layer.Path, "bin", "api"), Arguments: []string{}, Direct: true, Default: true},
+			{Type: "worker", Command: filepath.Join(layer.Path, "bin", "worker"), Arguments: []string{}, Direct: true},
+			{Type: "cleanup", Command: filepath.Join(layer.Path, "bin", "cleanup"), Arguments: []string{}, Direct: true},
+		}))
+	})
+
+	it("makes the configured process the default", func() {
+		processes, err := runner.NewCargoRunner(
+			runner.WithDefaultProcess("worker"),
+			runner.WithExecutor(executor),
+		).Processes(appDir, layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].Default).To(BeFalse())
+		Expect(processes[1].Default).To(BeTrue())
+		Expect(processes[2].Default).To(BeFalse())
+	})
+
+	it("fails when the configured process is not a binary target", func() {
+		_, err := runner.NewCargoRunner(
+			runner.WithDefaultProcess("web"),
+			runner.WithExecutor(executor),
+		).Processes(appDir, layer)
+		Expect(err).To(MatchError("unable to find default process web, the process types are api, worker, cleanup"))
+	})
+
+	it("makes web the default", func() {
+		processes, err := runner.ProcessTypes([]string{"migrate", "web"}, "/workspace/bin", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].Default).To(BeFalse())
+		Expect(processes[1].Default).To(BeTrue())
+	})
+
+	it("contributes no processes without binary targets", func() {
+		Expect(runner.ProcessTypes(nil, "/workspace/bin", "web")).To(BeEmpty())
+	})
+}
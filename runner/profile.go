@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProfileVariablePrefix is the prefix of the variables that set Cargo profile settings, like
+// `BP_CARGO_PROFILE_RELEASE_LTO=thin`
+const ProfileVariablePrefix = "BP_CARGO_PROFILE_"
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	profileNameValue   = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+)
+
+// profileSettings are the settings of a Cargo profile that can be set with environment variables, by the suffix of
+// their variable, with a function that validates a value
+var profileSettings = map[string]func(string) bool{
+	"CODEGEN_UNITS":    positiveInteger,
+	"DEBUG":            oneOf("true", "false", "0", "1", "2", "none", "line-directives-only", "line-tables-only", "limited", "full"),
+	"DEBUG_ASSERTIONS": boolean,
+	"INCREMENTAL":      boolean,
+	"INHERITS":         profileNameValue.MatchString,
+	"LTO":              oneOf("true", "false", "fat", "thin", "off"),
+	"OPT_LEVEL":        oneOf("0", "1", "2", "3", "s", "z"),
+	"OVERFLOW_CHECKS":  boolean,
+	"PANIC":            oneOf("unwind", "abort"),
+	"RPATH":            boolean,
+	"SPLIT_DEBUGINFO":  oneOf("off", "packed", "unpacked"),
+	"STRIP":            oneOf("true", "false", "none", "debuginfo", "symbols"),
+}
+
+// buildOverrideSettings are the settings that can be overridden for build scripts and proc-macros with
+// `CARGO_PROFILE_<PROFILE>_BUILD_OVERRIDE_<SETTING>`
+var buildOverrideSettings = []string{
+	"CODEGEN_UNITS", "DEBUG", "DEBUG_ASSERTIONS", "INCREMENTAL", "OPT_LEVEL", "OVERFLOW_CHECKS", "SPLIT_DEBUGINFO", "STRIP",
+}
+
+// ProfileVariables translates the `BP_CARGO_PROFILE_<PROFILE>_<SETTING>` variables of an environment, given as
+// `name=value` pairs, into the `CARGO_PROFILE_<PROFILE>_<SETTING>` variables read by Cargo. Settings of build
+// scripts are set with `BP_CARGO_PROFILE_<PROFILE>_BUILD_OVERRIDE_<SETTING>`. Unknown settings and invalid values are
+// rejected, so that only valid profile settings reach cargo.
+func ProfileVariables(environ []string) (map[string]string, error) {
+	variables := map[string]string{}
+	var invalid []string
+
+	for _, e := range environ {
+		name, value, _ := strings.Cut(e, "=")
+		if !strings.HasPrefix(name, ProfileVariablePrefix) {
+			continue
+		}
+
+		profile, setting, ok := splitProfileVariable(strings.TrimPrefix(name, ProfileVariablePrefix))
+		if !ok {
+			invalid = append(invalid, fmt.Sprintf("%s is not a Cargo profile setting, expected %s<PROFILE>_<SETTING> like %sRELEASE_LTO",
+				name, ProfileVariablePrefix, ProfileVariablePrefix))
+			continue
+		}
+
+		validate := profileSettings[strings.TrimPrefix(setting, "BUILD_OVERRIDE_")]
+		if !validate(value) {
+			invalid = append(invalid, fmt.Sprintf("%s has an invalid value %q", name, value))
+			continue
+		}
+
+		variables[fmt.Sprintf("CARGO_PROFILE_%s_%s", profile, setting)] = value
+	}
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return nil, fmt.Errorf("invalid Cargo profile settings:\n  %s", strings.Join(invalid, "\n  "))
+	}
+
+	return variables, nil
+}
+
+// splitProfileVariable splits `<PROFILE>_<SETTING>` by the longest known setting, as both profile names and settings
+// may contain underscores, like `RELEASE_LTO_OPT_LEVEL` for the `opt-level` of a `release-lto` profile
+func splitProfileVariable(s string) (string, string, bool) {
+	var settings []string
+	for setting := range profileSettings {
+		settings = append(settings, setting)
+	}
+	for _, setting := range buildOverrideSettings {
+		settings = append(settings, "BUILD_OVERRIDE_"+setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return len(settings[i]) > len(settings[j])
+	})
+
+	for _, setting := range settings {
+		profile, ok := strings.CutSuffix(s, "_"+setting)
+		// settings that cannot be overridden for build scripts must not be taken for the setting of a profile
+		if ok && profileNamePattern.MatchString(profile) && !strings.HasSuffix("_"+profile, "_BUILD_OVERRIDE") {
+			return profile, setting, true
+		}
+	}
+
+	return "", "", false
+}
+
+func boolean(s string) bool {
+	return s == "true" || s == "false"
+}
+
+func positiveInteger(s string) bool {
+	i, err := strconv.Atoi(s)
+	return err == nil && i > 0
+}
+
+func oneOf(values ...string) func(string) bool {
+	return func(s string) bool {
+		for _, v := range values {
+			if s == v {
+				return true
+			}
+		}
+		return false
+	}
+}
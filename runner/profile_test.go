@@ -0,0 +1,74 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testProfile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("translates profile variables into Cargo variables", func() {
+		Expect(runner.ProfileVariables([]string{
+			"PATH=/usr/bin",
+			"BP_CARGO_PROFILE_RELEASE_LTO=thin",
+			"BP_CARGO_PROFILE_RELEASE_CODEGEN_UNITS=1",
+			"BP_CARGO_PROFILE_DEV_BUILD_OVERRIDE_OPT_LEVEL=3",
+			"BP_CARGO_PROFILE_RELEASE_LTO_DEBUG_ASSERTIONS=true",
+			"BP_CARGO_PROFILE_STAGING_INHERITS=release",
+		})).To(Equal(map[string]string{
+			"CARGO_PROFILE_RELEASE_LTO":                  "thin",
+			"CARGO_PROFILE_RELEASE_CODEGEN_UNITS":        "1",
+			"CARGO_PROFILE_DEV_BUILD_OVERRIDE_OPT_LEVEL": "3",
+			"CARGO_PROFILE_RELEASE_LTO_DEBUG_ASSERTIONS": "true",
+			"CARGO_PROFILE_STAGING_INHERITS":             "release",
+		}))
+	})
+
+	it("returns nothing without profile variables", func() {
+		Expect(runner.ProfileVariables([]string{"BP_CARGO_INSTALL_ARGS=--locked"})).To(BeEmpty())
+	})
+
+	it("rejects unknown settings", func() {
+		_, err := runner.ProfileVariables([]string{"BP_CARGO_PROFILE_RELEASE_RUSTFLAGS=-Ctarget-cpu=native", "BP_CARGO_PROFILE_LTO=thin"})
+		Expect(err).To(MatchError(ContainSubstring("BP_CARGO_PROFILE_LTO is not a Cargo profile setting")))
+		Expect(err).To(MatchError(ContainSubstring("BP_CARGO_PROFILE_RELEASE_RUSTFLAGS is not a Cargo profile setting")))
+	})
+
+	it("rejects invalid values", func() {
+		_, err := runner.ProfileVariables([]string{
+			"BP_CARGO_PROFILE_RELEASE_LTO=thinnest",
+			"BP_CARGO_PROFILE_RELEASE_CODEGEN_UNITS=0",
+			"BP_CARGO_PROFILE_RELEASE_BUILD_OVERRIDE_PANIC=abort",
+			"BP_CARGO_PROFILE_RELEASE_OPT_LEVEL=3; rm -rf /",
+		})
+		Expect(err).To(MatchError(And(
+			ContainSubstring(`BP_CARGO_PROFILE_RELEASE_CODEGEN_UNITS has an invalid value "0"`),
+			ContainSubstring(`BP_CARGO_PROFILE_RELEASE_LTO has an invalid value "thinnest"`),
+			ContainSubstring(`BP_CARGO_PROFILE_RELEASE_OPT_LEVEL has an invalid value "3; rm -rf /"`),
+			ContainSubstring("BP_CARGO_PROFILE_RELEASE_BUILD_OVERRIDE_PANIC is not a Cargo profile setting"),
+		)))
+	})
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bindings"
+)
+
+// BindingTypeCargoRegistry is the type of a binding that configures a registry, with the URL of its `index`, its
+// `token` and an optional `name`, which defaults to the name of the binding
+const BindingTypeCargoRegistry = "cargo-registry"
+
+// CratesIO is the name of the default registry
+const CratesIO = "crates-io"
+
+var (
+	registryNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	urlUserInfoPattern  = regexp.MustCompile(`://[^/@\s]+@`)
+)
+
+// IndexSchemes are the schemes of the registry index URLs that cargo supports
+var IndexSchemes = []string{"file", "git", "http", "https", "sparse+http", "sparse+https", "ssh"}
+
+// RedactURLs hides the user information of the URLs in a string, which may contain credentials
+func RedactURLs(value string) string {
+	return urlUserInfoPattern.ReplaceAllString(value, "://<redacted>@")
+}
+
+// RegistryEnvironmentName returns the name of the environment variable that configures a setting of a registry, like
+// CARGO_REGISTRIES_MY_REGISTRY_INDEX
+func RegistryEnvironmentName(registry string, setting string) string {
+	name := strings.ToUpper(strings.ReplaceAll(registry, "-", "_"))
+	return fmt.Sprintf("CARGO_REGISTRIES_%s_%s", name, strings.ToUpper(setting))
+}
+
+// ValidateRegistryName ensures that a registry name is accepted by cargo
+func ValidateRegistryName(registry string) error {
+	if !registryNamePattern.MatchString(registry) {
+		return fmt.Errorf("invalid registry name %q, must only contain letters, numbers, - and _", registry)
+	}
+	return nil
+}
+
+// ValidateIndex ensures that an index URL is supported by cargo and does not contain credentials, which are logged
+// with the install arguments. Tokens are provided with CARGO_REGISTRIES_<NAME>_TOKEN instead.
+func ValidateIndex(index string) error {
+	u, err := url.Parse(index)
+	if err != nil {
+		return fmt.Errorf("invalid index URL %q\n%w", RedactURLs(index), err)
+	}
+
+	supported := false
+	for _, s := range IndexSchemes {
+		if u.Scheme == s {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("invalid index URL %q, the scheme must be one of %s", RedactURLs(index), strings.Join(IndexSchemes, ", "))
+	}
+
+	// only the user of ssh URLs, like `ssh://git@example.com/index`, is not a credential
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword || u.Scheme != "ssh" {
+			return fmt.Errorf("invalid index URL %q, it must not contain credentials", RedactURLs(index))
+		}
+	}
+
+	return nil
+}
+
+// InstallSource returns the registry and the index set with `--registry` and `--index` in the install arguments
+func InstallSource(installArgs string) (string, string, error) {
+	args, err := FilterInstallArgs(installArgs)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to filter: %w", err)
+	}
+
+	var registry, index string
+	for i, arg := range args {
+		switch {
+		case (arg == "--registry" || arg == "--index") && i+1 < len(args):
+			if arg == "--registry" {
+				registry = args[i+1]
+			} else {
+				index = args[i+1]
+			}
+		case strings.HasPrefix(arg, "--registry="):
+			registry = strings.TrimPrefix(arg, "--registry=")
+		case strings.HasPrefix(arg, "--index="):
+			index = strings.TrimPrefix(arg, "--index=")
+		}
+	}
+
+	return registry, index, nil
+}
+
+// RegistryCredential is a registry configured by a binding of type cargo-registry
+type RegistryCredential struct {
+	Binding string
+	Index   string
+	Name    string
+	Token   string
+}
+
+// RegistryCredentials reads the registries of the bindings of type cargo-registry, ordered by the name of the
+// binding. The index may be omitted if the registry is declared in the Cargo configuration, in which case the binding
+// only provides the token, and the token of crates.io is provided by a binding named crates-io.
+func RegistryCredentials(binds libcnb.Bindings) ([]RegistryCredential, error) {
+	var credentials []RegistryCredential
+
+	for _, b := range bindings.Resolve(binds, bindings.OfType(BindingTypeCargoRegistry)) {
+		r := RegistryCredential{
+			Binding: b.Name,
+			Index:   strings.TrimSpace(b.Secret["index"]),
+			Name:    strings.TrimSpace(b.Secret["name"]),
+			Token:   strings.TrimSpace(b.Secret["token"]),
+		}
+		if r.Name == "" {
+			r.Name = b.Name
+		}
+
+		if err := ValidateRegistryName(r.Name); err != nil {
+			return nil, fmt.Errorf("invalid binding %s\n%w", b.Name, err)
+		}
+
+		if r.Index == "" && r.Token == "" {
+			return nil, fmt.Errorf("binding %s of type %s requires an index or a token", b.Name, BindingTypeCargoRegistry)
+		}
+
+		if r.Index != "" {
+			if r.Name == CratesIO {
+				return nil, fmt.Errorf("binding %s of type %s cannot change the index of %s, use a source replacement instead", b.Name, BindingTypeCargoRegistry, CratesIO)
+			}
+			if err := ValidateIndex(r.Index); err != nil {
+				return nil, fmt.Errorf("invalid binding %s\n%w", b.Name, err)
+			}
+		}
+
+		credentials = append(credentials, r)
+	}
+
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].Binding < credentials[j].Binding })
+	return credentials, nil
+}
+
+// TokenEnvironmentName returns the name of the environment variable with the token of a registry, which is
+// CARGO_REGISTRY_TOKEN for crates.io
+func TokenEnvironmentName(registry string) string {
+	if registry == CratesIO {
+		return "CARGO_REGISTRY_TOKEN"
+	}
+	return RegistryEnvironmentName(registry, "token")
+}
+
+// tokenEnvironment returns the environment variables with the tokens of the registry credentials
+func (c CargoRunner) tokenEnvironment() []string {
+	var env []string
+	for _, r := range c.RegistryCredentials {
+		if r.Token != "" {
+			env = append(env, fmt.Sprintf("%s=%s", TokenEnvironmentName(r.Name), r.Token))
+		}
+	}
+	return env
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heroku/color"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// DefaultRetryBackoff is the time waited before the first retry of a command that failed with a network error
+const DefaultRetryBackoff = 5 * time.Second
+
+// RetryPolicy is how often commands that touch registries, like `cargo install`, are retried when they fail with a
+// network error. The time waited before each retry starts with the backoff and doubles with every retry.
+type RetryPolicy struct {
+	Backoff time.Duration
+	Retries int
+}
+
+var (
+	// networkFailures are messages of cargo and curl when a registry or git repository cannot be reached
+	networkFailures = []string{
+		"spurious network error",
+		"network failure seems to have happened",
+		"failed to download",
+		"failed to fetch into",
+		"Couldn't resolve host",
+		"Could not resolve host",
+		"Connection reset",
+		"Connection timed out",
+		"Operation timed out",
+		"Timeout was reached",
+		"SSL connect error",
+		"unexpected eof while reading",
+	}
+
+	// transientStatus matches HTTP responses of registries that may succeed when retried
+	transientStatus = regexp.MustCompile(`failed to get successful HTTP response from .*, got (429|5\d\d)`)
+
+	// compileFailures are messages of cargo when a crate fails to build, which is never retried
+	compileFailures = []string{
+		"could not compile",
+		"error[E",
+		"error: linking with",
+		"failed to run custom build command",
+	}
+)
+
+// NetworkFailure returns whether the output of a failed cargo command shows that it failed because a registry or git
+// repository could not be reached, and not because a crate failed to compile. Compiler messages are recognized in
+// plain output and in the JSON messages of `--message-format=json`.
+func NetworkFailure(output string) bool {
+	for _, f := range compileFailures {
+		if strings.Contains(output, f) {
+			return false
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if compilerError(scanner.Bytes()) {
+			return false
+		}
+	}
+
+	for _, f := range networkFailures {
+		if strings.Contains(output, f) {
+			return true
+		}
+	}
+	return transientStatus.MatchString(output)
+}
+
+// compilerError returns whether a line is a JSON compiler message of cargo with an error
+func compilerError(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte("{")) {
+		return false
+	}
+
+	var message struct {
+		Reason  string `json:"reason"`
+		Message struct {
+			Level string `json:"level"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &message); err != nil {
+		return false
+	}
+	return message.Reason == "compiler-message" && message.Message.Level == "error"
+}
+
+// executeRetrying runs an execution like execute and retries it with the retry policy of the runner when it fails with
+// a network error, but not when it fails otherwise, like when a crate does not compile. A failure caused by an outdated
+// Cargo.lock is explained.
+func (c CargoRunner) executeRetrying(execution effect.Execution) error {
+	ctx := c.context()
+
+	for attempt := 0; ; attempt++ {
+		output := &tailBuffer{Limit: 64 * 1024}
+		e := execution
+		e.Stdout = teeWriter(execution.Stdout, output)
+		e.Stderr = teeWriter(execution.Stderr, output)
+
+		err := c.execute(e)
+		if err == nil {
+			return nil
+		} else if attempt >= c.RetryPolicy.Retries || ctx.Err() != nil || !NetworkFailure(output.String()) {
+			return lockFileFailure(output.String(), err)
+		}
+
+		wait := c.RetryPolicy.Backoff << attempt
+		c.Logger.Bodyf("%s in %s, attempt %d of %d failed with a network error",
+			color.YellowString("Retrying"), wait, attempt+1, c.RetryPolicy.Retries+1)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// teeWriter writes to w and the output of a retried execution, or only the output if w is nil
+func teeWriter(w io.Writer, output io.Writer) io.Writer {
+	if w == nil {
+		return output
+	}
+	return io.MultiWriter(w, output)
+}
+
+// tailBuffer keeps the last Limit bytes written to it, where cargo reports why it failed
+type tailBuffer struct {
+	Limit int
+
+	buf []byte
+	mu  sync.Mutex
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.Limit {
+		t.buf = t.buf[len(t.buf)-t.Limit:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return string(t.buf)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testRetry(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		attempts int
+		executor *cargotest.Executor
+		logs     *bytes.Buffer
+		metadata []byte
+	)
+
+	it.Before(func() {
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+		})
+
+		var err error
+		metadata, err = cargotest.NewMetadata(appDir).WithMember("app", "0.1.0", ".", "app").JSON()
+		Expect(err).NotTo(HaveOccurred())
+
+		attempts = 0
+		executor = &cargotest.Executor{}
+		logs = &bytes.Buffer{}
+	})
+
+	// failMetadata fails `cargo metadata` with output for the given number of attempts before it succeeds
+	failMetadata := func(failures int, output string) {
+		executor.On("cargo", "metadata").Run = func(execution effect.Execution) error {
+			attempts++
+			if attempts <= failures {
+				_, _ = execution.Stderr.Write([]byte(output))
+				return errors.New("exit status 101")
+			}
+			_, err := execution.Stdout.Write(metadata)
+			return err
+		}
+	}
+
+	newRunner := func() runner.CargoRunner {
+		return runner.NewCargoRunner(
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(logs)),
+			runner.WithRetryPolicy(runner.RetryPolicy{Backoff: time.Millisecond, Retries: 2}))
+	}
+
+	it("recognizes network failures", func() {
+		Expect(runner.NetworkFailure("warning: spurious network error (2 tries remaining): [28] Timeout was reached")).To(BeTrue())
+		Expect(runner.NetworkFailure("error: failed to download from `https://index.crates.io/config.json`\n\nCaused by:\n  [6] Couldn't resolve host name")).To(BeTrue())
+		Expect(runner.NetworkFailure("failed to get successful HTTP response from `https://index.crates.io/se/rd/serde` (1.2.3.4), got 503")).To(BeTrue())
+
+		Expect(runner.NetworkFailure("failed to get successful HTTP response from `https://index.crates.io/no/pe/nope`, got 404")).To(BeFalse())
+		Expect(runner.NetworkFailure("error[E0425]: cannot find value `x` in this scope\nerror: could not compile `app`")).To(BeFalse())
+		Expect(runner.NetworkFailure(`{"reason":"compiler-message","message":{"level":"error","rendered":"failed to download"}}`)).To(BeFalse())
+	})
+
+	it("retries commands that fail with a network error", func() {
+		failMetadata(2, "error: failed to download from `https://index.crates.io/config.json`\n  [6] Couldn't resolve host name\n")
+
+		members, err := newRunner().WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(HaveLen(1))
+		Expect(attempts).To(Equal(3))
+		Expect(logs.String()).To(ContainSubstring("attempt 1 of 3 failed with a network error"))
+		Expect(logs.String()).To(ContainSubstring("attempt 2 of 3 failed with a network error"))
+	})
+
+	it("fails when the retries are exhausted", func() {
+		failMetadata(3, "warning: spurious network error (0 tries remaining): [28] Timeout was reached\n")
+
+		_, err := newRunner().WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).To(MatchError(ContainSubstring("exit status 101")))
+		Expect(attempts).To(Equal(3))
+	})
+
+	it("does not retry commands that fail otherwise", func() {
+		failMetadata(1, "error: failed to parse manifest at `Cargo.toml`\n")
+
+		_, err := newRunner().WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).To(HaveOccurred())
+		Expect(attempts).To(Equal(1))
+		Expect(logs.String()).NotTo(ContainSubstring("Retrying"))
+	})
+
+	it("does not retry without a retry policy", func() {
+		failMetadata(1, "warning: spurious network error (0 tries remaining): [28] Timeout was reached\n")
+
+		_, err := runner.NewCargoRunner(runner.WithExecutor(executor)).WorkspaceMembers(appDir, libcnb.Layer{})
+		Expect(err).To(HaveOccurred())
+		Expect(attempts).To(Equal(1))
+	})
+}
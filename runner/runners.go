@@ -18,43 +18,103 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/buildpacks/libcnb"
+	"github.com/heroku/color"
 	"github.com/mattn/go-shellwords"
-	"github.com/paketo-buildpacks/libpak"
 	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/effect"
 	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/paketo-community/cargo/manifest"
 )
 
 //go:generate mockery --name CargoService --case underscore
 
 type CargoService interface {
+	Build(srcDir string, destLayer libcnb.Layer) error
+	BuildDependencies(srcDir string) error
+	CacheKey(srcDir string) (string, error)
+	CrossInstallMember(memberPath string, srcDir string, destDir string, platform Platform) error
+	DependencyGraph(srcDir string) (DependencyGraph, error)
+	EnsureAuditable() error
+	EnsureCrossTool() error
+	EnsureDefaultTarget() error
+	EnsureTarget(triple string) error
+	Fetch(srcDir string) error
 	Install(srcDir string, destLayer libcnb.Layer) error
+	InstallAll(srcDir string, destLayer libcnb.Layer) error
 	InstallMember(memberPath string, srcDir string, destLayer libcnb.Layer) error
-	InstallTool(name string, additionalArgs []string) error
+	InstallTool(tool ToolRequest, sharedArgs []string) error
+	Licenses(srcDir string) (LicenseReport, error)
+	PostProcess(binaries []string) ([]BinarySize, error)
+	Metadata(srcDir string) ([]byte, error)
+	UninstallTool(name string) error
 	WorkspaceMembers(srcDir string, destLayer libcnb.Layer) ([]url.URL, error)
 	ProjectTargets(srcDir string) ([]string, error)
-	CleanCargoHomeCache() error
-	CargoVersion() (string, error)
-	RustVersion() (string, error)
+	PruneTargetDir(srcDir string, maxSize int64) (CleanStatistics, error)
+	SecurityAudit(srcDir string) (AuditReport, error)
+	StampTargetDir(srcDir string) error
+	SweepTargetDir(srcDir string, policy RetentionPolicy) (CleanStatistics, error)
+	Test(srcDir string, args []string) error
+	ValidateLockFile(srcDir string) error
+	ValidateTarget(triple string) error
+	ValidateToolchain(srcDir string) error
+	CleanCargoHomeCache() (CleanStatistics, error)
+	EnsureSccache() error
+	StopSccache() (SccacheStatistics, error)
+	CargoVersion() (Version, error)
+	CargoVersionInfo() (VersionInfo, error)
+	RustVersion() (Version, error)
+	RustVersionInfo() (VersionInfo, error)
 }
 
 const (
 	StaticTypeMUSLC   = "muslc"
 	StaticTypeGNULIBC = "gnulibc"
+
+	MemberSelectionPackage = "package"
+	MemberSelectionPath    = "path"
+
+	UnmatchedMembersFail = "fail"
+	UnmatchedMembersWarn = "warn"
+
+	ColorAlways = "always"
+	ColorAuto   = "auto"
+	ColorNever  = "never"
 )
 
 // Option is a function for configuring a CargoRunner
 type Option func(runner CargoRunner) CargoRunner
 
+// WithAuditIgnore sets the RustSec advisories, like `RUSTSEC-2020-0071`, that SecurityAudit does not report
+func WithAuditIgnore(ids []string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.AuditIgnore = ids
+		return runner
+	}
+}
+
+// WithAuditable sets whether binaries are built with `cargo auditable`, which embeds their dependency list, so that
+// it can be recovered from the binaries by scanners
+func WithAuditable(auditable bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Auditable = auditable
+		return runner
+	}
+}
+
 // WithCargoHome sets CARGO_HOME
 func WithCargoHome(cargoHome string) Option {
 	return func(runner CargoRunner) CargoRunner {
@@ -63,7 +123,8 @@ func WithCargoHome(cargoHome string) Option {
 	}
 }
 
-// WithCargoWorkspaceMembers sets a comma separate list of workspace members
+// WithCargoWorkspaceMembers sets a comma separated list of workspace members to build, which may be globs like `svc-*`
+// or exclusions like `!integration-tests`, see MemberFilter
 func WithCargoWorkspaceMembers(cargoWorkspaceMembers string) Option {
 	return func(runner CargoRunner) CargoRunner {
 		runner.CargoWorkspaceMembers = cargoWorkspaceMembers
@@ -71,6 +132,14 @@ func WithCargoWorkspaceMembers(cargoWorkspaceMembers string) Option {
 	}
 }
 
+// WithCargoFeatures sets the features to enable, a comma or space separated list like `postgres,tls`
+func WithCargoFeatures(features string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.CargoFeatures = ParseFeatures(features)
+		return runner
+	}
+}
+
 // WithCargoInstallArgs sets addition args to pass to cargo install
 func WithCargoInstallArgs(installArgs string) Option {
 	return func(runner CargoRunner) CargoRunner {
@@ -79,6 +148,75 @@ func WithCargoInstallArgs(installArgs string) Option {
 	}
 }
 
+// WithColor sets whether cargo colors its output, either ColorAlways or ColorNever
+func WithColor(color string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Color = color
+		return runner
+	}
+}
+
+// WithCommandTimeout sets how long each command may run before it is aborted, unlimited by default. Commands are only
+// aborted while running by executors that implement ContextExecutor.
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.CommandTimeout = timeout
+		return runner
+	}
+}
+
+// WithCompressBinaries sets whether the installed binaries are compressed with UPX by PostProcess
+func WithCompressBinaries(compress bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.CompressBinaries = compress
+		return runner
+	}
+}
+
+// WithContext sets the context of the commands that the runner executes, which aborts them when it is done, like when
+// the platform stops the build
+func WithContext(ctx context.Context) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Context = ctx
+		return runner
+	}
+}
+
+// WithCrossTool sets the tool used to cross compile for other platforms and targets, `cargo` or `zigbuild`, see
+// LookupCrossBackend
+func WithCrossTool(crossTool string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.CrossTool = crossTool
+		return runner
+	}
+}
+
+// WithDebugBuild sets whether binaries are built with the dev profile for fast rebuilds in inner-loop development,
+// with `--debug` unless a profile is selected otherwise. Incremental compilation is enabled, `cargo install` reuses the
+// cached target directory of the application and CARGO_HOME is not cleaned after building.
+func WithDebugBuild(debug bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.DebugBuild = debug
+		return runner
+	}
+}
+
+// WithDefaultProcess sets the binary target that is the default process, instead of `web` or the first binary target
+func WithDefaultProcess(defaultProcess string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.DefaultProcess = defaultProcess
+		return runner
+	}
+}
+
+// WithEnv sets environment variables for cargo invocations, without changing the environment of the buildpack
+func WithEnv(env map[string]string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Env = env
+		return runner
+	}
+}
+
 // WithExecutor sets the executor to use when running cargo
 func WithExecutor(executor effect.Executor) Option {
 	return func(runner CargoRunner) CargoRunner {
@@ -87,7 +225,33 @@ func WithExecutor(executor effect.Executor) Option {
 	}
 }
 
-// WithLogger sets additional args to pass to cargo install
+// WithIndex sets the URL of the registry index that `cargo install` uses, like `sparse+https://example.com/index/`
+func WithIndex(index string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Index = index
+		return runner
+	}
+}
+
+// WithJobs sets the number of jobs that cargo runs in parallel with `--jobs`, zero keeps the default of cargo, a job
+// per CPU. See AvailableResources to derive it from the memory of the build.
+func WithJobs(jobs int) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Jobs = jobs
+		return runner
+	}
+}
+
+// WithLinker sets the linker that rustc links the binaries with through the C compiler, LinkerLLD or LinkerMold, by
+// appending `-C link-arg=-fuse-ld=<linker>` to RUSTFLAGS. See ResolveLinker to check that it is installed.
+func WithLinker(linker string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Linker = linker
+		return runner
+	}
+}
+
+// WithLogger sets the logger that the runner writes its progress and the output of cargo to
 func WithLogger(logger bard.Logger) Option {
 	return func(runner CargoRunner) CargoRunner {
 		runner.Logger = logger
@@ -95,6 +259,128 @@ func WithLogger(logger bard.Logger) Option {
 	}
 }
 
+// WithLockedBuild sets whether cargo only uses the dependencies locked in Cargo.lock, by adding `--locked` unless the
+// install arguments contain `--locked` or `--frozen`. See ValidateLockFile.
+func WithLockedBuild(lockedBuild bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.LockedBuild = lockedBuild
+		return runner
+	}
+}
+
+// WithLogMode sets how cargo build output is logged, either LogModeFull or LogModeSummary
+func WithLogMode(logMode string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.LogMode = logMode
+		return runner
+	}
+}
+
+// WithMemberSelection sets how workspace members are selected, by `--path` or by package name with `-p`
+func WithMemberSelection(memberSelection string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.MemberSelection = memberSelection
+		return runner
+	}
+}
+
+// WithMessageSummary sets whether the warnings and errors of the compiler are summarized after compiling, with cargo
+// running with MessageFormat
+func WithMessageSummary(messageSummary bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.MessageSummary = messageSummary
+		return runner
+	}
+}
+
+// WithNoDefaultFeatures sets whether the default features of the packages are disabled
+func WithNoDefaultFeatures(noDefaultFeatures bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.NoDefaultFeatures = noDefaultFeatures
+		return runner
+	}
+}
+
+// WithOfflineBuild sets whether the build runs without network access, from vendored dependencies
+func WithOfflineBuild(offlineBuild bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.OfflineBuild = offlineBuild
+		return runner
+	}
+}
+
+// WithProfile sets the Cargo profile that binaries are built with, like `release-lto`, unless a profile is selected
+// in the install arguments. Custom profiles must be defined in Cargo.toml or a Cargo configuration.
+func WithProfile(name string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.BuildProfile = name
+		return runner
+	}
+}
+
+// WithProfileOverrides overrides settings of the Cargo profile used by `cargo install`, like `debug-assertions`, with
+// the corresponding `CARGO_PROFILE_<name>_<setting>` environment variables
+func WithProfileOverrides(overrides map[string]string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.ProfileOverrides = overrides
+		return runner
+	}
+}
+
+// WithProfileVariables sets `CARGO_PROFILE_<PROFILE>_<SETTING>` variables for the cargo executions, as returned by
+// ProfileVariables
+func WithProfileVariables(variables map[string]string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.ProfileVariables = variables
+		return runner
+	}
+}
+
+// WithRegistry sets the name of the registry that `cargo install` uses, which is configured with
+// CARGO_REGISTRIES_<NAME>_INDEX or in the Cargo configuration
+func WithRegistry(registry string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Registry = registry
+		return runner
+	}
+}
+
+// WithRegistryCredentials configures the registries of the bindings of type cargo-registry for cargo. The index of a
+// registry is set with CARGO_REGISTRIES_<NAME>_INDEX and its token with CARGO_REGISTRIES_<NAME>_TOKEN, which is only
+// passed to the cargo process, so that it is neither written to CARGO_HOME nor part of a planned invocation. The option
+// takes the credentials returned by RegistryCredentials rather than the bindings, so that invalid bindings are
+// reported by the caller instead of being ignored, and the bindings are only read once.
+func WithRegistryCredentials(credentials []RegistryCredential) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.RegistryCredentials = credentials
+		return runner
+	}
+}
+
+// WithRetryPolicy sets how often commands that touch registries are retried when they fail with a network error
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.RetryPolicy = policy
+		return runner
+	}
+}
+
+// WithRustcWrapper sets a wrapper, like a caching or auditing tool, that cargo runs rustc through when compiling
+func WithRustcWrapper(rustcWrapper string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.RustcWrapper = rustcWrapper
+		return runner
+	}
+}
+
+// WithSccache sets the directory of the sccache cache, which enables compiling through sccache
+func WithSccache(layerPath string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.SccacheDir = layerPath
+		return runner
+	}
+}
+
 // WithStack sets the stack on which we're running
 func WithStack(stack string) Option {
 	return func(runner CargoRunner) CargoRunner {
@@ -111,15 +397,153 @@ func WithStaticType(staticType string) Option {
 	}
 }
 
+// WithStatistics sets where the crates processed by cargo are counted
+func WithStatistics(statistics *Statistics) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Statistics = statistics
+		return runner
+	}
+}
+
+// WithStripSymbols sets whether the symbols are stripped from the installed binaries, with the strip setting of the
+// profile used by `cargo install`, unless the setting is configured already
+func WithStripSymbols(strip bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.StripSymbols = strip
+		return runner
+	}
+}
+
+// WithTarget sets the target triple to build for, like `wasm32-wasip1`, instead of the default target of the stack.
+// The triple is used as is, so that it overrides the architecture of BP_ARCH as well as the vendor and abi of the
+// stack policy, and the RUSTFLAGS of the stack policy, like `-C target-feature=+crt-static`, are not set.
+func WithTarget(target string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Target = target
+		return runner
+	}
+}
+
+// WithTargetDir sets the target directory that cargo builds in, like a cache layer, so that `cargo install` compiles
+// incrementally across builds instead of in a temporary directory. A relative path is resolved against the source
+// directory. PruneTargetDir limits the size of the directory.
+func WithTargetDir(dir string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.TargetDir = dir
+		return runner
+	}
+}
+
+// WithTimings enables `cargo install --timings`, which writes a report of the time spent compiling each crate
+func WithTimings(timings bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Timings = timings
+		return runner
+	}
+}
+
+// WithTimingsJSON enables `cargo install --timings=html,json`, which also writes the time spent compiling each unit
+// to stdout, where it is collected into the statistics. It needs unstable features, see WithUnstable.
+func WithTimingsJSON(timings bool) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.TimingsJSON = timings
+		return runner
+	}
+}
+
+// WithToolchainPath sets the directory containing the cargo and rustc binaries, by default they are located on PATH
+func WithToolchainPath(toolchainPath string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.ToolchainPath = toolchainPath
+		return runner
+	}
+}
+
+// WithToolStrategies sets how tools are installed
+func WithToolStrategies(toolStrategies ToolStrategies) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.ToolStrategies = toolStrategies
+		return runner
+	}
+}
+
+// WithUnmatchedMembers sets whether patterns of the workspace member filter that do not match any member fail the
+// build, UnmatchedMembersFail, or only log a warning, UnmatchedMembersWarn
+func WithUnmatchedMembers(unmatchedMembers string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.UnmatchedMembers = unmatchedMembers
+		return runner
+	}
+}
+
+// WithUnstable allows unstable features on any toolchain by setting RUSTC_BOOTSTRAP=1 and passes the given `-Z`
+// flags to `cargo install`
+func WithUnstable(unstable bool, flags []string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.Unstable = unstable
+		runner.UnstableFlags = flags
+		return runner
+	}
+}
+
+// WithWorkingDir sets the directory from which `cargo install` runs, independently of the source directory that
+// metadata is read from. Relative paths are resolved against the source directory.
+func WithWorkingDir(workingDir string) Option {
+	return func(runner CargoRunner) CargoRunner {
+		runner.WorkingDir = workingDir
+		return runner
+	}
+}
+
 // CargoRunner can execute cargo via CLI
 type CargoRunner struct {
+	AuditIgnore           []string
+	Auditable             bool
+	BuildProfile          string
+	CargoFeatures         []string
 	CargoHome             string
+	Color                 string
 	CargoWorkspaceMembers string
+	CommandTimeout        time.Duration
+	Context               context.Context
 	CargoInstallArgs      string
+	CompressBinaries      bool
+	CrossTool             string
+	DebugBuild            bool
+	DefaultProcess        string
+	Env                   map[string]string
 	Executor              effect.Executor
+	Index                 string
+	Jobs                  int
+	Linker                string
+	LockedBuild           bool
+	LogMode               string
+	MessageSummary        bool
 	Logger                bard.Logger
+	MemberSelection       string
+	NoDefaultFeatures     bool
+	OfflineBuild          bool
+	ProfileOverrides      map[string]string
+	ProfileVariables      map[string]string
+	Registry              string
+	RetryPolicy           RetryPolicy
+	RegistryCredentials   []RegistryCredential
+	RustcWrapper          string
+	SccacheDir            string
 	Stack                 string
 	StaticType            string
+	Statistics            *Statistics
+	StripSymbols          bool
+	Target                string
+	TargetDir             string
+	Timings               bool
+	TimingsJSON           bool
+	ToolchainPath         string
+	ToolStrategies        ToolStrategies
+	Unstable              bool
+	UnmatchedMembers      string
+	UnstableFlags         []string
+	WorkingDir            string
 }
 
 type metadataTarget struct {
@@ -135,77 +559,343 @@ type metadataTarget struct {
 
 type metadataPackage struct {
 	ID      string
+	Name    string           `json:"name"`
 	Targets []metadataTarget `json:"targets"`
+	Version string           `json:"version"`
+}
+
+type metadataDepKind struct {
+	Kind   *string `json:"kind"`
+	Target *string `json:"target"`
+}
+
+type metadataNodeDep struct {
+	DepKinds []metadataDepKind `json:"dep_kinds"`
+	Pkg      string            `json:"pkg"`
+}
+
+type metadataNode struct {
+	Deps []metadataNodeDep `json:"deps"`
+	ID   string            `json:"id"`
+}
+
+type metadataResolve struct {
+	Nodes []metadataNode `json:"nodes"`
 }
 
 type metadata struct {
 	Packages         []metadataPackage `json:"packages"`
+	Resolve          *metadataResolve  `json:"resolve"`
+	TargetDirectory  string            `json:"target_directory"`
 	WorkspaceMembers []string          `json:"workspace_members"`
+	WorkspaceRoot    string            `json:"workspace_root"`
 }
 
-// NewCargoRunner creates a new cargo runner with the given options
-func NewCargoRunner(options ...Option) CargoRunner {
-	runner := CargoRunner{}
+// NewCargoRunner creates a new cargo runner with the given options
+func NewCargoRunner(options ...Option) CargoRunner {
+	runner := CargoRunner{}
+
+	for _, option := range options {
+		runner = option(runner)
+	}
+
+	return runner
+}
+
+// Install will build and install the project using `cargo install`
+func (c CargoRunner) Install(srcDir string, destLayer libcnb.Layer) error {
+	return c.InstallMember(".", srcDir, destLayer)
+}
+
+// InstallMember will build and install a specific workspace member using `cargo install`
+func (c CargoRunner) InstallMember(memberPath string, srcDir string, destLayer libcnb.Layer) error {
+	if err := c.installMember(memberPath, srcDir, destLayer); err != nil {
+		return err
+	}
+
+	return c.cleanAfterBuild()
+}
+
+// installMember builds and installs a workspace member without cleaning up, so that members installed one after the
+// other do not extract the sources of their dependencies again
+func (c CargoRunner) installMember(memberPath string, srcDir string, destLayer libcnb.Layer) error {
+	// makes warning from `cargo install` go away
+	path := os.Getenv("PATH")
+	if path != "" && !strings.Contains(path, destLayer.Path) {
+		path = sherpa.AppendToEnvVar("PATH", ":", filepath.Join(destLayer.Path, "bin"))
+		err := os.Setenv("PATH", path)
+		if err != nil {
+			return fmt.Errorf("unable to update PATH\n%w", err)
+		}
+	}
+
+	if err := c.CopyTargetSpec(srcDir); err != nil {
+		return fmt.Errorf("unable to copy target specification\n%w", err)
+	}
+
+	plan, err := c.InstallPlan(memberPath, srcDir, destLayer)
+	if err != nil {
+		return fmt.Errorf("unable to plan install\n%w", err)
+	}
+
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(plan.Args, " ")))
+	output, flush := c.output()
+	stdout, stderr, flush := c.messageOutput(output, flush)
+	stdout, flush = c.timingOutput(stdout, flush)
+	if err := c.messageRunner().executeRetrying(effect.Execution{
+		Command: plan.Command,
+		Args:    plan.Args,
+		Dir:     plan.Dir,
+		Env:     c.inheritEnvironment(plan.Env),
+		Stdout:  stdout,
+		Stderr:  stderr,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to build\n%w", err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write build output\n%w", err)
+	}
+
+	return nil
+}
+
+// Invocation is a fully resolved cargo invocation. Env only holds the variables added by the runner, the rest of the
+// environment is inherited from the buildpack.
+type Invocation struct {
+	Args    []string `json:"args"`
+	Command string   `json:"command"`
+	Dir     string   `json:"dir"`
+	Env     []string `json:"env,omitempty"`
+}
+
+// String renders the invocation canonically, with the directory and each environment variable on their own line
+// followed by the command, so that changes to the invocation are reviewable as diffs
+func (i Invocation) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "dir: %s\n", i.Dir)
+	for _, e := range i.Env {
+		fmt.Fprintf(&b, "env: %s\n", e)
+	}
+	fmt.Fprintf(&b, "%s %s\n", i.Command, strings.Join(i.Args, " "))
+
+	return b.String()
+}
+
+// InstallPlan resolves the invocation that InstallMember runs to build and install a workspace member, without
+// running it
+func (c CargoRunner) InstallPlan(memberPath string, srcDir string, destLayer libcnb.Layer) (Invocation, error) {
+	c = c.targetDirRunner(srcDir)
+
+	if c.OfflineBuild {
+		if err := c.ValidateVendoredSources(srcDir); err != nil {
+			return Invocation{}, err
+		}
+	}
+
+	if err := c.ValidateProfile(srcDir); err != nil {
+		return Invocation{}, err
+	}
+
+	dir := srcDir
+	if c.WorkingDir != "" {
+		dir = c.WorkingDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(srcDir, dir)
+		}
+
+		// the member path is relative to the source directory, not to the working directory
+		if !filepath.IsAbs(memberPath) {
+			memberPath = filepath.Join(srcDir, memberPath)
+		}
+	}
+
+	var args []string
+	var err error
+	if c.MemberSelection == MemberSelectionPackage && memberPath != "." {
+		args, err = c.buildPackageArgs(destLayer, memberPath, srcDir)
+	} else {
+		args, err = c.BuildArgs(destLayer, memberPath)
+	}
+	if err != nil {
+		return Invocation{}, fmt.Errorf("unable to build args\n%w", err)
+	}
+
+	return Invocation{
+		Args:    c.auditableArgs(args),
+		Command: c.toolchainCommand("cargo"),
+		Dir:     dir,
+		Env:     c.WithEnv(c.ProfileEnvironment()).debugRunner(srcDir).compileRunner().addedEnvironment(),
+	}, nil
+}
+
+// InstallTool installs a tool with the configured strategies, falling back to the next strategy if one fails. The
+// shared arguments and those of the tool are only passed to `cargo install`.
+func (c CargoRunner) InstallTool(tool ToolRequest, sharedArgs []string) error {
+	strategies := c.ToolStrategies.For(tool.String())
+
+	var err error
+	for i, strategy := range strategies {
+		if err = c.executeTool(ToolArgs(strategy, tool.String(), tool.InstallArgs(sharedArgs)), c.compileEnvironment()); err == nil {
+			return nil
+		}
+
+		if i < len(strategies)-1 {
+			c.Logger.Bodyf("%s: unable to install %s with strategy %s, falling back to %s", color.YellowString("Warning"), tool, strategy, strategies[i+1])
+		}
+	}
+
+	return fmt.Errorf("unable to install tool\n%w", err)
+}
+
+// UninstallTool removes a tool installed with InstallTool
+func (c CargoRunner) UninstallTool(name string) error {
+	// tools may be pinned to a version, like `diesel_cli@2.1.0`, but are uninstalled by name
+	name, _, _ = strings.Cut(name, "@")
+
+	if err := c.executeTool([]string{"uninstall", name}, c.environment()); err != nil {
+		return fmt.Errorf("unable to uninstall tool %s\n%w", name, err)
+	}
+
+	return nil
+}
+
+func (c CargoRunner) executeTool(args []string, env []string) error {
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(args, " ")))
+	output, flush := c.output()
+	if err := c.executeRetrying(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
+		Args:    args,
+		Env:     env,
+		Stdout:  output,
+		Stderr:  output,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to execute cargo %s\n%w", args[0], err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write build output\n%w", err)
+	}
+
+	return nil
+}
+
+// BuildDependencies fetches and compiles the dependencies of the workspace members into the target directory,
+// without building the members, so that the caches are warm for later builds. Only the direct, platform independent
+// dependencies are selected with `-p`, cargo compiles their dependencies as well.
+func (c CargoRunner) BuildDependencies(srcDir string) error {
+	c = c.targetDirRunner(srcDir)
+
+	m, err := c.cargoMetadata(srcDir)
+	if err != nil {
+		return fmt.Errorf("unable to load cargo metadata\n%w", err)
+	}
+
+	filterMap, err := c.selectedMembers(m)
+	if err != nil {
+		return err
+	}
+
+	members := map[string]bool{}
+	selected := map[string]bool{}
+	for _, workspace := range m.WorkspaceMembers {
+		members[workspace] = true
+
+		pkgName, _, _, err := ParseWorkspaceMember(workspace)
+		if err != nil {
+			return fmt.Errorf("unable to parse: %w", err)
+		}
+		selected[workspace] = len(filterMap) == 0 || filterMap[pkgName]
+	}
 
-	for _, option := range options {
-		runner = option(runner)
+	packages := map[string]metadataPackage{}
+	for _, pkg := range m.Packages {
+		packages[pkg.ID] = pkg
 	}
 
-	return runner
-}
+	specs := map[string]bool{}
+	if m.Resolve != nil {
+		for _, node := range m.Resolve.Nodes {
+			if !selected[node.ID] {
+				continue
+			}
 
-// Install will build and install the project using `cargo install`
-func (c CargoRunner) Install(srcDir string, destLayer libcnb.Layer) error {
-	return c.InstallMember(".", srcDir, destLayer)
-}
+			for _, dep := range node.Deps {
+				pkg, ok := packages[dep.Pkg]
+				if !ok || members[dep.Pkg] {
+					continue
+				}
 
-// InstallMember will build and install a specific workspace member using `cargo install`
-func (c CargoRunner) InstallMember(memberPath string, srcDir string, destLayer libcnb.Layer) error {
-	// makes warning from `cargo install` go away
-	path := os.Getenv("PATH")
-	if path != "" && !strings.Contains(path, destLayer.Path) {
-		path = sherpa.AppendToEnvVar("PATH", ":", filepath.Join(destLayer.Path, "bin"))
-		err := os.Setenv("PATH", path)
-		if err != nil {
-			return fmt.Errorf("unable to update PATH\n%w", err)
+				for _, kind := range dep.DepKinds {
+					if (kind.Kind == nil || *kind.Kind == "build") && kind.Target == nil {
+						specs[fmt.Sprintf("%s@%s", pkg.Name, pkg.Version)] = true
+					}
+				}
+			}
 		}
 	}
 
-	args, err := c.BuildArgs(destLayer, memberPath)
+	if len(specs) == 0 {
+		c.Logger.Body("No dependencies to build")
+		return nil
+	}
+
+	profile, err := c.Profile()
 	if err != nil {
-		return fmt.Errorf("unable to build args\n%w", err)
+		return fmt.Errorf("unable to determine profile\n%w", err)
 	}
 
-	c.Logger.Bodyf("cargo %s", strings.Join(args, " "))
-	if err := c.Executor.Execute(effect.Execution{
-		Command: "cargo",
-		Args:    args,
-		Dir:     srcDir,
-		Stdout:  bard.NewWriter(c.Logger.Logger.InfoWriter(), bard.WithIndent(3)),
-		Stderr:  bard.NewWriter(c.Logger.Logger.InfoWriter(), bard.WithIndent(3)),
-	}); err != nil {
-		return fmt.Errorf("unable to build\n%w", err)
+	color := c.Color
+	if color == "" {
+		color = ColorNever
 	}
 
-	err = c.CleanCargoHomeCache()
+	args := []string{"build"}
+	args = append(args, c.UnstableFlags...)
+	args = append(args, fmt.Sprintf("--color=%s", color), fmt.Sprintf("--profile=%s", profile))
+
+	triple, err := ResolveTargetTriple(c.installArgs(), c.Stack, c.StaticType)
 	if err != nil {
-		return fmt.Errorf("unable to cleanup: %w", err)
+		return fmt.Errorf("unable to resolve target triple\n%w", err)
+	}
+	if triple != "" {
+		args = append(args, fmt.Sprintf("--target=%s", triple))
+	}
+
+	if err := c.CopyTargetSpec(srcDir); err != nil {
+		return fmt.Errorf("unable to copy target specification\n%w", err)
+	}
+
+	names := make([]string, 0, len(specs))
+	for spec := range specs {
+		names = append(names, spec)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "-p", name)
 	}
-	return nil
-}
 
-func (c CargoRunner) InstallTool(name string, additionalArgs []string) error {
-	args := []string{"install", name}
-	args = append(args, additionalArgs...)
+	dir := c.workingDir(srcDir)
 
-	c.Logger.Bodyf("cargo %s", strings.Join(args, " "))
-	if err := c.Executor.Execute(effect.Execution{
-		Command: "cargo",
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(args, " ")))
+	output, flush := c.output()
+	if err := c.executeRetrying(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
 		Args:    args,
-		Stdout:  bard.NewWriter(c.Logger.Logger.InfoWriter(), bard.WithIndent(3)),
-		Stderr:  bard.NewWriter(c.Logger.Logger.InfoWriter(), bard.WithIndent(3)),
+		Dir:     dir,
+		Env:     c.WithEnv(c.ProfileEnvironment()).compileEnvironment(),
+		Stdout:  output,
+		Stderr:  output,
 	}); err != nil {
-		return fmt.Errorf("unable to install tool\n%w", err)
+		_ = flush()
+		return fmt.Errorf("unable to build dependencies\n%w", err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write build output\n%w", err)
 	}
 
 	return nil
@@ -218,7 +908,10 @@ func (c CargoRunner) WorkspaceMembers(srcDir string, destLayer libcnb.Layer) ([]
 		return []url.URL{}, fmt.Errorf("unable to load cargo metadata\n%w", err)
 	}
 
-	filterMap := c.makeFilterMap()
+	filterMap, err := c.selectedMembers(m)
+	if err != nil {
+		return []url.URL{}, err
+	}
 
 	var paths []url.URL
 	for _, workspace := range m.WorkspaceMembers {
@@ -283,14 +976,19 @@ func (c CargoRunner) ProjectTargets(srcDir string) ([]string, error) {
 		return []string{}, fmt.Errorf("unable to load cargo metadata\n%w", err)
 	}
 
-	filterMap := c.makeFilterMap()
+	filterMap, err := c.selectedMembers(m)
+	if err != nil {
+		return []string{}, err
+	}
 
 	workspaces := []string{}
 	for _, workspace := range m.WorkspaceMembers {
-		// This is OK because the workspace member format is `package-name package-version (url)` and
-		//   none of name, version or URL may contain a space & be valid
-		parts := strings.SplitN(workspace, " ", 3)
-		if len(filterMap) > 0 && filterMap[strings.TrimSpace(parts[0])] || len(filterMap) == 0 {
+		pkgName, _, _, err := ParseWorkspaceMember(workspace)
+		if err != nil {
+			return []string{}, fmt.Errorf("unable to parse: %w", err)
+		}
+
+		if len(filterMap) > 0 && filterMap[pkgName] || len(filterMap) == 0 {
 			workspaces = append(workspaces, workspace)
 		}
 	}
@@ -313,108 +1011,95 @@ func (c CargoRunner) ProjectTargets(srcDir string) ([]string, error) {
 	return names, nil
 }
 
-// CleanCargoHomeCache clears out unnecessary files from under $CARGO_HOME
-func (c CargoRunner) CleanCargoHomeCache() error {
-	files, err := os.ReadDir(c.CargoHome)
+// CargoVersion returns the version of cargo installed
+func (c CargoRunner) CargoVersion() (Version, error) {
+	info, err := c.CargoVersionInfo()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("unable to read directory\n%w", err)
-	}
-
-	for _, file := range files {
-		if file.IsDir() && file.Name() == "bin" ||
-			file.IsDir() && file.Name() == "registry" ||
-			file.IsDir() && file.Name() == "git" {
-			continue
-		}
-		err := os.RemoveAll(filepath.Join(c.CargoHome, file.Name()))
-		if err != nil {
-			return fmt.Errorf("unable to remove files\n%w", err)
-		}
+		return Version{}, err
 	}
 
-	registryDir := filepath.Join(c.CargoHome, "registry")
-	files, err = os.ReadDir(registryDir)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("unable to read directory\n%w", err)
-	}
-
-	for _, file := range files {
-		if file.IsDir() && file.Name() == "index" ||
-			file.IsDir() && file.Name() == "cache" {
-			continue
-		}
-		err := os.RemoveAll(filepath.Join(registryDir, file.Name()))
-		if err != nil {
-			return fmt.Errorf("unable to remove files\n%w", err)
-		}
-	}
-
-	gitDir := filepath.Join(c.CargoHome, "git")
-	files, err = os.ReadDir(gitDir)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("unable to read directory\n%w", err)
-	}
-
-	for _, file := range files {
-		if file.IsDir() && file.Name() == "db" {
-			continue
-		}
-		err := os.RemoveAll(filepath.Join(gitDir, file.Name()))
-		if err != nil {
-			return fmt.Errorf("unable to remove files\n%w", err)
-		}
-	}
-
-	return nil
+	return info.Version, nil
 }
 
-// CargoVersion returns the version of cargo installed
-func (c CargoRunner) CargoVersion() (string, error) {
+// CargoVersionInfo returns the version, channel, commit and host of cargo installed, from `cargo version --verbose`
+func (c CargoRunner) CargoVersionInfo() (VersionInfo, error) {
 	buf := &bytes.Buffer{}
 
-	if err := c.Executor.Execute(effect.Execution{
-		Command: "cargo",
-		Args:    []string{"version"},
+	if err := c.captured().execute(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
+		Args:    []string{"version", "--verbose"},
 		Stdout:  buf,
 		Stderr:  buf,
 	}); err != nil {
-		return "", fmt.Errorf("error executing 'cargo version':\n Combined Output: %s: \n%w", buf.String(), err)
+		return VersionInfo{}, fmt.Errorf("error executing 'cargo version --verbose':\n Combined Output: %s: \n%w", buf.String(), err)
+	}
+
+	info, err := ParseVersionInfo(buf.String())
+	if err != nil {
+		return VersionInfo{}, fmt.Errorf("unable to parse cargo version\n%w", err)
 	}
 
-	s := strings.SplitN(strings.TrimSpace(buf.String()), " ", 3)
-	return s[1], nil
+	return info, nil
 }
 
 // RustVersion returns the version of rustc installed
-func (c CargoRunner) RustVersion() (string, error) {
+func (c CargoRunner) RustVersion() (Version, error) {
+	info, err := c.RustVersionInfo()
+	if err != nil {
+		return Version{}, err
+	}
+
+	return info.Version, nil
+}
+
+// RustVersionInfo returns the version, channel, commit and host of rustc installed, from `rustc -vV`
+func (c CargoRunner) RustVersionInfo() (VersionInfo, error) {
 	buf := &bytes.Buffer{}
 
-	if err := c.Executor.Execute(effect.Execution{
-		Command: "rustc",
-		Args:    []string{"--version"},
+	if err := c.captured().execute(effect.Execution{
+		Command: c.toolchainCommand("rustc"),
+		Args:    []string{"-vV"},
 		Stdout:  buf,
 		Stderr:  buf,
 	}); err != nil {
-		return "", fmt.Errorf("error executing 'rustc --version':\n Combined Output: %s: \n%w", buf.String(), err)
+		return VersionInfo{}, fmt.Errorf("error executing 'rustc -vV':\n Combined Output: %s: \n%w", buf.String(), err)
+	}
+
+	info, err := ParseVersionInfo(buf.String())
+	if err != nil {
+		return VersionInfo{}, fmt.Errorf("unable to parse rustc version\n%w", err)
 	}
 
-	s := strings.Split(strings.TrimSpace(buf.String()), " ")
-	return s[1], nil
+	return info, nil
 }
 
 // BuildArgs will build the list of arguments to pass `cargo install`
 func (c CargoRunner) BuildArgs(destLayer libcnb.Layer, defaultMemberPath string) ([]string, error) {
-	envArgs, err := FilterInstallArgs(c.CargoInstallArgs)
+	envArgs, err := FilterInstallArgs(c.installArgs())
 	if err != nil {
 		return nil, fmt.Errorf("filter failed: %w", err)
 	}
 
 	args := []string{"install"}
-	args = append(args, envArgs...)
-	args = append(args, "--color=never", fmt.Sprintf("--root=%s", destLayer.Path))
+	args = append(args, c.UnstableFlags...)
+	args = append(args, replaceTargetSpec(envArgs)...)
+	color := c.Color
+	if color == "" {
+		color = ColorNever
+	}
+
+	args = append(args, fmt.Sprintf("--color=%s", color), fmt.Sprintf("--root=%s", destLayer.Path))
+	if c.Registry != "" {
+		args = append(args, fmt.Sprintf("--registry=%s", c.Registry))
+	}
+	if c.Index != "" {
+		args = append(args, fmt.Sprintf("--index=%s", c.Index))
+	}
+	args = append(args, c.timingsArgs()...)
+	args = append(args, c.FeatureArgs()...)
+	args = append(args, c.offlineArgs(envArgs)...)
+	args = append(args, c.jobsArgs(envArgs)...)
+	args = append(args, c.messageArgs(envArgs)...)
 	args = AddDefaultPath(args, defaultMemberPath)
 
 	args, err = AddDefaultTargetForTinyOrStatic(args, c.Stack, c.StaticType)
@@ -425,6 +1110,113 @@ func (c CargoRunner) BuildArgs(destLayer libcnb.Layer, defaultMemberPath string)
 	return args, nil
 }
 
+// buildPackageArgs builds the arguments to install a workspace member by its package name, the workspace root is
+// passed with `--path` and the member is selected with `-p`
+func (c CargoRunner) buildPackageArgs(destLayer libcnb.Layer, memberPath string, srcDir string) ([]string, error) {
+	m, err := manifest.Load(filepath.Join(memberPath, "Cargo.toml"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load manifest of %s\n%w", memberPath, err)
+	}
+
+	if m.Package == nil || m.Package.Name == "" {
+		return nil, fmt.Errorf("unable to find a package name in %s", filepath.Join(memberPath, "Cargo.toml"))
+	}
+
+	rootPath := "."
+	if c.WorkingDir != "" {
+		rootPath = srcDir
+	}
+
+	args, err := c.BuildArgs(destLayer, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(args, "-p", m.Package.Name), nil
+}
+
+// Profile returns the Cargo profile used by `cargo install`, which is `release` unless set with `--profile` or
+// `--debug` in the install arguments or with WithProfile
+func (c CargoRunner) Profile() (string, error) {
+	profile, err := ExplicitProfile(c.installArgs())
+	if err != nil {
+		return "", err
+	}
+
+	if profile == "" {
+		return "release", nil
+	}
+	return profile, nil
+}
+
+// ProfileEnvironment returns the environment variables that set the profile variables and apply the profile overrides
+// to the profile used by `cargo install`. The overrides take precedence over a profile variable of the same setting.
+func (c CargoRunner) ProfileEnvironment() map[string]string {
+	overrides := c.ProfileOverrides
+	if c.StripSymbols {
+		overrides = c.defaultOverride(overrides, "strip", "symbols")
+	}
+	if c.DebugBuild {
+		// incremental artifacts are kept in the cached target directory, so that rebuilds only compile what changed
+		overrides = c.defaultOverride(overrides, "incremental", "true")
+	}
+
+	if len(overrides) == 0 && len(c.ProfileVariables) == 0 {
+		return nil
+	}
+
+	env := map[string]string{}
+	for name, value := range c.ProfileVariables {
+		env[name] = value
+	}
+
+	if len(overrides) == 0 {
+		return env
+	}
+
+	profile, err := c.Profile()
+	if err != nil {
+		// invalid install arguments fail when the arguments are built
+		return env
+	}
+
+	for setting, value := range overrides {
+		env[profileVariableName(profile, setting)] = value
+	}
+
+	return env
+}
+
+// defaultOverride returns the profile overrides with a setting, unless the setting is set by an override or a profile
+// variable already
+func (c CargoRunner) defaultOverride(overrides map[string]string, setting string, value string) map[string]string {
+	result := map[string]string{}
+	for k, v := range overrides {
+		result[k] = v
+	}
+
+	if _, ok := result[setting]; ok {
+		return result
+	}
+
+	if profile, err := c.Profile(); err == nil {
+		if _, ok := c.ProfileVariables[profileVariableName(profile, setting)]; ok {
+			return result
+		}
+	}
+
+	result[setting] = value
+	return result
+}
+
+// profileVariableName returns the variable that sets a setting of a profile, like CARGO_PROFILE_RELEASE_STRIP
+func profileVariableName(profile string, setting string) string {
+	name := func(s string) string {
+		return strings.ToUpper(strings.ReplaceAll(s, "-", "_"))
+	}
+	return fmt.Sprintf("CARGO_PROFILE_%s_%s", name(profile), name(setting))
+}
+
 // FilterInstallArgs provides a clean list of allowed arguments
 func FilterInstallArgs(args string) ([]string, error) {
 	argwords, err := shellwords.Parse(args)
@@ -462,9 +1254,10 @@ func AddDefaultPath(args []string, defaultMemberPath string) []string {
 	return append(args, fmt.Sprintf("--path=%s", defaultMemberPath))
 }
 
-// AddDefaultTargetForTinyOrStatic will add the appropriate options if not already set
+// AddDefaultTargetForTinyOrStatic will add the default target of the stack policy, if the stack has one and the
+// options are not already set
 func AddDefaultTargetForTinyOrStatic(args []string, stack string, staticType string) ([]string, error) {
-	if !libpak.IsTinyStack(stack) && !libpak.IsStaticStack(stack) {
+	if _, ok := LookupStackPolicy(stack); !ok {
 		return args, nil
 	}
 
@@ -481,17 +1274,18 @@ func AddDefaultTargetForTinyOrStatic(args []string, stack string, staticType str
 		return args, nil
 	}
 
-	arch := archFromSystem()
-
-	target := fmt.Sprintf("--target=%s-unknown-linux-musl", arch)
-	if staticType == StaticTypeGNULIBC {
-		target = fmt.Sprintf("--target=%s-unknown-linux-gnu", arch)
+	triple, flags, err := DefaultTarget(stack, staticType)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to determine default target\n%w", err)
+	}
 
+	target := fmt.Sprintf("--target=%s", triple)
+	if len(flags) > 0 {
 		rustFlagsList := []string{}
 		if len(rustFlags) > 0 {
 			rustFlagsList = append(rustFlagsList, rustFlags)
 		}
-		rustFlagsList = append(rustFlagsList, "-C target-feature=+crt-static")
+		rustFlagsList = append(rustFlagsList, flags...)
 		newRustFlags := strings.Join(rustFlagsList, " ")
 
 		err := os.Setenv("RUSTFLAGS", newRustFlags)
@@ -503,54 +1297,318 @@ func AddDefaultTargetForTinyOrStatic(args []string, stack string, staticType str
 	return append(args, target), nil
 }
 
+// ResolveColor resolves the color mode for cargo. With ColorAuto, output is colored if out is a terminal, so that
+// interactive builds get colored diagnostics while CI logs stay plain.
+func ResolveColor(color string, out *os.File) (string, error) {
+	switch color {
+	case "", ColorNever:
+		return ColorNever, nil
+	case ColorAlways:
+		return ColorAlways, nil
+	case ColorAuto:
+		if info, err := out.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return ColorAlways, nil
+		}
+		return ColorNever, nil
+	default:
+		return "", fmt.Errorf("color must be %q, %q or %q, found %q", ColorAlways, ColorAuto, ColorNever, color)
+	}
+}
+
+// IsStaticBuild returns true if the binaries will be statically linked, because they are built for a musl target or
+// with `crt-static`, either set by the user or by the default target of the stack policy
+func IsStaticBuild(installArgs string, stack string, staticType string) (bool, error) {
+	if strings.Contains(os.Getenv("RUSTFLAGS"), "target-feature=+crt-static") {
+		return true, nil
+	}
+
+	triple, err := ResolveTargetTriple(installArgs, stack, staticType)
+	if err != nil {
+		return false, fmt.Errorf("unable to resolve target triple\n%w", err)
+	}
+
+	if strings.HasSuffix(triple, "-musl") {
+		return true, nil
+	}
+
+	if triple == "" {
+		return false, nil
+	}
+
+	// the default target of the stack may be built with `crt-static`
+	defaultTriple, flags, err := DefaultTarget(stack, staticType)
+	if err != nil {
+		return false, fmt.Errorf("unable to determine default target\n%w", err)
+	}
+
+	return triple == defaultTriple && strings.Contains(strings.Join(flags, " "), "target-feature=+crt-static"), nil
+}
+
+// ResolveRustcWrapper returns the absolute path of a rustc wrapper, which is either a path or the name of an
+// executable on PATH. Returns an error if the wrapper cannot be found or is not executable.
+func ResolveRustcWrapper(wrapper string) (string, error) {
+	if wrapper == "" {
+		return "", nil
+	}
+
+	path, err := exec.LookPath(wrapper)
+	if err != nil {
+		return "", fmt.Errorf("unable to find rustc wrapper %s\n%w", wrapper, err)
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve rustc wrapper %s\n%w", wrapper, err)
+	}
+
+	return path, nil
+}
+
+// installArgs returns the additional arguments of cargo, including `--locked` for a locked build and the target of the
+// runner unless the arguments already pick one
+func (c CargoRunner) installArgs() string {
+	if c.Target == "" && c.BuildProfile == "" && !c.DebugBuild && !c.LockedBuild {
+		return c.CargoInstallArgs
+	}
+
+	installArgs := c.CargoInstallArgs
+	args, err := FilterInstallArgs(c.CargoInstallArgs)
+	if locked := c.lockedArgs(args); err == nil && len(locked) > 0 {
+		installArgs = fmt.Sprintf("%s %s", installArgs, strings.Join(locked, " "))
+	}
+	if c.Target != "" && (err != nil || explicitTarget(args) == "") {
+		installArgs = fmt.Sprintf("%s --target=%s", installArgs, c.Target)
+	}
+
+	// a profile selected in the install arguments takes precedence
+	if profile, err := ExplicitProfile(c.CargoInstallArgs); err != nil || profile == "" {
+		if c.BuildProfile != "" {
+			installArgs = fmt.Sprintf("%s --profile=%s", installArgs, c.BuildProfile)
+		} else if c.DebugBuild {
+			installArgs = fmt.Sprintf("%s --debug", installArgs)
+		}
+	}
+
+	return strings.TrimSpace(installArgs)
+}
+
+// ResolveTargetTriple returns the target triple that will be passed to cargo, the name of the target of a custom
+// target specification, or an empty string if cargo will build for the host
+func ResolveTargetTriple(installArgs string, stack string, staticType string) (string, error) {
+	args, err := FilterInstallArgs(installArgs)
+	if err != nil {
+		return "", fmt.Errorf("unable to filter: %w", err)
+	}
+
+	// custom targets are referred to by name, their specification is found in RUST_TARGET_PATH
+	if target := explicitTarget(args); isTargetSpec(target) {
+		return TargetSpecName(target), nil
+	} else if target != "" {
+		return target, nil
+	}
+
+	if strings.Contains(os.Getenv("RUSTFLAGS"), "target-feature=+crt-static") {
+		return "", nil
+	}
+
+	triple, _, err := DefaultTarget(stack, staticType)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine default target\n%w", err)
+	}
+
+	return triple, nil
+}
+
 func (c CargoRunner) fetchCargoMetadata(srcDir string) (metadata, error) {
+	return c.cargoMetadata(srcDir, "--no-deps")
+}
+
+// Metadata returns the output of `cargo metadata` for the application, including its dependencies, as printed by
+// cargo
+func (c CargoRunner) Metadata(srcDir string) ([]byte, error) {
+	return c.metadataOutput(c.workingDir(srcDir))
+}
+
+func (c CargoRunner) cargoMetadata(srcDir string, args ...string) (metadata, error) {
+	out, err := c.metadataOutput(srcDir, args...)
+	if err != nil {
+		return metadata{}, err
+	}
+
+	var m metadata
+	if err := json.Unmarshal(out, &m); err != nil {
+		return metadata{}, fmt.Errorf("unable to parse Cargo metadata: %w", err)
+	}
+
+	return m, nil
+}
+
+func (c CargoRunner) metadataOutput(srcDir string, args ...string) ([]byte, error) {
 	stdout := bytes.Buffer{}
 	stderr := bytes.Buffer{}
 
-	if err := c.Executor.Execute(effect.Execution{
-		Command: "cargo",
-		Args:    []string{"metadata", "--format-version=1", "--no-deps"},
+	if err := c.captured().executeRetrying(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
+		Args:    append([]string{"metadata", "--format-version=1"}, args...),
 		Dir:     srcDir,
+		Env:     c.environment(),
 		Stdout:  &stdout,
 		Stderr:  &stderr,
 	}); err != nil {
-		return metadata{}, fmt.Errorf("unable to read metadata: \n%s\n%s\n%w", &stdout, &stderr, err)
+		return nil, fmt.Errorf("unable to read metadata: \n%s\n%s\n%w", &stdout, &stderr, err)
 	}
 
-	var m metadata
-	if err := json.Unmarshal(stdout.Bytes(), &m); err != nil {
-		return metadata{}, fmt.Errorf("unable to parse Cargo metadata: %w", err)
+	return stdout.Bytes(), nil
+}
+
+func (c CargoRunner) toolchainCommand(name string) string {
+	if c.ToolchainPath == "" {
+		return name
 	}
+	return filepath.Join(c.ToolchainPath, name)
+}
 
-	return m, nil
+// output returns the writer for cargo build output and a function to flush it once cargo has finished
+func (c CargoRunner) output() (io.Writer, func() error) {
+	w := bard.NewWriter(c.Logger.Logger.InfoWriter(), bard.WithIndent(3))
+
+	var (
+		out io.Writer
+		s   *SummaryWriter
+	)
+	if c.LogMode == LogModeSummary {
+		s = NewSummaryWriter(w, DefaultSummaryInterval)
+		out = s
+	} else {
+		// output is only counted, not summarized
+		s = NewSummaryWriter(io.Discard, 0)
+		out = io.MultiWriter(w, s)
+	}
+
+	return out, func() error {
+		err := s.Flush()
+		if c.Statistics != nil {
+			c.Statistics.Compiled += s.Compiled()
+			c.Statistics.Downloaded += s.Downloaded()
+		}
+		return err
+	}
+}
+
+// WithEnv returns a copy of the runner that adds environment variables to its cargo invocations, for a single call
+// like `runner.WithEnv(map[string]string{"SQLX_OFFLINE": "true"}).Install(srcDir, layer)`
+func (c CargoRunner) WithEnv(env map[string]string) CargoRunner {
+	merged := make(map[string]string, len(c.Env)+len(env))
+	for k, v := range c.Env {
+		merged[k] = v
+	}
+	for k, v := range env {
+		merged[k] = v
+	}
+
+	c.Env = merged
+	return c
+}
+
+// compileEnvironment returns the environment for cargo invocations that compile code
+func (c CargoRunner) compileEnvironment() []string {
+	return c.compileRunner().environment()
 }
 
-func (c CargoRunner) makeFilterMap() map[string]bool {
-	filter := c.CargoWorkspaceMembers != ""
-	filterMap := make(map[string]bool)
-	if filter {
-		if !strings.Contains(c.CargoWorkspaceMembers, ",") {
-			filterMap[c.CargoWorkspaceMembers] = true
+// compileRunner returns a copy of the runner that adds the environment variables for cargo invocations that compile
+// code
+func (c CargoRunner) compileRunner() CargoRunner {
+	if c.RustcWrapper != "" {
+		c = c.WithEnv(map[string]string{"RUSTC_WRAPPER": c.RustcWrapper})
+	} else if c.SccacheDir != "" {
+		// sccache may not be installed yet, when the tools that install it are compiled
+		if path := c.sccache(); path != "" {
+			c = c.WithEnv(map[string]string{"RUSTC_WRAPPER": path, "SCCACHE_DIR": c.SccacheDir})
 		}
-		for _, f := range strings.Split(c.CargoWorkspaceMembers, ",") {
-			filterMap[strings.TrimSpace(f)] = true
+	}
+
+	if c.Linker != "" {
+		c = c.WithEnv(c.linkerEnvironment())
+	}
+
+	if spec, err := TargetSpec(c.installArgs()); err == nil && spec != "" {
+		c = c.WithEnv(map[string]string{"RUST_TARGET_PATH": sherpa.AppendToEnvVar("RUST_TARGET_PATH", string(os.PathListSeparator), c.TargetSpecDir())})
+	}
+
+	return c
+}
+
+// environment returns the environment for cargo invocations, nil inherits the environment of the buildpack
+func (c CargoRunner) environment() []string {
+	return c.inheritEnvironment(c.addedEnvironment())
+}
+
+// addedEnvironment returns the environment variables that the runner adds to cargo invocations, sorted by name.
+// Registry tokens are not included, they are added by inheritEnvironment.
+func (c CargoRunner) addedEnvironment() []string {
+	env := map[string]string{}
+	for _, r := range c.RegistryCredentials {
+		if r.Index != "" {
+			env[RegistryEnvironmentName(r.Name, "index")] = r.Index
 		}
 	}
+	for k, v := range c.Env {
+		env[k] = v
+	}
+
+	names := make([]string, 0, len(env))
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var added []string
+	for _, k := range names {
+		added = append(added, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+
+	if c.Unstable {
+		added = append(added, "RUSTC_BOOTSTRAP=1")
+	}
+
+	return added
+}
+
+// inheritEnvironment appends variables and the registry tokens to the environment of the buildpack, nil inherits it
+// unchanged
+func (c CargoRunner) inheritEnvironment(added []string) []string {
+	added = append(append([]string{}, added...), c.tokenEnvironment()...)
+	if len(added) == 0 {
+		return nil
+	}
+
+	return append(os.Environ(), added...)
+}
 
-	return filterMap
+// SupportedArchitectures maps the values accepted in BP_ARCH to the architecture used in target triples
+var SupportedArchitectures = map[string]string{
+	"aarch64": "aarch64",
+	"amd64":   "x86_64",
+	"arm64":   "aarch64",
+	"x86_64":  "x86_64",
 }
 
-func archFromSystem() string {
+func archFromSystem() (string, error) {
 	archFromEnv, ok := os.LookupEnv("BP_ARCH")
 	if !ok {
 		archFromEnv = runtime.GOARCH
 	}
 
-	if archFromEnv == "amd64" {
-		return "x86_64"
-	} else if archFromEnv == "arm64" {
-		return "aarch64"
-	} else {
-		return archFromEnv
+	arch, ok := SupportedArchitectures[archFromEnv]
+	if !ok {
+		var valid []string
+		for a := range SupportedArchitectures {
+			valid = append(valid, a)
+		}
+		sort.Strings(valid)
+
+		return "", fmt.Errorf("unsupported architecture %q in BP_ARCH, valid values are %s", archFromEnv, strings.Join(valid, ", "))
 	}
+
+	return arch, nil
 }
@@ -64,12 +64,13 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 			Stdout:  &bytes.Buffer{},
 			Args: []string{
 				"version",
+				"--verbose",
 			},
 		}
 		executor.On("Execute", mock.MatchedBy(func(ex effect.Execution) bool {
 			return reflect.DeepEqual(ex.Args, execution.Args) && ex.Command == execution.Command
 		})).Return(func(ex effect.Execution) error {
-			_, err := ex.Stdout.Write([]byte("cargo 1.2.3 (4369396ce 2021-04-27)\n"))
+			_, err := ex.Stdout.Write([]byte("cargo 1.2.3 (4369396ce 2021-04-27)\nrelease: 1.2.3\ncommit-hash: 4369396ce7d270972955d876eaa4954bea56bcd9\ncommit-date: 2021-04-27\nhost: x86_64-unknown-linux-gnu\n"))
 			Expect(err).ToNot(HaveOccurred())
 			return nil
 		})
@@ -82,7 +83,12 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 		version, err := runner.CargoVersion()
 
 		Expect(err).ToNot(HaveOccurred())
-		Expect(version).To(Equal("1.2.3"))
+		Expect(version.String()).To(Equal("1.2.3"))
+		Expect(version.Raw()).To(Equal("cargo 1.2.3 (4369396ce 2021-04-27)"))
+
+		info, err := runner.CargoVersionInfo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Host).To(Equal("x86_64-unknown-linux-gnu"))
 	})
 
 	it("fetches Rust version", func() {
@@ -90,13 +96,13 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 			Command: "rustc",
 			Stdout:  &bytes.Buffer{},
 			Args: []string{
-				"--version",
+				"-vV",
 			},
 		}
 		executor.On("Execute", mock.MatchedBy(func(ex effect.Execution) bool {
 			return reflect.DeepEqual(ex.Args, execution.Args) && ex.Command == execution.Command
 		})).Return(func(ex effect.Execution) error {
-			_, err := ex.Stdout.Write([]byte("rustc 1.2.3 (53cb7b09b 2021-06-17)\n"))
+			_, err := ex.Stdout.Write([]byte("rustc 1.2.3 (53cb7b09b 2021-06-17)\nbinary: rustc\ncommit-hash: 53cb7b09b00cbea8754ffb78e7e3cb521cb8af4b\ncommit-date: 2021-06-17\nhost: aarch64-unknown-linux-gnu\nrelease: 1.2.3\nLLVM version: 12.0.1\n"))
 			Expect(err).ToNot(HaveOccurred())
 			return nil
 		})
@@ -109,7 +115,86 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 		version, err := runner.RustVersion()
 
 		Expect(err).ToNot(HaveOccurred())
-		Expect(version).To(Equal("1.2.3"))
+		Expect(version.String()).To(Equal("1.2.3"))
+		Expect(version.Raw()).To(Equal("rustc 1.2.3 (53cb7b09b 2021-06-17)"))
+
+		info, err := runner.RustVersionInfo()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Channel).To(Equal("stable"))
+		Expect(info.CommitHash).To(Equal("53cb7b09b00cbea8754ffb78e7e3cb521cb8af4b"))
+		Expect(info.Host).To(Equal("aarch64-unknown-linux-gnu"))
+	})
+
+	it("installs from the working directory", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+			runner.WithWorkingDir("service"))
+
+		Expect(r.InstallMember("member", "/workspace", libcnb.Layer{Path: "/layers/cargo"})).To(Succeed())
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Dir).To(Equal("/workspace/service"))
+		Expect(e.Args).To(ContainElement("--path=/workspace/member"))
+	})
+
+	it("installs workspace members by package name", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		appDir := t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(appDir, "member"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "member", "Cargo.toml"), []byte("[package]\nname = \"my-member\"\n"), 0644)).To(Succeed())
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+			runner.WithMemberSelection(runner.MemberSelectionPackage))
+
+		Expect(r.InstallMember(filepath.Join(appDir, "member"), appDir, libcnb.Layer{Path: "/layers/cargo"})).To(Succeed())
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Dir).To(Equal(appDir))
+		Expect(e.Args).To(ContainElement("--path=."))
+		Expect(e.Args[len(e.Args)-2:]).To(Equal([]string{"-p", "my-member"}))
+	})
+
+	it("prints the metadata with dependencies in the working directory", func() {
+		executor.On("Execute", mock.Anything).Return(func(ex effect.Execution) error {
+			_, err := ex.Stdout.Write([]byte(`{"packages": []}`))
+			return err
+		})
+
+		r := runner.NewCargoRunner(
+			runner.WithExecutor(executor),
+			runner.WithWorkingDir("service"))
+
+		Expect(r.Metadata("/workspace")).To(Equal([]byte(`{"packages": []}`)))
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Args).To(Equal([]string{"metadata", "--format-version=1"}))
+		Expect(e.Dir).To(Equal("/workspace/service"))
+	})
+
+	it("runs the toolchain from the configured path", func() {
+		executor.On("Execute", mock.Anything).Return(func(ex effect.Execution) error {
+			_, err := ex.Stdout.Write([]byte("rustc 1.2.3 (53cb7b09b 2021-06-17)\n"))
+			Expect(err).ToNot(HaveOccurred())
+			return nil
+		})
+
+		runner := runner.NewCargoRunner(
+			runner.WithExecutor(executor),
+			runner.WithToolchainPath("/rust/bin"))
+
+		_, err := runner.RustVersion()
+		Expect(err).ToNot(HaveOccurred())
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Command).To(Equal("/rust/bin/rustc"))
 	})
 
 	context("builds install arguments", func() {
@@ -145,11 +230,128 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				}))
 			})
 		})
+
+		it("builds with color", func() {
+			runner := runner.NewCargoRunner(runner.WithColor(runner.ColorAlways))
+
+			args, err := runner.BuildArgs(destLayer, "foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(args).To(ContainElement("--color=always"))
+			Expect(args).NotTo(ContainElement("--color=never"))
+		})
+
+		it("builds with timings", func() {
+			runner := runner.NewCargoRunner(runner.WithTimings(true))
+
+			args, err := runner.BuildArgs(destLayer, "foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(args).To(Equal([]string{
+				"install",
+				"--color=never",
+				"--root=/some/location/2",
+				"--timings",
+				"--path=foo",
+			}))
+		})
+
+		it("builds with unstable flags", func() {
+			runner := runner.NewCargoRunner(runner.WithUnstable(true, []string{"-Z", "build-std=std", "-Zunstable-options"}))
+
+			args, err := runner.BuildArgs(destLayer, "foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(args).To(Equal([]string{
+				"install",
+				"-Z",
+				"build-std=std",
+				"-Zunstable-options",
+				"--color=never",
+				"--root=/some/location/2",
+				"--path=foo",
+			}))
+		})
+	})
+
+	context("profile overrides", func() {
+		it("resolves the profile from the install args", func() {
+			Expect(runner.NewCargoRunner().Profile()).To(Equal("release"))
+			Expect(runner.NewCargoRunner(runner.WithCargoInstallArgs("--debug")).Profile()).To(Equal("dev"))
+			Expect(runner.NewCargoRunner(runner.WithCargoInstallArgs("--profile staging")).Profile()).To(Equal("staging"))
+			Expect(runner.NewCargoRunner(runner.WithCargoInstallArgs("--profile=release-lto")).Profile()).To(Equal("release-lto"))
+		})
+
+		it("maps overrides to profile environment variables", func() {
+			r := runner.NewCargoRunner(
+				runner.WithCargoInstallArgs("--profile=release-lto"),
+				runner.WithProfileOverrides(map[string]string{"debug-assertions": "true"}))
+
+			Expect(r.ProfileEnvironment()).To(Equal(map[string]string{"CARGO_PROFILE_RELEASE_LTO_DEBUG_ASSERTIONS": "true"}))
+			Expect(runner.NewCargoRunner().ProfileEnvironment()).To(BeNil())
+		})
+
+		it("applies overrides over profile variables", func() {
+			r := runner.NewCargoRunner(
+				runner.WithProfileOverrides(map[string]string{"debug-assertions": "true"}),
+				runner.WithProfileVariables(map[string]string{
+					"CARGO_PROFILE_RELEASE_DEBUG_ASSERTIONS": "false",
+					"CARGO_PROFILE_RELEASE_LTO":              "thin",
+				}))
+
+			Expect(r.ProfileEnvironment()).To(Equal(map[string]string{
+				"CARGO_PROFILE_RELEASE_DEBUG_ASSERTIONS": "true",
+				"CARGO_PROFILE_RELEASE_LTO":              "thin",
+			}))
+		})
+
+		it("passes overrides to cargo install", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			r := runner.NewCargoRunner(
+				runner.WithCargoHome(cargoHome),
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+				runner.WithProfileOverrides(map[string]string{"debug-assertions": "true"}))
+
+			Expect(r.Install("/workspace", libcnb.Layer{Path: "/layers/cargo"})).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Env).To(ContainElement("CARGO_PROFILE_RELEASE_DEBUG_ASSERTIONS=true"))
+		})
+	})
+
+	context("rustc wrapper", func() {
+		it("resolves the wrapper", func() {
+			wrapper := filepath.Join(t.TempDir(), "wrapper")
+			Expect(os.WriteFile(wrapper, []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+
+			Expect(runner.ResolveRustcWrapper("")).To(BeEmpty())
+			Expect(runner.ResolveRustcWrapper(wrapper)).To(Equal(wrapper))
+
+			_, err := runner.ResolveRustcWrapper(filepath.Join(t.TempDir(), "missing"))
+			Expect(err).To(MatchError(ContainSubstring("unable to find rustc wrapper")))
+		})
+
+		it("passes the wrapper to compile executions", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			r := runner.NewCargoRunner(
+				runner.WithCargoHome(cargoHome),
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+				runner.WithRustcWrapper("/bin/wrapper"))
+
+			Expect(r.Install("/workspace", libcnb.Layer{Path: "/layers/cargo"})).To(Succeed())
+			Expect(r.InstallTool(runner.ToolRequest{Name: "foo"}, nil)).To(Succeed())
+			Expect(r.UninstallTool("foo")).To(Succeed())
+
+			Expect(executor.Calls[0].Arguments[0].(effect.Execution).Env).To(ContainElement("RUSTC_WRAPPER=/bin/wrapper"))
+			Expect(executor.Calls[1].Arguments[0].(effect.Execution).Env).To(ContainElement("RUSTC_WRAPPER=/bin/wrapper"))
+			Expect(executor.Calls[2].Arguments[0].(effect.Execution).Env).To(BeNil())
+		})
 	})
 
 	context("cargo install tools", func() {
 		it("installs with no args", func() {
-			runner := runner.CargoRunner{
+			r := runner.CargoRunner{
 				CargoHome: cargoHome,
 				Executor:  executor,
 			}
@@ -158,7 +360,7 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				return reflect.DeepEqual(ex.Args, []string{"install", "foo"})
 			})).Return(nil)
 
-			err := runner.InstallTool("foo", []string{})
+			err := r.InstallTool(runner.ToolRequest{Name: "foo"}, []string{})
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(executor.Calls).To(HaveLen(1))
@@ -169,7 +371,7 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("installs with additional args", func() {
-			runner := runner.CargoRunner{
+			r := runner.CargoRunner{
 				CargoHome: cargoHome,
 				Executor:  executor,
 			}
@@ -178,7 +380,7 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				return reflect.DeepEqual(ex.Args, []string{"install", "foo", "--bar", "--baz"})
 			})).Return(nil)
 
-			err := runner.InstallTool("foo", []string{"--bar", "--baz"})
+			err := r.InstallTool(runner.ToolRequest{Name: "foo"}, []string{"--bar", "--baz"})
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(executor.Calls).To(HaveLen(1))
@@ -186,6 +388,124 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 			e := executor.Calls[0].Arguments[0].(effect.Execution)
 			Expect(e.Command).To(Equal("cargo"))
 			Expect(e.Args).To(Equal([]string{"install", "foo", "--bar", "--baz"}))
+			Expect(e.Env).To(BeNil())
+		})
+
+		it("installs with the features and arguments of the tool", func() {
+			r := runner.CargoRunner{
+				CargoHome: cargoHome,
+				Executor:  executor,
+			}
+
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			tool := runner.ToolRequest{Name: "foo", Version: "1.2.3", Args: []string{"--bins"}, Features: []string{"bar"}, Locked: true}
+			Expect(r.InstallTool(tool, []string{"--baz"})).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(Equal([]string{"install", "foo@1.2.3", "--baz", "--features", "bar", "--locked", "--bins"}))
+		})
+
+		it("falls back to the next strategy", func() {
+			r := runner.NewCargoRunner(
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+				runner.WithToolStrategies(runner.ToolStrategies{Default: runner.ToolStrategyAuto}))
+
+			executor.On("Execute", mock.MatchedBy(func(ex effect.Execution) bool {
+				return ex.Args[0] == "binstall"
+			})).Return(fmt.Errorf("test-error"))
+			executor.On("Execute", mock.MatchedBy(func(ex effect.Execution) bool {
+				return ex.Args[0] == "install"
+			})).Return(nil)
+
+			Expect(r.InstallTool(runner.ToolRequest{Name: "foo"}, []string{"--bar"})).To(Succeed())
+
+			Expect(executor.Calls).To(HaveLen(3))
+			Expect(executor.Calls[0].Arguments[0].(effect.Execution).Args).To(Equal([]string{"binstall", "--no-confirm", "--strategies", "crate-meta-data", "foo"}))
+			Expect(executor.Calls[1].Arguments[0].(effect.Execution).Args).To(Equal([]string{"binstall", "--no-confirm", "--disable-strategies", "compile", "foo"}))
+			Expect(executor.Calls[2].Arguments[0].(effect.Execution).Args).To(Equal([]string{"install", "foo", "--bar"}))
+		})
+
+		it("fails when every strategy fails", func() {
+			r := runner.NewCargoRunner(
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+				runner.WithToolStrategies(runner.ToolStrategies{Default: runner.ToolStrategyPrebuilt}))
+
+			executor.On("Execute", mock.Anything).Return(fmt.Errorf("test-error"))
+
+			Expect(r.InstallTool(runner.ToolRequest{Name: "foo"}, nil)).To(MatchError(ContainSubstring("test-error")))
+			Expect(executor.Calls).To(HaveLen(1))
+		})
+
+		it("uninstalls a tool", func() {
+			r := runner.NewCargoRunner(
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			Expect(r.UninstallTool("foo@1.2.3")).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(Equal([]string{"uninstall", "foo"}))
+		})
+
+		it("counts the crates processed", func() {
+			statistics := &runner.Statistics{}
+			r := runner.CargoRunner{
+				CargoHome:  cargoHome,
+				Executor:   executor,
+				Logger:     bard.NewLogger(&bytes.Buffer{}),
+				Statistics: statistics,
+			}
+
+			executor.On("Execute", mock.Anything).Return(func(ex effect.Execution) error {
+				_, err := ex.Stderr.Write([]byte("  Downloaded foo v1.0.0\n   Compiling foo v1.0.0\n   Compiling bar v1.0.0\n"))
+				return err
+			})
+
+			Expect(r.InstallTool(runner.ToolRequest{Name: "foo"}, []string{})).To(Succeed())
+			Expect(statistics).To(Equal(&runner.Statistics{Compiled: 2, Downloaded: 1}))
+		})
+
+		it("installs with additional environment variables", func() {
+			r := runner.NewCargoRunner(
+				runner.WithCargoHome(cargoHome),
+				runner.WithEnv(map[string]string{"RUSTC_LOG": "info"}),
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			Expect(r.WithEnv(map[string]string{"SQLX_OFFLINE": "true"}).InstallTool(runner.ToolRequest{Name: "foo"}, []string{})).To(Succeed())
+			Expect(r.InstallTool(runner.ToolRequest{Name: "bar"}, []string{})).To(Succeed())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Env).To(ContainElements("RUSTC_LOG=info", "SQLX_OFFLINE=true"))
+			Expect(e.Env).To(ContainElement(HavePrefix("PATH=")))
+
+			e = executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(e.Env).To(ContainElement("RUSTC_LOG=info"))
+			Expect(e.Env).NotTo(ContainElement("SQLX_OFFLINE=true"))
+			Expect(os.Getenv("SQLX_OFFLINE")).To(BeEmpty())
+		})
+
+		it("installs with unstable features enabled", func() {
+			r := runner.CargoRunner{
+				CargoHome: cargoHome,
+				Executor:  executor,
+				Unstable:  true,
+			}
+
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			err := r.InstallTool(runner.ToolRequest{Name: "foo"}, []string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Env).To(ContainElement("RUSTC_BOOTSTRAP=1"))
 		})
 	})
 
@@ -353,6 +673,65 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				Expect(os.Getenv("RUSTFLAGS")).To(Equal(""))
 			})
 		})
+
+		context("unsupported architecture", func() {
+			it.Before(func() {
+				t.Setenv("BP_ARCH", "riscv64")
+				t.Setenv("RUSTFLAGS", "")
+			})
+
+			it("fails with the list of valid values", func() {
+				_, err := runner.AddDefaultTargetForTinyOrStatic([]string{"install"}, libpak.BionicTinyStackID, "")
+				Expect(err).To(MatchError(ContainSubstring(`unsupported architecture "riscv64" in BP_ARCH, valid values are aarch64, amd64, arm64, x86_64`)))
+			})
+
+			it("is not needed on full stacks", func() {
+				args, err := runner.AddDefaultTargetForTinyOrStatic([]string{"install"}, libpak.JammyStackID, "")
+				Expect(err).To(Succeed())
+				Expect(args).To(Equal([]string{"install"}))
+			})
+		})
+	})
+
+	context("detects static builds", func() {
+		it.Before(func() {
+			t.Setenv("RUSTFLAGS", "")
+			t.Setenv("BP_ARCH", "amd64")
+		})
+
+		it("is dynamic on full stacks by default", func() {
+			Expect(runner.IsStaticBuild("", libpak.JammyStackID, "")).To(BeFalse())
+			Expect(runner.IsStaticBuild("--target=x86_64-unknown-linux-gnu", libpak.JammyStackID, "")).To(BeFalse())
+		})
+
+		it("is static for musl targets and crt-static", func() {
+			Expect(runner.IsStaticBuild("--target x86_64-unknown-linux-musl", libpak.JammyStackID, "")).To(BeTrue())
+			Expect(runner.IsStaticBuild("", libpak.JammyTinyStackID, runner.StaticTypeGNULIBC)).To(BeTrue())
+
+			t.Setenv("RUSTFLAGS", "-C target-feature=+crt-static")
+			Expect(runner.IsStaticBuild("", libpak.JammyStackID, "")).To(BeTrue())
+		})
+	})
+
+	context("resolves the target triple", func() {
+		it.Before(func() {
+			t.Setenv("BP_ARCH", "amd64")
+			t.Setenv("RUSTFLAGS", "")
+		})
+
+		it("uses the target set by the user", func() {
+			Expect(runner.ResolveTargetTriple("--target=foo", libpak.BionicTinyStackID, runner.StaticTypeMUSLC)).To(Equal("foo"))
+			Expect(runner.ResolveTargetTriple("--locked --target bar", "foo", runner.StaticTypeMUSLC)).To(Equal("bar"))
+		})
+
+		it("is empty when building for the host", func() {
+			Expect(runner.ResolveTargetTriple("--locked", "foo", runner.StaticTypeMUSLC)).To(BeEmpty())
+		})
+
+		it("uses the default target on tiny stacks", func() {
+			Expect(runner.ResolveTargetTriple("", libpak.BionicTinyStackID, runner.StaticTypeMUSLC)).To(Equal("x86_64-unknown-linux-musl"))
+			Expect(runner.ResolveTargetTriple("", libpak.BionicTinyStackID, runner.StaticTypeGNULIBC)).To(Equal("x86_64-unknown-linux-gnu"))
+		})
 	})
 
 	context("when there is a valid Rust project", func() {
@@ -598,7 +977,8 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				runner.WithExecutor(executor),
 				runner.WithLogger(bard.Logger{}))
 
-			Expect(runner.CleanCargoHomeCache()).To(BeNil())
+			_, err := runner.CleanCargoHomeCache()
+			Expect(err).To(BeNil())
 		})
 
 		it("is cleaned up", func() {
@@ -618,7 +998,8 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				runner.WithExecutor(executor),
 				runner.WithLogger(bard.Logger{}))
 
-			Expect(runner.CleanCargoHomeCache()).ToNot(HaveOccurred())
+			_, err := runner.CleanCargoHomeCache()
+			Expect(err).ToNot(HaveOccurred())
 			Expect(filepath.Join(cargoHome, "bin")).To(BeADirectory())
 			Expect(filepath.Join(cargoHome, "registry", "index")).To(BeADirectory())
 			Expect(filepath.Join(cargoHome, "registry", "cache")).To(BeADirectory())
@@ -640,10 +1021,80 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 				runner.WithExecutor(executor),
 				runner.WithLogger(bard.Logger{}))
 
-			Expect(runner.CleanCargoHomeCache()).ToNot(HaveOccurred())
+			_, err := runner.CleanCargoHomeCache()
+			Expect(err).ToNot(HaveOccurred())
 			Expect(filepath.Join(cargoHome, "bin")).To(BeADirectory())
 			Expect(filepath.Join(cargoHome, "baz")).ToNot(BeADirectory())
 		})
+
+		it("returns the bytes freed", func() {
+			for i := 0; i < 150; i++ {
+				crate := filepath.Join(cargoHome, "registry", "src", "index.crates.io-6f17d22bba15001f", fmt.Sprintf("crate-%d", i), "src")
+				Expect(os.MkdirAll(crate, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(crate, "lib.rs"), make([]byte, 100), 0644)).To(Succeed())
+			}
+			Expect(os.MkdirAll(filepath.Join(cargoHome, "registry", "cache"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cargoHome, "registry", "cache", "serde.crate"), make([]byte, 100), 0644)).To(Succeed())
+
+			buf := &bytes.Buffer{}
+			r := runner.NewCargoRunner(
+				runner.WithCargoHome(cargoHome),
+				runner.WithLogger(bard.NewLogger(buf)))
+
+			stats, err := r.CleanCargoHomeCache()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal(runner.CleanStatistics{Bytes: 15000, Entries: 150}))
+
+			Expect(filepath.Join(cargoHome, "registry", "src")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(cargoHome, "registry", "cache", "serde.crate")).To(BeARegularFile())
+			Expect(buf.String()).To(ContainSubstring("Cleaning 150 entries from CARGO_HOME"))
+			Expect(buf.String()).To(ContainSubstring("Removed 150 of 150 entries"))
+		})
+	})
+
+	context("build dependencies", func() {
+		it("builds the direct dependencies of the workspace members", func() {
+			metadata := `{
+  "packages": [
+    {"id": "app 0.1.0 (path+file:///workspace)", "name": "app", "version": "0.1.0"},
+    {"id": "serde 1.0.1 (registry+https://github.com/rust-lang/crates.io-index)", "name": "serde", "version": "1.0.1"},
+    {"id": "cc 1.0.2 (registry+https://github.com/rust-lang/crates.io-index)", "name": "cc", "version": "1.0.2"},
+    {"id": "mockall 0.12.0 (registry+https://github.com/rust-lang/crates.io-index)", "name": "mockall", "version": "0.12.0"},
+    {"id": "winapi 0.3.9 (registry+https://github.com/rust-lang/crates.io-index)", "name": "winapi", "version": "0.3.9"}
+  ],
+  "workspace_members": ["app 0.1.0 (path+file:///workspace)"],
+  "resolve": {"nodes": [
+    {"id": "app 0.1.0 (path+file:///workspace)", "deps": [
+      {"pkg": "serde 1.0.1 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": null, "target": null}]},
+      {"pkg": "cc 1.0.2 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": "build", "target": null}]},
+      {"pkg": "mockall 0.12.0 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": "dev", "target": null}]},
+      {"pkg": "winapi 0.3.9 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": null, "target": "cfg(windows)"}]}
+    ]}
+  ]}
+}`
+
+			executor.On("Execute", mock.MatchedBy(func(ex effect.Execution) bool {
+				return ex.Args[0] == "metadata"
+			})).Return(func(ex effect.Execution) error {
+				Expect(ex.Args).To(Equal([]string{"metadata", "--format-version=1"}))
+				_, err := ex.Stdout.Write([]byte(metadata))
+				return err
+			})
+			executor.On("Execute", mock.MatchedBy(func(ex effect.Execution) bool {
+				return ex.Args[0] == "build"
+			})).Return(nil)
+
+			r := runner.NewCargoRunner(
+				runner.WithCargoHome(cargoHome),
+				runner.WithExecutor(executor),
+				runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+			Expect(r.BuildDependencies("/workspace")).To(Succeed())
+
+			e := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(e.Dir).To(Equal("/workspace"))
+			Expect(e.Args).To(Equal([]string{"build", "--color=never", "--profile=release", "-p", "cc@1.0.2", "-p", "serde@1.0.1"}))
+		})
 	})
 
 	context("package targets", func() {
@@ -848,6 +1299,26 @@ func testRunners(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 	})
+
+	context("resolves the color mode", func() {
+		it("defaults to never", func() {
+			Expect(runner.ResolveColor("", os.Stdout)).To(Equal(runner.ColorNever))
+			Expect(runner.ResolveColor("always", os.Stdout)).To(Equal(runner.ColorAlways))
+		})
+
+		it("colors automatically only in a terminal", func() {
+			f, err := os.CreateTemp(t.TempDir(), "out")
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			Expect(runner.ResolveColor("auto", f)).To(Equal(runner.ColorNever))
+		})
+
+		it("rejects unknown modes", func() {
+			_, err := runner.ResolveColor("sometimes", os.Stdout)
+			Expect(err).To(MatchError(`color must be "always", "auto" or "never", found "sometimes"`))
+		})
+	})
 }
 
 type buildMetadata struct {
@@ -920,4 +1391,5 @@ func BuildMetadataWithPackages(workspacePath string, data buildMetadata) string
 	packageJson = strings.Trim(packageJson, ",") + `]`
 
 	return fmt.Sprintf(tmp, packageJson, workspacePath, filepath.Join(workspacePath, "target"), memberJson)
+
 }
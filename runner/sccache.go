@@ -0,0 +1,114 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// sccacheStatPattern matches the counters of `sccache --show-stats`, like `Cache hits    42`
+var sccacheStatPattern = regexp.MustCompile(`(?m)^Cache (hits|misses)\s+(\d+)\s*$`)
+
+// SccacheStatistics are the compilations that sccache served from its cache or had to compile
+type SccacheStatistics struct {
+	Hits   int
+	Misses int
+}
+
+// HitRate returns the percentage of compilations served from the cache
+func (s SccacheStatistics) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) * 100 / float64(s.Hits+s.Misses)
+}
+
+// ParseSccacheStatistics parses the output of `sccache --show-stats` or `sccache --stop-server`
+func ParseSccacheStatistics(output string) SccacheStatistics {
+	var stats SccacheStatistics
+	for _, match := range sccacheStatPattern.FindAllStringSubmatch(output, -1) {
+		n, _ := strconv.Atoi(match[2])
+		if match[1] == "hits" {
+			stats.Hits = n
+		} else {
+			stats.Misses = n
+		}
+	}
+	return stats
+}
+
+// sccache returns the path of sccache, installed into CARGO_HOME or found on PATH, or an empty string if it is not
+// installed
+func (c CargoRunner) sccache() string {
+	if c.CargoHome != "" {
+		path := filepath.Join(c.CargoHome, "bin", "sccache")
+		if found, err := sherpa.FileExists(path); err == nil && found {
+			return path
+		}
+	}
+
+	if path, err := exec.LookPath("sccache"); err == nil {
+		return path
+	}
+
+	return ""
+}
+
+// EnsureSccache installs sccache into CARGO_HOME with `cargo install`, unless it is already installed, for example as
+// a tool or by another buildpack
+func (c CargoRunner) EnsureSccache() error {
+	if path := c.sccache(); path != "" {
+		c.Logger.Bodyf("Using sccache from %s", path)
+		return nil
+	}
+
+	if err := c.InstallTool(ToolRequest{Locked: true, Name: "sccache"}, nil); err != nil {
+		return fmt.Errorf("unable to install sccache\n%w", err)
+	}
+
+	return nil
+}
+
+// StopSccache stops the sccache server, so that it does not outlive the build, and returns the statistics of the
+// compilations that it served
+func (c CargoRunner) StopSccache() (SccacheStatistics, error) {
+	path := c.sccache()
+	if path == "" {
+		return SccacheStatistics{}, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.captured().execute(effect.Execution{
+		Command: path,
+		Args:    []string{"--stop-server"},
+		Env:     c.compileEnvironment(),
+		Stdout:  buf,
+		Stderr:  buf,
+	}); err != nil {
+		return SccacheStatistics{}, fmt.Errorf("error executing 'sccache --stop-server':\n Combined Output: %s: \n%w", buf.String(), err)
+	}
+
+	return ParseSccacheStatistics(buf.String()), nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testSccache(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir     string
+		cargoHome  string
+		executor   *cargotest.Executor
+		sccacheDir string
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("PATH", t.TempDir())
+
+		appDir = cargotest.Application(t, map[string]string{"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n"})
+		cargoHome = cargotest.CargoHome(t)
+		executor = &cargotest.Executor{}
+		sccacheDir = t.TempDir()
+	})
+
+	install := func() string {
+		path := filepath.Join(cargoHome, "bin", "sccache")
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte{}, 0755)).To(Succeed())
+		return path
+	}
+
+	newRunner := func(options ...runner.Option) runner.CargoRunner {
+		return runner.NewCargoRunner(append([]runner.Option{
+			runner.WithCargoHome(cargoHome),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+			runner.WithSccache(sccacheDir),
+		}, options...)...)
+	}
+
+	it("parses the statistics of sccache", func() {
+		stats := runner.ParseSccacheStatistics("Compile requests                    40\nCache hits                          30\nCache hits (Rust)                   30\nCache misses                        10\n")
+		Expect(stats).To(Equal(runner.SccacheStatistics{Hits: 30, Misses: 10}))
+		Expect(stats.HitRate()).To(Equal(75.0))
+		Expect(runner.SccacheStatistics{}.HitRate()).To(BeZero())
+	})
+
+	it("compiles through sccache once it is installed", func() {
+		plan, err := newRunner().BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Env).NotTo(ContainElement(HavePrefix("RUSTC_WRAPPER=")))
+
+		path := install()
+
+		plan, err = newRunner().BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Env).To(ContainElements("RUSTC_WRAPPER="+path, "SCCACHE_DIR="+sccacheDir))
+	})
+
+	it("prefers the configured rustc wrapper", func() {
+		install()
+
+		plan, err := newRunner(runner.WithRustcWrapper("/usr/bin/wrapper")).BuildPlan(appDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Env).To(ContainElement("RUSTC_WRAPPER=/usr/bin/wrapper"))
+		Expect(plan.Env).NotTo(ContainElement(HavePrefix("SCCACHE_DIR=")))
+	})
+
+	it("installs sccache if it is missing", func() {
+		executor.On("cargo", "install", "sccache")
+
+		Expect(newRunner().EnsureSccache()).To(Succeed())
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "install", "sccache")
+		Expect(execution.Args).To(ContainElement("--locked"))
+	})
+
+	it("uses an installed sccache", func() {
+		install()
+
+		Expect(newRunner().EnsureSccache()).To(Succeed())
+		Expect(executor.Executions).To(BeEmpty())
+	})
+
+	it("stops the server and returns its statistics", func() {
+		path := install()
+		executor.On("sccache", "--stop-server").Stdout = "Stopping sccache server...\nCache hits                          12\nCache misses                         4\n"
+
+		stats, err := newRunner().StopSccache()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(Equal(runner.SccacheStatistics{Hits: 12, Misses: 4}))
+
+		execution := cargotest.AssertExecuted(t, executor, "sccache", "--stop-server")
+		Expect(execution.Command).To(Equal(path))
+		Expect(execution.Env).To(ContainElement("SCCACHE_DIR=" + sccacheDir))
+	})
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// SeedCargoHome overlays the registry and git caches of a pre-populated CARGO_HOME, like one produced by a cache
+// warming job and provided with a binding or a mounted volume, onto cargoHome. Seeding only happens on cold builds,
+// when the registry cache of cargoHome is empty, and files that already exist are kept. Returns whether cargoHome
+// was seeded.
+func SeedCargoHome(cargoHome string, seed string) (bool, error) {
+	entries, err := os.ReadDir(filepath.Join(cargoHome, "registry", "cache"))
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("unable to read registry cache\n%w", err)
+	}
+	if len(entries) > 0 {
+		return false, nil
+	}
+
+	seeded := false
+	for _, dir := range []string{"registry", "git"} {
+		source := filepath.Join(seed, dir)
+
+		err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+			if os.IsNotExist(err) && path == source {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(seed, path)
+			if err != nil {
+				return fmt.Errorf("unable to resolve %s relative to %s\n%w", path, seed, err)
+			}
+			destination := filepath.Join(cargoHome, rel)
+
+			if d.IsDir() {
+				return os.MkdirAll(destination, 0755)
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			if found, err := sherpa.FileExists(destination); err != nil {
+				return fmt.Errorf("unable to check for %s\n%w", destination, err)
+			} else if found {
+				return nil
+			}
+
+			// not hard linked, because cargo rewrites some of its caches in place which would change the seed
+			if err := CloneFile(path, destination); err != nil {
+				return fmt.Errorf("unable to copy %s\n%w", path, err)
+			}
+			seeded = true
+
+			return nil
+		})
+		if err != nil {
+			return false, fmt.Errorf("unable to seed %s from %s\n%w", dir, seed, err)
+		}
+	}
+
+	return seeded, nil
+}
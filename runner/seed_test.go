@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testSeed(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cargoHome string
+		seed      string
+	)
+
+	it.Before(func() {
+		cargoHome = t.TempDir()
+		seed = t.TempDir()
+
+		Expect(os.MkdirAll(filepath.Join(seed, "registry", "cache", "index"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(seed, "registry", "cache", "index", "serde.crate"), []byte("seed"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(seed, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(seed, "bin", "tool"), []byte("seed"), 0755)).To(Succeed())
+	})
+
+	it("seeds a cold CARGO_HOME", func() {
+		Expect(os.MkdirAll(filepath.Join(cargoHome, "registry", "index"), 0755)).To(Succeed())
+
+		Expect(runner.SeedCargoHome(cargoHome, seed)).To(BeTrue())
+
+		Expect(filepath.Join(cargoHome, "registry", "cache", "index", "serde.crate")).To(BeARegularFile())
+		Expect(filepath.Join(cargoHome, "bin", "tool")).NotTo(BeAnExistingFile())
+	})
+
+	it("does not seed a warm CARGO_HOME", func() {
+		Expect(os.MkdirAll(filepath.Join(cargoHome, "registry", "cache", "index"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(cargoHome, "registry", "cache", "index", "tokio.crate"), []byte("warm"), 0644)).To(Succeed())
+
+		Expect(runner.SeedCargoHome(cargoHome, seed)).To(BeFalse())
+
+		Expect(filepath.Join(cargoHome, "registry", "cache", "index", "serde.crate")).NotTo(BeAnExistingFile())
+	})
+
+	it("does nothing if the seed is empty", func() {
+		Expect(runner.SeedCargoHome(cargoHome, t.TempDir())).To(BeFalse())
+	})
+}
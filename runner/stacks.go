@@ -0,0 +1,120 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/paketo-buildpacks/libpak"
+)
+
+// StackPolicy sets the default target of the binaries built on the stacks it matches, for stacks whose run image
+// cannot run binaries linked against the glibc of the build image
+type StackPolicy struct {
+	// Pattern matches stack IDs with path.Match, like `io.example.stacks.*.minimal`
+	Pattern string
+
+	// Target returns the default target triple for an architecture, like `x86_64`, and the static binary type
+	Target func(arch string, staticType string) string
+
+	// RustFlags returns the flags appended to RUSTFLAGS when building for the default target, may be nil
+	RustFlags func(staticType string) []string
+}
+
+// StaticStackPolicy links the binaries statically, against musl or, with the gnulibc static type, against glibc with
+// `crt-static`
+func StaticStackPolicy(pattern string) StackPolicy {
+	return StackPolicy{
+		Pattern: pattern,
+		Target: func(arch string, staticType string) string {
+			if staticType == StaticTypeGNULIBC {
+				return fmt.Sprintf("%s-unknown-linux-gnu", arch)
+			}
+			return fmt.Sprintf("%s-unknown-linux-musl", arch)
+		},
+		RustFlags: func(staticType string) []string {
+			if staticType == StaticTypeGNULIBC {
+				return []string{"-C target-feature=+crt-static"}
+			}
+			return nil
+		},
+	}
+}
+
+var (
+	stackPoliciesMutex sync.RWMutex
+	stackPolicies      = []StackPolicy{
+		StaticStackPolicy(libpak.TinyStackID),
+		StaticStackPolicy(libpak.BionicTinyStackID),
+		StaticStackPolicy(libpak.JammyTinyStackID),
+		StaticStackPolicy(libpak.JammyStaticStackID),
+	}
+)
+
+// RegisterStackPolicy adds a policy for custom stacks. Policies registered later take precedence, so that the
+// policies of the tiny and static stacks may also be replaced.
+func RegisterStackPolicy(policy StackPolicy) error {
+	if _, err := path.Match(policy.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid stack pattern %q\n%w", policy.Pattern, err)
+	}
+	if policy.Target == nil {
+		return fmt.Errorf("stack policy %q has no target", policy.Pattern)
+	}
+
+	stackPoliciesMutex.Lock()
+	defer stackPoliciesMutex.Unlock()
+
+	stackPolicies = append(stackPolicies, policy)
+	return nil
+}
+
+// LookupStackPolicy returns the policy of the stack, the policy registered last if several match
+func LookupStackPolicy(stack string) (StackPolicy, bool) {
+	stackPoliciesMutex.RLock()
+	defer stackPoliciesMutex.RUnlock()
+
+	for i := len(stackPolicies) - 1; i >= 0; i-- {
+		if ok, _ := path.Match(stackPolicies[i].Pattern, stack); ok {
+			return stackPolicies[i], true
+		}
+	}
+
+	return StackPolicy{}, false
+}
+
+// DefaultTarget returns the default target triple of the stack for the architecture of the build and the flags
+// appended to RUSTFLAGS, or an empty triple if the stack has no policy and cargo builds for the host
+func DefaultTarget(stack string, staticType string) (string, []string, error) {
+	policy, ok := LookupStackPolicy(stack)
+	if !ok {
+		return "", nil, nil
+	}
+
+	arch, err := archFromSystem()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var flags []string
+	if policy.RustFlags != nil {
+		flags = policy.RustFlags(staticType)
+	}
+
+	return policy.Target(arch, staticType), flags, nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testStacks(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+	})
+
+	it("has policies for the tiny and static stacks", func() {
+		for _, stack := range []string{libpak.TinyStackID, libpak.BionicTinyStackID, libpak.JammyTinyStackID, libpak.JammyStaticStackID} {
+			_, ok := runner.LookupStackPolicy(stack)
+			Expect(ok).To(BeTrue(), stack)
+		}
+
+		_, ok := runner.LookupStackPolicy(libpak.JammyStackID)
+		Expect(ok).To(BeFalse())
+
+		Expect(runner.DefaultTarget(libpak.JammyStackID, "")).To(BeEmpty())
+
+		triple, flags, err := runner.DefaultTarget(libpak.JammyTinyStackID, runner.StaticTypeGNULIBC)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(triple).To(Equal("x86_64-unknown-linux-gnu"))
+		Expect(flags).To(Equal([]string{"-C target-feature=+crt-static"}))
+	})
+
+	it("applies the policies of registered stacks", func() {
+		Expect(runner.RegisterStackPolicy(runner.StackPolicy{
+			Pattern: "io.example.stacks.*.minimal",
+			Target: func(arch string, _ string) string {
+				return arch + "-unknown-linux-musl"
+			},
+			RustFlags: func(string) []string {
+				return []string{"-C relocation-model=static"}
+			},
+		})).To(Succeed())
+
+		args, err := runner.AddDefaultTargetForTinyOrStatic([]string{"install"}, "io.example.stacks.noble.minimal", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).To(Equal([]string{"install", "--target=x86_64-unknown-linux-musl"}))
+		Expect(os.Getenv("RUSTFLAGS")).To(Equal("-C relocation-model=static"))
+
+		Expect(runner.ResolveTargetTriple("", "io.example.stacks.jammy.minimal", "")).To(Equal("x86_64-unknown-linux-musl"))
+		Expect(runner.IsStaticBuild("", "io.example.stacks.jammy.minimal", "")).To(BeTrue())
+		Expect(runner.Platform{OS: "linux", Arch: "arm64"}.TargetTriple("io.example.stacks.jammy.minimal", "")).To(Equal("aarch64-unknown-linux-musl"))
+	})
+
+	it("prefers the policy registered last", func() {
+		Expect(runner.RegisterStackPolicy(runner.StaticStackPolicy("io.example.stacks.replaced"))).To(Succeed())
+		Expect(runner.IsStaticBuild("", "io.example.stacks.replaced", "")).To(BeTrue())
+
+		Expect(runner.RegisterStackPolicy(runner.StackPolicy{
+			Pattern: "io.example.stacks.replaced",
+			Target: func(arch string, _ string) string {
+				return arch + "-unknown-linux-gnu"
+			},
+		})).To(Succeed())
+
+		Expect(runner.ResolveTargetTriple("", "io.example.stacks.replaced", "")).To(Equal("x86_64-unknown-linux-gnu"))
+		Expect(runner.IsStaticBuild("", "io.example.stacks.replaced", "")).To(BeFalse())
+	})
+
+	it("rejects invalid policies", func() {
+		Expect(runner.RegisterStackPolicy(runner.StackPolicy{Pattern: "io.example.[stacks"})).To(MatchError(ContainSubstring(`invalid stack pattern "io.example.[stacks"`)))
+		Expect(runner.RegisterStackPolicy(runner.StackPolicy{Pattern: "io.example.*"})).To(MatchError(`stack policy "io.example.*" has no target`))
+	})
+}
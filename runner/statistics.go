@@ -0,0 +1,70 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Statistics counts the crates processed by cargo across all invocations of a runner, and summarizes the messages of
+// the compiler and the time spent compiling each unit when they are collected, see WithMessageSummary and
+// WithTimingsJSON
+type Statistics struct {
+	Compiled   int
+	Downloaded int
+	Messages   MessageSummary
+	Timings    []UnitTiming
+}
+
+// FormatBytes formats a number of bytes for humans
+func FormatBytes(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// ParseBytes parses a number of bytes, like `512M` or `2G`, with an optional suffix K, M or G for powers of 1024,
+// which may be followed by `B` or `iB`
+func ParseBytes(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "B"), "I")
+
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if strings.HasSuffix(value, suffix) {
+			value = strings.TrimSuffix(value, suffix)
+			multiplier = m
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a number of bytes like 512M or 2G", s)
+	}
+
+	return size * multiplier, nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+const (
+	LogModeFull    = "full"
+	LogModeSummary = "summary"
+
+	// DefaultSummaryInterval is the number of crates between progress summaries
+	DefaultSummaryInterval = 50
+)
+
+var (
+	progressPattern   = regexp.MustCompile(`^\s*(Compiling|Checking|Downloaded|Downloading)\s`)
+	diagnosticPattern = regexp.MustCompile(`^(warning|error)(\[\w+\])?:`)
+)
+
+// SummaryWriter collapses the per-crate progress lines written by cargo into periodic summaries. Warnings and errors
+// are written verbatim, as are all other lines.
+type SummaryWriter struct {
+	Interval int
+	Writer   io.Writer
+
+	buf        []byte
+	compiled   int
+	downloaded int
+	reported   int
+	diagnostic bool
+}
+
+// NewSummaryWriter creates a SummaryWriter that writes a progress summary every interval crates
+func NewSummaryWriter(w io.Writer, interval int) *SummaryWriter {
+	return &SummaryWriter{Interval: interval, Writer: w}
+}
+
+func (s *SummaryWriter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+
+	for {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := s.buf[:i+1]
+		if err := s.line(line); err != nil {
+			return 0, err
+		}
+		s.buf = s.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any partial line and the final summary
+func (s *SummaryWriter) Flush() error {
+	if len(s.buf) > 0 {
+		if err := s.line(append(s.buf, '\n')); err != nil {
+			return err
+		}
+		s.buf = nil
+	}
+
+	if s.compiled+s.downloaded > s.reported {
+		return s.summary()
+	}
+
+	return nil
+}
+
+// Compiled returns the number of crates compiled so far
+func (s *SummaryWriter) Compiled() int {
+	return s.compiled
+}
+
+// Downloaded returns the number of crates downloaded so far
+func (s *SummaryWriter) Downloaded() int {
+	return s.downloaded
+}
+
+func (s *SummaryWriter) line(line []byte) error {
+	// colored output of a TTY has escape sequences, ends lines with `\r\n` and redraws the progress bar after a
+	// carriage return
+	segments := bytes.Split(bytes.TrimRight(stripANSI(line), "\r\n"), []byte("\r"))
+	trimmed := bytes.TrimSpace(segments[len(segments)-1])
+
+	if diagnosticPattern.Match(trimmed) {
+		s.diagnostic = true
+	}
+
+	if s.diagnostic {
+		if len(trimmed) == 0 {
+			s.diagnostic = false
+		}
+		_, err := s.Writer.Write(line)
+		return err
+	}
+
+	progress := false
+	for _, segment := range segments {
+		m := progressPattern.FindSubmatch(segment)
+		if m == nil {
+			continue
+		}
+		progress = true
+
+		switch string(m[1]) {
+		case "Compiling", "Checking":
+			s.compiled++
+		case "Downloaded":
+			s.downloaded++
+		}
+	}
+
+	if !progress {
+		_, err := s.Writer.Write(line)
+		return err
+	}
+
+	if s.Interval > 0 && s.compiled+s.downloaded-s.reported >= s.Interval {
+		return s.summary()
+	}
+
+	return nil
+}
+
+func (s *SummaryWriter) summary() error {
+	s.reported = s.compiled + s.downloaded
+	_, err := fmt.Fprintf(s.Writer, "Downloaded %d crates, compiled %d crates\n", s.downloaded, s.compiled)
+	return err
+}
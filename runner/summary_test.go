@@ -0,0 +1,104 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testSummary(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buf *bytes.Buffer
+	)
+
+	it.Before(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	it("collapses progress lines into periodic summaries", func() {
+		w := runner.NewSummaryWriter(buf, 2)
+
+		_, err := fmt.Fprint(w, "    Updating crates.io index\n  Downloaded serde v1.0.0\n  Downloaded libc v0.2.0\n")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = fmt.Fprint(w, "   Compiling libc v0.2.0\n   Compiling ser")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = fmt.Fprint(w, "de v1.0.0\n   Compiling app v0.1.0\n    Finished release [optimized] target(s)")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal(
+			"    Updating crates.io index\n" +
+				"Downloaded 2 crates, compiled 0 crates\n" +
+				"Downloaded 2 crates, compiled 2 crates\n" +
+				"    Finished release [optimized] target(s)\n" +
+				"Downloaded 2 crates, compiled 3 crates\n"))
+		Expect(w.Compiled()).To(Equal(3))
+		Expect(w.Downloaded()).To(Equal(2))
+	})
+
+	it("counts the colored progress lines of a TTY", func() {
+		w := runner.NewSummaryWriter(buf, 10)
+
+		_, err := fmt.Fprint(w, "\x1b[1m\x1b[32m  Downloaded\x1b[0m serde v1.0.0\r\n"+
+			"\x1b[1m\x1b[36m    Building\x1b[0m [>      ] 0/2\r\x1b[K\x1b[1m\x1b[32m   Compiling\x1b[0m serde v1.0.0\r\n"+
+			"\x1b[1m\x1b[36m    Building\x1b[0m [===>   ] 1/2: serde\r\x1b[K\x1b[1m\x1b[32m   Compiling\x1b[0m app v0.1.0\r\n"+
+			"\x1b[0m\x1b[1m\x1b[33mwarning\x1b[0m\x1b[1m: unused variable: `x`\x1b[0m\r\n"+
+			"\x1b[1m\x1b[32m   Compiling\x1b[0m in a note\r\n"+
+			"\r\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal(
+			"\x1b[0m\x1b[1m\x1b[33mwarning\x1b[0m\x1b[1m: unused variable: `x`\x1b[0m\r\n" +
+				"\x1b[1m\x1b[32m   Compiling\x1b[0m in a note\r\n" +
+				"\r\n" +
+				"Downloaded 1 crates, compiled 2 crates\n"))
+		Expect(w.Compiled()).To(Equal(2))
+		Expect(w.Downloaded()).To(Equal(1))
+	})
+
+	it("writes warnings and errors verbatim", func() {
+		w := runner.NewSummaryWriter(buf, 10)
+
+		_, err := fmt.Fprint(w, "   Compiling app v0.1.0\n"+
+			"warning: unused variable: `x`\n"+
+			" --> src/main.rs:2:9\n"+
+			"   Compiling in a note\n"+
+			"\n"+
+			"error[E0425]: cannot find value `y` in this scope\n"+
+			"\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal(
+			"warning: unused variable: `x`\n" +
+				" --> src/main.rs:2:9\n" +
+				"   Compiling in a note\n" +
+				"\n" +
+				"error[E0425]: cannot find value `y` in this scope\n" +
+				"\n" +
+				"Downloaded 0 crates, compiled 1 crates\n"))
+	})
+}
@@ -0,0 +1,326 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heroku/color"
+)
+
+// sweepHistoryFile records the times that builds started in the target directory, relative to the target directory
+const sweepHistoryFile = ".sweep-history.json"
+
+// RetentionPolicy selects the artifacts of compiled units that SweepTargetDir keeps in the target directory. A unit
+// is kept if it is used by one of the latest builds or within the maximum age.
+type RetentionPolicy struct {
+	Builds int
+	MaxAge time.Duration
+}
+
+// Enabled returns whether the policy removes anything
+func (p RetentionPolicy) Enabled() bool {
+	return p.Builds > 0 || p.MaxAge > 0
+}
+
+// ParseRetentionPolicy parses a retention policy, which is either a number of builds, like `3`, or a maximum age,
+// like `72h` or `7d`
+func ParseRetentionPolicy(s string) (RetentionPolicy, error) {
+	s = strings.TrimSpace(s)
+
+	if builds, err := strconv.Atoi(s); err == nil {
+		if builds < 1 {
+			return RetentionPolicy{}, fmt.Errorf("invalid retention %q, keep the artifacts of at least 1 build", s)
+		}
+		return RetentionPolicy{Builds: builds}, nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return RetentionPolicy{MaxAge: time.Duration(n) * 24 * time.Hour}, nil
+		}
+	}
+
+	if age, err := time.ParseDuration(s); err == nil && age > 0 {
+		return RetentionPolicy{MaxAge: age}, nil
+	}
+
+	return RetentionPolicy{}, fmt.Errorf("invalid retention %q, expected a number of builds like 3 or an age like 72h or 7d", s)
+}
+
+// sweepHistory is the content of the sweep history file
+type sweepHistory struct {
+	Builds []time.Time `json:"builds"`
+}
+
+// compiledUnit is a unit that cargo compiled into a profile of the target directory, identified by the name of its
+// fingerprint directory, like `serde-1a2b3c4d5e6f7a8b`
+type compiledUnit struct {
+	name    string
+	profile string
+	used    time.Time
+}
+
+// crate returns the name of the crate of the unit, like rustc names its incremental directories
+func (u compiledUnit) crate() string {
+	return strings.ReplaceAll(u.name[:max(strings.LastIndex(u.name, "-"), 0)], "-", "_")
+}
+
+// hash returns the metadata hash of the unit, which is the suffix of the names of its artifacts
+func (u compiledUnit) hash() string {
+	return u.name[strings.LastIndex(u.name, "-")+1:]
+}
+
+// cachedTargetDir returns the target directory that is cached, the one selected with WithTargetDir or the target
+// directory of the application, with links resolved. An empty string is returned if it does not exist.
+func (c CargoRunner) cachedTargetDir(srcDir string) (string, error) {
+	dir := c.targetDir(srcDir)
+	if dir == "" {
+		dir = filepath.Join(srcDir, "target")
+	}
+
+	dir, err := filepath.EvalSymlinks(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("unable to resolve target directory\n%w", err)
+	}
+
+	return dir, nil
+}
+
+// StampTargetDir records the start of a build in the cached target directory, so that SweepTargetDir can tell which
+// units the build used. Cargo reads the fingerprint of every unit of a build to check if it is fresh, so the access
+// times of the fingerprints are reset to before their modification times, which makes file systems mounted with
+// relatime record the next read.
+func (c CargoRunner) StampTargetDir(srcDir string) error {
+	dir, err := c.cachedTargetDir(srcDir)
+	if err != nil || dir == "" {
+		return err
+	}
+
+	fingerprints, err := filepath.Glob(filepath.Join(dir, "*", ".fingerprint", "*", "*"))
+	if err != nil {
+		return fmt.Errorf("unable to find fingerprints in %s\n%w", dir, err)
+	}
+	triples, err := filepath.Glob(filepath.Join(dir, "*", "*", ".fingerprint", "*", "*"))
+	if err != nil {
+		return fmt.Errorf("unable to find fingerprints in %s\n%w", dir, err)
+	}
+
+	for _, fingerprint := range append(fingerprints, triples...) {
+		info, err := os.Stat(fingerprint)
+		if err != nil {
+			return fmt.Errorf("unable to stat %s\n%w", fingerprint, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		if err := os.Chtimes(fingerprint, info.ModTime().Add(-time.Second), info.ModTime()); err != nil {
+			return fmt.Errorf("unable to reset access time of %s\n%w", fingerprint, err)
+		}
+	}
+
+	history, err := readSweepHistory(dir)
+	if err != nil {
+		return err
+	}
+
+	// file systems record times with a coarser clock, the stamp must not be later than the reads of the build
+	history.Builds = append(history.Builds, time.Now().Truncate(time.Second))
+	return writeSweepHistory(dir, history)
+}
+
+// SweepTargetDir removes the artifacts of the units that were not used by the builds that the retention policy keeps,
+// like `cargo sweep` does, so that artifacts of dependencies that were updated or removed do not pile up in the cached
+// target directory. Builds are recorded by StampTargetDir, nothing is removed before a build is recorded.
+func (c CargoRunner) SweepTargetDir(srcDir string, policy RetentionPolicy) (CleanStatistics, error) {
+	dir, err := c.cachedTargetDir(srcDir)
+	if err != nil || dir == "" || !policy.Enabled() {
+		return CleanStatistics{}, err
+	}
+
+	history, err := readSweepHistory(dir)
+	if err != nil || len(history.Builds) == 0 {
+		return CleanStatistics{}, err
+	}
+	latest := history.Builds[len(history.Builds)-1]
+
+	var cutoff time.Time
+	if policy.Builds > 0 {
+		cutoff = history.Builds[max(len(history.Builds)-policy.Builds, 0)]
+	}
+	if policy.MaxAge > 0 {
+		if age := time.Now().Add(-policy.MaxAge); cutoff.IsZero() || age.Before(cutoff) {
+			cutoff = age
+		}
+	}
+
+	units, err := compiledUnits(dir)
+	if err != nil {
+		return CleanStatistics{}, err
+	}
+
+	var (
+		kept  = map[string]bool{}
+		stale []compiledUnit
+		used  bool
+	)
+	for _, unit := range units {
+		if !unit.used.Before(latest) {
+			used = true
+		}
+
+		if unit.used.Before(cutoff) {
+			stale = append(stale, unit)
+		} else {
+			kept[filepath.Join(unit.profile, unit.crate())] = true
+		}
+	}
+
+	// without a unit used by the latest build, the file system does not record access times
+	if len(units) > 0 && !used {
+		c.Logger.Bodyf("%s: the file system does not record which artifacts the build used, the target directory is not swept", color.YellowString("Warning"))
+		return CleanStatistics{}, nil
+	}
+
+	var stats CleanStatistics
+	for _, unit := range stale {
+		paths := []string{
+			filepath.Join(unit.profile, ".fingerprint", unit.name),
+			filepath.Join(unit.profile, "build", unit.name),
+		}
+
+		artifacts, err := filepath.Glob(filepath.Join(unit.profile, "deps", "*-"+unit.hash()+"*"))
+		if err != nil {
+			return stats, fmt.Errorf("unable to find artifacts of %s\n%w", unit.name, err)
+		}
+		for _, artifact := range artifacts {
+			stem, _, _ := strings.Cut(filepath.Base(artifact), ".")
+			if strings.HasSuffix(stem, "-"+unit.hash()) {
+				paths = append(paths, artifact)
+			}
+		}
+
+		// the incremental state of a crate is kept as long as one of its units is
+		if !kept[filepath.Join(unit.profile, unit.crate())] {
+			incremental, err := filepath.Glob(filepath.Join(unit.profile, "incremental", unit.crate()+"-*"))
+			if err != nil {
+				return stats, fmt.Errorf("unable to find incremental state of %s\n%w", unit.name, err)
+			}
+			paths = append(paths, incremental...)
+		}
+
+		for _, path := range paths {
+			if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			size, err := removeEntry(path)
+			if err != nil {
+				return stats, err
+			}
+			stats.Bytes += size
+			stats.Entries++
+		}
+	}
+
+	var builds []time.Time
+	for _, build := range history.Builds {
+		if !build.Before(cutoff) {
+			builds = append(builds, build)
+		}
+	}
+	history.Builds = builds
+
+	return stats, writeSweepHistory(dir, history)
+}
+
+// compiledUnits returns the units of the profiles in a target directory with the latest time one of their
+// fingerprints was read or written
+func compiledUnits(dir string) ([]compiledUnit, error) {
+	var units []compiledUnit
+
+	for _, pattern := range []string{"*", filepath.Join("*", "*")} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern, ".fingerprint", "*"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to find fingerprints in %s\n%w", dir, err)
+		}
+
+		for _, match := range matches {
+			files, err := os.ReadDir(match)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %s\n%w", match, err)
+			}
+
+			unit := compiledUnit{name: filepath.Base(match), profile: filepath.Dir(filepath.Dir(match))}
+			for _, file := range files {
+				info, err := file.Info()
+				if err != nil {
+					return nil, fmt.Errorf("unable to stat %s\n%w", filepath.Join(match, file.Name()), err)
+				}
+
+				for _, t := range []time.Time{accessTime(info), info.ModTime()} {
+					if t.After(unit.used) {
+						unit.used = t
+					}
+				}
+			}
+			units = append(units, unit)
+		}
+	}
+
+	return units, nil
+}
+
+func readSweepHistory(dir string) (sweepHistory, error) {
+	b, err := os.ReadFile(filepath.Join(dir, sweepHistoryFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return sweepHistory{}, nil
+	} else if err != nil {
+		return sweepHistory{}, fmt.Errorf("unable to read sweep history\n%w", err)
+	}
+
+	var history sweepHistory
+	if err := json.Unmarshal(b, &history); err != nil {
+		return sweepHistory{}, fmt.Errorf("unable to parse sweep history\n%w", err)
+	}
+
+	return history, nil
+}
+
+func writeSweepHistory(dir string, history sweepHistory) error {
+	b, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("unable to marshal sweep history\n%w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, sweepHistoryFile), b, 0644); err != nil {
+		return fmt.Errorf("unable to write sweep history\n%w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testSweep(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		buf      *bytes.Buffer
+		cacheDir string
+		r        runner.CargoRunner
+	)
+
+	write := func(path string, modified time.Time) {
+		t.Helper()
+
+		file := filepath.Join(cacheDir, path)
+		Expect(os.MkdirAll(filepath.Dir(file), 0755)).To(Succeed())
+		Expect(os.WriteFile(file, make([]byte, 100), 0644)).To(Succeed())
+		Expect(os.Chtimes(file, modified, modified)).To(Succeed())
+	}
+
+	// unit creates the fingerprint and artifacts of a unit that was built some time ago
+	unit := func(name string, crate string, age time.Duration) {
+		t.Helper()
+
+		modified := time.Now().Add(-age)
+		hash := name[len(name)-4:]
+		write(filepath.Join("release", ".fingerprint", name, "lib-"+crate), modified)
+		write(filepath.Join("release", "deps", "lib"+crate+"-"+hash+".rlib"), modified)
+		write(filepath.Join("release", "deps", crate+"-"+hash+".d"), modified)
+	}
+
+	// use records that the build read the fingerprint of a unit at a time
+	use := func(name string, crate string, at time.Time) {
+		t.Helper()
+
+		file := filepath.Join(cacheDir, "release", ".fingerprint", name, "lib-"+crate)
+		info, err := os.Stat(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chtimes(file, at, info.ModTime())).To(Succeed())
+	}
+
+	it.Before(func() {
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+		})
+
+		cacheDir = t.TempDir()
+		Expect(os.Symlink(cacheDir, filepath.Join(appDir, "target"))).To(Succeed())
+
+		buf = &bytes.Buffer{}
+		r = runner.NewCargoRunner(runner.WithLogger(bard.NewLogger(buf)))
+
+		unit("app-aaaa", "app", 48*time.Hour)
+		unit("serde-bbbb", "serde", 48*time.Hour)
+		unit("serde-cccc", "serde", 48*time.Hour)
+		unit("old-crate-dddd", "old_crate", 48*time.Hour)
+		write(filepath.Join("release", "build", "old-crate-dddd", "out", "generated.rs"), time.Now().Add(-48*time.Hour))
+		write(filepath.Join("release", "incremental", "app-1xyz", "s-1", "query-cache.bin"), time.Now().Add(-48*time.Hour))
+		write(filepath.Join("release", "incremental", "old_crate-2xyz", "s-1", "query-cache.bin"), time.Now().Add(-48*time.Hour))
+		write(filepath.Join("release", "app"), time.Now().Add(-48*time.Hour))
+	})
+
+	it("removes the artifacts of units that the build did not use", func() {
+		Expect(r.StampTargetDir(appDir)).To(Succeed())
+		use("app-aaaa", "app", time.Now())
+		use("serde-cccc", "serde", time.Now())
+
+		stats, err := r.SweepTargetDir(appDir, runner.RetentionPolicy{Builds: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(Equal(runner.CleanStatistics{Bytes: 800, Entries: 8}))
+
+		for _, path := range []string{
+			"release/.fingerprint/serde-bbbb",
+			"release/deps/libserde-bbbb.rlib",
+			"release/deps/serde-bbbb.d",
+			"release/.fingerprint/old-crate-dddd",
+			"release/deps/libold_crate-dddd.rlib",
+			"release/deps/old_crate-dddd.d",
+			"release/build/old-crate-dddd",
+			"release/incremental/old_crate-2xyz",
+		} {
+			Expect(filepath.Join(cacheDir, path)).NotTo(BeAnExistingFile())
+		}
+
+		for _, path := range []string{
+			"release/.fingerprint/app-aaaa",
+			"release/deps/libapp-aaaa.rlib",
+			"release/deps/libserde-cccc.rlib",
+			"release/incremental/app-1xyz",
+			"release/app",
+		} {
+			Expect(filepath.Join(cacheDir, path)).To(BeAnExistingFile())
+		}
+	})
+
+	it("keeps the artifacts of units used within the maximum age", func() {
+		Expect(r.StampTargetDir(appDir)).To(Succeed())
+		use("app-aaaa", "app", time.Now())
+		use("serde-bbbb", "serde", time.Now().Add(-time.Hour))
+
+		stats, err := r.SweepTargetDir(appDir, runner.RetentionPolicy{MaxAge: 2 * time.Hour})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats.Entries).To(Equal(8))
+
+		Expect(filepath.Join(cacheDir, "release", ".fingerprint", "serde-bbbb")).To(BeADirectory())
+		Expect(filepath.Join(cacheDir, "release", ".fingerprint", "serde-cccc")).NotTo(BeAnExistingFile())
+	})
+
+	it("keeps everything if the file system does not record access times", func() {
+		Expect(r.StampTargetDir(appDir)).To(Succeed())
+
+		stats, err := r.SweepTargetDir(appDir, runner.RetentionPolicy{Builds: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(Equal(runner.CleanStatistics{}))
+		Expect(filepath.Join(cacheDir, "release", ".fingerprint", "serde-bbbb")).To(BeADirectory())
+		Expect(buf.String()).To(ContainSubstring("the target directory is not swept"))
+	})
+
+	it("keeps everything before a build is recorded", func() {
+		stats, err := r.SweepTargetDir(appDir, runner.RetentionPolicy{Builds: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(Equal(runner.CleanStatistics{}))
+		Expect(filepath.Join(cacheDir, "release", ".fingerprint", "serde-bbbb")).To(BeADirectory())
+	})
+
+	it("parses retention policies", func() {
+		Expect(runner.ParseRetentionPolicy("3")).To(Equal(runner.RetentionPolicy{Builds: 3}))
+		Expect(runner.ParseRetentionPolicy("72h")).To(Equal(runner.RetentionPolicy{MaxAge: 72 * time.Hour}))
+		Expect(runner.ParseRetentionPolicy("7d")).To(Equal(runner.RetentionPolicy{MaxAge: 7 * 24 * time.Hour}))
+
+		_, err := runner.ParseRetentionPolicy("0")
+		Expect(err).To(MatchError(ContainSubstring("keep the artifacts of at least 1 build")))
+
+		_, err = runner.ParseRetentionPolicy("forever")
+		Expect(err).To(MatchError(ContainSubstring(`invalid retention "forever"`)))
+	})
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// targetArtifactDirs are the directories of a profile in the target directory whose entries are pruned. Cargo
+// rebuilds a unit whose fingerprint or artifacts are missing, so removing an entry only costs its recompilation.
+var targetArtifactDirs = []string{".fingerprint", "build", "deps", "incremental"}
+
+// targetEntry is a prunable entry of the target directory
+type targetEntry struct {
+	modified time.Time
+	path     string
+}
+
+// targetDir returns the target directory that cargo builds in, with a relative path resolved against the source
+// directory, or an empty string if cargo selects the target directory
+func (c CargoRunner) targetDir(srcDir string) string {
+	if c.TargetDir == "" || filepath.IsAbs(c.TargetDir) {
+		return c.TargetDir
+	}
+
+	return filepath.Join(srcDir, c.TargetDir)
+}
+
+// targetDirRunner returns a copy of the runner that builds in the target directory, unless CARGO_TARGET_DIR is set
+// in the environment of cargo invocations already
+func (c CargoRunner) targetDirRunner(srcDir string) CargoRunner {
+	dir := c.targetDir(srcDir)
+	if dir == "" {
+		return c
+	}
+
+	if _, ok := c.Env["CARGO_TARGET_DIR"]; ok {
+		return c
+	}
+
+	return c.WithEnv(map[string]string{"CARGO_TARGET_DIR": dir})
+}
+
+// PruneTargetDir removes the least recently modified artifacts from the target directory, until its size is at most
+// maxSize bytes, so that a cached target directory does not grow without bounds across builds. Only the artifacts of
+// compiled units are removed, cargo compiles them again when they are needed.
+func (c CargoRunner) PruneTargetDir(srcDir string, maxSize int64) (CleanStatistics, error) {
+	dir := c.targetDir(srcDir)
+	if dir == "" {
+		return CleanStatistics{}, nil
+	}
+
+	// the target directory is usually a link to a cache layer
+	dir, err := filepath.EvalSymlinks(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return CleanStatistics{}, nil
+	} else if err != nil {
+		return CleanStatistics{}, fmt.Errorf("unable to resolve target directory\n%w", err)
+	}
+
+	total, _, err := measure(dir)
+	if err != nil {
+		return CleanStatistics{}, err
+	}
+	if total <= maxSize {
+		return CleanStatistics{}, nil
+	}
+
+	entries, err := targetEntries(dir)
+	if err != nil {
+		return CleanStatistics{}, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].modified.Equal(entries[j].modified) {
+			return entries[i].path < entries[j].path
+		}
+		return entries[i].modified.Before(entries[j].modified)
+	})
+
+	var stats CleanStatistics
+	for _, entry := range entries {
+		if total <= maxSize {
+			break
+		}
+
+		size, err := removeEntry(entry.path)
+		if err != nil {
+			return stats, err
+		}
+
+		total -= size
+		stats.Bytes += size
+		stats.Entries++
+	}
+
+	return stats, nil
+}
+
+// targetEntries returns the prunable entries of the profiles in a target directory, which are in the directory itself
+// or in a directory per target triple
+func targetEntries(dir string) ([]targetEntry, error) {
+	var profiles []string
+	for _, pattern := range []string{"*", filepath.Join("*", "*")} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern, ".fingerprint"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to find profiles in %s\n%w", dir, err)
+		}
+		for _, match := range matches {
+			profiles = append(profiles, filepath.Dir(match))
+		}
+	}
+
+	var entries []targetEntry
+	for _, profile := range profiles {
+		for _, name := range targetArtifactDirs {
+			files, err := os.ReadDir(filepath.Join(profile, name))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("unable to read %s\n%w", filepath.Join(profile, name), err)
+			}
+
+			for _, file := range files {
+				path := filepath.Join(profile, name, file.Name())
+				_, modified, err := measure(path)
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, targetEntry{modified: modified, path: path})
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// measure returns the size of the files in a file or directory and the latest time one of them was modified, or the
+// time the directory was modified if it is empty
+func measure(path string) (int64, time.Time, error) {
+	var (
+		modified time.Time
+		size     int64
+	)
+
+	if err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// creating a file changes the time of its directory, so only files tell when an entry was built
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+		if d.Type().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	}); err != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to measure %s\n%w", path, err)
+	}
+
+	if modified.IsZero() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("unable to stat %s\n%w", path, err)
+		}
+		modified = info.ModTime()
+	}
+
+	return size, modified, nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testTargetDir(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir string
+		layer  = libcnb.Layer{Path: "/layers/cargo"}
+	)
+
+	it.Before(func() {
+		t.Setenv("BP_ARCH", "amd64")
+		t.Setenv("RUSTFLAGS", "")
+
+		appDir = cargotest.Application(t, map[string]string{
+			"Cargo.toml": "[package]\nname = \"app\"\nversion = \"0.1.0\"\n",
+		})
+	})
+
+	context("target directory", func() {
+		it("builds in the target directory", func() {
+			r := runner.NewCargoRunner(runner.WithTargetDir("/layers/cargo-cache"))
+
+			plan, err := r.InstallPlan(".", appDir, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Env).To(ContainElement("CARGO_TARGET_DIR=/layers/cargo-cache"))
+
+			plan, err = r.TestPlan(appDir, runner.TestRunnerCargo, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Env).To(ContainElement("CARGO_TARGET_DIR=/layers/cargo-cache"))
+		})
+
+		it("resolves a relative target directory against the source directory", func() {
+			plan, err := runner.NewCargoRunner(runner.WithTargetDir("target")).InstallPlan(".", appDir, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Env).To(ContainElement("CARGO_TARGET_DIR=" + filepath.Join(appDir, "target")))
+		})
+
+		it("prefers CARGO_TARGET_DIR from the environment", func() {
+			r := runner.NewCargoRunner(
+				runner.WithTargetDir("target"),
+				runner.WithEnv(map[string]string{"CARGO_TARGET_DIR": "/tmp/target"}))
+
+			plan, err := r.InstallPlan(".", appDir, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Env).To(ContainElement("CARGO_TARGET_DIR=/tmp/target"))
+			Expect(plan.Env).NotTo(ContainElement("CARGO_TARGET_DIR=" + filepath.Join(appDir, "target")))
+		})
+
+		it("lets cargo select the target directory by default", func() {
+			plan, err := runner.NewCargoRunner().InstallPlan(".", appDir, layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Env).NotTo(ContainElement(HavePrefix("CARGO_TARGET_DIR=")))
+		})
+	})
+
+	context("pruning", func() {
+		var (
+			cacheDir string
+			now      = time.Now()
+		)
+
+		artifact := func(path string, size int, age time.Duration) {
+			t.Helper()
+
+			file := filepath.Join(cacheDir, path)
+			Expect(os.MkdirAll(filepath.Dir(file), 0755)).To(Succeed())
+			Expect(os.WriteFile(file, make([]byte, size), 0644)).To(Succeed())
+			Expect(os.Chtimes(file, now.Add(-age), now.Add(-age))).To(Succeed())
+		}
+
+		it.Before(func() {
+			cacheDir = t.TempDir()
+			Expect(os.Symlink(cacheDir, filepath.Join(appDir, "target"))).To(Succeed())
+
+			artifact("release/.fingerprint/serde-1/lib-serde", 100, 3*time.Hour)
+			artifact("release/deps/libserde-1.rlib", 1000, 3*time.Hour)
+			artifact("release/.fingerprint/app-1/bin-app", 100, time.Hour)
+			artifact("release/deps/app-1", 1000, time.Hour)
+			artifact("release/app", 1000, time.Hour)
+			artifact("x86_64-unknown-linux-musl/release/incremental/app-2/s-1/query-cache.bin", 1000, 2*time.Hour)
+			artifact("x86_64-unknown-linux-musl/release/.fingerprint/app-2/bin-app", 100, 2*time.Hour)
+		})
+
+		it("removes the least recently modified artifacts until the directory fits", func() {
+			stats, err := runner.NewCargoRunner(runner.WithTargetDir("target")).PruneTargetDir(appDir, 3500)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(runner.CleanStatistics{Bytes: 1100, Entries: 2}))
+
+			Expect(filepath.Join(cacheDir, "release", "deps", "libserde-1.rlib")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cacheDir, "release", ".fingerprint", "serde-1")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cacheDir, "x86_64-unknown-linux-musl", "release", "incremental", "app-2")).To(BeADirectory())
+			Expect(filepath.Join(cacheDir, "release", "deps", "app-1")).To(BeARegularFile())
+
+			stats, err = runner.NewCargoRunner(runner.WithTargetDir("target")).PruneTargetDir(appDir, 2000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(runner.CleanStatistics{Bytes: 1200, Entries: 3}))
+
+			Expect(filepath.Join(cacheDir, "x86_64-unknown-linux-musl", "release", "incremental", "app-2")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cacheDir, "release", ".fingerprint", "app-1")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cacheDir, "release", "deps", "app-1")).To(BeARegularFile())
+			Expect(filepath.Join(cacheDir, "release", "app")).To(BeARegularFile())
+		})
+
+		it("keeps a directory that fits", func() {
+			stats, err := runner.NewCargoRunner(runner.WithTargetDir("target")).PruneTargetDir(appDir, 1<<20)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(runner.CleanStatistics{}))
+		})
+
+		it("does nothing without a target directory", func() {
+			stats, err := runner.NewCargoRunner().PruneTargetDir(appDir, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(runner.CleanStatistics{}))
+			Expect(filepath.Join(cacheDir, "release", "deps", "libserde-1.rlib")).To(BeARegularFile())
+		})
+	})
+
+	it("parses sizes", func() {
+		Expect(runner.ParseBytes("1024")).To(Equal(int64(1024)))
+		Expect(runner.ParseBytes("512M")).To(Equal(int64(512 << 20)))
+		Expect(runner.ParseBytes("2G")).To(Equal(int64(2 << 30)))
+		Expect(runner.ParseBytes("2GiB")).To(Equal(int64(2 << 30)))
+		Expect(runner.ParseBytes("64kb")).To(Equal(int64(64 << 10)))
+
+		_, err := runner.ParseBytes("lots")
+		Expect(err).To(MatchError(ContainSubstring(`invalid size "lots"`)))
+	})
+}
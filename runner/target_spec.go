@@ -0,0 +1,123 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// TargetSpec returns the path of the custom target specification set in the install arguments, like
+// `--target my-target.json`, or an empty string if the target is not a specification
+func TargetSpec(installArgs string) (string, error) {
+	args, err := FilterInstallArgs(installArgs)
+	if err != nil {
+		return "", fmt.Errorf("unable to filter: %w", err)
+	}
+
+	if target := explicitTarget(args); isTargetSpec(target) {
+		return target, nil
+	}
+
+	return "", nil
+}
+
+// TargetSpecName returns the name of the target of a specification, which is the file name without `.json`
+func TargetSpecName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".json")
+}
+
+// TargetSpecDir returns the directory that custom target specifications are copied to and that RUST_TARGET_PATH points
+// to, so that the target is referred to by name and its path does not change between builds
+func (c CargoRunner) TargetSpecDir() string {
+	return filepath.Join(c.CargoHome, "target-specs")
+}
+
+// CopyTargetSpec copies the custom target specification set in the install arguments into TargetSpecDir. A relative
+// path is resolved against the directory cargo runs in, like cargo does.
+func (c CargoRunner) CopyTargetSpec(srcDir string) error {
+	spec, err := TargetSpec(c.installArgs())
+	if err != nil {
+		return fmt.Errorf("unable to resolve target specification\n%w", err)
+	}
+	if spec == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(spec) {
+		dir := srcDir
+		if c.WorkingDir != "" {
+			dir = c.WorkingDir
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(srcDir, dir)
+			}
+		}
+		spec = filepath.Join(dir, spec)
+	}
+
+	in, err := os.Open(spec)
+	if err != nil {
+		return fmt.Errorf("unable to open target specification %s\n%w", spec, err)
+	}
+	defer in.Close()
+
+	dest := filepath.Join(c.TargetSpecDir(), filepath.Base(spec))
+	if err := sherpa.CopyFile(in, dest); err != nil {
+		return fmt.Errorf("unable to copy target specification to %s\n%w", dest, err)
+	}
+
+	return nil
+}
+
+// replaceTargetSpec replaces the path of a custom target specification in the arguments by the name of the target,
+// which rustc finds in RUST_TARGET_PATH
+func replaceTargetSpec(args []string) []string {
+	var replaced []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--target" && i+1 < len(args) && isTargetSpec(args[i+1]):
+			replaced = append(replaced, fmt.Sprintf("--target=%s", TargetSpecName(args[i+1])))
+			i++
+		case strings.HasPrefix(args[i], "--target=") && isTargetSpec(strings.TrimPrefix(args[i], "--target=")):
+			replaced = append(replaced, fmt.Sprintf("--target=%s", TargetSpecName(strings.TrimPrefix(args[i], "--target="))))
+		default:
+			replaced = append(replaced, args[i])
+		}
+	}
+	return replaced
+}
+
+// explicitTarget returns the target set in the arguments, or an empty string
+func explicitTarget(args []string) string {
+	for i, arg := range args {
+		if arg == "--target" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--target=") {
+			return strings.TrimPrefix(arg, "--target=")
+		}
+	}
+	return ""
+}
+
+func isTargetSpec(target string) bool {
+	return strings.HasSuffix(target, ".json")
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+func testTargetSpec(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir    string
+		cargoHome string
+		executor  *mocks.Executor
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		cargoHome = t.TempDir()
+		executor = &mocks.Executor{}
+
+		t.Setenv("RUST_TARGET_PATH", "")
+		Expect(os.Unsetenv("RUST_TARGET_PATH")).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appDir, "my-target.json"), []byte(`{"llvm-target": "x86_64-unknown-none"}`), 0644)).To(Succeed())
+	})
+
+	it("finds the target specification in the install arguments", func() {
+		Expect(runner.TargetSpec("--target my-target.json --locked")).To(Equal("my-target.json"))
+		Expect(runner.TargetSpec("--target=specs/my-target.json")).To(Equal("specs/my-target.json"))
+		Expect(runner.TargetSpec("--target=x86_64-unknown-linux-musl")).To(BeEmpty())
+		Expect(runner.TargetSpec("")).To(BeEmpty())
+
+		Expect(runner.ResolveTargetTriple("--target=specs/my-target.json", "", "")).To(Equal("my-target"))
+	})
+
+	it("builds the custom target by name from a copy of the specification", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithCargoInstallArgs("--target my-target.json"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+
+		Expect(r.Install(appDir, libcnb.Layer{Path: "/layers/cargo"})).To(Succeed())
+
+		Expect(filepath.Join(cargoHome, "target-specs", "my-target.json")).To(BeARegularFile())
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Args).To(ContainElement("--target=my-target"))
+		Expect(e.Args).NotTo(ContainElement("my-target.json"))
+		Expect(e.Env).To(ContainElement("RUST_TARGET_PATH=" + filepath.Join(cargoHome, "target-specs")))
+	})
+
+	it("resolves the specification against the working directory", func() {
+		Expect(os.MkdirAll(filepath.Join(appDir, "service"), 0755)).To(Succeed())
+		Expect(os.Rename(filepath.Join(appDir, "my-target.json"), filepath.Join(appDir, "service", "my-target.json"))).To(Succeed())
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithCargoInstallArgs("--target=my-target.json"),
+			runner.WithWorkingDir("service"))
+
+		Expect(r.CopyTargetSpec(appDir)).To(Succeed())
+		Expect(filepath.Join(cargoHome, "target-specs", "my-target.json")).To(BeARegularFile())
+	})
+
+	it("fails if the specification does not exist", func() {
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(cargoHome),
+			runner.WithCargoInstallArgs("--target=missing.json"))
+
+		Expect(r.CopyTargetSpec(appDir)).To(MatchError(ContainSubstring("unable to open target specification")))
+	})
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	// TestRunnerCargo runs the tests with `cargo test`
+	TestRunnerCargo = "cargo"
+
+	// TestRunnerNextest runs the tests with `cargo nextest run`
+	TestRunnerNextest = "nextest"
+)
+
+// TestRunner returns the runner of the tests, nextest if `cargo nextest` is installed, for example with
+// BP_CARGO_INSTALL_TOOLS, or otherwise `cargo test`
+func (c CargoRunner) TestRunner() string {
+	if err := c.execute(effect.Execution{
+		Command: c.toolchainCommand("cargo"),
+		Args:    []string{"nextest", "--version"},
+		Env:     c.environment(),
+		Stdout:  io.Discard,
+		Stderr:  io.Discard,
+	}); err != nil {
+		return TestRunnerCargo
+	}
+
+	return TestRunnerNextest
+}
+
+// TestPlan resolves the invocation that Test runs to test the workspace members with a test runner, without running
+// it. The tests are built with the features and unstable flags of the build, args are appended.
+func (c CargoRunner) TestPlan(srcDir string, testRunner string, args []string) (Invocation, error) {
+	c = c.targetDirRunner(srcDir)
+
+	var testArgs []string
+	switch testRunner {
+	case TestRunnerCargo:
+		testArgs = []string{"test"}
+	case TestRunnerNextest:
+		testArgs = []string{"nextest", "run"}
+	default:
+		return Invocation{}, fmt.Errorf("unsupported test runner %q", testRunner)
+	}
+	testArgs = append(testArgs, c.UnstableFlags...)
+
+	color := c.Color
+	if color == "" {
+		color = ColorNever
+	}
+	testArgs = append(testArgs, fmt.Sprintf("--color=%s", color))
+	testArgs = append(testArgs, c.FeatureArgs()...)
+	testArgs = append(testArgs, c.offlineArgs(args)...)
+	testArgs = append(testArgs, c.jobsArgs(args)...)
+
+	members, err := c.workspaceArgs(c.workingDir(srcDir))
+	if err != nil {
+		return Invocation{}, err
+	}
+	testArgs = append(testArgs, members...)
+	testArgs = append(testArgs, args...)
+
+	return Invocation{
+		Args:    testArgs,
+		Command: c.toolchainCommand("cargo"),
+		Dir:     c.workingDir(srcDir),
+		Env:     c.compileRunner().addedEnvironment(),
+	}, nil
+}
+
+// Test runs the tests of the workspace members, with `cargo nextest run` if nextest is installed or otherwise with
+// `cargo test`, and fails if any test fails
+func (c CargoRunner) Test(srcDir string, args []string) error {
+	testRunner := c.TestRunner()
+
+	plan, err := c.TestPlan(srcDir, testRunner, args)
+	if err != nil {
+		return fmt.Errorf("unable to plan tests\n%w", err)
+	}
+
+	c.Logger.Bodyf("cargo %s", RedactURLs(strings.Join(plan.Args, " ")))
+	output, flush := c.output()
+	if err := c.executeRetrying(effect.Execution{
+		Command: plan.Command,
+		Args:    plan.Args,
+		Dir:     plan.Dir,
+		Env:     c.inheritEnvironment(plan.Env),
+		Stdout:  output,
+		Stderr:  output,
+	}); err != nil {
+		_ = flush()
+		return fmt.Errorf("unable to run tests with %s\n%w", testRunner, err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write test output\n%w", err)
+	}
+
+	return nil
+}
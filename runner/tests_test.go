@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testTests(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		executor *cargotest.Executor
+	)
+
+	it.Before(func() {
+		appDir = t.TempDir()
+		executor = &cargotest.Executor{}
+	})
+
+	it("runs the tests of the workspace with cargo test", func() {
+		executor.On("cargo", "test")
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoFeatures("postgres"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})),
+			runner.WithWorkingDir("crates"))
+		Expect(r.TestRunner()).To(Equal(runner.TestRunnerCargo))
+		Expect(r.Test(appDir, []string{"--", "--skip", "integration"})).To(Succeed())
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "test")
+		Expect(execution.Args).To(Equal([]string{"test", "--color=never", "--features=postgres", "--workspace", "--", "--skip", "integration"}))
+		Expect(execution.Dir).To(Equal(filepath.Join(appDir, "crates")))
+	})
+
+	it("runs the tests with nextest if it is installed", func() {
+		executor.On("cargo", "nextest", "--version")
+		executor.On("cargo", "nextest", "run")
+
+		r := runner.NewCargoRunner(
+			runner.WithCargoWorkspaceMembers("worker,api"),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{})))
+		Expect(r.TestRunner()).To(Equal(runner.TestRunnerNextest))
+		Expect(r.Test(appDir, nil)).To(Succeed())
+
+		execution := cargotest.AssertExecuted(t, executor, "cargo", "nextest", "run")
+		Expect(execution.Args).To(Equal([]string{"nextest", "run", "--color=never", "-p", "api", "-p", "worker"}))
+		cargotest.AssertNotExecuted(t, executor, "cargo", "test")
+	})
+
+	it("fails when a test fails", func() {
+		executor.On("cargo", "test").Err = fmt.Errorf("exit status 101")
+
+		err := runner.NewCargoRunner(
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(&bytes.Buffer{}))).Test(appDir, nil)
+		Expect(err).To(MatchError(ContainSubstring("unable to run tests with cargo")))
+	})
+
+	it("fails for an unsupported test runner", func() {
+		_, err := runner.NewCargoRunner().TestPlan(appDir, "pytest", nil)
+		Expect(err).To(MatchError(`unsupported test runner "pytest"`))
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// UnitTiming is the time cargo spent compiling a unit of a package, like its library or its build script, read from
+// the `timing-info` messages written with `--timings=json`
+type UnitTiming struct {
+	Duration  time.Duration
+	Mode      string
+	PackageID string
+	RmetaTime time.Duration
+	Target    string
+}
+
+// TimingCollector collects the `timing-info` messages that cargo writes to stdout with `--timings=json`
+type TimingCollector struct {
+	mu      sync.Mutex
+	timings []UnitTiming
+}
+
+// JSON returns a writer for the stdout of cargo, which writes everything but the timing messages to w
+func (t *TimingCollector) JSON(w io.Writer) *LineWriter {
+	return &LineWriter{Line: func(line []byte) error {
+		var message struct {
+			Duration  float64  `json:"duration"`
+			Mode      string   `json:"mode"`
+			PackageID string   `json:"package_id"`
+			Reason    string   `json:"reason"`
+			RmetaTime *float64 `json:"rmeta_time"`
+			Target    struct {
+				Name string `json:"name"`
+			} `json:"target"`
+		}
+		if err := json.Unmarshal(line, &message); err != nil || message.Reason != "timing-info" {
+			_, err := w.Write(line)
+			return err
+		}
+
+		timing := UnitTiming{
+			Duration:  seconds(message.Duration),
+			Mode:      message.Mode,
+			PackageID: message.PackageID,
+			Target:    message.Target.Name,
+		}
+		if message.RmetaTime != nil {
+			timing.RmetaTime = seconds(*message.RmetaTime)
+		}
+
+		t.mu.Lock()
+		t.timings = append(t.timings, timing)
+		t.mu.Unlock()
+		return nil
+	}}
+}
+
+// Timings returns the timings collected so far
+func (t *TimingCollector) Timings() []UnitTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.timings)
+}
+
+// timingsArgs returns the arguments that make cargo report the time spent compiling each crate. `--timings=json` is
+// unstable and needs `-Z unstable-options`.
+func (c CargoRunner) timingsArgs() []string {
+	switch {
+	case c.TimingsJSON && slices.Contains(c.UnstableFlags, "-Zunstable-options"):
+		return []string{"--timings=html,json"}
+	case c.TimingsJSON:
+		return []string{"-Zunstable-options", "--timings=html,json"}
+	case c.Timings:
+		return []string{"--timings"}
+	default:
+		return nil
+	}
+}
+
+// timingOutput returns the writer of stdout for a compiling command that writes it to w, and a function that flushes
+// it with flush and adds the collected timings to the statistics. Without TimingsJSON, stdout is written to w
+// unchanged.
+func (c CargoRunner) timingOutput(w io.Writer, flush func() error) (io.Writer, func() error) {
+	if !c.TimingsJSON {
+		return w, flush
+	}
+
+	collector := &TimingCollector{}
+	stdout := collector.JSON(w)
+
+	return stdout, func() error {
+		// flushed first, as the last line may be passed on to w
+		if err := errors.Join(stdout.Flush(), flush()); err != nil {
+			return err
+		}
+
+		if c.Statistics != nil {
+			c.Statistics.Timings = append(c.Statistics.Timings, collector.Timings()...)
+		}
+		return nil
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
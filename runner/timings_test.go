@@ -0,0 +1,132 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+const timingMessages = `{"reason":"timing-info","package_id":"registry+https://github.com/rust-lang/crates.io-index#serde@1.0.200","target":{"kind":["lib"],"name":"serde"},"mode":"build","duration":3.0,"rmeta_time":1.25}
+{"reason":"timing-info","package_id":"path+file:///workspace#app@0.1.0","target":{"kind":["bin"],"name":"app"},"mode":"build","duration":1.5,"rmeta_time":null}
+build script output
+`
+
+func testTimings(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("collects the timing messages of cargo", func() {
+		out := &bytes.Buffer{}
+		collector := &runner.TimingCollector{}
+		w := collector.JSON(out)
+
+		_, err := io.WriteString(w, timingMessages)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Flush()).To(Succeed())
+		Expect(out.String()).To(Equal("build script output\n"))
+
+		Expect(collector.Timings()).To(Equal([]runner.UnitTiming{
+			{
+				Duration:  3 * time.Second,
+				Mode:      "build",
+				PackageID: "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.200",
+				RmetaTime: 1250 * time.Millisecond,
+				Target:    "serde",
+			},
+			{
+				Duration:  1500 * time.Millisecond,
+				Mode:      "build",
+				PackageID: "path+file:///workspace#app@0.1.0",
+				Target:    "app",
+			},
+		}))
+	})
+
+	it("writes the timings as JSON with unstable options", func() {
+		plan, err := runner.NewCargoRunner(runner.WithTimingsJSON(true)).
+			InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Args).To(ContainElements("-Zunstable-options", "--timings=html,json"))
+		Expect(plan.Args).NotTo(ContainElement("--timings"))
+	})
+
+	it("does not repeat unstable options", func() {
+		plan, err := runner.NewCargoRunner(
+			runner.WithTimingsJSON(true),
+			runner.WithUnstable(true, []string{"-Zunstable-options"})).
+			InstallPlan(".", t.TempDir(), libcnb.Layer{Path: "/layers/cargo"})
+		Expect(err).NotTo(HaveOccurred())
+
+		count := 0
+		for _, arg := range plan.Args {
+			if arg == "-Zunstable-options" {
+				count++
+			}
+		}
+		Expect(count).To(Equal(1))
+	})
+
+	it("collects the timings after installing", func() {
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "install").Stdout = timingMessages
+
+		buf := &bytes.Buffer{}
+		statistics := &runner.Statistics{}
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(t.TempDir()),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(buf)),
+			runner.WithStatistics(statistics),
+			runner.WithTimingsJSON(true))
+		Expect(r.InstallMember(".", t.TempDir(), libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		Expect(buf.String()).To(ContainSubstring("build script output"))
+		Expect(buf.String()).NotTo(ContainSubstring("timing-info"))
+		Expect(statistics.Timings).To(HaveLen(2))
+		Expect(statistics.Timings[0].Target).To(Equal("serde"))
+	})
+
+	it("collects the timings alongside the compiler messages", func() {
+		executor := &cargotest.Executor{}
+		executor.On("cargo", "install").Stdout = timingMessages +
+			`{"reason":"compiler-message","message":{"level":"warning","message":"unused","rendered":"warning: unused\n","spans":[]}}` + "\n"
+
+		statistics := &runner.Statistics{}
+		r := runner.NewCargoRunner(
+			runner.WithCargoHome(t.TempDir()),
+			runner.WithExecutor(executor),
+			runner.WithLogger(bard.NewLogger(io.Discard)),
+			runner.WithMessageSummary(true),
+			runner.WithStatistics(statistics),
+			runner.WithTimingsJSON(true))
+		Expect(r.InstallMember(".", t.TempDir(), libcnb.Layer{Path: t.TempDir()})).To(Succeed())
+
+		Expect(statistics.Timings).To(HaveLen(2))
+		Expect(statistics.Messages.Warnings).To(Equal(1))
+	})
+}
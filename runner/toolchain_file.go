@@ -0,0 +1,117 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver/v3"
+)
+
+// ToolchainFiles are the files that select a toolchain for a directory, in the order they are read
+var ToolchainFiles = []string{"rust-toolchain.toml", "rust-toolchain"}
+
+var toolchainVersionPattern = regexp.MustCompile(`^\d+\.\d+(?:\.\d+)?`)
+
+// ToolchainFile is the toolchain selected with a `rust-toolchain.toml` or `rust-toolchain` file
+type ToolchainFile struct {
+	Channel    string   `toml:"channel"`
+	Components []string `toml:"components"`
+	File       string   `toml:"-"`
+	Path       string   `toml:"path"`
+	Profile    string   `toml:"profile"`
+	Targets    []string `toml:"targets"`
+}
+
+// ToolchainRequirements reads the toolchain file in srcDir, like rustup does. A legacy `rust-toolchain` file that only
+// contains a channel name is supported. Returns nil if srcDir has no toolchain file.
+func ToolchainRequirements(srcDir string) (*ToolchainFile, error) {
+	for _, name := range ToolchainFiles {
+		b, err := os.ReadFile(filepath.Join(srcDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read %s\n%w", name, err)
+		}
+
+		content := strings.TrimSpace(string(b))
+		if name == "rust-toolchain" && !strings.Contains(content, "\n") && !strings.HasPrefix(content, "[") {
+			return &ToolchainFile{Channel: content, File: name}, nil
+		}
+
+		var raw struct {
+			Toolchain ToolchainFile `toml:"toolchain"`
+		}
+		if _, err := toml.Decode(content, &raw); err != nil {
+			return nil, fmt.Errorf("unable to decode %s\n%w", name, err)
+		}
+
+		raw.Toolchain.File = name
+		return &raw.Toolchain, nil
+	}
+
+	return nil, nil
+}
+
+// Nightly returns true if the toolchain file selects a nightly channel
+func (t ToolchainFile) Nightly() bool {
+	return strings.HasPrefix(t.Channel, ChannelNightly)
+}
+
+// Verify fails if the installed toolchain does not match the channel of the toolchain file, for example a stable rustc
+// when `nightly-2024-05-01` is selected or rustc 1.79.0 when `1.80` is selected. Toolchains selected with a path and
+// development builds of rustc are not verified.
+func (t ToolchainFile) Verify(info VersionInfo) error {
+	if t.Channel == "" || info.Channel == ChannelDev {
+		return nil
+	}
+
+	for _, channel := range []string{ChannelStable, ChannelBeta, ChannelNightly} {
+		if strings.HasPrefix(t.Channel, channel) {
+			if info.Channel != channel {
+				return fmt.Errorf("rustc %s is a %s toolchain, but %s selects the %s channel", info.Version, info.Channel, t.File, t.Channel)
+			}
+			return nil
+		}
+	}
+
+	raw := toolchainVersionPattern.FindString(t.Channel)
+	if raw == "" {
+		return nil
+	}
+
+	v, err := semver.NewVersion(raw)
+	if err != nil {
+		return fmt.Errorf("unable to parse channel %s of %s\n%w", t.Channel, t.File, err)
+	}
+
+	matches := v.Major() == info.Major() && v.Minor() == info.Minor()
+	if strings.Count(raw, ".") == 2 {
+		matches = matches && v.Patch() == info.Patch()
+	}
+
+	if !matches {
+		return fmt.Errorf("rustc %s does not match the channel %s selected by %s", info.Version, t.Channel, t.File)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/cargotest"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testToolchainFile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	info := func(output string) runner.VersionInfo {
+		i, err := runner.ParseVersionInfo(output)
+		Expect(err).NotTo(HaveOccurred())
+		return i
+	}
+
+	it("reads rust-toolchain.toml", func() {
+		appDir := cargotest.Application(t, map[string]string{
+			"rust-toolchain.toml": "[toolchain]\nchannel = \"1.80.1\"\ncomponents = [\"clippy\", \"rustfmt\"]\ntargets = [\"wasm32-wasip1\"]\nprofile = \"minimal\"\n",
+			"rust-toolchain":      "nightly\n",
+		})
+
+		Expect(runner.ToolchainRequirements(appDir)).To(Equal(&runner.ToolchainFile{
+			Channel:    "1.80.1",
+			Components: []string{"clippy", "rustfmt"},
+			File:       "rust-toolchain.toml",
+			Profile:    "minimal",
+			Targets:    []string{"wasm32-wasip1"},
+		}))
+	})
+
+	it("reads legacy rust-toolchain files", func() {
+		appDir := cargotest.Application(t, map[string]string{"rust-toolchain": "nightly-2024-05-01\n"})
+		Expect(runner.ToolchainRequirements(appDir)).To(Equal(&runner.ToolchainFile{Channel: "nightly-2024-05-01", File: "rust-toolchain"}))
+
+		appDir = cargotest.Application(t, map[string]string{"rust-toolchain": "[toolchain]\nchannel = \"beta\"\n"})
+		Expect(runner.ToolchainRequirements(appDir)).To(Equal(&runner.ToolchainFile{Channel: "beta", File: "rust-toolchain"}))
+	})
+
+	it("does not require a toolchain file", func() {
+		Expect(runner.ToolchainRequirements(t.TempDir())).To(BeNil())
+	})
+
+	it("fails on an invalid toolchain file", func() {
+		appDir := cargotest.Application(t, map[string]string{"rust-toolchain.toml": "[toolchain\n"})
+
+		_, err := runner.ToolchainRequirements(appDir)
+		Expect(err).To(MatchError(ContainSubstring("unable to decode rust-toolchain.toml")))
+	})
+
+	it("verifies the channel", func() {
+		stable := info("rustc 1.80.1 (3f5fd8dd4 2024-08-06)\nrelease: 1.80.1\n")
+		nightly := info("rustc 1.82.0-nightly (6de928dce 2024-08-18)\nrelease: 1.82.0-nightly\n")
+
+		Expect(runner.ToolchainFile{Channel: "stable", File: "rust-toolchain.toml"}.Verify(stable)).To(Succeed())
+		Expect(runner.ToolchainFile{Channel: "1.80", File: "rust-toolchain.toml"}.Verify(stable)).To(Succeed())
+		Expect(runner.ToolchainFile{Channel: "1.80.1", File: "rust-toolchain.toml"}.Verify(stable)).To(Succeed())
+		Expect(runner.ToolchainFile{Channel: "nightly-2024-08-18", File: "rust-toolchain.toml"}.Verify(nightly)).To(Succeed())
+		Expect(runner.ToolchainFile{Path: "/opt/rust", File: "rust-toolchain.toml"}.Verify(stable)).To(Succeed())
+
+		Expect(runner.ToolchainFile{Channel: "nightly", File: "rust-toolchain.toml"}.Verify(stable)).To(MatchError(
+			"rustc 1.80.1 is a stable toolchain, but rust-toolchain.toml selects the nightly channel"))
+		Expect(runner.ToolchainFile{Channel: "1.79", File: "rust-toolchain.toml"}.Verify(stable)).To(MatchError(
+			"rustc 1.80.1 does not match the channel 1.79 selected by rust-toolchain.toml"))
+		Expect(runner.ToolchainFile{Channel: "1.80.0", File: "rust-toolchain.toml"}.Verify(stable)).To(HaveOccurred())
+	})
+}
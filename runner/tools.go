@@ -0,0 +1,295 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+const (
+	ToolStrategyAuto     = "auto"
+	ToolStrategyBinstall = "binstall"
+	ToolStrategyPrebuilt = "prebuilt"
+	ToolStrategySource   = "source"
+)
+
+// ToolStrategies configures how tools are installed, with a default strategy and per-tool overrides
+type ToolStrategies struct {
+	Default   string
+	Overrides map[string]string
+}
+
+// ParseToolStrategies parses a comma separated list of strategies, like `auto,diesel_cli=source`. An entry without a
+// tool name sets the default strategy, which is `source` if not set.
+func ParseToolStrategies(raw string) (ToolStrategies, error) {
+	strategies := ToolStrategies{Default: ToolStrategySource, Overrides: map[string]string{}}
+
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		tool, strategy, found := strings.Cut(entry, "=")
+		if !found {
+			tool, strategy = "", tool
+		}
+		tool, strategy = strings.TrimSpace(tool), strings.TrimSpace(strategy)
+
+		switch strategy {
+		case ToolStrategyAuto, ToolStrategyBinstall, ToolStrategyPrebuilt, ToolStrategySource:
+		default:
+			return ToolStrategies{}, fmt.Errorf("unknown tool strategy %q, must be one of %s, %s, %s or %s",
+				strategy, ToolStrategyPrebuilt, ToolStrategyBinstall, ToolStrategySource, ToolStrategyAuto)
+		}
+
+		if tool == "" {
+			strategies.Default = strategy
+		} else {
+			strategies.Overrides[tool] = strategy
+		}
+	}
+
+	return strategies, nil
+}
+
+// For returns the strategies to try, in order, to install a tool. The `auto` strategy tries a prebuilt binary first
+// and falls back to compiling the tool from source.
+func (t ToolStrategies) For(tool string) []string {
+	strategy := t.Default
+
+	// tools may be pinned to a version, like `diesel_cli@2.1.0`
+	name, _, _ := strings.Cut(tool, "@")
+	if s, ok := t.Overrides[name]; ok {
+		strategy = s
+	}
+
+	switch strategy {
+	case "":
+		return []string{ToolStrategySource}
+	case ToolStrategyAuto:
+		return []string{ToolStrategyPrebuilt, ToolStrategyBinstall, ToolStrategySource}
+	default:
+		return []string{strategy}
+	}
+}
+
+// Unverified returns the strategies, like `prebuilt` or `tool=auto`, that may download binaries which are not
+// verified against a digest, sorted by tool with the default strategy first
+func (t ToolStrategies) Unverified() []string {
+	var unverified []string
+
+	if t.Default != "" && t.Default != ToolStrategySource {
+		unverified = append(unverified, t.Default)
+	}
+
+	var tools []string
+	for tool, strategy := range t.Overrides {
+		if strategy != ToolStrategySource {
+			tools = append(tools, tool)
+		}
+	}
+	slices.Sort(tools)
+
+	for _, tool := range tools {
+		unverified = append(unverified, fmt.Sprintf("%s=%s", tool, t.Overrides[tool]))
+	}
+
+	return unverified
+}
+
+// ToolArgs builds the arguments to install a tool with a strategy. Prebuilt binaries are only downloaded from the
+// release artifacts published by the crate, binstall may also use third party mirrors, and source compiles the tool
+// with `cargo install`. Additional arguments are only passed to `cargo install`.
+func ToolArgs(strategy string, tool string, additionalArgs []string) []string {
+	switch strategy {
+	case ToolStrategyPrebuilt:
+		return []string{"binstall", "--no-confirm", "--strategies", "crate-meta-data", tool}
+	case ToolStrategyBinstall:
+		return []string{"binstall", "--no-confirm", "--disable-strategies", "compile", tool}
+	default:
+		return append([]string{"install", tool}, additionalArgs...)
+	}
+}
+
+// ToolRequest is a tool to install, with an optional version, features, `--locked` and further arguments passed to
+// `cargo install` for this tool only
+type ToolRequest struct {
+	Args     []string
+	Features []string
+	Locked   bool
+	Name     string
+	Version  string
+}
+
+// featurePattern matches the name of a feature, which may be the feature of a dependency like `tokio/full`
+var featurePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_+\-./]*$`)
+
+// String returns the tool as understood by `cargo install` and `cargo binstall`, like `sqlx-cli@0.7`
+func (t ToolRequest) String() string {
+	if t.Version == "" {
+		return t.Name
+	}
+	return fmt.Sprintf("%s@%s", t.Name, t.Version)
+}
+
+// InstallArgs returns the arguments passed to `cargo install` for the tool, the arguments shared by all tools followed
+// by those of the tool
+func (t ToolRequest) InstallArgs(shared []string) []string {
+	args := append([]string{}, shared...)
+
+	if len(t.Features) > 0 {
+		args = append(args, "--features", strings.Join(t.Features, ","))
+	}
+
+	if t.Locked && !slices.Contains(shared, "--locked") {
+		args = append(args, "--locked")
+	}
+
+	return append(args, t.Args...)
+}
+
+// Customized returns true if the tool has arguments, features or `--locked`, which only apply when it is compiled
+// from source
+func (t ToolRequest) Customized() bool {
+	return len(t.Args) > 0 || len(t.Features) > 0 || t.Locked
+}
+
+// ParseToolRequests parses the tools to install. A comma separated list declares each tool with an optional version
+// and arguments, like `cargo-about@0.6,sqlx-cli@0.7:--no-default-features --features postgres --locked`, where
+// arguments containing commas must be quoted. Features and `--locked` are taken out of the arguments. A space separated
+// list of tools, like `cargo-about sqlx-cli@0.7`, is also accepted. Errors name the offending entry.
+func ParseToolRequests(raw string) ([]ToolRequest, error) {
+	var entries []string
+	if strings.ContainsAny(raw, ",:") {
+		entries = splitUnquoted(raw, ',')
+	} else {
+		var err error
+		if entries, err = shellwords.Parse(raw); err != nil {
+			return nil, fmt.Errorf("unable to parse tools %q\n%w", raw, err)
+		}
+	}
+
+	var requests []ToolRequest
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		request, err := parseToolRequest(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tool %q\n%w", entry, err)
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+func parseToolRequest(entry string) (ToolRequest, error) {
+	spec, rawArgs, _ := strings.Cut(entry, ":")
+	name, version, _ := strings.Cut(strings.TrimSpace(spec), "@")
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return ToolRequest{}, fmt.Errorf("must be like name, name@version or name@version:args")
+	}
+
+	request := ToolRequest{Name: name, Version: version}
+
+	args, err := shellwords.Parse(rawArgs)
+	if err != nil {
+		return ToolRequest{}, fmt.Errorf("unable to parse arguments\n%w", err)
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		flag, value, hasValue := strings.Cut(arg, "=")
+
+		switch flag {
+		case "--features", "-F":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return ToolRequest{}, fmt.Errorf("%s requires a value", flag)
+				}
+				i++
+				value = args[i]
+			}
+
+			for _, feature := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+				if !featurePattern.MatchString(feature) {
+					return ToolRequest{}, fmt.Errorf("invalid feature %q", feature)
+				}
+				request.Features = append(request.Features, feature)
+			}
+		case "--locked":
+			request.Locked = true
+		case "--version", "--vers":
+			return ToolRequest{}, fmt.Errorf("%s is not supported, pin the version with name@version", flag)
+		case "--root":
+			return ToolRequest{}, fmt.Errorf("--root is not supported, tools are installed into CARGO_HOME")
+		default:
+			request.Args = append(request.Args, arg)
+		}
+	}
+
+	return request, nil
+}
+
+// ValidateToolRequests ensures that the arguments, features and `--locked` of each tool are applied, which is only the
+// case if the tool may be compiled from source with its strategies
+func ValidateToolRequests(requests []ToolRequest, strategies ToolStrategies) error {
+	for _, r := range requests {
+		if r.Customized() && !slices.Contains(strategies.For(r.String()), ToolStrategySource) {
+			return fmt.Errorf("invalid tool %q\narguments, features and --locked are only applied when compiling "+
+				"from source, but it is installed with strategy %s, use the %s or %s strategy",
+				r.String(), strings.Join(strategies.For(r.String()), ", "), ToolStrategySource, ToolStrategyAuto)
+		}
+	}
+
+	return nil
+}
+
+// splitUnquoted splits s at each separator that is not within single or double quotes
+func splitUnquoted(s string, separator rune) []string {
+	var (
+		parts []string
+		quote rune
+		start int
+	)
+
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == separator:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
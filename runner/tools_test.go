@@ -0,0 +1,142 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testTools(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("parses tool strategies", func() {
+		it("defaults to source", func() {
+			strategies, err := runner.ParseToolStrategies("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strategies.For("foo")).To(Equal([]string{runner.ToolStrategySource}))
+		})
+
+		it("parses a default and overrides", func() {
+			strategies, err := runner.ParseToolStrategies("auto, diesel_cli=source,cargo-bloat=prebuilt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strategies.For("foo")).To(Equal([]string{runner.ToolStrategyPrebuilt, runner.ToolStrategyBinstall, runner.ToolStrategySource}))
+			Expect(strategies.For("diesel_cli@2.1.0")).To(Equal([]string{runner.ToolStrategySource}))
+			Expect(strategies.For("cargo-bloat")).To(Equal([]string{runner.ToolStrategyPrebuilt}))
+		})
+
+		it("lists the strategies that download unverified binaries", func() {
+			strategies, err := runner.ParseToolStrategies("auto,diesel_cli=source,sqlx-cli=binstall,cargo-bloat=prebuilt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strategies.Unverified()).To(Equal([]string{"auto", "cargo-bloat=prebuilt", "sqlx-cli=binstall"}))
+
+			strategies, err = runner.ParseToolStrategies("diesel_cli=source")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strategies.Unverified()).To(BeEmpty())
+		})
+
+		it("fails for unknown strategies", func() {
+			_, err := runner.ParseToolStrategies("foo=download")
+			Expect(err).To(MatchError(ContainSubstring(`unknown tool strategy "download"`)))
+		})
+	})
+
+	context("parses tool requests", func() {
+		it("parses a space separated list", func() {
+			Expect(runner.ParseToolRequests("cargo-about  diesel_cli@2.1.0")).To(Equal([]runner.ToolRequest{
+				{Name: "cargo-about"},
+				{Name: "diesel_cli", Version: "2.1.0"},
+			}))
+			Expect(runner.ParseToolRequests("")).To(BeEmpty())
+		})
+
+		it("parses declared tools with versions and arguments", func() {
+			requests, err := runner.ParseToolRequests("cargo-about@0.6, sqlx-cli@0.7:--no-default-features --features postgres,cargo-bloat")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]runner.ToolRequest{
+				{Name: "cargo-about", Version: "0.6"},
+				{Name: "sqlx-cli", Version: "0.7", Args: []string{"--no-default-features"}, Features: []string{"postgres"}},
+				{Name: "cargo-bloat"},
+			}))
+			Expect(requests[1].String()).To(Equal("sqlx-cli@0.7"))
+			Expect(requests[2].String()).To(Equal("cargo-bloat"))
+		})
+
+		it("keeps quoted commas in arguments", func() {
+			Expect(runner.ParseToolRequests(`sqlx-cli:--features "postgres,rustls",cargo-about`)).To(Equal([]runner.ToolRequest{
+				{Name: "sqlx-cli", Features: []string{"postgres", "rustls"}},
+				{Name: "cargo-about"},
+			}))
+		})
+
+		it("takes features and --locked out of the arguments", func() {
+			Expect(runner.ParseToolRequests(`sqlx-cli:-F postgres --locked --features=rustls --bins,cargo-about:--features "a b"`)).To(Equal([]runner.ToolRequest{
+				{Name: "sqlx-cli", Args: []string{"--bins"}, Features: []string{"postgres", "rustls"}, Locked: true},
+				{Name: "cargo-about", Features: []string{"a", "b"}},
+			}))
+		})
+
+		it("renders the install arguments", func() {
+			tool := runner.ToolRequest{Name: "sqlx-cli", Args: []string{"--bins"}, Features: []string{"postgres", "tokio/full"}, Locked: true}
+
+			Expect(tool.InstallArgs([]string{"--jobs", "2"})).To(Equal([]string{"--jobs", "2", "--features", "postgres,tokio/full", "--locked", "--bins"}))
+			Expect(tool.InstallArgs([]string{"--locked"})).To(Equal([]string{"--locked", "--features", "postgres,tokio/full", "--bins"}))
+			Expect(runner.ToolRequest{Name: "cargo-about"}.InstallArgs(nil)).To(BeEmpty())
+		})
+
+		it("fails for invalid arguments naming the tool", func() {
+			_, err := runner.ParseToolRequests("cargo-about,sqlx-cli@0.7:--features")
+			Expect(err).To(MatchError(And(ContainSubstring(`invalid tool "sqlx-cli@0.7:--features"`), ContainSubstring("--features requires a value"))))
+
+			_, err = runner.ParseToolRequests("sqlx-cli:--features post$gres")
+			Expect(err).To(MatchError(And(ContainSubstring(`invalid tool "sqlx-cli:--features post$gres"`), ContainSubstring(`invalid feature "post$gres"`))))
+
+			_, err = runner.ParseToolRequests("sqlx-cli:--version 0.7")
+			Expect(err).To(MatchError(ContainSubstring("pin the version with name@version")))
+
+			_, err = runner.ParseToolRequests("sqlx-cli:--root /tmp")
+			Expect(err).To(MatchError(ContainSubstring("--root is not supported")))
+		})
+
+		it("validates arguments against the tool strategies", func() {
+			strategies, err := runner.ParseToolStrategies("auto,cargo-bloat=prebuilt")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runner.ValidateToolRequests([]runner.ToolRequest{
+				{Name: "cargo-about", Locked: true},
+				{Name: "cargo-bloat"},
+			}, strategies)).To(Succeed())
+
+			Expect(runner.ValidateToolRequests([]runner.ToolRequest{
+				{Name: "cargo-bloat", Version: "0.11", Features: []string{"regex-filter"}},
+			}, strategies)).To(MatchError(ContainSubstring(`invalid tool "cargo-bloat@0.11"`)))
+		})
+
+		it("fails for invalid tools", func() {
+			_, err := runner.ParseToolRequests("cargo about:--locked")
+			Expect(err).To(MatchError(ContainSubstring(`invalid tool "cargo about:--locked"`)))
+
+			_, err = runner.ParseToolRequests("@0.6:--locked")
+			Expect(err).To(MatchError(ContainSubstring("invalid tool")))
+		})
+	})
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?`)
+
+// Version is a toolchain version reported by `cargo version` or `rustc --version`
+type Version struct {
+	*semver.Version
+
+	raw string
+}
+
+// ParseVersion parses the first semantic version found in the output of a toolchain version command. Vendor
+// suffixes, like commit hashes, build dates or distribution names, are ignored.
+func ParseVersion(output string) (Version, error) {
+	raw := strings.TrimSpace(output)
+	if i := strings.IndexByte(raw, '\n'); i >= 0 {
+		raw = strings.TrimSpace(raw[:i])
+	}
+
+	s := versionPattern.FindString(raw)
+	if s == "" {
+		return Version{}, fmt.Errorf("unable to find a version in %q", raw)
+	}
+
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return Version{}, fmt.Errorf("unable to parse version %s\n%w", s, err)
+	}
+
+	return Version{Version: v, raw: raw}, nil
+}
+
+// Raw returns the version as it was reported by the toolchain
+func (v Version) Raw() string {
+	return v.raw
+}
+
+const (
+	ChannelBeta    = "beta"
+	ChannelDev     = "dev"
+	ChannelNightly = "nightly"
+	ChannelStable  = "stable"
+)
+
+// VersionInfo is a toolchain version with the details reported by `cargo version --verbose` or `rustc -vV`
+type VersionInfo struct {
+	Version
+
+	Channel    string
+	CommitDate string
+	CommitHash string
+	Host       string
+}
+
+// ParseVersionInfo parses the verbose output of a toolchain version command, which is the version followed by
+// `key: value` lines, like `commit-hash: 54d8815d0...` and `host: x86_64-unknown-linux-gnu`. The channel is derived
+// from the pre-release of the version, which is empty for stable toolchains.
+func ParseVersionInfo(output string) (VersionInfo, error) {
+	v, err := ParseVersion(output)
+	if err != nil {
+		return VersionInfo{}, err
+	}
+
+	info := VersionInfo{Version: v, Channel: ChannelStable}
+	if pre := v.Prerelease(); pre != "" {
+		info.Channel, _, _ = strings.Cut(pre, ".")
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "commit-date":
+			info.CommitDate = value
+		case "commit-hash":
+			info.CommitHash = value
+		case "host":
+			info.Host = value
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/runner"
+	"github.com/sclevine/spec"
+)
+
+func testVersion(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("parses plain versions", func() {
+		v, err := runner.ParseVersion("cargo 1.78.0 (54d8815d0 2024-03-26)\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.String()).To(Equal("1.78.0"))
+		Expect(v.Raw()).To(Equal("cargo 1.78.0 (54d8815d0 2024-03-26)"))
+	})
+
+	it("parses nightly versions", func() {
+		v, err := runner.ParseVersion("rustc 1.80.0-nightly (ada5e2c7b 2024-05-31)")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.String()).To(Equal("1.80.0-nightly"))
+		Expect(v.Prerelease()).To(Equal("nightly"))
+	})
+
+	it("parses distribution versions", func() {
+		v, err := runner.ParseVersion("rustc 1.79.0 (129f3b996 2024-06-10) (Fedora 1.79.0-1.fc40)")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.String()).To(Equal("1.79.0"))
+
+		v, err = runner.ParseVersion("rustc 1.75.0 (82e1608df 2023-12-21) (built from a source tarball)")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.String()).To(Equal("1.75.0"))
+	})
+
+	it("compares versions", func() {
+		older, err := runner.ParseVersion("rustc 1.70.0")
+		Expect(err).NotTo(HaveOccurred())
+
+		newer, err := runner.ParseVersion("rustc 1.78.2")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(older.LessThan(newer.Version)).To(BeTrue())
+	})
+
+	it("parses verbose versions", func() {
+		info, err := runner.ParseVersionInfo(`rustc 1.78.0 (9b00956e5 2024-04-29)
+binary: rustc
+commit-hash: 9b00956e56009bab2aa15d7bff10916599e3d6d6
+commit-date: 2024-04-29
+host: x86_64-unknown-linux-gnu
+release: 1.78.0
+LLVM version: 18.1.2
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.String()).To(Equal("1.78.0"))
+		Expect(info.Raw()).To(Equal("rustc 1.78.0 (9b00956e5 2024-04-29)"))
+		Expect(info.Channel).To(Equal(runner.ChannelStable))
+		Expect(info.CommitHash).To(Equal("9b00956e56009bab2aa15d7bff10916599e3d6d6"))
+		Expect(info.CommitDate).To(Equal("2024-04-29"))
+		Expect(info.Host).To(Equal("x86_64-unknown-linux-gnu"))
+	})
+
+	it("derives the channel from the version", func() {
+		info, err := runner.ParseVersionInfo("cargo 1.80.0-beta.3 (e9a1e4d2f 2024-06-20)\nhost: aarch64-unknown-linux-musl\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Channel).To(Equal(runner.ChannelBeta))
+		Expect(info.Host).To(Equal("aarch64-unknown-linux-musl"))
+
+		info, err = runner.ParseVersionInfo("rustc 1.81.0-nightly (ada5e2c7b 2024-05-31)")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Channel).To(Equal(runner.ChannelNightly))
+		Expect(info.CommitHash).To(BeEmpty())
+	})
+
+	it("compares verbose versions for MSRV checks", func() {
+		info, err := runner.ParseVersionInfo("rustc 1.74.1 (a28077b28 2023-12-04)\nhost: x86_64-unknown-linux-gnu\n")
+		Expect(err).NotTo(HaveOccurred())
+
+		msrv, err := runner.ParseVersion("1.75.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.LessThan(msrv.Version)).To(BeTrue())
+	})
+
+	it("fails without a version", func() {
+		_, err := runner.ParseVersion("error: no such command: `version`")
+		Expect(err).To(MatchError(ContainSubstring("unable to find a version")))
+	})
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rustup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/paketo-community/cargo/download"
+)
+
+// DefaultToolchain is the toolchain installed when no specific version has been requested
+const DefaultToolchain = "stable"
+
+// Rustup installs a Rust toolchain into a cached build layer using rustup-init. It is used when the build image does
+// not provide a Rust toolchain.
+type Rustup struct {
+	Executor         effect.Executor
+	LayerContributor download.DependencyLayerContributor
+	Logger           bard.Logger
+	Toolchain        string
+}
+
+func NewRustup(dependency libpak.BuildpackDependency, downloader download.Downloader, toolchain string) Rustup {
+	toolchain = ToolchainFromConstraint(toolchain)
+
+	contributor := download.NewDependencyLayerContributor(dependency, downloader, libcnb.LayerTypes{
+		Build: true,
+		Cache: true,
+	})
+	contributor.ExpectedMetadata = map[string]interface{}{
+		"dependency": dependency,
+		"toolchain":  toolchain,
+	}
+
+	return Rustup{
+		Executor:         effect.NewExecutor(),
+		LayerContributor: contributor,
+		Toolchain:        toolchain,
+	}
+}
+
+func (r Rustup) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	r.LayerContributor.Logger = r.Logger
+
+	layer, err := r.LayerContributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
+		installer := filepath.Join(layer.Path, "rustup-init")
+		if err := sherpa.CopyFile(artifact, installer); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to copy artifact to %s\n%w", installer, err)
+		}
+		defer os.Remove(installer)
+
+		if err := os.Chmod(installer, 0755); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to make rustup-init executable\n%w", err)
+		}
+
+		r.Logger.Bodyf("Installing Rust toolchain %s", r.Toolchain)
+		if err := r.Executor.Execute(effect.Execution{
+			Command: installer,
+			Args:    []string{"-y", "--no-modify-path", "--profile", "minimal", "--default-toolchain", r.Toolchain},
+			Env: append(os.Environ(),
+				fmt.Sprintf("RUSTUP_HOME=%s", RustupHome(layer)),
+				fmt.Sprintf("CARGO_HOME=%s", CargoHome(layer))),
+			Stdout: bard.NewWriter(r.Logger.Logger.InfoWriter(), bard.WithIndent(3)),
+			Stderr: bard.NewWriter(r.Logger.Logger.InfoWriter(), bard.WithIndent(3)),
+		}); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to install Rust toolchain %s\n%w", r.Toolchain, err)
+		}
+
+		return layer, nil
+	})
+	if err != nil {
+		return libcnb.Layer{}, err
+	}
+
+	bin := filepath.Join(CargoHome(layer), "bin")
+	layer.BuildEnvironment.Override("RUSTUP_HOME", RustupHome(layer))
+	layer.BuildEnvironment.Override("CARGO_HOME", CargoHome(layer))
+	layer.BuildEnvironment.Prepend("PATH", string(os.PathListSeparator), bin)
+
+	// subsequent steps of this buildpack run cargo from the same process
+	for name, value := range map[string]string{
+		"RUSTUP_HOME": RustupHome(layer),
+		"CARGO_HOME":  CargoHome(layer),
+		"PATH":        strings.Join([]string{bin, os.Getenv("PATH")}, string(os.PathListSeparator)),
+	} {
+		if err := os.Setenv(name, value); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to set %s\n%w", name, err)
+		}
+	}
+
+	return layer, nil
+}
+
+func (r Rustup) Name() string {
+	return r.LayerContributor.LayerName()
+}
+
+// CargoHome returns the CARGO_HOME used by a toolchain installed into the given layer
+func CargoHome(layer libcnb.Layer) string {
+	return filepath.Join(layer.Path, "cargo")
+}
+
+// RustupHome returns the RUSTUP_HOME used by a toolchain installed into the given layer
+func RustupHome(layer libcnb.Layer) string {
+	return filepath.Join(layer.Path, "rustup")
+}
+
+// ToolchainFromConstraint converts a version constraint into a toolchain that rustup can install. Wildcard constraints,
+// like `1.78.*`, select the latest patch release. Channel names are used as-is and anything else installs the default
+// toolchain.
+func ToolchainFromConstraint(constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return DefaultToolchain
+	}
+
+	if _, err := semver.NewConstraint(constraint); err != nil {
+		return constraint
+	}
+
+	version := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(constraint, "="), ".*"), ".x")
+	if _, err := semver.NewVersion(version); err != nil || strings.ContainsAny(version, "*x<>~^|, ") || !strings.Contains(version, ".") {
+		return DefaultToolchain
+	}
+
+	return version
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rustup_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/paketo-community/cargo/download"
+	"github.com/paketo-community/cargo/rustup"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/mock"
+)
+
+func testRustup(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx      libcnb.BuildContext
+		executor *mocks.Executor
+	)
+
+	it.Before(func() {
+		ctx.Layers.Path = t.TempDir()
+		executor = &mocks.Executor{}
+
+		t.Setenv("PATH", "/usr/bin")
+		t.Setenv("CARGO_HOME", "")
+		t.Setenv("RUSTUP_HOME", "")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(ctx.Layers.Path)).To(Succeed())
+	})
+
+	it("installs the toolchain", func() {
+		dep := libpak.BuildpackDependency{
+			URI:    "https://localhost/stub-rustup-init",
+			SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		}
+		dc := download.NewDownloader(libpak.DependencyCache{CachePath: "testdata"})
+
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		r := rustup.NewRustup(dep, dc, "1.78.0")
+		r.Executor = executor
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = r.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Build).To(BeTrue())
+		Expect(layer.Cache).To(BeTrue())
+		Expect(layer.Launch).To(BeFalse())
+		Expect(layer.Metadata).To(HaveKeyWithValue("toolchain", "1.78.0"))
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Command).To(Equal(filepath.Join(layer.Path, "rustup-init")))
+		Expect(e.Args).To(Equal([]string{"-y", "--no-modify-path", "--profile", "minimal", "--default-toolchain", "1.78.0"}))
+		Expect(e.Env).To(ContainElement("RUSTUP_HOME=" + filepath.Join(layer.Path, "rustup")))
+		Expect(e.Env).To(ContainElement("CARGO_HOME=" + filepath.Join(layer.Path, "cargo")))
+		Expect(filepath.Join(layer.Path, "rustup-init")).ToNot(BeAnExistingFile())
+
+		Expect(layer.BuildEnvironment).To(HaveKeyWithValue("CARGO_HOME.override", filepath.Join(layer.Path, "cargo")))
+		Expect(layer.BuildEnvironment).To(HaveKeyWithValue("RUSTUP_HOME.override", filepath.Join(layer.Path, "rustup")))
+		Expect(layer.BuildEnvironment).To(HaveKeyWithValue("PATH.prepend", filepath.Join(layer.Path, "cargo", "bin")))
+
+		Expect(os.Getenv("CARGO_HOME")).To(Equal(filepath.Join(layer.Path, "cargo")))
+		Expect(os.Getenv("PATH")).To(Equal(filepath.Join(layer.Path, "cargo", "bin") + ":/usr/bin"))
+	})
+
+	it("defaults to the stable toolchain", func() {
+		r := rustup.NewRustup(libpak.BuildpackDependency{}, download.Downloader{}, "")
+		Expect(r.Toolchain).To(Equal("stable"))
+	})
+
+	it("converts constraints into toolchains", func() {
+		Expect(rustup.ToolchainFromConstraint("")).To(Equal("stable"))
+		Expect(rustup.ToolchainFromConstraint("nightly")).To(Equal("nightly"))
+		Expect(rustup.ToolchainFromConstraint("1.78.0")).To(Equal("1.78.0"))
+		Expect(rustup.ToolchainFromConstraint("1.78.*")).To(Equal("1.78"))
+		Expect(rustup.ToolchainFromConstraint("1.78.x")).To(Equal("1.78"))
+		Expect(rustup.ToolchainFromConstraint(">=1.70")).To(Equal("stable"))
+		Expect(rustup.ToolchainFromConstraint("1.*")).To(Equal("stable"))
+	})
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// Tool is the name of the tool that created the documents
+const Tool = "paketo-community/cargo"
+
+// spdxIDInvalid matches the characters that are not allowed in SPDX identifiers
+var spdxIDInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]`)
+
+// SPDXDocument is an SPDX 2.3 document
+type SPDXDocument struct {
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	DataLicense       string             `json:"dataLicense"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	Name              string             `json:"name"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+	SPDXID            string             `json:"SPDXID"`
+	SPDXVersion       string             `json:"spdxVersion"`
+}
+
+// SPDXCreationInfo describes when and by which tool an SPDX document was created
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage is a package of an SPDX document
+type SPDXPackage struct {
+	DownloadLocation string            `json:"downloadLocation"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo"`
+}
+
+// SPDXExternalRef references a package by its package URL
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceLocator  string `json:"referenceLocator"`
+	ReferenceType     string `json:"referenceType"`
+}
+
+// SPDXRelationship relates two elements of an SPDX document
+type SPDXRelationship struct {
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+	RelationshipType   string `json:"relationshipType"`
+	SPDXElementID      string `json:"spdxElementId"`
+}
+
+// SPDX returns the graph as an SPDX document, which describes the workspace members
+func (g Graph) SPDX(name string, created time.Time) SPDXDocument {
+	ids := map[string]string{}
+	used := map[string]bool{}
+
+	h := sha256.New()
+	doc := SPDXDocument{
+		CreationInfo: SPDXCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %s", Tool)},
+		},
+		DataLicense:   "CC0-1.0",
+		Name:          name,
+		Packages:      []SPDXPackage{},
+		Relationships: []SPDXRelationship{},
+		SPDXID:        "SPDXRef-DOCUMENT",
+		SPDXVersion:   "SPDX-2.3",
+	}
+
+	for _, p := range g.Packages {
+		id := fmt.Sprintf("SPDXRef-Package-%s-%s", spdxIDInvalid.ReplaceAllString(p.Name, "-"), spdxIDInvalid.ReplaceAllString(p.Version, "-"))
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("SPDXRef-Package-%s-%s-%d", spdxIDInvalid.ReplaceAllString(p.Name, "-"), spdxIDInvalid.ReplaceAllString(p.Version, "-"), i)
+		}
+		used[id] = true
+		ids[p.ID] = id
+
+		license := p.License
+		if license == "" {
+			license = "NOASSERTION"
+		}
+
+		doc.Packages = append(doc.Packages, SPDXPackage{
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []SPDXExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceLocator:  p.PURL(),
+				ReferenceType:     "purl",
+			}},
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  license,
+			Name:             p.Name,
+			SPDXID:           id,
+			VersionInfo:      p.Version,
+		})
+		_, _ = fmt.Fprintln(h, p.PURL())
+	}
+
+	for _, p := range g.Packages {
+		if p.Member {
+			doc.Relationships = append(doc.Relationships, SPDXRelationship{
+				RelatedSPDXElement: ids[p.ID],
+				RelationshipType:   "DESCRIBES",
+				SPDXElementID:      doc.SPDXID,
+			})
+		}
+	}
+
+	for _, p := range g.Packages {
+		for _, dep := range g.Dependencies[p.ID] {
+			doc.Relationships = append(doc.Relationships, SPDXRelationship{
+				RelatedSPDXElement: ids[dep],
+				RelationshipType:   "DEPENDS_ON",
+				SPDXElementID:      ids[p.ID],
+			})
+		}
+	}
+
+	// the namespace is unique for each set of packages, but the same for repeated builds of the same packages
+	doc.DocumentNamespace = fmt.Sprintf("https://paketo.io/spdx/%s-%s", spdxIDInvalid.ReplaceAllString(name, "-"),
+		hex.EncodeToString(h.Sum(nil))[:16])
+
+	return doc
+}
+
+// WriteTo adds the packages of the graph to the CycloneDX SBOM of the layer, unless they are listed already, and
+// writes the graph as the SPDX SBOM of the layer. Returns the number of packages added to the CycloneDX SBOM.
+func (g Graph) WriteTo(layer libcnb.Layer, created time.Time) (int, error) {
+	added, err := g.MergeCycloneDX(layer.SBOMPath(libcnb.CycloneDXJSON), created)
+	if err != nil {
+		return 0, fmt.Errorf("unable to merge CycloneDX SBOM\n%w", err)
+	}
+
+	b, err := json.Marshal(g.SPDX(layer.Name, created))
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode SPDX SBOM\n%w", err)
+	}
+
+	if err := os.WriteFile(layer.SBOMPath(libcnb.SPDXJSON), b, 0644); err != nil {
+		return 0, fmt.Errorf("unable to write SPDX SBOM\n%w", err)
+	}
+
+	return added, nil
+}
+
+// MergeCycloneDX adds the packages of the graph, and their dependencies, to the CycloneDX document at path, unless
+// they are listed already, for example by a scan of the binaries. The document is created if it does not exist.
+// Returns the number of packages added.
+func (g Graph) MergeCycloneDX(path string, created time.Time) (int, error) {
+	document := map[string]interface{}{}
+	if b, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(b, &document); err != nil {
+			return 0, fmt.Errorf("unable to decode %s\n%w", path, err)
+		}
+	} else if os.IsNotExist(err) {
+		document = map[string]interface{}{
+			"bomFormat":   "CycloneDX",
+			"specVersion": "1.4",
+			"version":     1,
+			"metadata": map[string]interface{}{
+				"timestamp": created.UTC().Format(time.RFC3339),
+				"tools":     []interface{}{map[string]interface{}{"name": Tool}},
+			},
+		}
+	} else {
+		return 0, fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	// packages listed already keep their reference, so that dependencies refer to them
+	components, _ := document["components"].([]interface{})
+	refs := map[string]string{}
+	for _, c := range components {
+		if m, ok := c.(map[string]interface{}); ok {
+			purl, _ := m["purl"].(string)
+			ref, _ := m["bom-ref"].(string)
+			if purl != "" {
+				refs[purl] = ref
+			}
+		}
+	}
+
+	added := 0
+	for _, p := range g.Packages {
+		if _, ok := refs[p.PURL()]; ok {
+			continue
+		}
+		refs[p.PURL()] = p.PURL()
+
+		component := map[string]interface{}{
+			"bom-ref": p.PURL(),
+			"name":    p.Name,
+			"purl":    p.PURL(),
+			"type":    "library",
+			"version": p.Version,
+		}
+		if p.Member {
+			component["type"] = "application"
+		}
+		if p.License != "" {
+			component["licenses"] = []interface{}{map[string]interface{}{"expression": p.License}}
+		}
+		components = append(components, component)
+		added++
+	}
+	document["components"] = components
+
+	dependencies, _ := document["dependencies"].([]interface{})
+	listed := map[string]bool{}
+	for _, d := range dependencies {
+		if m, ok := d.(map[string]interface{}); ok {
+			if ref, ok := m["ref"].(string); ok {
+				listed[ref] = true
+			}
+		}
+	}
+
+	for _, p := range g.Packages {
+		ref := refs[p.PURL()]
+		if listed[ref] || ref == "" {
+			continue
+		}
+
+		dependsOn := []interface{}{}
+		for _, id := range g.Dependencies[p.ID] {
+			if dep, ok := g.Package(id); ok && refs[dep.PURL()] != "" {
+				dependsOn = append(dependsOn, refs[dep.PURL()])
+			}
+		}
+		dependencies = append(dependencies, map[string]interface{}{"ref": ref, "dependsOn": dependsOn})
+	}
+	document["dependencies"] = dependencies
+
+	b, err := json.Marshal(document)
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode %s\n%w", path, err)
+	}
+
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return 0, fmt.Errorf("unable to write %s\n%w", path, err)
+	}
+
+	return added, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sbom_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/sbom"
+	"github.com/sclevine/spec"
+)
+
+func testDocuments(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		created = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		graph   sbom.Graph
+		layer   libcnb.Layer
+	)
+
+	it.Before(func() {
+		b, err := os.ReadFile(filepath.Join("testdata", "metadata.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		graph, err = sbom.ParseMetadata(b)
+		Expect(err).NotTo(HaveOccurred())
+
+		layers := libcnb.Layers{Path: t.TempDir()}
+		layer, err = layers.Layer("Cargo")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	readJSON := func(path string) map[string]interface{} {
+		b, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		var document map[string]interface{}
+		Expect(json.Unmarshal(b, &document)).To(Succeed())
+		return document
+	}
+
+	it("renders an SPDX document describing the workspace members", func() {
+		doc := graph.SPDX("Cargo", created)
+
+		Expect(doc.SPDXVersion).To(Equal("SPDX-2.3"))
+		Expect(doc.CreationInfo.Created).To(Equal("2024-05-01T12:00:00Z"))
+		Expect(doc.DocumentNamespace).To(MatchRegexp(`^https://paketo.io/spdx/Cargo-[0-9a-f]{16}$`))
+		Expect(doc.Packages).To(HaveLen(5))
+		Expect(doc.Packages[0]).To(Equal(sbom.SPDXPackage{
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []sbom.SPDXExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceLocator:  "pkg:cargo/app@0.1.0",
+				ReferenceType:     "purl",
+			}},
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "MIT",
+			Name:             "app",
+			SPDXID:           "SPDXRef-Package-app-0.1.0",
+			VersionInfo:      "0.1.0",
+		}))
+		Expect(doc.Packages[2].LicenseDeclared).To(Equal("NOASSERTION"))
+
+		Expect(doc.Relationships).To(ContainElements(
+			sbom.SPDXRelationship{RelatedSPDXElement: "SPDXRef-Package-app-0.1.0", RelationshipType: "DESCRIBES", SPDXElementID: "SPDXRef-DOCUMENT"},
+			sbom.SPDXRelationship{RelatedSPDXElement: "SPDXRef-Package-serde-derive-1.0.210", RelationshipType: "DEPENDS_ON", SPDXElementID: "SPDXRef-Package-serde-1.0.210"},
+		))
+
+		Expect(graph.SPDX("Cargo", created.Add(time.Hour)).DocumentNamespace).To(Equal(doc.DocumentNamespace))
+	})
+
+	it("writes the SBOMs of the layer", func() {
+		added, err := graph.WriteTo(layer, created)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(added).To(Equal(5))
+
+		cyclonedx := readJSON(layer.SBOMPath(libcnb.CycloneDXJSON))
+		Expect(cyclonedx["bomFormat"]).To(Equal("CycloneDX"))
+		Expect(cyclonedx["components"]).To(ContainElement(map[string]interface{}{
+			"bom-ref":  "pkg:cargo/serde@1.0.210",
+			"licenses": []interface{}{map[string]interface{}{"expression": "MIT OR Apache-2.0"}},
+			"name":     "serde",
+			"purl":     "pkg:cargo/serde@1.0.210",
+			"type":     "library",
+			"version":  "1.0.210",
+		}))
+		Expect(cyclonedx["dependencies"]).To(ContainElement(map[string]interface{}{
+			"ref":       "pkg:cargo/serde@1.0.210",
+			"dependsOn": []interface{}{"pkg:cargo/serde_derive@1.0.210"},
+		}))
+
+		spdx := readJSON(layer.SBOMPath(libcnb.SPDXJSON))
+		Expect(spdx["spdxVersion"]).To(Equal("SPDX-2.3"))
+	})
+
+	it("keeps the components of an existing CycloneDX SBOM", func() {
+		Expect(os.WriteFile(layer.SBOMPath(libcnb.CycloneDXJSON), []byte(`{
+			"bomFormat": "CycloneDX",
+			"components": [{"bom-ref": "syft-1", "name": "serde", "purl": "pkg:cargo/serde@1.0.210", "type": "library", "version": "1.0.210"}]
+		}`), 0644)).To(Succeed())
+
+		added, err := graph.MergeCycloneDX(layer.SBOMPath(libcnb.CycloneDXJSON), created)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(added).To(Equal(4))
+
+		cyclonedx := readJSON(layer.SBOMPath(libcnb.CycloneDXJSON))
+		Expect(cyclonedx["components"]).To(HaveLen(5))
+		Expect(cyclonedx["components"]).To(ContainElement(HaveKeyWithValue("bom-ref", "syft-1")))
+		Expect(cyclonedx["dependencies"]).To(ContainElement(map[string]interface{}{
+			"ref":       "syft-1",
+			"dependsOn": []interface{}{"pkg:cargo/serde_derive@1.0.210"},
+		}))
+	})
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sbom describes the crates that are compiled into the binaries of an application, as resolved by
+// `cargo metadata`, in the CycloneDX and SPDX formats supported by the lifecycle.
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/paketo-community/cargo/runner"
+)
+
+// MetadataSource prints the output of `cargo metadata` for an application, including its dependencies, like
+// runner.CargoRunner
+type MetadataSource interface {
+	Metadata(srcDir string) ([]byte, error)
+}
+
+// crates.io is referenced by its git and by its sparse index
+var cratesIO = []string{
+	"registry+https://github.com/rust-lang/crates.io-index",
+	"sparse+https://index.crates.io/",
+}
+
+// Package is a crate of the dependency graph
+type Package struct {
+	ID      string
+	License string
+	Member  bool
+	Name    string
+	Source  string
+	Version string
+}
+
+// PURL returns the package URL of the package, like `pkg:cargo/serde@1.0.0`. Crates that are not published on
+// crates.io are qualified by the URL of their registry or git repository.
+func (p Package) PURL() string {
+	purl := fmt.Sprintf("pkg:cargo/%s@%s", p.Name, p.Version)
+
+	switch {
+	case p.Source == "":
+		return purl
+	case strings.HasPrefix(p.Source, "git+"):
+		return fmt.Sprintf("%s?vcs_url=%s", purl, url.QueryEscape(p.Source))
+	}
+
+	for _, s := range cratesIO {
+		if p.Source == s {
+			return purl
+		}
+	}
+
+	_, index, _ := strings.Cut(p.Source, "+")
+	return fmt.Sprintf("%s?repository_url=%s", purl, url.QueryEscape(index))
+}
+
+// Graph is the graph of the crates linked into the binaries of the workspace members, which are the roots of the
+// graph. Development and build dependencies are not part of the graph, since they are not linked into the binaries.
+type Graph struct {
+	// Dependencies are the ids of the direct dependencies of a package, keyed by its id
+	Dependencies map[string][]string
+
+	// Packages are the packages of the graph, sorted by name and version
+	Packages []Package
+}
+
+// Generate returns the dependency graph of the application in srcDir, resolved by `cargo metadata`
+func Generate(source MetadataSource, srcDir string) (Graph, error) {
+	b, err := source.Metadata(srcDir)
+	if err != nil {
+		return Graph{}, fmt.Errorf("unable to run cargo metadata\n%w", err)
+	}
+
+	return ParseMetadata(b)
+}
+
+// ParseMetadata returns the dependency graph of the output of `cargo metadata --format-version=1`
+func ParseMetadata(b []byte) (Graph, error) {
+	dependencies, err := runner.ParseDependencyGraph(b)
+	if err != nil {
+		return Graph{}, err
+	}
+
+	return FromDependencyGraph(dependencies), nil
+}
+
+// FromDependencyGraph returns the graph of the crates linked into the binaries of the workspace members, which are
+// the packages reachable through normal dependencies
+func FromDependencyGraph(dependencies runner.DependencyGraph) Graph {
+	members := map[string]bool{}
+	for _, id := range dependencies.WorkspaceMembers {
+		members[id] = true
+	}
+
+	reachable := map[string]bool{}
+	for _, id := range dependencies.Reachable(runner.DependencyKindNormal) {
+		reachable[id] = true
+	}
+
+	g := Graph{Dependencies: map[string][]string{}}
+	for _, p := range dependencies.Packages {
+		if !reachable[p.ID] {
+			continue
+		}
+
+		g.Packages = append(g.Packages, Package{
+			ID:      p.ID,
+			License: p.License,
+			Member:  members[p.ID],
+			Name:    p.Name,
+			Source:  p.Source,
+			Version: p.Version,
+		})
+
+		if deps := dependencies.DependenciesOf(p.ID, runner.DependencyKindNormal); len(deps) > 0 {
+			g.Dependencies[p.ID] = deps
+		}
+	}
+
+	return g
+}
+
+// Package returns the package with an id
+func (g Graph) Package(id string) (Package, bool) {
+	for _, p := range g.Packages {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Package{}, false
+}
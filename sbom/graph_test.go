@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sbom_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-community/cargo/sbom"
+	"github.com/sclevine/spec"
+)
+
+type metadataSource struct {
+	err  error
+	out  []byte
+	dirs []string
+}
+
+func (m *metadataSource) Metadata(srcDir string) ([]byte, error) {
+	m.dirs = append(m.dirs, srcDir)
+	return m.out, m.err
+}
+
+func testGraph(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		source *metadataSource
+	)
+
+	it.Before(func() {
+		b, err := os.ReadFile(filepath.Join("testdata", "metadata.json"))
+		Expect(err).NotTo(HaveOccurred())
+		source = &metadataSource{out: b}
+	})
+
+	it("resolves the crates linked into the workspace members", func() {
+		g, err := sbom.Generate(source, "/workspace")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(source.dirs).To(Equal([]string{"/workspace"}))
+
+		var names []string
+		for _, p := range g.Packages {
+			names = append(names, p.Name)
+		}
+		Expect(names).To(Equal([]string{"app", "gitdep", "private", "serde", "serde_derive"}))
+
+		app, ok := g.Package("path+file:///workspace#app@0.1.0")
+		Expect(ok).To(BeTrue())
+		Expect(app.Member).To(BeTrue())
+		Expect(app.License).To(Equal("MIT"))
+
+		Expect(g.Dependencies["path+file:///workspace#app@0.1.0"]).To(Equal([]string{
+			"git+https://github.com/example/gitdep?branch=main#abc123",
+			"registry+https://github.com/rust-lang/crates.io-index#serde@1.0.210",
+			"sparse+https://crates.example.com/index/#private@2.0.0",
+		}))
+		Expect(g.Dependencies["registry+https://github.com/rust-lang/crates.io-index#serde@1.0.210"]).To(Equal([]string{
+			"registry+https://github.com/rust-lang/crates.io-index#serde_derive@1.0.210",
+		}))
+	})
+
+	it("qualifies package URLs of crates not published on crates.io", func() {
+		Expect(sbom.Package{Name: "app", Version: "0.1.0"}.PURL()).To(Equal("pkg:cargo/app@0.1.0"))
+		Expect(sbom.Package{Name: "serde", Version: "1.0.0", Source: "registry+https://github.com/rust-lang/crates.io-index"}.PURL()).
+			To(Equal("pkg:cargo/serde@1.0.0"))
+		Expect(sbom.Package{Name: "serde", Version: "1.0.0", Source: "sparse+https://index.crates.io/"}.PURL()).
+			To(Equal("pkg:cargo/serde@1.0.0"))
+		Expect(sbom.Package{Name: "private", Version: "2.0.0", Source: "sparse+https://crates.example.com/index/"}.PURL()).
+			To(Equal("pkg:cargo/private@2.0.0?repository_url=https%3A%2F%2Fcrates.example.com%2Findex%2F"))
+		Expect(sbom.Package{Name: "gitdep", Version: "0.3.0", Source: "git+https://github.com/example/gitdep#abc123"}.PURL()).
+			To(Equal("pkg:cargo/gitdep@0.3.0?vcs_url=git%2Bhttps%3A%2F%2Fgithub.com%2Fexample%2Fgitdep%23abc123"))
+	})
+
+	it("fails without resolved dependencies", func() {
+		_, err := sbom.ParseMetadata([]byte(`{"packages": [], "resolve": null, "workspace_members": []}`))
+		Expect(err).To(MatchError(ContainSubstring("--no-deps")))
+	})
+
+	it("fails when cargo metadata fails", func() {
+		source.err = fmt.Errorf("exit status 101")
+
+		_, err := sbom.Generate(source, "/workspace")
+		Expect(err).To(MatchError(ContainSubstring("unable to run cargo metadata")))
+	})
+}
@@ -25,15 +25,16 @@ import (
 	"github.com/paketo-buildpacks/libpak"
 	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/paketo-community/cargo/download"
 )
 
 type Tini struct {
-	LayerContributor libpak.DependencyLayerContributor
+	LayerContributor download.DependencyLayerContributor
 	Logger           bard.Logger
 }
 
-func NewTini(dependency libpak.BuildpackDependency, cache libpak.DependencyCache) Tini {
-	contributor := libpak.NewDependencyLayerContributor(dependency, cache, libcnb.LayerTypes{
+func NewTini(dependency libpak.BuildpackDependency, downloader download.Downloader) Tini {
+	contributor := download.NewDependencyLayerContributor(dependency, downloader, libcnb.LayerTypes{
 		Launch: true,
 	})
 	return Tini{LayerContributor: contributor}
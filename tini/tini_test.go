@@ -24,6 +24,7 @@ import (
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-community/cargo/download"
 	"github.com/paketo-community/cargo/tini"
 	"github.com/sclevine/spec"
 )
@@ -51,7 +52,7 @@ func testTini(t *testing.T, context spec.G, it spec.S) {
 			URI:    "https://localhost/stub-tini",
 			SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
 		}
-		dc := libpak.DependencyCache{CachePath: "testdata"}
+		dc := download.NewDownloader(libpak.DependencyCache{CachePath: "testdata"})
 
 		d := tini.NewTini(dep, dc)
 		layer, err := ctx.Layers.Layer("test-layer")